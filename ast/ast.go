@@ -2,6 +2,8 @@
 package ast
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -34,7 +36,20 @@ func ParseAddress(address string) Address {
 
 // Parse takes the coloured output of the clang AST command and returns a root
 // node for the AST.
-func Parse(fullline string) Node {
+//
+// A line that cannot be understood, either because the node kind is not known
+// or because its format cannot be parsed, will return an *Unknown node. If the
+// node kind is known, the reason it cannot be parsed is in the ParseError of
+// the *Unknown.
+func Parse(fullline string) (node Node) {
+	defer func() {
+		if r := recover(); r != nil {
+			n := parseUnknown(fullline)
+			n.ParseError = newParseError(r)
+			node = n
+		}
+	}()
+
 	line := fullline
 
 	// This is a special case. I'm not sure if it's a bug in the clang AST
@@ -291,10 +306,21 @@ func Parse(fullline string) Node {
 	case "NullStmt":
 		return nil
 	default:
-		panic("unknown node type: '" + fullline + "'")
+		return parseUnknown(fullline)
 	}
 }
 
+// newParseError returns the error of the recovered panic of a parser. It is on
+// a single line because it is used in the comments of the Go output.
+func newParseError(r interface{}) error {
+	message := strings.TrimSpace(fmt.Sprint(r))
+	if err, ok := r.(error); ok {
+		message = strings.TrimSpace(err.Error())
+	}
+
+	return errors.New(strings.Join(strings.Split(message, "\n"), ": "))
+}
+
 func groupsFromRegex(rx, line string) map[string]string {
 	// We remove tabs and newlines from the regex. This is purely cosmetic,
 	// as the regex input can be quite long and it's nice for the caller to
@@ -14,6 +14,9 @@ type ImplicitCastExpr struct {
 // ImplicitCastExprArrayToPointerDecay - constant
 const ImplicitCastExprArrayToPointerDecay = "ArrayToPointerDecay"
 
+// ImplicitCastExprFunctionToPointerDecay - constant
+const ImplicitCastExprFunctionToPointerDecay = "FunctionToPointerDecay"
+
 func parseImplicitCastExpr(line string) *ImplicitCastExpr {
 	groups := groupsFromRegex(
 		`<(?P<position>.*)>
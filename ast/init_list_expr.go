@@ -2,16 +2,21 @@ package ast
 
 // InitListExpr is expression.
 type InitListExpr struct {
-	Addr       Address
-	Pos        Position
-	Type1      string
-	Type2      string
+	Addr  Address
+	Pos   Position
+	Type1 string
+	Type2 string
+
+	// Field is the name of the member of a union that is initialized, like
+	// "d" in "union number n = {.d = 1.5}".
+	Field      string
 	ChildNodes []Node
 }
 
 func parseInitListExpr(line string) *InitListExpr {
 	groups := groupsFromRegex(
-		"<(?P<position>.*)> '(?P<type1>.*?)'(:'(?P<type2>.*)')?",
+		`<(?P<position>.*)> '(?P<type1>.*?)'(:'(?P<type2>.*?)')?
+		( field Field [0-9a-fx]+ '(?P<field>.*?)' '.*?')?`,
 		line,
 	)
 
@@ -20,6 +25,7 @@ func parseInitListExpr(line string) *InitListExpr {
 		Pos:        NewPositionFromString(groups["position"]),
 		Type1:      groups["type1"],
 		Type2:      groups["type2"],
+		Field:      groups["field"],
 		ChildNodes: []Node{},
 	}
 }
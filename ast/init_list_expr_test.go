@@ -25,6 +25,21 @@ func TestInitListExpr(t *testing.T) {
 			Type2:      "struct node",
 			ChildNodes: []Node{},
 		},
+		`0x55d0c8a8e1d8 <col:22, col:31> 'union number':'union number' field Field 0x55d0c8a8dee0 'd' 'double'`: &InitListExpr{
+			Addr:       0x55d0c8a8e1d8,
+			Pos:        NewPositionFromString("col:22, col:31"),
+			Type1:      "union number",
+			Type2:      "union number",
+			Field:      "d",
+			ChildNodes: []Node{},
+		},
+		`0x2ea3d28 <col:16, col:18> 'union number' field Field 0x2ea3a80 'i' 'int'`: &InitListExpr{
+			Addr:       0x2ea3d28,
+			Pos:        NewPositionFromString("col:16, col:18"),
+			Type1:      "union number",
+			Field:      "i",
+			ChildNodes: []Node{},
+		},
 	}
 
 	runNodeTests(t, nodes)
@@ -0,0 +1,842 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The JSON front end reads the output of:
+//
+//     clang -Xclang -ast-dump=json -fsyntax-only file.c
+//
+// and builds exactly the same Node types as Parse does for the text dump. The
+// JSON format is far more stable between clang versions than the text format
+// and it contains exact values for literals as well as complete source ranges,
+// so none of the Repair*FromSource functions are needed when it is used.
+
+// jsonNode is a single object of the clang JSON AST. Only the attributes that
+// are used by c2go are decoded, everything else is ignored.
+//
+// Null children (like the omitted parts of "for(;;)") are represented by an
+// empty object which will have an empty Kind.
+type jsonNode struct {
+	ID                   string          `json:"id"`
+	Kind                 string          `json:"kind"`
+	Loc                  *jsonLoc        `json:"loc"`
+	Range                *jsonRange      `json:"range"`
+	Type                 *jsonType       `json:"type"`
+	Name                 string          `json:"name"`
+	PreviousDecl         string          `json:"previousDecl"`
+	ParentDeclContextID  string          `json:"parentDeclContextId"`
+	IsImplicit           bool            `json:"isImplicit"`
+	IsUsed               bool            `json:"isUsed"`
+	IsReferenced         bool            `json:"isReferenced"`
+	IsArrow              bool            `json:"isArrow"`
+	IsPostfix            bool            `json:"isPostfix"`
+	IsBitfield           bool            `json:"isBitfield"`
+	IsPartOfExplicitCast bool            `json:"isPartOfExplicitCast"`
+	CanOverflow          *bool           `json:"canOverflow"`
+	HasElse              bool            `json:"hasElse"`
+	Inline               bool            `json:"inline"`
+	NRVO                 bool            `json:"nrvo"`
+	CompleteDefinition   bool            `json:"completeDefinition"`
+	Implicit             bool            `json:"implicit"`
+	Inherited            bool            `json:"inherited"`
+	StorageClass         string          `json:"storageClass"`
+	Init                 string          `json:"init"`
+	TagUsed              string          `json:"tagUsed"`
+	Opcode               string          `json:"opcode"`
+	CastKind             string          `json:"castKind"`
+	ValueCategory        string          `json:"valueCategory"`
+	Value                json.RawMessage `json:"value"`
+	ArgType              *jsonType       `json:"argType"`
+	ComputeLHSType       *jsonType       `json:"computeLHSType"`
+	ComputeResultType    *jsonType       `json:"computeResultType"`
+	ReferencedDecl       *jsonNode       `json:"referencedDecl"`
+	ReferencedMemberDecl string          `json:"referencedMemberDecl"`
+	Decl                 *jsonNode       `json:"decl"`
+	Size                 int             `json:"size"`
+	Qualifiers           string          `json:"qualifiers"`
+	CC                   string          `json:"cc"`
+	NonOdrUseReason      string          `json:"nonOdrUseReason"`
+	TargetLabelDeclID    string          `json:"targetLabelDeclId"`
+	DeclID               string          `json:"declId"`
+	Text                 string          `json:"text"`
+	CloseName            string          `json:"closeName"`
+	Inner                []*jsonNode     `json:"inner"`
+}
+
+// jsonType is the "type" attribute attached to expressions, declarations and
+// type nodes.
+type jsonType struct {
+	QualType          string `json:"qualType"`
+	DesugaredQualType string `json:"desugaredQualType"`
+}
+
+// jsonRange is the "range" attribute of a node.
+type jsonRange struct {
+	Begin jsonLoc `json:"begin"`
+	End   jsonLoc `json:"end"`
+}
+
+// jsonLoc is a source location. Locations that come from a macro expansion are
+// split into a spelling and an expansion location. Locations that are
+// otherwise the same as the previously printed location omit the "file" and
+// "line" attributes. Invalid locations are an empty object.
+type jsonLoc struct {
+	File         string   `json:"file"`
+	Line         int      `json:"line"`
+	Col          int      `json:"col"`
+	PresumedFile string   `json:"presumedFile"`
+	PresumedLine int      `json:"presumedLine"`
+	SpellingLoc  *jsonLoc `json:"spellingLoc"`
+	ExpansionLoc *jsonLoc `json:"expansionLoc"`
+}
+
+// jsonLocTracker resolves the abbreviated locations in the JSON dump. clang
+// only prints the file or line of a location when it is different from the
+// previously printed location so the locations must be visited in the same
+// order that they appear in the output.
+type jsonLocTracker struct {
+	file         string
+	line         int
+	presumedFile string
+	presumedLine int
+}
+
+// resolve returns the presumed (after applying "# line" markers from the
+// preprocessor) file and line of the location.
+func (t *jsonLocTracker) resolve(l *jsonLoc) (file string, line, col int) {
+	if l == nil || l.Col == 0 {
+		return "", 0, 0
+	}
+
+	if l.File != "" {
+		t.file = l.File
+	}
+	if l.Line != 0 {
+		t.line = l.Line
+	}
+
+	switch {
+	case l.PresumedFile != "":
+		t.presumedFile = l.PresumedFile
+	case l.File != "" || t.presumedFile == "":
+		t.presumedFile = t.file
+	}
+
+	switch {
+	case l.PresumedLine != 0:
+		t.presumedLine = l.PresumedLine
+	case t.presumedFile == t.file:
+		t.presumedLine = t.line
+	}
+
+	return t.presumedFile, t.presumedLine, l.Col
+}
+
+// resolveLoc visits the spelling and expansion locations in the order they
+// are printed. The spelling location is returned because that is the same
+// location that the text dump shows.
+func (t *jsonLocTracker) resolveLoc(l *jsonLoc) (file string, line, col int) {
+	if l == nil {
+		return "", 0, 0
+	}
+
+	if l.SpellingLoc == nil && l.ExpansionLoc == nil {
+		return t.resolve(l)
+	}
+
+	file, line, col = t.resolve(l.SpellingLoc)
+	t.resolve(l.ExpansionLoc)
+
+	return
+}
+
+func (t *jsonLocTracker) position(n *jsonNode) Position {
+	t.resolveLoc(n.Loc)
+	if n.Range == nil {
+		return Position{}
+	}
+
+	file, line, column := t.resolveLoc(&n.Range.Begin)
+	_, lineEnd, columnEnd := t.resolveLoc(&n.Range.End)
+	if column == 0 {
+		return Position{}
+	}
+
+	return Position{
+		File:      file,
+		Line:      line,
+		Column:    column,
+		LineEnd:   lineEnd,
+		ColumnEnd: columnEnd,
+		StringValue: fmt.Sprintf("%s:%d:%d, line:%d:%d",
+			file, line, column, lineEnd, columnEnd),
+	}
+}
+
+// ParseJSON decodes the complete JSON output of the clang AST dump and returns
+// the root node (the TranslationUnitDecl).
+func ParseJSON(r io.Reader) (Node, error) {
+	var root jsonNode
+	if err := json.NewDecoder(r).Decode(&root); err != nil {
+		return nil, fmt.Errorf("cannot decode JSON AST: %v", err)
+	}
+
+	// GotoStmt only references the label by its declaration ID so all of
+	// the labels are collected first.
+	labels := map[string]string{}
+	collectJSONLabels(&root, labels)
+
+	c := jsonConverter{labels: labels}
+
+	return c.convert(&root)
+}
+
+func collectJSONLabels(n *jsonNode, labels map[string]string) {
+	if n == nil {
+		return
+	}
+
+	if n.Kind == "LabelStmt" && n.DeclID != "" {
+		labels[n.DeclID] = n.Name
+	}
+
+	for _, c := range n.Inner {
+		collectJSONLabels(c, labels)
+	}
+}
+
+type jsonConverter struct {
+	labels map[string]string
+	loc    jsonLocTracker
+}
+
+func (c *jsonConverter) convert(n *jsonNode) (Node, error) {
+	if n == nil || n.Kind == "" {
+		return nil, nil
+	}
+
+	node, err := c.convertNode(n)
+	if node == nil || err != nil {
+		return nil, err
+	}
+
+	for _, child := range n.Inner {
+		childNode, err := c.convert(child)
+		if err != nil {
+			return nil, err
+		}
+
+		node.AddChild(childNode)
+	}
+
+	return node, nil
+}
+
+func (n *jsonNode) qualType() string {
+	if n.Type == nil {
+		return ""
+	}
+
+	return n.Type.QualType
+}
+
+func (n *jsonNode) desugaredType() string {
+	if n.Type == nil {
+		return ""
+	}
+
+	return n.Type.DesugaredQualType
+}
+
+func (n *jsonNode) isLvalue() bool {
+	return n.ValueCategory == "lvalue"
+}
+
+func (n *jsonNode) declKind() string {
+	if n.ReferencedDecl == nil {
+		return ""
+	}
+
+	return strings.TrimSuffix(n.ReferencedDecl.Kind, "Decl")
+}
+
+func (n *jsonNode) stringValue() (string, error) {
+	var s string
+	err := json.Unmarshal(n.Value, &s)
+
+	return s, err
+}
+
+func (n *jsonNode) position2() string {
+	if n.Loc == nil || n.Loc.Col == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("col:%d", n.Loc.Col)
+}
+
+func jsonTypeName(t *jsonType) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.QualType
+}
+
+func (c *jsonConverter) convertNode(n *jsonNode) (Node, error) {
+	addr := ParseAddress(n.ID)
+	pos := c.loc.position(n)
+
+	switch n.Kind {
+	case "AlignedAttr":
+		return &AlignedAttr{Addr: addr, Pos: pos, IsAligned: true, ChildNodes: []Node{}}, nil
+	case "AllocSizeAttr":
+		return &AllocSizeAttr{Addr: addr, Pos: pos, Inherited: n.Inherited, ChildNodes: []Node{}}, nil
+	case "AlwaysInlineAttr":
+		return &AlwaysInlineAttr{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "ArraySubscriptExpr":
+		return &ArraySubscriptExpr{
+			Addr:       addr,
+			Pos:        pos,
+			Type:       n.qualType(),
+			Type2:      n.desugaredType(),
+			IsLvalue:   n.isLvalue(),
+			ChildNodes: []Node{},
+		}, nil
+	case "AsmLabelAttr":
+		return &AsmLabelAttr{Addr: addr, Pos: pos, Inherited: n.Inherited, ChildNodes: []Node{}}, nil
+	case "AttributedType":
+		return &AttributedType{Addr: addr, Type: n.qualType(), Sugar: true, ChildNodes: []Node{}}, nil
+	case "AvailabilityAttr":
+		return &AvailabilityAttr{Addr: addr, Pos: pos, IsInherited: n.Inherited, ChildNodes: []Node{}}, nil
+	case "BinaryOperator":
+		return &BinaryOperator{
+			Addr:       addr,
+			Pos:        pos,
+			Type:       n.qualType(),
+			Type2:      n.desugaredType(),
+			Operator:   n.Opcode,
+			ChildNodes: []Node{},
+		}, nil
+	case "BlockCommandComment":
+		return &BlockCommandComment{Addr: addr, Pos: pos, Name: n.Name, ChildNodes: []Node{}}, nil
+	case "BreakStmt":
+		return &BreakStmt{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "BuiltinType":
+		return &BuiltinType{Addr: addr, Type: n.qualType(), ChildNodes: []Node{}}, nil
+	case "C11NoReturnAttr":
+		return &C11NoReturnAttr{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "CallExpr":
+		return &CallExpr{Addr: addr, Pos: pos, Type: n.qualType(), ChildNodes: []Node{}}, nil
+	case "CaseStmt":
+		return &CaseStmt{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "CharacterLiteral":
+		var value int
+		if err := json.Unmarshal(n.Value, &value); err != nil {
+			return nil, fmt.Errorf("cannot read character literal %s: %v", n.ID, err)
+		}
+
+		return &CharacterLiteral{
+			Addr:       addr,
+			Pos:        pos,
+			Type:       n.qualType(),
+			Value:      value,
+			ChildNodes: []Node{},
+		}, nil
+	case "CompoundAssignOperator":
+		return &CompoundAssignOperator{
+			Addr:                  addr,
+			Pos:                   pos,
+			Type:                  n.qualType(),
+			Opcode:                n.Opcode,
+			ComputationLHSType:    jsonTypeName(n.ComputeLHSType),
+			ComputationResultType: jsonTypeName(n.ComputeResultType),
+			ChildNodes:            []Node{},
+		}, nil
+	case "CompoundLiteralExpr":
+		return &CompoundLiteralExpr{
+			Addr:       addr,
+			Pos:        pos,
+			Type1:      n.qualType(),
+			Type2:      n.desugaredType(),
+			ChildNodes: []Node{},
+		}, nil
+	case "CompoundStmt":
+		return &CompoundStmt{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "ConditionalOperator":
+		return &ConditionalOperator{Addr: addr, Pos: pos, Type: n.qualType(), ChildNodes: []Node{}}, nil
+	case "ConstAttr":
+		return &ConstAttr{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "ConstantArrayType":
+		return &ConstantArrayType{Addr: addr, Type: n.qualType(), Size: n.Size, ChildNodes: []Node{}}, nil
+	case "ConstantExpr":
+		return &ConstantExpr{Addr: addr, Pos: pos, Type: n.qualType(), ChildNodes: []Node{}}, nil
+	case "ContinueStmt":
+		return &ContinueStmt{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "CStyleCastExpr":
+		return &CStyleCastExpr{
+			Addr:       addr,
+			Pos:        pos,
+			Type:       n.qualType(),
+			Type2:      n.desugaredType(),
+			Kind:       n.CastKind,
+			ChildNodes: []Node{},
+		}, nil
+	case "DecayedType":
+		return &DecayedType{Addr: addr, Type: n.qualType(), ChildNodes: []Node{}}, nil
+	case "DeclRefExpr":
+		d := &DeclRefExpr{
+			Addr:                 addr,
+			Pos:                  pos,
+			Type:                 n.qualType(),
+			Type1:                n.desugaredType(),
+			Lvalue:               n.isLvalue(),
+			For:                  n.declKind(),
+			NonODRUseUnevaluated: n.NonOdrUseReason == "unevaluated",
+			ChildNodes:           []Node{},
+		}
+		if r := n.ReferencedDecl; r != nil {
+			d.Address2 = r.ID
+			d.Name = r.Name
+			d.Type2 = r.qualType()
+			d.Type3 = r.desugaredType()
+		}
+
+		return d, nil
+	case "DeclStmt":
+		return &DeclStmt{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "DefaultStmt":
+		return &DefaultStmt{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "DeprecatedAttr":
+		return &DeprecatedAttr{Addr: addr, Pos: pos, IsInherited: n.Inherited, ChildNodes: []Node{}}, nil
+	case "DisableTailCallsAttr":
+		return &DisableTailCallsAttr{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "DoStmt":
+		return &DoStmt{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "ElaboratedType":
+		return &ElaboratedType{Addr: addr, Type: n.qualType(), Tags: "sugar", ChildNodes: []Node{}}, nil
+	case "EmptyDecl":
+		return &EmptyDecl{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "EnumConstantDecl":
+		return &EnumConstantDecl{
+			Addr:       addr,
+			Pos:        pos,
+			Position2:  n.position2(),
+			Referenced: n.IsReferenced,
+			Name:       n.Name,
+			Type:       n.qualType(),
+			ChildNodes: []Node{},
+		}, nil
+	case "EnumDecl":
+		return &EnumDecl{
+			Addr:       addr,
+			Prev:       ParseAddress(n.PreviousDecl),
+			Pos:        pos,
+			Position2:  n.position2(),
+			Name:       n.Name,
+			ChildNodes: []Node{},
+		}, nil
+	case "EnumType":
+		e := &EnumType{Addr: addr, Name: n.qualType(), ChildNodes: []Node{}}
+		if n.Decl != nil {
+			e.AddChild(&Enum{Addr: ParseAddress(n.Decl.ID), Name: n.Decl.Name, ChildNodes: []Node{}})
+		}
+
+		return e, nil
+	case "FieldDecl":
+		return &FieldDecl{
+			Addr:       addr,
+			Pos:        pos,
+			Position2:  n.position2(),
+			Name:       n.Name,
+			Type:       n.qualType(),
+			Type2:      n.desugaredType(),
+			Implicit:   n.IsImplicit,
+			Referenced: n.IsReferenced,
+			ChildNodes: []Node{},
+		}, nil
+	case "FloatingLiteral":
+		s, err := n.stringValue()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read floating literal %s: %v", n.ID, err)
+		}
+
+		value, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read floating literal %s: %v", n.ID, err)
+		}
+
+		return &FloatingLiteral{
+			Addr:       addr,
+			Pos:        pos,
+			Type:       n.qualType(),
+			Value:      value,
+			ChildNodes: []Node{},
+		}, nil
+	case "FormatArgAttr":
+		return &FormatArgAttr{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "FormatAttr":
+		return &FormatAttr{
+			Addr:       addr,
+			Pos:        pos,
+			Implicit:   n.Implicit,
+			Inherited:  n.Inherited,
+			ChildNodes: []Node{},
+		}, nil
+	case "FullComment":
+		return &FullComment{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "FunctionDecl":
+		return &FunctionDecl{
+			Addr:         addr,
+			Pos:          pos,
+			Prev:         n.PreviousDecl,
+			Position2:    n.position2(),
+			Name:         n.Name,
+			Type:         n.qualType(),
+			Type2:        n.desugaredType(),
+			IsExtern:     n.StorageClass == "extern",
+			IsImplicit:   n.IsImplicit,
+			IsUsed:       n.IsUsed,
+			IsReferenced: n.IsReferenced,
+			IsStatic:     n.StorageClass == "static",
+			IsInline:     n.Inline,
+			ChildNodes:   []Node{},
+		}, nil
+	case "FunctionNoProtoType":
+		return &FunctionNoProtoType{Addr: addr, Type: n.qualType(), CallingConv: n.CC, ChildNodes: []Node{}}, nil
+	case "FunctionProtoType":
+		return &FunctionProtoType{Addr: addr, Type: n.qualType(), Kind: n.CC, ChildNodes: []Node{}}, nil
+	case "ForStmt":
+		return &ForStmt{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "GCCAsmStmt":
+		return &GCCAsmStmt{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "GotoStmt":
+		return &GotoStmt{
+			Addr:       addr,
+			Pos:        pos,
+			Name:       c.labels[n.TargetLabelDeclID],
+			Position2:  n.TargetLabelDeclID,
+			ChildNodes: []Node{},
+		}, nil
+	case "HTMLEndTagComment":
+		return &HTMLEndTagComment{Addr: addr, Pos: pos, Name: n.Name, ChildNodes: []Node{}}, nil
+	case "HTMLStartTagComment":
+		return &HTMLStartTagComment{Addr: addr, Pos: pos, Name: n.Name, ChildNodes: []Node{}}, nil
+	case "IfStmt":
+		return &IfStmt{Addr: addr, Pos: pos, HasElse: n.HasElse, ChildNodes: []Node{}}, nil
+	case "ImplicitCastExpr":
+		return &ImplicitCastExpr{
+			Addr:               addr,
+			Pos:                pos,
+			Type:               n.qualType(),
+			Type2:              n.desugaredType(),
+			Kind:               n.CastKind,
+			PartOfExplicitCast: n.IsPartOfExplicitCast,
+			ChildNodes:         []Node{},
+		}, nil
+	case "ImplicitValueInitExpr":
+		return &ImplicitValueInitExpr{
+			Addr:       addr,
+			Pos:        pos,
+			Type1:      n.qualType(),
+			Type2:      n.desugaredType(),
+			ChildNodes: []Node{},
+		}, nil
+	case "IncompleteArrayType":
+		return &IncompleteArrayType{Addr: addr, Type: n.qualType(), ChildNodes: []Node{}}, nil
+	case "IndirectFieldDecl":
+		return &IndirectFieldDecl{
+			Addr:       addr,
+			Pos:        pos,
+			Position2:  n.position2(),
+			Implicit:   n.IsImplicit,
+			Name:       n.Name,
+			Type:       n.qualType(),
+			ChildNodes: []Node{},
+		}, nil
+	case "InitListExpr":
+		return &InitListExpr{
+			Addr:       addr,
+			Pos:        pos,
+			Type1:      n.qualType(),
+			Type2:      n.desugaredType(),
+			ChildNodes: []Node{},
+		}, nil
+	case "InlineCommandComment":
+		return &InlineCommandComment{Addr: addr, Pos: pos, Other: n.Name, ChildNodes: []Node{}}, nil
+	case "IntegerLiteral":
+		s, err := n.stringValue()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read integer literal %s: %v", n.ID, err)
+		}
+
+		return &IntegerLiteral{
+			Addr:       addr,
+			Pos:        pos,
+			Type:       n.qualType(),
+			Value:      s,
+			ChildNodes: []Node{},
+		}, nil
+	case "LabelStmt":
+		return &LabelStmt{Addr: addr, Pos: pos, Name: n.Name, ChildNodes: []Node{}}, nil
+	case "MallocAttr":
+		return &MallocAttr{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "MaxFieldAlignmentAttr":
+		return &MaxFieldAlignmentAttr{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "MemberExpr":
+		return &MemberExpr{
+			Addr:                 addr,
+			Pos:                  pos,
+			Type:                 n.qualType(),
+			Type2:                n.desugaredType(),
+			Name:                 n.Name,
+			IsLvalue:             n.isLvalue(),
+			IsBitfield:           n.IsBitfield,
+			Address2:             n.ReferencedMemberDecl,
+			IsPointer:            n.IsArrow,
+			NonODRUseUnevaluated: n.NonOdrUseReason == "unevaluated",
+			ChildNodes:           []Node{},
+		}, nil
+	case "ModeAttr":
+		return &ModeAttr{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "NoAliasAttr":
+		return &NoAliasAttr{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "NoInlineAttr":
+		return &NoInlineAttr{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "NoThrowAttr":
+		return &NoThrowAttr{
+			Addr:       addr,
+			Pos:        pos,
+			Implicit:   n.Implicit,
+			Inherited:  n.Inherited,
+			ChildNodes: []Node{},
+		}, nil
+	case "NonNullAttr":
+		return &NonNullAttr{Addr: addr, Pos: pos, Inherited: n.Inherited, ChildNodes: []Node{}}, nil
+	case "NotTailCalledAttr":
+		return &NotTailCalledAttr{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "OffsetOfExpr":
+		return &OffsetOfExpr{Addr: addr, Pos: pos, Type: n.qualType(), ChildNodes: []Node{}}, nil
+	case "PackedAttr":
+		return &PackedAttr{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "ParagraphComment":
+		return &ParagraphComment{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "ParamCommandComment":
+		return &ParamCommandComment{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "ParenExpr":
+		return &ParenExpr{
+			Addr:       addr,
+			Pos:        pos,
+			Type:       n.qualType(),
+			Type2:      n.desugaredType(),
+			Lvalue:     n.isLvalue(),
+			IsBitfield: n.IsBitfield,
+			ChildNodes: []Node{},
+		}, nil
+	case "ParenType":
+		return &ParenType{Addr: addr, Type: n.qualType(), Sugar: true, ChildNodes: []Node{}}, nil
+	case "ParmVarDecl":
+		return &ParmVarDecl{
+			Addr:         addr,
+			Pos:          pos,
+			Position2:    n.position2(),
+			Name:         n.Name,
+			Type:         n.qualType(),
+			Type2:        n.desugaredType(),
+			IsUsed:       n.IsUsed,
+			IsReferenced: n.IsReferenced,
+			IsRegister:   n.StorageClass == "register",
+			ChildNodes:   []Node{},
+		}, nil
+	case "PointerType":
+		return &PointerType{Addr: addr, Type: n.qualType(), ChildNodes: []Node{}}, nil
+	case "PredefinedExpr":
+		return &PredefinedExpr{
+			Addr:       addr,
+			Pos:        pos,
+			Type:       n.qualType(),
+			Name:       n.Name,
+			Lvalue:     true,
+			ChildNodes: []Node{},
+		}, nil
+	case "PureAttr":
+		return &PureAttr{
+			Addr:       addr,
+			Pos:        pos,
+			Implicit:   n.Implicit,
+			Inherited:  n.Inherited,
+			ChildNodes: []Node{},
+		}, nil
+	case "QualType":
+		return &QualType{Addr: addr, Type: n.qualType(), Kind: n.Qualifiers, ChildNodes: []Node{}}, nil
+	case "RecordDecl":
+		return &RecordDecl{
+			Addr:       addr,
+			Pos:        pos,
+			Prev:       n.PreviousDecl,
+			Position2:  n.position2(),
+			Kind:       n.TagUsed,
+			Name:       n.Name,
+			Definition: n.CompleteDefinition,
+			ChildNodes: []Node{},
+		}, nil
+	case "RecordType":
+		r := &RecordType{Addr: addr, Type: n.qualType(), ChildNodes: []Node{}}
+		if n.Decl != nil {
+			r.AddChild(&Record{Addr: ParseAddress(n.Decl.ID), Type: n.Decl.Name, ChildNodes: []Node{}})
+		}
+
+		return r, nil
+	case "RestrictAttr":
+		return &RestrictAttr{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "ReturnStmt":
+		return &ReturnStmt{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "ReturnsTwiceAttr":
+		return &ReturnsTwiceAttr{
+			Addr:       addr,
+			Pos:        pos,
+			Implicit:   n.Implicit,
+			Inherited:  n.Inherited,
+			ChildNodes: []Node{},
+		}, nil
+	case "SentinelAttr":
+		return &SentinelAttr{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "StmtExpr":
+		return &StmtExpr{Addr: addr, Pos: pos, Type: n.qualType(), ChildNodes: []Node{}}, nil
+	case "StringLiteral":
+		s, err := n.stringValue()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read string literal %s: %v", n.ID, err)
+		}
+
+		// The value is the C literal including the quotes. Wide strings
+		// are prefixed with an "L", the same as in the text dump.
+		value, err := strconv.Unquote(strings.TrimPrefix(s, "L"))
+		if err != nil {
+			return nil, fmt.Errorf("cannot unquote string literal %s: %v", s, err)
+		}
+
+		return &StringLiteral{
+			Addr:       addr,
+			Pos:        pos,
+			Type:       n.qualType(),
+			Value:      value,
+			Lvalue:     n.isLvalue(),
+			ChildNodes: []Node{},
+		}, nil
+	case "SwitchStmt":
+		return &SwitchStmt{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "TextComment":
+		return &TextComment{Addr: addr, Pos: pos, Text: n.Text, ChildNodes: []Node{}}, nil
+	case "TranslationUnitDecl":
+		return &TranslationUnitDecl{Addr: addr, ChildNodes: []Node{}}, nil
+	case "TransparentUnionAttr":
+		return &TransparentUnionAttr{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "TypedefDecl":
+		return &TypedefDecl{
+			Addr:         addr,
+			Pos:          pos,
+			Position2:    n.position2(),
+			Name:         n.Name,
+			Type:         n.qualType(),
+			Type2:        n.desugaredType(),
+			IsImplicit:   n.IsImplicit,
+			IsReferenced: n.IsReferenced,
+			ChildNodes:   []Node{},
+		}, nil
+	case "TypedefType":
+		t := &TypedefType{Addr: addr, Type: n.qualType(), Tags: "sugar", ChildNodes: []Node{}}
+		if n.Decl != nil {
+			t.AddChild(&Typedef{Addr: ParseAddress(n.Decl.ID), Type: n.Decl.Name, ChildNodes: []Node{}})
+		}
+
+		return t, nil
+	case "UnaryExprOrTypeTraitExpr":
+		return &UnaryExprOrTypeTraitExpr{
+			Addr:       addr,
+			Pos:        pos,
+			Type1:      n.qualType(),
+			Function:   n.Name,
+			Type2:      jsonTypeName(n.ArgType),
+			ChildNodes: []Node{},
+		}, nil
+	case "UnaryOperator":
+		return &UnaryOperator{
+			Addr:           addr,
+			Pos:            pos,
+			Type:           n.qualType(),
+			Type2:          n.desugaredType(),
+			IsLvalue:       n.isLvalue(),
+			IsPrefix:       !n.IsPostfix,
+			Operator:       n.Opcode,
+			CannotOverflow: n.CanOverflow != nil && !*n.CanOverflow,
+			ChildNodes:     []Node{},
+		}, nil
+	case "UnusedAttr":
+		return &UnusedAttr{Addr: addr, Pos: pos, IsUnused: true, ChildNodes: []Node{}}, nil
+	case "VAArgExpr":
+		return &VAArgExpr{Addr: addr, Pos: pos, Type: n.qualType(), ChildNodes: []Node{}}, nil
+	case "VarDecl":
+		return &VarDecl{
+			Addr:         addr,
+			Parent:       ParseAddress(n.ParentDeclContextID),
+			Pos:          pos,
+			Position2:    n.position2(),
+			Name:         n.Name,
+			Type:         n.qualType(),
+			Type2:        n.desugaredType(),
+			IsExtern:     n.StorageClass == "extern",
+			IsUsed:       n.IsUsed,
+			IsNRVO:       n.NRVO,
+			IsCInit:      n.Init == "c",
+			IsReferenced: n.IsReferenced,
+			IsStatic:     n.StorageClass == "static",
+			IsRegister:   n.StorageClass == "register",
+			ChildNodes:   []Node{},
+		}, nil
+	case "VerbatimBlockComment":
+		return &VerbatimBlockComment{
+			Addr:       addr,
+			Pos:        pos,
+			Name:       n.Name,
+			CloseName:  n.CloseName,
+			ChildNodes: []Node{},
+		}, nil
+	case "VerbatimBlockLineComment":
+		return &VerbatimBlockLineComment{Addr: addr, Pos: pos, Text: n.Text, ChildNodes: []Node{}}, nil
+	case "VerbatimLineComment":
+		return &VerbatimLineComment{Addr: addr, Pos: pos, Text: n.Text, ChildNodes: []Node{}}, nil
+	case "VisibilityAttr":
+		return &VisibilityAttr{Addr: addr, Pos: pos, IsInherited: n.Inherited, ChildNodes: []Node{}}, nil
+	case "WarnUnusedResultAttr":
+		return &WarnUnusedResultAttr{Addr: addr, Pos: pos, Inherited: n.Inherited, ChildNodes: []Node{}}, nil
+	case "WeakAttr":
+		return &WeakAttr{Addr: addr, Pos: pos, Inherited: n.Inherited, ChildNodes: []Node{}}, nil
+	case "WhileStmt":
+		return &WhileStmt{Addr: addr, Pos: pos, ChildNodes: []Node{}}, nil
+	case "NullStmt":
+		return nil, nil
+	}
+
+	// There is no raw line for the JSON AST so the kind, address and type
+	// are used to make a line that looks like the text output.
+	context := n.Kind + " " + n.ID
+	if t := n.qualType(); t != "" {
+		context += " '" + t + "'"
+	}
+
+	return &Unknown{
+		Addr:       addr,
+		Pos:        pos,
+		Name:       n.Kind,
+		Type:       n.qualType(),
+		Context:    context,
+		ChildNodes: []Node{},
+	}, nil
+}
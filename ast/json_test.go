@@ -0,0 +1,246 @@
+package ast
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/util"
+)
+
+// This is a trimmed version of:
+//
+//     clang -Xclang -ast-dump=json -fsyntax-only main.c
+//
+// for the program:
+//
+//     int main() {
+//         for (;;) { goto end; }
+//     end:
+//         return 'a' + 1.5;
+//     }
+//
+var jsonAST = `{
+  "id": "0x1",
+  "kind": "TranslationUnitDecl",
+  "loc": {},
+  "range": {"begin": {}, "end": {}},
+  "inner": [
+    {
+      "id": "0x2",
+      "kind": "FunctionDecl",
+      "loc": {"offset": 4, "file": "pp.c", "line": 10, "presumedFile": "main.c", "presumedLine": 1, "col": 5, "tokLen": 4},
+      "range": {
+        "begin": {"offset": 0, "col": 1, "tokLen": 3},
+        "end": {"offset": 80, "line": 14, "presumedLine": 5, "col": 1, "tokLen": 1}
+      },
+      "name": "main",
+      "type": {"qualType": "int ()"},
+      "inner": [
+        {
+          "id": "0x3",
+          "kind": "CompoundStmt",
+          "range": {
+            "begin": {"offset": 11, "line": 10, "presumedLine": 1, "col": 12, "tokLen": 1},
+            "end": {"offset": 80, "line": 14, "presumedLine": 5, "col": 1, "tokLen": 1}
+          },
+          "inner": [
+            {
+              "id": "0x4",
+              "kind": "ForStmt",
+              "range": {
+                "begin": {"offset": 17, "line": 11, "presumedLine": 2, "col": 5, "tokLen": 3},
+                "end": {"offset": 38, "col": 26, "tokLen": 1}
+              },
+              "inner": [
+                {}, {}, {}, {},
+                {
+                  "id": "0x5",
+                  "kind": "CompoundStmt",
+                  "range": {
+                    "begin": {"offset": 26, "col": 14, "tokLen": 1},
+                    "end": {"offset": 38, "col": 26, "tokLen": 1}
+                  },
+                  "inner": [
+                    {
+                      "id": "0x6",
+                      "kind": "GotoStmt",
+                      "range": {
+                        "begin": {"offset": 28, "col": 16, "tokLen": 4},
+                        "end": {"offset": 33, "col": 21, "tokLen": 3}
+                      },
+                      "targetLabelDeclId": "0x7"
+                    }
+                  ]
+                }
+              ]
+            },
+            {
+              "id": "0x8",
+              "kind": "LabelStmt",
+              "range": {
+                "begin": {"offset": 40, "line": 12, "presumedLine": 3, "col": 1, "tokLen": 3},
+                "end": {"offset": 70, "line": 13, "presumedLine": 4, "col": 22, "tokLen": 3}
+              },
+              "declId": "0x7",
+              "name": "end",
+              "inner": [
+                {
+                  "id": "0x9",
+                  "kind": "ReturnStmt",
+                  "range": {
+                    "begin": {"offset": 49, "col": 5, "tokLen": 6},
+                    "end": {"offset": 70, "col": 22, "tokLen": 3}
+                  },
+                  "inner": [
+                    {
+                      "id": "0xa",
+                      "kind": "ImplicitCastExpr",
+                      "range": {
+                        "begin": {"offset": 56, "col": 12, "tokLen": 3},
+                        "end": {"offset": 62, "col": 18, "tokLen": 3}
+                      },
+                      "type": {"qualType": "int"},
+                      "valueCategory": "prvalue",
+                      "castKind": "FloatingToIntegral",
+                      "inner": [
+                        {
+                          "id": "0xb",
+                          "kind": "BinaryOperator",
+                          "range": {
+                            "begin": {"offset": 56, "col": 12, "tokLen": 3},
+                            "end": {"offset": 62, "col": 18, "tokLen": 3}
+                          },
+                          "type": {"qualType": "double"},
+                          "valueCategory": "prvalue",
+                          "opcode": "+",
+                          "inner": [
+                            {
+                              "id": "0xc",
+                              "kind": "CharacterLiteral",
+                              "range": {
+                                "begin": {"offset": 56, "col": 12, "tokLen": 3},
+                                "end": {"offset": 56, "col": 12, "tokLen": 3}
+                              },
+                              "type": {"qualType": "char"},
+                              "valueCategory": "prvalue",
+                              "value": 97
+                            },
+                            {
+                              "id": "0xd",
+                              "kind": "FloatingLiteral",
+                              "range": {
+                                "begin": {"offset": 62, "col": 18, "tokLen": 3},
+                                "end": {"offset": 62, "col": 18, "tokLen": 3}
+                              },
+                              "type": {"qualType": "double"},
+                              "valueCategory": "prvalue",
+                              "value": "1.5"
+                            }
+                          ]
+                        }
+                      ]
+                    }
+                  ]
+                }
+              ]
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}`
+
+func TestParseJSON(t *testing.T) {
+	root, err := ParseJSON(strings.NewReader(jsonAST))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tu, ok := root.(*TranslationUnitDecl)
+	if !ok {
+		t.Fatalf("root is %T", root)
+	}
+
+	f := tu.Children()[0].(*FunctionDecl)
+	if f.Name != "main" || f.Type != "int ()" {
+		t.Errorf("wrong function: %#v", f)
+	}
+
+	expectedPos := Position{
+		File:        "main.c",
+		Line:        1,
+		Column:      1,
+		LineEnd:     5,
+		ColumnEnd:   1,
+		StringValue: "main.c:1:1, line:5:1",
+	}
+	if !reflect.DeepEqual(f.Pos, expectedPos) {
+		t.Errorf("%s", util.ShowDiff(formatMultiLine(expectedPos),
+			formatMultiLine(f.Pos)))
+	}
+
+	body := f.Children()[0].(*CompoundStmt)
+	forStmt := body.Children()[0].(*ForStmt)
+	if len(forStmt.Children()) != 5 {
+		t.Fatalf("for statement must have 5 children, got %d",
+			len(forStmt.Children()))
+	}
+	for i := 0; i < 4; i++ {
+		if forStmt.Children()[i] != nil {
+			t.Errorf("child %d of for statement must be nil", i)
+		}
+	}
+
+	gotoStmt := forStmt.Children()[4].Children()[0].(*GotoStmt)
+	if gotoStmt.Name != "end" {
+		t.Errorf("goto label is '%s'", gotoStmt.Name)
+	}
+	if gotoStmt.Pos.Line != 2 || gotoStmt.Pos.Column != 16 {
+		t.Errorf("wrong goto position: %#v", gotoStmt.Pos)
+	}
+
+	label := body.Children()[1].(*LabelStmt)
+	if label.Name != "end" || label.Pos.Line != 3 || label.Pos.LineEnd != 4 {
+		t.Errorf("wrong label: %#v", label)
+	}
+
+	cast := label.Children()[0].Children()[0].(*ImplicitCastExpr)
+	if cast.Kind != "FloatingToIntegral" || cast.Type != "int" {
+		t.Errorf("wrong cast: %#v", cast)
+	}
+
+	binary := cast.Children()[0].(*BinaryOperator)
+	if binary.Operator != "+" {
+		t.Errorf("wrong operator: %s", binary.Operator)
+	}
+
+	if c := binary.Children()[0].(*CharacterLiteral); c.Value != 'a' {
+		t.Errorf("wrong character literal: %d", c.Value)
+	}
+
+	if f := binary.Children()[1].(*FloatingLiteral); f.Value != 1.5 {
+		t.Errorf("wrong floating literal: %f", f.Value)
+	}
+}
+
+func TestParseJSONUnknownNode(t *testing.T) {
+	node, err := ParseJSON(strings.NewReader(
+		`{"id": "0x1", "kind": "AtomicExpr", "type": {"qualType": "int"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Unknown{
+		Addr:       0x1,
+		Name:       "AtomicExpr",
+		Type:       "int",
+		Context:    "AtomicExpr 0x1 'int'",
+		ChildNodes: []Node{},
+	}
+	if !reflect.DeepEqual(expected, node) {
+		t.Errorf("%s", util.ShowDiff(formatMultiLine(expected),
+			formatMultiLine(node)))
+	}
+}
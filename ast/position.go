@@ -409,6 +409,8 @@ func setPosition(node Node, position Position) {
 		n.Pos = position
 	case *WhileStmt:
 		n.Pos = position
+	case *Unknown:
+		n.Pos = position
 	case *TypedefType, *Typedef, *TranslationUnitDecl, *RecordType, *Record,
 		*QualType, *PointerType, *DecayedType, *ParenType,
 		*IncompleteArrayType, *FunctionNoProtoType, *FunctionProtoType,
@@ -0,0 +1,86 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/elliotchance/c2go/util"
+)
+
+// Unknown is used for any node that c2go does not understand. This may be a
+// node kind that was added in a newer version of clang, or a known node that
+// is printed in a format that cannot be parsed.
+//
+// The original line from the clang AST is kept so the transpiler can produce a
+// precise warning (and stub) rather than failing the whole file.
+type Unknown struct {
+	Addr       Address
+	Pos        Position
+	Name       string
+	Type       string
+	Context    string
+	ChildNodes []Node
+
+	// ParseError is the reason the line could not be parsed if the node kind
+	// is known. It is nil if the node kind is not known.
+	ParseError error
+}
+
+func parseUnknown(fullline string) *Unknown {
+	parts := strings.SplitN(fullline, " ", 2)
+	line := ""
+	if len(parts) > 1 {
+		line = parts[1]
+	}
+
+	groups := util.GetRegex(
+		`^(?P<address>0x[0-9a-f]+)?[^<']*(?:<(?P<position>[^<>]*(?:<[^<>]*>[^<>]*)*)>)?[^']*(?:'(?P<type>[^']*)')?`,
+	).FindStringSubmatch(line)
+
+	n := &Unknown{
+		Name:       parts[0],
+		Context:    fullline,
+		ChildNodes: []Node{},
+	}
+	if len(groups) > 0 {
+		n.Addr = ParseAddress(groups[1])
+		n.Pos = parseUnknownPosition(groups[2])
+		n.Type = groups[3]
+	}
+
+	return n
+}
+
+// parseUnknownPosition is the same as NewPositionFromString except that an
+// invalid position will return an empty position rather than panicking.
+func parseUnknownPosition(s string) (pos Position) {
+	defer func() {
+		if r := recover(); r != nil {
+			pos = Position{}
+		}
+	}()
+
+	return NewPositionFromString(s)
+}
+
+// AddChild adds a new child node. Child nodes can then be accessed with the
+// Children attribute.
+func (n *Unknown) AddChild(node Node) {
+	n.ChildNodes = append(n.ChildNodes, node)
+}
+
+// Address returns the numeric address of the node. See the documentation for
+// the Address type for more information.
+func (n *Unknown) Address() Address {
+	return n.Addr
+}
+
+// Children returns the child nodes. If this node does not have any children or
+// this node does not support children it will always return an empty slice.
+func (n *Unknown) Children() []Node {
+	return n.ChildNodes
+}
+
+// Position returns the position in the original source code.
+func (n *Unknown) Position() Position {
+	return n.Pos
+}
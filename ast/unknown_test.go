@@ -0,0 +1,61 @@
+package ast
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/elliotchance/c2go/util"
+)
+
+func TestUnknown(t *testing.T) {
+	nodes := map[string]Node{
+		`OMPParallelDirective 0x55e8a5a4c8f8 <line:5:1, col:21>`: &Unknown{
+			Addr:       0x55e8a5a4c8f8,
+			Pos:        NewPositionFromString("line:5:1, col:21"),
+			Name:       "OMPParallelDirective",
+			Context:    "OMPParallelDirective 0x55e8a5a4c8f8 <line:5:1, col:21>",
+			ChildNodes: []Node{},
+		},
+		`BlockExpr 0x7f9a4a01e2c8 <col:11, line:7:3> 'int (^)(int)'`: &Unknown{
+			Addr:       0x7f9a4a01e2c8,
+			Pos:        NewPositionFromString("col:11, line:7:3"),
+			Name:       "BlockExpr",
+			Type:       "int (^)(int)",
+			Context:    "BlockExpr 0x7f9a4a01e2c8 <col:11, line:7:3> 'int (^)(int)'",
+			ChildNodes: []Node{},
+		},
+		`AtomicExpr 0x2a93170 <<invalid sloc>> 'int'`: &Unknown{
+			Addr:       0x2a93170,
+			Pos:        Position{},
+			Name:       "AtomicExpr",
+			Type:       "int",
+			Context:    "AtomicExpr 0x2a93170 <<invalid sloc>> 'int'",
+			ChildNodes: []Node{},
+		},
+
+		// A known node that cannot be parsed is also unknown.
+		`IntegerLiteral 0x7fbe9804bcc8 <col:14> 'int' foo`: &Unknown{
+			Addr:       0x7fbe9804bcc8,
+			Pos:        NewPositionFromString("col:14"),
+			Name:       "IntegerLiteral",
+			Type:       "int",
+			Context:    "IntegerLiteral 0x7fbe9804bcc8 <col:14> 'int' foo",
+			ChildNodes: []Node{},
+			ParseError: errors.New("could not match regexp with string: " +
+				`^(?P<address>[0-9a-fx]+) <(?P<position>.*)> '(?P<type>.*?)' (?P<value>\d+)[\s]*$: ` +
+				"0x7fbe9804bcc8 <col:14> 'int' foo"),
+		},
+	}
+
+	for line, expected := range nodes {
+		t.Run(line, func(t *testing.T) {
+			actual := Parse(line)
+
+			if !reflect.DeepEqual(expected, actual) {
+				t.Errorf("%s", util.ShowDiff(formatMultiLine(expected),
+					formatMultiLine(actual)))
+			}
+		})
+	}
+}
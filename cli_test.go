@@ -34,6 +34,18 @@ var cliTests = map[string][]string{
 
 	// Test that help is printed if help flag is set, even if file is given
 	"AstHelpFlag": {"test", "ast", "-h", "foo.c"},
+
+	// Test that help is printed if no compilation database is given
+	"ProjectNoFilesHelp": {"test", "project"},
+
+	// Test that help is printed if help flag is set, even if file is given
+	"ProjectHelpFlag": {"test", "project", "-h", "compile_commands.json"},
+
+	// Test that -o and -dir cannot be used together
+	"TranspileOutputWithDir": {"test", "transpile", "-o", "foo.go", "-dir", "out", "foo.c"},
+
+	// Test that -split-headers needs -dir
+	"ProjectSplitHeadersWithoutDir": {"test", "project", "-split-headers", "compile_commands.json"},
 }
 
 func TestCLI(t *testing.T) {
@@ -35,8 +35,14 @@ func Memset(dst unsafe.Pointer, val int32, size int32, _ int32) unsafe.Pointer {
 	return noarch.Memset(dst, val, size)
 }
 
-// Memcpy  is for __builtin___memcpy_chk and __builtin___memmove_chk
-//// https://opensource.apple.com/source/Libc/Libc-498/include/secure/_string.h
+// Memcpy  is for __builtin___memcpy_chk
+// https://opensource.apple.com/source/Libc/Libc-498/include/secure/_string.h
 func Memcpy(dst, src unsafe.Pointer, size int32, _ int32) unsafe.Pointer {
 	return noarch.Memcpy(dst, src, size)
 }
+
+// Memmove is for __builtin___memmove_chk
+// https://opensource.apple.com/source/Libc/Libc-498/include/secure/_string.h
+func Memmove(dst, src unsafe.Pointer, size int32, _ int32) unsafe.Pointer {
+	return noarch.Memmove(dst, src, size)
+}
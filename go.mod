@@ -1,3 +1,8 @@
 module github.com/elliotchance/c2go
 
-require golang.org/x/tools v0.0.0-20181109182537-4e34152f1676
+go 1.18
+
+require (
+	github.com/bradleyjkemp/cupaloy v2.3.0+incompatible
+	golang.org/x/tools v0.0.0-20181109182537-4e34152f1676
+)
@@ -11,22 +11,17 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
-	"path"
 	"path/filepath"
-	"runtime"
 	"strings"
 
-	"errors"
-
-	"github.com/elliotchance/c2go/ast"
-	"github.com/elliotchance/c2go/preprocessor"
 	"github.com/elliotchance/c2go/program"
-	"github.com/elliotchance/c2go/transpiler"
+	"github.com/elliotchance/c2go/transpile"
 )
 
 var stderr io.Writer = os.Stderr
@@ -48,6 +43,34 @@ type ProgramArgs struct {
 	outputFile  string
 	packageName string
 
+	// Use the JSON output of the clang AST dump rather than the text output.
+	// The JSON output is more stable between versions of clang and contains
+	// exact literal values.
+	jsonAST bool
+
+	// Write one Go file for each C file to this directory, rather than a
+	// single Go file.
+	outputDir string
+
+	// Also write one Go file for each user header. Only used with outputDir.
+	splitHeaders bool
+
+	// Transpile the function-like macros in user headers to generic Go
+	// functions.
+	macroFunctions bool
+
+	// Read the translation units and their clang flags from this
+	// compile_commands.json file rather than using inputFiles.
+	compilationDatabase string
+
+	// Write the diagnostics (warnings and errors) in this format ("json" or
+	// "sarif"). No diagnostics file is written if this is empty.
+	diagnosticsFormat string
+
+	// The file for the diagnostics. If it is empty the name is based on the
+	// output file, for example "foo.sarif".
+	diagnosticsFile string
+
 	// A private option to output the Go as a *_test.go file.
 	outputAsTest bool
 }
@@ -63,255 +86,160 @@ func DefaultProgramArgs() ProgramArgs {
 	}
 }
 
-func readAST(data []byte) []string {
-	return strings.Split(string(data), "\n")
-}
-
-type treeNode struct {
-	indent int
-	node   ast.Node
-}
-
-func convertLinesToNodes(lines []string) []treeNode {
-	nodes := make([]treeNode, len(lines))
-	var counter int
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
+// Start begins transpiling an input file.
+func Start(args ProgramArgs) (err error) {
+	opts := transpile.Options{
+		InputFiles:    args.inputFiles,
+		ClangFlags:    args.clangFlags,
+		PackageName:   args.packageName,
+		Verbose:       args.verbose,
+		VerboseOutput: os.Stdout,
+		JSONAST:       args.jsonAST,
+		OutputAsTest:  args.outputAsTest,
+		SplitFiles:    args.outputDir != "",
+		SplitHeaders:  args.splitHeaders,
+
+		MacroFunctions: args.macroFunctions,
+	}
+
+	if args.compilationDatabase != "" {
+		opts.TranslationUnits, err = transpile.ReadCompilationDatabase(
+			args.compilationDatabase)
+		if err != nil {
+			return err
 		}
-
-		// It is tempting to discard null AST nodes, but these may
-		// have semantic importance: for example, they represent omitted
-		// for-loop conditions, as in for(;;).
-		line = strings.Replace(line, "<<<NULL>>>", "NullStmt", 1)
-		trimmed := strings.TrimLeft(line, "|\\- `")
-		node := ast.Parse(trimmed)
-		indentLevel := (len(line) - len(trimmed)) / 2
-		nodes[counter] = treeNode{indentLevel, node}
-		counter++
-	}
-	nodes = nodes[0:counter]
-
-	return nodes
-}
-
-func convertLinesToNodesParallel(lines []string) []treeNode {
-	// function f separate full list on 2 parts and
-	// then each part can recursive run function f
-	var f func([]string, int) []treeNode
-
-	f = func(lines []string, deep int) []treeNode {
-		deep = deep - 2
-		part := len(lines) / 2
-
-		var tr1 = make(chan []treeNode)
-		var tr2 = make(chan []treeNode)
-
-		go func(lines []string, deep int) {
-			if deep <= 0 || len(lines) < deep {
-				tr1 <- convertLinesToNodes(lines)
-				return
-			}
-			tr1 <- f(lines, deep)
-		}(lines[0:part], deep)
-
-		go func(lines []string, deep int) {
-			if deep <= 0 || len(lines) < deep {
-				tr2 <- convertLinesToNodes(lines)
-				return
-			}
-			tr2 <- f(lines, deep)
-		}(lines[part:], deep)
-
-		defer close(tr1)
-		defer close(tr2)
-
-		return append(<-tr1, <-tr2...)
-	}
-
-	// Parameter of deep - can be any, but effective to use
-	// same amount of CPU
-	return f(lines, runtime.NumCPU())
-}
-
-// buildTree converts an array of nodes, each prefixed with a depth into a tree.
-func buildTree(nodes []treeNode, depth int) []ast.Node {
-	if len(nodes) == 0 {
-		return []ast.Node{}
 	}
 
-	// Split the list into sections, treat each section as a tree with its own
-	// root.
-	sections := [][]treeNode{}
-	for _, node := range nodes {
-		if node.indent == depth {
-			sections = append(sections, []treeNode{node})
-		} else {
-			sections[len(sections)-1] = append(sections[len(sections)-1], node)
+	if args.ast {
+		astPP, err := transpile.AST(opts)
+		if err != nil {
+			return err
 		}
-	}
 
-	results := []ast.Node{}
-	for _, section := range sections {
-		slice := []treeNode{}
-		for _, n := range section {
-			if n.indent > depth {
-				slice = append(slice, n)
-			}
+		if args.jsonAST {
+			fmt.Println(string(astPP))
+			return nil
 		}
 
-		children := buildTree(slice, depth+1)
-		for _, child := range children {
-			section[0].node.AddChild(child)
+		for _, l := range strings.Split(string(astPP), "\n") {
+			fmt.Println(l)
 		}
-		results = append(results, section[0].node)
+		fmt.Println()
+
+		return nil
 	}
 
-	return results
-}
+	switch transpile.DiagnosticsFormat(args.diagnosticsFormat) {
+	case "", transpile.DiagnosticsJSON, transpile.DiagnosticsSARIF:
+	default:
+		return fmt.Errorf("unknown diagnostics format: %s", args.diagnosticsFormat)
+	}
 
-// Start begins transpiling an input file.
-func Start(args ProgramArgs) (err error) {
-	if args.verbose {
-		fmt.Println("Start tanspiling ...")
+	result, err := transpile.Transpile(opts)
+	if err != nil {
+		return err
 	}
 
-	// 1. Compile it first (checking for errors)
-	for _, in := range args.inputFiles {
-		_, err := os.Stat(in)
+	if args.outputDir != "" {
+		err = writeOutputDir(args, result.Files)
 		if err != nil {
-			return fmt.Errorf("Input file %s is not found", in)
+			return err
 		}
-	}
 
-	// 2. Preprocess
-	if args.verbose {
-		fmt.Println("Running clang preprocessor...")
-	}
+		if args.diagnosticsFormat != "" {
+			return writeDiagnostics(args,
+				filepath.Join(args.outputDir, "c2go.go"), result.Diagnostics)
+		}
 
-	pp, comments, includes, err := preprocessor.Analyze(args.inputFiles, args.clangFlags, args.verbose)
-	if err != nil {
-		return fmt.Errorf("issue running preprocessor: %w", err)
+		return nil
 	}
 
-	if args.verbose {
-		fmt.Println("Writing preprocessor ...")
-	}
-	dir, err := os.MkdirTemp("", "c2go")
-	if err != nil {
-		return fmt.Errorf("Cannot create temp folder: %v", err)
-	}
-	defer os.RemoveAll(dir) // clean up
+	outputFilePath := args.outputFile
 
-	ppFilePath := path.Join(dir, "pp.c")
-	err = os.WriteFile(ppFilePath, pp, 0644)
-	if err != nil {
-		return fmt.Errorf("writing to %s failed: %v", ppFilePath, err)
+	if outputFilePath == "" {
+		// Choose inputFile for creating name of output file
+		var input string
+		if len(opts.TranslationUnits) > 0 {
+			input = opts.TranslationUnits[0].File
+		} else {
+			input = args.inputFiles[0]
+		}
+		// We choose name for output Go code at the base
+		// on filename for choosed input file
+		cleanFileName := filepath.Clean(filepath.Base(input))
+		extension := filepath.Ext(input)
+		outputFilePath = cleanFileName[0:len(cleanFileName)-len(extension)] + ".go"
 	}
 
-	// 3. Generate JSON from AST
+	// write the output Go code
 	if args.verbose {
-		fmt.Println("Running clang for AST tree...")
+		fmt.Println("Writing the output Go code...")
 	}
-	astPP, err := exec.Command("clang", "-Xclang", "-ast-dump",
-		"-fsyntax-only", "-fno-color-diagnostics", ppFilePath).Output()
+	err = os.WriteFile(outputFilePath, []byte(result.GoCode), 0644)
 	if err != nil {
-		// If clang fails it still prints out the AST, so we have to run it
-		// again to get the real error.
-		errBody, _ := exec.Command("clang", ppFilePath).CombinedOutput()
-
-		panic("clang failed: " + err.Error() + ":\n\n" + string(errBody))
+		return fmt.Errorf("writing Go output file failed: %v", err)
 	}
 
-	if args.verbose {
-		fmt.Println("Reading clang AST tree...")
-	}
-	lines := readAST(astPP)
-	if args.ast {
-		for _, l := range lines {
-			fmt.Println(l)
-		}
-		fmt.Println()
+	// simplify Go code by `gofmt`
+	// error ignored, because it is not change the workflow
+	_, _ = exec.Command("gofmt", "-w", outputFilePath).Output()
 
-		return nil
+	if args.diagnosticsFormat != "" {
+		err = writeDiagnostics(args, outputFilePath, result.Diagnostics)
+		if err != nil {
+			return err
+		}
 	}
 
-	p := program.NewProgram()
-	p.Verbose = args.verbose
-	p.OutputAsTest = args.outputAsTest
-	p.Comments = comments
-	p.IncludeHeaders = includes
+	return nil
+}
 
-	// Converting to nodes
+// writeOutputDir writes each of the Go files to the output directory. The
+// directory is created if it does not exist.
+func writeOutputDir(args ProgramArgs, files map[string]string) error {
 	if args.verbose {
-		fmt.Println("Converting to nodes...")
+		fmt.Println("Writing the output Go code...")
 	}
-	nodes := convertLinesToNodesParallel(lines)
 
-	// build tree
-	if args.verbose {
-		fmt.Println("Building tree...")
-	}
-	tree := buildTree(nodes, 0)
-	ast.FixPositions(tree)
-	p.SetNodes(tree)
-
-	// Repair the character literals. See RepairCharacterLiteralsFromSource for
-	// more information.
-	characterErrors := ast.RepairCharacterLiteralsFromSource(tree[0], ppFilePath)
-
-	for _, cErr := range characterErrors {
-		message := fmt.Sprintf("could not read exact character literal: %s",
-			cErr.Err.Error())
-		p.AddMessage(p.GenerateWarningMessage(errors.New(message), cErr.Node))
+	err := os.MkdirAll(args.outputDir, 0755)
+	if err != nil {
+		return fmt.Errorf("cannot create output directory: %v", err)
 	}
 
-	// Repair the floating literals. See RepairFloatingLiteralsFromSource for
-	// more information.
-	floatingErrors := ast.RepairFloatingLiteralsFromSource(tree[0], ppFilePath)
-
-	for _, fErr := range floatingErrors {
-		message := fmt.Sprintf("could not read exact floating literal: %s",
-			fErr.Err.Error())
-		p.AddMessage(p.GenerateWarningMessage(errors.New(message), fErr.Node))
+	for name, code := range files {
+		err = os.WriteFile(filepath.Join(args.outputDir, name), []byte(code), 0644)
+		if err != nil {
+			return fmt.Errorf("writing Go output file failed: %v", err)
+		}
 	}
 
-	outputFilePath := args.outputFile
+	// simplify Go code by `gofmt`
+	// error ignored, because it is not change the workflow
+	_, _ = exec.Command("gofmt", "-w", args.outputDir).Output()
 
-	if outputFilePath == "" {
-		// Choose inputFile for creating name of output file
-		input := args.inputFiles[0]
-		// We choose name for output Go code at the base
-		// on filename for choosed input file
-		cleanFileName := filepath.Clean(filepath.Base(input))
-		extension := filepath.Ext(input)
-		outputFilePath = cleanFileName[0:len(cleanFileName)-len(extension)] + ".go"
-	}
+	return nil
+}
 
-	// transpile ast tree
-	if args.verbose {
-		fmt.Println("Transpiling tree...")
+func writeDiagnostics(args ProgramArgs, outputFilePath string, diagnostics []transpile.Diagnostic) error {
+	diagnosticsFilePath := args.diagnosticsFile
+	if diagnosticsFilePath == "" {
+		extension := filepath.Ext(outputFilePath)
+		diagnosticsFilePath = outputFilePath[0:len(outputFilePath)-len(extension)] +
+			"." + args.diagnosticsFormat
 	}
 
-	err = transpiler.TranspileAST(args.outputFile, args.packageName,
-		p, tree[0].(ast.Node))
+	f, err := os.Create(diagnosticsFilePath)
 	if err != nil {
-		return fmt.Errorf("cannot transpile AST : %v", err)
+		return fmt.Errorf("writing diagnostics file failed: %v", err)
 	}
+	defer f.Close()
 
-	// write the output Go code
-	if args.verbose {
-		fmt.Println("Writing the output Go code...")
-	}
-	err = os.WriteFile(outputFilePath, []byte(p.String()), 0644)
+	err = transpile.WriteDiagnostics(f,
+		transpile.DiagnosticsFormat(args.diagnosticsFormat), diagnostics)
 	if err != nil {
-		return fmt.Errorf("writing Go output file failed: %v", err)
+		return fmt.Errorf("writing diagnostics file failed: %v", err)
 	}
 
-	// simplify Go code by `gofmt`
-	// error ignored, because it is not change the workflow
-	_, _ = exec.Command("gofmt", "-w", outputFilePath).Output()
-
 	return nil
 }
 
@@ -334,17 +262,74 @@ var clangFlags inputDataFlags
 func init() {
 	transpileCommand.Var(&clangFlags, "clang-flag", "Pass arguments to clang. You may provide multiple -clang-flag items.")
 	astCommand.Var(&clangFlags, "clang-flag", "Pass arguments to clang. You may provide multiple -clang-flag items.")
+	projectCommand.Var(&clangFlags, "clang-flag", "Pass arguments to clang for every file. You may provide multiple -clang-flag items.")
+}
+
+// commandFlags are the flags that are shared by the transpile and project
+// commands.
+type commandFlags struct {
+	verbose         *bool
+	output          *string
+	packageName     *string
+	help            *bool
+	json            *bool
+	diagnostics     *string
+	outputDir       *string
+	splitHeaders    *bool
+	macroFunctions  *bool
+	diagnosticsFile *string
+}
+
+// newCommandFlags registers the flags that are shared by the transpile and
+// project commands in the flag set.
+func newCommandFlags(f *flag.FlagSet) *commandFlags {
+	return &commandFlags{
+		verbose:         f.Bool("V", false, "print progress as comments"),
+		output:          f.String("o", "", "output Go generated code to the specified file"),
+		packageName:     f.String("p", "main", "set the name of the generated package"),
+		help:            f.Bool("h", false, "print help information"),
+		json:            f.Bool("json", false, "read the clang AST as JSON rather than text"),
+		diagnostics:     f.String("diagnostics", "", "write warnings and errors to a file as json or sarif"),
+		outputDir:       f.String("dir", "", "output one Go file for each C file to the specified directory"),
+		splitHeaders:    f.Bool("split-headers", false, "with -dir, also output one Go file for each user header"),
+		macroFunctions:  f.Bool("macro-functions", false, "transpile function-like macros in user headers to generic Go functions"),
+		diagnosticsFile: f.String("diagnostics-file", "", "file for -diagnostics (default is the output file, or c2go in -dir, with a .json or .sarif extension)"),
+	}
+}
+
+// setArgs sets the arguments from the flags. It returns an error if the flags
+// cannot be used together.
+func (f *commandFlags) setArgs(args *ProgramArgs) error {
+	if *f.outputDir != "" && *f.output != "" {
+		return errors.New("-o cannot be used with -dir")
+	}
+	if *f.splitHeaders && *f.outputDir == "" {
+		return errors.New("-split-headers can only be used with -dir")
+	}
+
+	args.outputFile = *f.output
+	args.packageName = *f.packageName
+	args.verbose = *f.verbose
+	args.clangFlags = clangFlags
+	args.jsonAST = *f.json
+	args.diagnosticsFormat = *f.diagnostics
+	args.diagnosticsFile = *f.diagnosticsFile
+	args.outputDir = *f.outputDir
+	args.splitHeaders = *f.splitHeaders
+	args.macroFunctions = *f.macroFunctions
+
+	return nil
 }
 
 var (
-	versionFlag       = flag.Bool("v", false, "print the version and exit")
-	transpileCommand  = flag.NewFlagSet("transpile", flag.ContinueOnError)
-	verboseFlag       = transpileCommand.Bool("V", false, "print progress as comments")
-	outputFlag        = transpileCommand.String("o", "", "output Go generated code to the specified file")
-	packageFlag       = transpileCommand.String("p", "main", "set the name of the generated package")
-	transpileHelpFlag = transpileCommand.Bool("h", false, "print help information")
-	astCommand        = flag.NewFlagSet("ast", flag.ContinueOnError)
-	astHelpFlag       = astCommand.Bool("h", false, "print help information")
+	versionFlag      = flag.Bool("v", false, "print the version and exit")
+	transpileCommand = flag.NewFlagSet("transpile", flag.ContinueOnError)
+	transpileFlags   = newCommandFlags(transpileCommand)
+	projectCommand   = flag.NewFlagSet("project", flag.ContinueOnError)
+	projectFlags     = newCommandFlags(projectCommand)
+	astCommand       = flag.NewFlagSet("ast", flag.ContinueOnError)
+	astHelpFlag      = astCommand.Bool("h", false, "print help information")
+	astJSONFlag      = astCommand.Bool("json", false, "print the clang AST as JSON rather than text")
 )
 
 func main() {
@@ -360,6 +345,7 @@ func runCommand() int {
 		usage := "Usage: %s [-v] [<command>] [<flags>] file1.c ...\n\n"
 		usage += "Commands:\n"
 		usage += "  transpile\ttranspile an input C source file or files to Go\n"
+		usage += "  project\ttranspile the C files in a compile_commands.json to Go\n"
 		usage += "  ast\t\tprint AST before translated Go code\n\n"
 
 		usage += "Flags:\n"
@@ -369,6 +355,7 @@ func runCommand() int {
 
 	transpileCommand.SetOutput(stderr)
 	astCommand.SetOutput(stderr)
+	projectCommand.SetOutput(stderr)
 
 	flag.Parse()
 
@@ -394,7 +381,7 @@ func runCommand() int {
 		}
 
 		if *astHelpFlag || astCommand.NArg() == 0 {
-			fmt.Fprintf(stderr, "Usage: %s ast file.c\n", os.Args[0])
+			fmt.Fprintf(stderr, "Usage: %s ast [-json] file.c\n", os.Args[0])
 			astCommand.PrintDefaults()
 			return 1
 		}
@@ -402,6 +389,7 @@ func runCommand() int {
 		args.ast = true
 		args.inputFiles = astCommand.Args()
 		args.clangFlags = clangFlags
+		args.jsonAST = *astJSONFlag
 	case "transpile":
 		err := transpileCommand.Parse(os.Args[2:])
 		if err != nil {
@@ -409,17 +397,43 @@ func runCommand() int {
 			return 1
 		}
 
-		if *transpileHelpFlag || transpileCommand.NArg() == 0 {
-			fmt.Fprintf(stderr, "Usage: %s transpile [-V] [-o file.go] [-p package] file1.c ...\n", os.Args[0])
+		usage := func() {
+			fmt.Fprintf(stderr, "Usage: %s transpile [-V] [-json] [-diagnostics json|sarif] [-o file.go | -dir directory [-split-headers]] [-p package] file1.c ...\n", os.Args[0])
 			transpileCommand.PrintDefaults()
+		}
+		if *transpileFlags.help || transpileCommand.NArg() == 0 {
+			usage()
 			return 1
 		}
 
 		args.inputFiles = transpileCommand.Args()
-		args.outputFile = *outputFlag
-		args.packageName = *packageFlag
-		args.verbose = *verboseFlag
-		args.clangFlags = clangFlags
+		if err := transpileFlags.setArgs(&args); err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			usage()
+			return 1
+		}
+	case "project":
+		err := projectCommand.Parse(os.Args[2:])
+		if err != nil {
+			fmt.Printf("project command cannot parse: %v", err)
+			return 1
+		}
+
+		usage := func() {
+			fmt.Fprintf(stderr, "Usage: %s project [-V] [-json] [-diagnostics json|sarif] [-o file.go | -dir directory [-split-headers]] [-p package] compile_commands.json\n", os.Args[0])
+			projectCommand.PrintDefaults()
+		}
+		if *projectFlags.help || projectCommand.NArg() != 1 {
+			usage()
+			return 1
+		}
+
+		args.compilationDatabase = projectCommand.Arg(0)
+		if err := projectFlags.setArgs(&args); err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			usage()
+			return 1
+		}
 	default:
 		flag.Usage()
 		return 1
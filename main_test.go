@@ -359,6 +359,13 @@ func TestMultiFileTranspilation(t *testing.T) {
 			},
 			"42\n",
 		},
+		{
+			[]string{
+				"./tests/multi-static/main.c",
+				"./tests/multi-static/counter.c",
+			},
+			"4 12 4\n",
+		},
 	}
 
 	for pos, tc := range tcs {
@@ -396,6 +403,153 @@ func TestMultiFileTranspilation(t *testing.T) {
 	}
 }
 
+func TestProjectTranspilation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "c2go_project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // clean up
+
+	projectDir, err := filepath.Abs("./tests/project")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each file has its own include path and defines. The command for main.c
+	// also checks that quoted arguments are split correctly.
+	compileCommands := fmt.Sprintf(`[
+		{
+			"directory": %q,
+			"arguments": ["cc", "-Iinclude", "-DSCALE=2", "-c", "-o", "lib.o", "lib.c"],
+			"file": "lib.c"
+		},
+		{
+			"directory": %q,
+			"command": "cc -I ../lib/include -DGREETING=\\\"sum\\\" -c main.c",
+			"file": "main.c"
+		}
+	]`, path.Join(projectDir, "lib"), path.Join(projectDir, "main"))
+
+	args := DefaultProgramArgs()
+	args.compilationDatabase = path.Join(dir, "compile_commands.json")
+	args.outputFile = path.Join(dir, "project.go")
+	args.outputAsTest = true
+
+	err = ioutil.WriteFile(args.compilationDatabase, []byte(compileCommands), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Start(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run Go program
+	var buf bytes.Buffer
+	cmd := exec.Command("go", "run", args.outputFile)
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	err = cmd.Run()
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	if buf.String() != "sum=6\n1\n" {
+		t.Errorf("Wrong result: %v", buf.String())
+	}
+}
+
+func TestOutputDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "c2go_dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // clean up
+
+	args := DefaultProgramArgs()
+	args.inputFiles = []string{
+		"./tests/multi-struct/main.c",
+		"./tests/multi-struct/types.c",
+	}
+	args.outputDir = dir
+	args.splitHeaders = true
+	args.outputAsTest = true
+
+	err = Start(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(path.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"main.go", "types.go", "types_h.go", "c2go_shared.go"} {
+		if !util.InStrings(path.Join(dir, name), files) {
+			t.Errorf("%s was not created, got: %v", name, files)
+		}
+	}
+
+	// Run Go program
+	var buf bytes.Buffer
+	cmd := exec.Command("go", append([]string{"run"}, files...)...)
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	err = cmd.Run()
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	if buf.String() != "42\n" {
+		t.Errorf("Wrong result: %v", buf.String())
+	}
+}
+
+func TestMacroFunctions(t *testing.T) {
+	var args = DefaultProgramArgs()
+	args.inputFiles = []string{"./tests/macro-functions/main.c"}
+	dir, err := ioutil.TempDir("", "c2go_macro_functions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // clean up
+	args.outputFile = path.Join(dir, "main.go")
+	args.macroFunctions = true
+
+	err = Start(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	goCode, err := ioutil.ReadFile(args.outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"func MAX[T noarch.Number](a, b T) T {",
+		"MAX[int32](a, b)",
+		"func IS_EVEN[T noarch.Integer](x T) int32 {",
+		"func CLAMP[T noarch.Number](x T) T {",
+	} {
+		if !strings.Contains(string(goCode), s) {
+			t.Errorf("expected %s in:\n%s", s, goCode)
+		}
+	}
+
+	// Run Go program
+	var buf bytes.Buffer
+	cmd := exec.Command("go", "run", args.outputFile)
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	err = cmd.Run()
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	if buf.String() != "7\n3.0\n0\n10\n0 1\n" {
+		t.Errorf("Wrong result: %v", buf.String())
+	}
+}
+
 func TestTriGraph(t *testing.T) {
 	var args = DefaultProgramArgs()
 	args.inputFiles = []string{"./tests/trigraph/main.c"}
@@ -0,0 +1,23 @@
+package noarch
+
+// Integer is the type constraint for generic functions that were transpiled
+// from function-like macros that use integer operators (like "%" or "<<").
+//
+// Only the types that C arithmetic is done in are included. Smaller types are
+// promoted to int before they are used, so any constant up to INT_MAX can be
+// used with the type parameter.
+type Integer interface {
+	~int32 | ~int64 | ~uint32 | ~uint64
+}
+
+// Float is the type constraint for generic functions that were transpiled from
+// function-like macros that use floating-point constants.
+type Float interface {
+	~float32 | ~float64
+}
+
+// Number is the type constraint for generic functions that were transpiled
+// from all other function-like macros.
+type Number interface {
+	Integer | Float
+}
@@ -0,0 +1,21 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+// +build darwin dragonfly freebsd netbsd openbsd
+
+package noarch
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// dupFile makes the file descriptor of dst refer to the same open file as
+// src, like dup2(). See Freopen.
+func dupFile(src, dst *os.File) error {
+	return syscall.Dup2(int(src.Fd()), int(dst.Fd()))
+}
+
+// fdPath returns the path that opens the file of f again.
+func fdPath(f *os.File) string {
+	return fmt.Sprintf("/dev/fd/%d", f.Fd())
+}
@@ -0,0 +1,18 @@
+package noarch
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// dupFile makes the file descriptor of dst refer to the same open file as
+// src, like dup2(). See Freopen.
+func dupFile(src, dst *os.File) error {
+	return syscall.Dup3(int(src.Fd()), int(dst.Fd()), 0)
+}
+
+// fdPath returns the path that opens the file of f again.
+func fdPath(f *os.File) string {
+	return fmt.Sprintf("/proc/self/fd/%d", f.Fd())
+}
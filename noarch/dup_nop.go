@@ -0,0 +1,29 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package noarch
+
+import "os"
+
+// dupFile is not supported on this platform, so a stream that is reopened by
+// Freopen gets a new file descriptor.
+func dupFile(src, dst *os.File) error {
+	return errnoError(ENOSYS)
+}
+
+// fdPath returns "" because the file of a file descriptor cannot be opened
+// again on this platform.
+func fdPath(f *os.File) string {
+	return ""
+}
+
+// fileFlags is not supported on this platform, so freopen() without a file
+// name fails.
+func fileFlags(f *os.File) (int, error) {
+	return 0, errnoError(ENOSYS)
+}
+
+// setFileFlags is not supported on this platform.
+func setFileFlags(f *os.File, flags int) error {
+	return errnoError(ENOSYS)
+}
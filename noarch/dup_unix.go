@@ -0,0 +1,31 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package noarch
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileFlags returns the access mode and the status flags of the file, like
+// fcntl(fd, F_GETFL).
+func fileFlags(f *os.File) (int, error) {
+	fl, _, errno := syscall.Syscall(syscall.SYS_FCNTL, f.Fd(), syscall.F_GETFL, 0)
+	if errno != 0 {
+		return 0, errno
+	}
+
+	return int(fl), nil
+}
+
+// setFileFlags sets the status flags (like O_APPEND) of the file, like
+// fcntl(fd, F_SETFL, flags).
+func setFileFlags(f *os.File, flags int) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_FCNTL, f.Fd(), syscall.F_SETFL, uintptr(flags))
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
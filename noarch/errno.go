@@ -1,6 +1,8 @@
 package noarch
 
 import (
+	"fmt"
+	"os"
 	"strings"
 )
 
@@ -300,9 +302,35 @@ func Strerror(errno int32) *byte {
 	return &err2bytes[0][0]
 }
 
+// StrerrorR is the XSI-compliant strerror_r(). It copies the message of the
+// errno error code to buf, which has the size buflen. It returns ERANGE if the
+// message had to be truncated, and EINVAL for an unknown error code.
+func StrerrorR(errno int32, buf *byte, buflen int32) int32 {
+	var result int32
+	message, ok := err2bytes[int(errno)]
+	if !ok || errno == 0 {
+		message = []byte(fmt.Sprintf("Unknown error %d\x00", errno))
+		result = EINVAL
+	}
+	if buflen <= 0 {
+		return ERANGE
+	}
+
+	if int(buflen) < len(message) {
+		message = append(message[:buflen-1:buflen-1], 0)
+		result = ERANGE
+	}
+	copy(toByteSlice(buf, buflen), message)
+	return result
+}
+
 var currentErrno int32
 
 func setCurrentErrnoErr(err error) {
+	if pe, ok := err.(*os.PathError); ok {
+		err = pe.Err
+	}
+
 	if err == nil {
 		currentErrno = 0
 	} else {
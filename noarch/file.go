@@ -0,0 +1,476 @@
+package noarch
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// File represents the definition has been translated from the original
+// definition for __sFILE, which is an alias for FILE.
+//
+// Like the C standard library, a stream has a buffer that is used either for
+// reading or for writing. The data is read from (or written to) the file in
+// blocks of the buffer size, unless the stream is unbuffered. A line buffered
+// stream also writes the buffer at the end of each line.
+type File struct {
+	// This is not part of the original struct but it is needed for internal
+	// calls in Go. It is nil if the stream is not a file.
+	OsFile *os.File
+
+	// The file (or other backend) of the stream. Each of them is nil if the
+	// stream does not support the operation.
+	reader io.Reader
+	writer io.Writer
+	seeker io.Seeker
+	closer io.Closer
+
+	// buffer[bufferPos:bufferEnd] is the data that has been read but not used
+	// yet if the stream is reading, and buffer[:bufferEnd] is the data that
+	// has not been written yet if the stream is writing.
+	buffer    []byte
+	bufferPos int
+	bufferEnd int
+	writing   bool
+
+	// pushback are the characters that were put back by ungetc(). The last
+	// character is read first.
+	pushback []byte
+
+	_flags int32
+}
+
+const (
+	// constants for the File flags
+	io_MAGIC             = 0x7BAD0000 // Magic number
+	io_MAGIC_MASK        = 0xFFFF0000
+	io_USER_BUF          = 1 // User owns buffer; don't delete it on close.
+	io_UNBUFFERED        = 2
+	io_NO_READS          = 4 // Reading not allowed
+	io_NO_WRITES         = 8 // Writing not allowed
+	io_EOF_SEEN          = 0x10
+	io_ERR_SEEN          = 0x20
+	io_DELETE_DONT_CLOSE = 0x40 // Don't call close(_fileno) on cleanup.
+	io_LINKED            = 0x80 // Set if linked (using _chain) to streambuf::_list_all.
+	io_IN_BACKUP         = 0x100
+	io_LINE_BUF          = 0x200
+	io_TIED_PUT_GET      = 0x400 // Set if put and get pointer logicly tied.
+	io_CURRENTLY_PUTTING = 0x800
+	io_IS_APPENDING      = 0x1000
+	io_IS_FILEBUF        = 0x2000
+	io_BAD_SEEN          = 0x4000
+	io_USER_LOCK         = 0x8000
+)
+
+// The modes of setvbuf().
+const (
+	_IOFBF = 0 // Fully buffered
+	_IOLBF = 1 // Line buffered
+	_IONBF = 2 // Unbuffered
+)
+
+// BUFSIZ is the size of the buffer of a stream.
+const BUFSIZ = 8192
+
+// openFiles are the streams that have not been closed. They are flushed by
+// fflush(NULL) and when the program exits. openFilesSync guards the slice, but
+// not the streams.
+var (
+	openFiles     []*File
+	openFilesSync sync.Mutex
+)
+
+// NewFile creates a File pointer from a Go file pointer. The stream is line
+// buffered if the file is a terminal, otherwise it is fully buffered.
+func NewFile(f *os.File) *File {
+	file := NewFileFromReadWriter(f, f)
+	file.OsFile = f
+	if info, err := f.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		file._flags |= io_LINE_BUF
+	}
+
+	return file
+}
+
+// NewFileFromReadWriter creates a fully buffered File pointer that reads from
+// r and writes to w, so that Go code can pass its own streams to the C
+// functions. Either of them may be nil if the stream is only used for writing
+// or reading.
+//
+// The stream can be repositioned if r (or w) is also an io.Seeker, and fclose()
+// closes r (or w) if it is also an io.Closer.
+func NewFileFromReadWriter(r io.Reader, w io.Writer) *File {
+	file := &File{
+		reader: r,
+		writer: w,
+		_flags: io_MAGIC,
+	}
+	if r == nil {
+		file._flags |= io_NO_READS
+	}
+	if w == nil {
+		file._flags |= io_NO_WRITES
+	}
+	for _, backend := range []interface{}{r, w} {
+		if seeker, ok := backend.(io.Seeker); ok && file.seeker == nil {
+			file.seeker = seeker
+		}
+		if closer, ok := backend.(io.Closer); ok && file.closer == nil {
+			file.closer = closer
+		}
+	}
+	addOpenFile(file)
+
+	return file
+}
+
+// SetReader replaces the backend of the stream by r, like
+// NewFileFromReadWriter(r, nil) but in place, so that the existing pointers to
+// the stream read from r. The data that has not been read yet is dropped and
+// the previous backend is not closed.
+//
+// The tests of the transpiled programs use it to replace Stdin, because "go
+// test" does not redirect the stdin to the executable it is testing.
+func (f *File) SetReader(r io.Reader) {
+	f.flush()
+	nf := NewFileFromReadWriter(r, nil)
+	removeOpenFile(nf)
+	*f = *nf
+}
+
+// newStandardFiles returns the streams for stdin, stdout and stderr. Like the
+// C standard library, stderr is unbuffered.
+func newStandardFiles() (stdin, stdout, stderr *File) {
+	stdin = NewFile(os.Stdin)
+	stdin._flags |= io_NO_WRITES
+	stdout = NewFile(os.Stdout)
+	stdout._flags |= io_NO_READS
+	stderr = NewFile(os.Stderr)
+	stderr._flags |= io_NO_READS | io_UNBUFFERED
+
+	return
+}
+
+// flushAll writes the buffers of all of the streams.
+func flushAll() (err error) {
+	for _, f := range getOpenFiles() {
+		if f.flush() != nil {
+			err = io.ErrShortWrite
+		}
+	}
+
+	return
+}
+
+// flushLineBuffered writes the buffers of the line buffered streams. It is
+// called before reading from a line buffered or unbuffered file, so that a
+// prompt is written before the input is read.
+func flushLineBuffered() {
+	for _, f := range getOpenFiles() {
+		if f._flags&io_LINE_BUF != 0 {
+			f.flush()
+		}
+	}
+}
+
+// getOpenFiles returns a copy of openFiles, so that the streams can be used
+// while other streams are opened or closed.
+func getOpenFiles() []*File {
+	openFilesSync.Lock()
+	defer openFilesSync.Unlock()
+
+	return append([]*File(nil), openFiles...)
+}
+
+// addOpenFile adds the stream to openFiles.
+func addOpenFile(f *File) {
+	openFilesSync.Lock()
+	openFiles = append(openFiles, f)
+	openFilesSync.Unlock()
+}
+
+// removeOpenFile removes the stream from openFiles.
+func removeOpenFile(f *File) {
+	openFilesSync.Lock()
+	defer openFilesSync.Unlock()
+
+	for i, file := range openFiles {
+		if file == f {
+			openFiles = append(openFiles[:i], openFiles[i+1:]...)
+			return
+		}
+	}
+}
+
+// setBuffer sets the buffering mode of the stream, and the buffer if it is not
+// nil.
+func (f *File) setBuffer(buffer []byte, mode int32) {
+	f.flush()
+	f.discardInput()
+
+	f._flags &^= io_UNBUFFERED | io_LINE_BUF | io_USER_BUF
+	switch mode {
+	case _IOLBF:
+		f._flags |= io_LINE_BUF
+	case _IONBF:
+		f._flags |= io_UNBUFFERED
+	}
+
+	f.buffer = nil
+	if buffer != nil && mode != _IONBF {
+		f.buffer = buffer
+		f._flags |= io_USER_BUF
+	}
+}
+
+// allocateBuffer allocates the buffer if the stream does not have one yet.
+func (f *File) allocateBuffer() {
+	switch {
+	case f._flags&io_UNBUFFERED != 0:
+		if len(f.buffer) != 1 {
+			f.buffer = make([]byte, 1)
+		}
+	case len(f.buffer) == 0:
+		f.buffer = make([]byte, BUFSIZ)
+	}
+}
+
+// unread returns the number of bytes that have been read from the file but
+// not used yet.
+func (f *File) unread() int {
+	if f.writing {
+		return 0
+	}
+
+	return f.bufferEnd - f.bufferPos + len(f.pushback)
+}
+
+// discardInput drops the data that has been read but not used. The file is
+// moved back so that its position is the position of the stream.
+func (f *File) discardInput() {
+	if n := f.unread(); n > 0 && f.seeker != nil {
+		f.seeker.Seek(int64(-n), io.SeekCurrent)
+	}
+	f.bufferPos = 0
+	f.bufferEnd = 0
+	f.pushback = nil
+}
+
+// flush writes the data in the buffer. An error sets the error indicator.
+func (f *File) flush() error {
+	if !f.writing {
+		return nil
+	}
+
+	data := f.buffer[:f.bufferEnd]
+	f.bufferEnd = 0
+	f.writing = false
+	if len(data) == 0 {
+		return nil
+	}
+
+	if _, err := f.writer.Write(data); err != nil {
+		f._flags |= io_ERR_SEEN
+		setCurrentErrnoErr(err)
+		return err
+	}
+
+	return nil
+}
+
+// startReading prepares the stream for reading. It returns false if the
+// stream cannot be read.
+func (f *File) startReading() bool {
+	if f.reader == nil || f._flags&io_NO_READS != 0 {
+		f._flags |= io_ERR_SEEN
+		setCurrentErrno(EBADF)
+		return false
+	}
+	if f.writing && f.flush() != nil {
+		return false
+	}
+
+	return true
+}
+
+// fill reads the next block of the file into the buffer. It returns false if
+// there is no more data.
+func (f *File) fill() bool {
+	if f._flags&io_EOF_SEEN != 0 || !f.startReading() {
+		return false
+	}
+
+	if f._flags&(io_LINE_BUF|io_UNBUFFERED) != 0 {
+		flushLineBuffered()
+	}
+	f.allocateBuffer()
+	n, err := f.reader.Read(f.buffer)
+	for n == 0 && err == nil {
+		n, err = f.reader.Read(f.buffer)
+	}
+	f.bufferPos = 0
+	f.bufferEnd = n
+	if n > 0 {
+		return true
+	}
+
+	if err == io.EOF {
+		f._flags |= io_EOF_SEEN
+	} else {
+		f._flags |= io_ERR_SEEN
+	}
+
+	return false
+}
+
+// getc returns the next character, or EOF.
+func (f *File) getc() int32 {
+	if n := len(f.pushback); n > 0 && !f.writing {
+		c := f.pushback[n-1]
+		f.pushback = f.pushback[:n-1]
+		return int32(c)
+	}
+	if f.writing || f.bufferPos == f.bufferEnd {
+		if !f.fill() {
+			return EOF
+		}
+	}
+
+	c := f.buffer[f.bufferPos]
+	f.bufferPos++
+
+	return int32(c)
+}
+
+// ungetc puts back the character, so that it is the next character that is
+// read.
+func (f *File) ungetc(c int32) {
+	if f.writing && f.flush() != nil {
+		return
+	}
+	f.pushback = append(f.pushback, byte(c))
+	f._flags &^= io_EOF_SEEN
+}
+
+// read reads up to len(p) bytes. It returns the number of bytes that were
+// read, which is less than len(p) at the end of the file or if there is an
+// error.
+func (f *File) read(p []byte) (n int) {
+	for ; n < len(p) && len(f.pushback) > 0 && !f.writing; n++ {
+		p[n] = byte(f.getc())
+	}
+
+	for n < len(p) {
+		if f.writing || f.bufferPos == f.bufferEnd {
+			if !f.fill() {
+				break
+			}
+		}
+		copied := copy(p[n:], f.buffer[f.bufferPos:f.bufferEnd])
+		f.bufferPos += copied
+		n += copied
+	}
+
+	return
+}
+
+// write writes p to the stream. It returns the number of bytes that were
+// written (or buffered), which is less than len(p) if there is an error.
+func (f *File) write(p []byte) int {
+	if f.writer == nil || f._flags&io_NO_WRITES != 0 {
+		f._flags |= io_ERR_SEEN
+		setCurrentErrno(EBADF)
+		return 0
+	}
+	if !f.writing {
+		f.discardInput()
+		f.writing = true
+		f.bufferEnd = 0
+	}
+
+	if f._flags&io_UNBUFFERED != 0 {
+		n, err := f.writer.Write(p)
+		if err != nil {
+			f._flags |= io_ERR_SEEN
+		}
+		return n
+	}
+
+	f.allocateBuffer()
+	n := 0
+	for n < len(p) {
+		if f.bufferEnd == len(f.buffer) && f.flush() != nil {
+			return n
+		}
+		f.writing = true
+		copied := copy(f.buffer[f.bufferEnd:], p[n:])
+		f.bufferEnd += copied
+		n += copied
+	}
+
+	if f._flags&io_LINE_BUF != 0 && indexByte(p, '\n') >= 0 && f.flush() != nil {
+		return 0
+	}
+
+	return n
+}
+
+// tell returns the position of the stream.
+func (f *File) tell() (int64, error) {
+	if f.seeker == nil {
+		return -1, os.ErrInvalid
+	}
+
+	pos, err := f.seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return -1, err
+	}
+	if f.writing {
+		return pos + int64(f.bufferEnd), nil
+	}
+
+	return pos - int64(f.unread()), nil
+}
+
+// seek moves the position of the stream, and clears the end-of-file
+// indicator and the characters that were put back.
+func (f *File) seek(offset int64, whence int) (int64, error) {
+	if f.seeker == nil {
+		return -1, os.ErrInvalid
+	}
+	if err := f.flush(); err != nil {
+		return -1, err
+	}
+	if whence == io.SeekCurrent {
+		offset -= int64(f.unread())
+	}
+	f.bufferPos = 0
+	f.bufferEnd = 0
+	f.pushback = nil
+
+	pos, err := f.seeker.Seek(offset, whence)
+	if err != nil {
+		return -1, err
+	}
+	f._flags &^= io_EOF_SEEN
+
+	return pos, nil
+}
+
+// close flushes and closes the stream.
+func (f *File) close() error {
+	err := f.flush()
+	removeOpenFile(f)
+	f.buffer = nil
+	f.bufferPos = 0
+	f.bufferEnd = 0
+	f.pushback = nil
+
+	if f.closer != nil {
+		if closeErr := f.closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,241 @@
+package noarch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// openTemp opens a new temporary file with the mode, and returns the stream
+// and the name of the file.
+func openTemp(t *testing.T, mode string) (*File, string) {
+	name := filepath.Join(t.TempDir(), "file")
+	f := Fopen(cString(name), cString(mode))
+	if f == nil {
+		t.Fatalf("cannot open %s", name)
+	}
+
+	return f, name
+}
+
+func readFile(t *testing.T, name string) string {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+func TestFileBuffering(t *testing.T) {
+	f, name := openTemp(t, "w")
+	defer Fclose(f)
+
+	// The output is kept in order and written when the stream is flushed.
+	Fprintf(f, cString("%d"), int32(1))
+	Fputc('2', f)
+	Fputs(cString("3\n"), f)
+	Fwrite(cString("45"), 1, 2, f)
+	if got := readFile(t, name); got != "" {
+		t.Errorf("expected nothing to be written, got %q", got)
+	}
+	if Ftell(f) != 6 {
+		t.Errorf("expected the position 6, got %d", Ftell(f))
+	}
+	if Fflush(f) != 0 {
+		t.Fatal("cannot flush")
+	}
+	if got := readFile(t, name); got != "123\n45" {
+		t.Errorf("expected %q, got %q", "123\n45", got)
+	}
+
+	// A line buffered stream is written at the end of each line.
+	Setvbuf(f, nil, _IOLBF, 0)
+	Fputs(cString("6"), f)
+	Fputs(cString("7\n8"), f)
+	if got := readFile(t, name); got != "123\n4567\n8" {
+		t.Errorf("expected %q, got %q", "123\n4567\n8", got)
+	}
+
+	// An unbuffered stream is written immediately.
+	Setvbuf(f, nil, _IONBF, 0)
+	Fputc('9', f)
+	if got := readFile(t, name); got != "123\n4567\n89" {
+		t.Errorf("expected %q, got %q", "123\n4567\n89", got)
+	}
+
+	if Setvbuf(f, nil, 3, 0) != EOF {
+		t.Error("expected an invalid mode to fail")
+	}
+}
+
+func TestFileUserBuffer(t *testing.T) {
+	f, name := openTemp(t, "w")
+	buffer := make([]byte, 4)
+	Setvbuf(f, &buffer[0], _IOFBF, 4)
+
+	Fputs(cString("abcdef"), f)
+	if got := readFile(t, name); got != "abcd" {
+		t.Errorf("expected %q, got %q", "abcd", got)
+	}
+	Fclose(f)
+	if got := readFile(t, name); got != "abcdef" {
+		t.Errorf("expected %q, got %q", "abcdef", got)
+	}
+}
+
+func TestUngetc(t *testing.T) {
+	f, _ := openTemp(t, "w+")
+	defer Fclose(f)
+	Fputs(cString("abc"), f)
+	Rewind(f)
+
+	if c := Fgetc(f); c != 'a' {
+		t.Fatalf("expected 'a', got %q", c)
+	}
+	if Ungetc('x', f) != 'x' || Ungetc('y', f) != 'y' {
+		t.Fatal("cannot put back the characters")
+	}
+	if Ungetc(EOF, f) != EOF {
+		t.Error("expected EOF to not be put back")
+	}
+
+	buffer := make([]byte, 10)
+	if Fgets(&buffer[0], 10, f) == nil || CStringToString(&buffer[0]) != "yxbc" {
+		t.Errorf("expected %q, got %q", "yxbc", CStringToString(&buffer[0]))
+	}
+	if Fgetc(f) != EOF || Feof(f) == 0 {
+		t.Fatal("expected the end of the file")
+	}
+
+	// ungetc() clears the end-of-file indicator and a seek drops the
+	// characters that were put back.
+	Ungetc('z', f)
+	if Feof(f) != 0 {
+		t.Error("expected the end-of-file indicator to be cleared")
+	}
+	Fseek(f, 1, 0)
+	if c := Fgetc(f); c != 'b' {
+		t.Errorf("expected 'b', got %q", c)
+	}
+}
+
+func TestFileReadWrite(t *testing.T) {
+	f, name := openTemp(t, "w+")
+	defer Fclose(f)
+	Fputs(cString("0123456789"), f)
+	Rewind(f)
+
+	// A write after a read is at the position of the stream, not the position
+	// of the file after the buffer was filled.
+	if c := Fgetc(f); c != '0' {
+		t.Fatalf("expected '0', got %q", c)
+	}
+	Fputc('x', f)
+	if c := Fgetc(f); c != '2' {
+		t.Errorf("expected '2', got %q", c)
+	}
+	Fflush(f)
+	if got := readFile(t, name); got != "0x23456789" {
+		t.Errorf("expected %q, got %q", "0x23456789", got)
+	}
+
+	// fread() returns the number of complete elements.
+	Fseek(f, -3, 2)
+	buffer := make([]byte, 10)
+	if n := Fread(unsafe.Pointer(&buffer[0]), 2, 5, f); n != 1 {
+		t.Errorf("expected 1 element, got %d", n)
+	}
+	if Feof(f) == 0 {
+		t.Error("expected the end of the file")
+	}
+
+	// Reading a write-only stream is an error.
+	g, _ := openTemp(t, "w")
+	defer Fclose(g)
+	if Fgetc(g) != EOF || Ferror(g) == 0 {
+		t.Error("expected an error")
+	}
+}
+
+func TestFreopen(t *testing.T) {
+	f, name := openTemp(t, "w")
+	Fputs(cString("abc"), f)
+	fd := Fileno(f)
+
+	g := Freopen(cString(name), cString("r"), f)
+	if g != f {
+		t.Fatal("expected the same stream")
+	}
+	defer Fclose(f)
+	if c := Fgetc(f); c != 'a' {
+		t.Errorf("expected 'a', got %q", c)
+	}
+
+	// Like glibc, the stream keeps its file descriptor.
+	if Fileno(f) != fd {
+		t.Errorf("expected the file descriptor %d, got %d", fd, Fileno(f))
+	}
+
+	// The mode of the same file is changed without a file name.
+	if Freopen(nil, cString("r+"), f) != f || Fileno(f) != fd {
+		t.Fatalf("expected the file descriptor %d, got %d", fd, Fileno(f))
+	}
+	Fputs(cString("x"), f)
+	Rewind(f)
+	if c := Fgetc(f); c != 'x' {
+		t.Errorf("expected 'x', got %q", c)
+	}
+
+	// The file descriptor allows appending, so the file is not opened again.
+	if Freopen(nil, cString("a"), f) != f || Fileno(f) != fd {
+		t.Fatalf("expected the file descriptor %d, got %d", fd, Fileno(f))
+	}
+	Fputs(cString("y"), f)
+	Fflush(f)
+	if got := readFile(t, name); got != "xbcy" {
+		t.Errorf("expected %q, got %q", "xbcy", got)
+	}
+	if Fgetc(f) != EOF {
+		t.Error("expected the stream to not be readable")
+	}
+
+	// The stream is not closed if its mode cannot be changed.
+	g = NewFileFromReadWriter(strings.NewReader("abc"), nil)
+	defer Fclose(g)
+	if Freopen(nil, cString("r"), g) != nil {
+		t.Fatal("expected freopen() to fail")
+	}
+	if c := Fgetc(g); c != 'a' {
+		t.Errorf("expected 'a', got %q", c)
+	}
+}
+
+func TestFdopen(t *testing.T) {
+	f, name := openTemp(t, "w")
+	defer Fclose(f)
+
+	fd := Fileno(f)
+	if fd < 0 {
+		t.Fatalf("expected a file descriptor, got %d", fd)
+	}
+	// The stream owns the file descriptor, so it is not opened with os.Open.
+	rfd, err := syscall.Open(name, syscall.O_RDONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := Fdopen(int32(rfd), cString("r"))
+	if g == nil {
+		t.Fatal("cannot open the file descriptor")
+	}
+	defer Fclose(g)
+	Fputs(cString("abc"), f)
+	Fflush(nil)
+	if c := Fgetc(g); c != 'a' {
+		t.Errorf("expected 'a', got %q", c)
+	}
+}
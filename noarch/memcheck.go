@@ -0,0 +1,154 @@
+//go:build c2go_memcheck
+// +build c2go_memcheck
+
+// The checked allocator is used when the program is built with the
+// "c2go_memcheck" tag:
+//
+//     go build -tags c2go_memcheck
+//
+// It records where each block is allocated and freed, and stops the program
+// with a report when a block is freed twice or a pointer that was not
+// allocated is freed. The blocks that are still allocated when the program
+// exits are reported as leaks.
+//
+// The freed blocks are never reused, so if the environment variable
+// C2GO_MEMCHECK_POISON is set the freed memory is overwritten with the byte
+// 0xdd to make any use after free easier to see.
+
+package noarch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"unsafe"
+)
+
+// poisonByte is written over the freed memory.
+const poisonByte = 0xdd
+
+// freedBlock is a block that was freed at site.
+type freedBlock struct {
+	memBlock
+	site Site
+}
+
+var (
+	freedBlocks = map[uint64]freedBlock{}
+	poison      = os.Getenv("C2GO_MEMCHECK_POISON") != ""
+)
+
+func init() {
+	atExit(reportLeaks)
+}
+
+// allocationSite returns the site, or the position of the Go code that called
+// the noarch package if the site is empty.
+func allocationSite(site Site) Site {
+	if site != "" {
+		return site
+	}
+
+	pc := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pc[:runtime.Callers(2, pc)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "github.com/elliotchance/c2go/noarch.") ||
+			strings.HasSuffix(frame.File, "_test.go") {
+			return Site(fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line))
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+func freed(addr uint64, block memBlock, site Site) {
+	if poison {
+		for i := range block.memory {
+			block.memory[i] = poisonByte
+		}
+	}
+
+	memSync.Lock()
+	defer memSync.Unlock()
+	freedBlocks[addr] = freedBlock{memBlock: block, site: allocationSite(site)}
+}
+
+// invalidPointer stops the program with a report of the pointer that was not
+// allocated.
+func invalidPointer(function string, ptr unsafe.Pointer, site Site) {
+	site = allocationSite(site)
+	addr := uint64(uintptr(ptr))
+
+	memSync.Lock()
+	defer memSync.Unlock()
+
+	if f, ok := freedBlocks[addr]; ok {
+		panic(fmt.Sprintf("c2go memcheck: %s() of freed pointer %p at %s\n"+
+			"\tallocated at %s\n\tfreed at %s", function, ptr, site,
+			f.memBlock.site, f.site))
+	}
+
+	// The pointer may be inside of a block.
+	for start, block := range memMgmt {
+		if addr > start && addr < start+uint64(block.size) {
+			panic(fmt.Sprintf("c2go memcheck: %s() of pointer %p at %s "+
+				"that is %d bytes inside of a block of %d bytes\n"+
+				"\tallocated at %s", function, ptr, site, addr-start,
+				block.size, block.site))
+		}
+	}
+
+	panic(fmt.Sprintf("c2go memcheck: %s() of pointer %p at %s that was "+
+		"not allocated", function, ptr, site))
+}
+
+// reportLeaks writes the blocks that are still allocated to stderr.
+func reportLeaks() {
+	memSync.Lock()
+	defer memSync.Unlock()
+
+	if len(memMgmt) == 0 {
+		return
+	}
+
+	type leak struct {
+		site   Site
+		bytes  int
+		blocks int
+	}
+	leaks := map[Site]*leak{}
+	total := 0
+	for _, block := range memMgmt {
+		l, ok := leaks[block.site]
+		if !ok {
+			l = &leak{site: block.site}
+			leaks[block.site] = l
+		}
+		l.bytes += block.size
+		l.blocks++
+		total += block.size
+	}
+
+	var sorted []*leak
+	for _, l := range leaks {
+		sorted = append(sorted, l)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].bytes != sorted[j].bytes {
+			return sorted[i].bytes > sorted[j].bytes
+		}
+		return sorted[i].site < sorted[j].site
+	})
+
+	fmt.Fprintf(os.Stderr, "c2go memcheck: %d bytes in %d blocks are still allocated\n",
+		total, len(memMgmt))
+	for _, l := range sorted {
+		fmt.Fprintf(os.Stderr, "\t%d bytes in %d blocks allocated at %s\n",
+			l.bytes, l.blocks, l.site)
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !c2go_memcheck
+// +build !c2go_memcheck
+
+// The memory allocation functions are not checked unless the program is built
+// with the "c2go_memcheck" tag. See memcheck.go.
+
+package noarch
+
+import (
+	"unsafe"
+)
+
+func allocationSite(site Site) Site {
+	return site
+}
+
+func freed(addr uint64, block memBlock, site Site) {
+}
+
+// invalidPointer is called when ptr was not allocated. free() ignores the
+// pointer.
+func invalidPointer(function string, ptr unsafe.Pointer, site Site) {
+}
@@ -0,0 +1,110 @@
+//go:build c2go_memcheck
+// +build c2go_memcheck
+
+package noarch
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func expectPanic(t *testing.T, expected string, f func()) {
+	t.Helper()
+	defer func() {
+		t.Helper()
+		r := recover()
+		if s, _ := r.(string); !strings.Contains(s, expected) {
+			t.Errorf("expected a panic with %q, got %v", expected, r)
+		}
+	}()
+
+	f()
+}
+
+func TestMemcheckDoubleFree(t *testing.T) {
+	p := Site("main.c:10").Malloc(8)
+	Site("main.c:11").Free(p)
+
+	expectPanic(t, "free() of freed pointer", func() {
+		Site("main.c:12").Free(p)
+	})
+	expectPanic(t, "allocated at main.c:10\n\tfreed at main.c:11", func() {
+		Site("main.c:13").Realloc(p, 16)
+	})
+}
+
+func TestMemcheckInvalidFree(t *testing.T) {
+	var x int32
+	expectPanic(t, "at main.c:20 that was not allocated", func() {
+		Site("main.c:20").Free(unsafe.Pointer(&x))
+	})
+
+	p := Site("main.c:21").Malloc(40)
+	defer Free(p)
+	expectPanic(t, "4 bytes inside of a block of 40 bytes\n\tallocated at main.c:21", func() {
+		Free(unsafe.Add(p, 4))
+	})
+}
+
+func TestMemcheckPoison(t *testing.T) {
+	poison = true
+	defer func() { poison = false }()
+
+	p := (*byte)(Malloc(4))
+	*p = 1
+	Free(unsafe.Pointer(p))
+	if *p != poisonByte {
+		t.Errorf("expected %#x, got %#x", poisonByte, *p)
+	}
+}
+
+func TestMemcheckLeaks(t *testing.T) {
+	memSync.Lock()
+	saved := memMgmt
+	memMgmt = map[uint64]memBlock{}
+	memSync.Unlock()
+	defer func() {
+		memMgmt = saved
+	}()
+
+	Site("main.c:30").Malloc(10)
+	Site("main.c:30").Malloc(20)
+	Site("list.c:5").Calloc(4, 25)
+	Free(Malloc(1))
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	reportLeaks()
+	os.Stderr = stderr
+	w.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "c2go memcheck: 130 bytes in 3 blocks are still allocated\n" +
+		"\t100 bytes in 1 blocks allocated at list.c:5\n" +
+		"\t30 bytes in 2 blocks allocated at main.c:30\n"
+	if string(out) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+}
+
+func TestMemcheckGoSite(t *testing.T) {
+	p := Malloc(1)
+	defer Free(p)
+
+	memSync.Lock()
+	site := memMgmt[uint64(uintptr(p))].site
+	memSync.Unlock()
+	if !strings.HasPrefix(string(site), "memcheck_test.go:") {
+		t.Errorf("expected the site in memcheck_test.go, got %s", site)
+	}
+}
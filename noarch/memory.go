@@ -0,0 +1,217 @@
+package noarch
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"unsafe"
+)
+
+// memBlock is a block of memory that was allocated by Malloc (or one of the
+// other allocation functions). The whole block is kept so that it is not
+// collected by the Go garbage collector. Size is the number of bytes that were
+// requested, the memory may be larger because of the alignment.
+//
+// Site is where the block was allocated. It is only used by the checked
+// allocator, see memcheck.go.
+type memBlock struct {
+	memory []byte
+	size   int
+	site   Site
+}
+
+var (
+	memMgmt map[uint64]memBlock
+	memSync sync.Mutex
+)
+
+func init() {
+	memMgmt = make(map[uint64]memBlock)
+}
+
+// Site is the position in the C source (like "main.c:12") of a call to one of
+// the memory allocation functions. The transpiler generates the calls with the
+// site so that the checked allocator can report the C positions:
+//
+//     p = malloc(10);  ->  p = noarch.Site("main.c:12").Malloc(10)
+//
+// The methods are the same as the functions with the same names, which use an
+// empty site.
+type Site string
+
+// allocate returns a pointer to a new zeroed memory block of size bytes. The
+// pointer is a multiple of the alignment, which must be a power of two.
+func (site Site) allocate(size, alignment int) unsafe.Pointer {
+	if size < 0 {
+		setCurrentErrno(ENOMEM)
+		return nil
+	}
+
+	// The block always has at least one byte so that it has an address.
+	memory := make([]byte, size+alignment)
+	offset := 0
+	if r := int(uintptr(unsafe.Pointer(&memory[0])) % uintptr(alignment)); r != 0 {
+		offset = alignment - r
+	}
+	ptr := unsafe.Pointer(&memory[offset])
+
+	block := memBlock{memory: memory, size: size, site: allocationSite(site)}
+	memSync.Lock()
+	defer memSync.Unlock()
+	memMgmt[uint64(uintptr(ptr))] = block
+
+	return ptr
+}
+
+// Malloc returns a pointer to a memory block of the given length.
+//
+// To prevent the Go garbage collector from collecting this memory,
+// we store the whole block in a map.
+func Malloc(numBytes int32) unsafe.Pointer {
+	return Site("").Malloc(numBytes)
+}
+
+// Malloc handles malloc(). See the function Malloc.
+func (site Site) Malloc(numBytes int32) unsafe.Pointer {
+	return site.allocate(int(numBytes), 1)
+}
+
+// Calloc handles calloc(). It returns a pointer to a zeroed memory block for
+// an array of num elements of size bytes, or nil if the size of the block
+// overflows.
+func Calloc(num, size int32) unsafe.Pointer {
+	return Site("").Calloc(num, size)
+}
+
+// Calloc handles calloc(). See the function Calloc.
+func (site Site) Calloc(num, size int32) unsafe.Pointer {
+	total := int64(num) * int64(size)
+	if total > math.MaxInt32 {
+		setCurrentErrno(ENOMEM)
+		return nil
+	}
+
+	return site.allocate(int(total), 1)
+}
+
+// Realloc handles realloc(). It changes the size of the memory block that ptr
+// points to. The contents of the block are unchanged up to the smaller of the
+// old and new sizes, and any new memory is zeroed.
+//
+// The block may be moved to a new address, in which case the old block is
+// freed. If ptr is nil Realloc is the same as Malloc, and if the size is zero
+// the block is freed and nil is returned.
+func Realloc(ptr unsafe.Pointer, size int32) unsafe.Pointer {
+	return Site("").Realloc(ptr, size)
+}
+
+// Realloc handles realloc(). See the function Realloc.
+func (site Site) Realloc(ptr unsafe.Pointer, size int32) unsafe.Pointer {
+	if ptr == nil {
+		return site.Malloc(size)
+	}
+	if size == 0 {
+		site.Free(ptr)
+		return nil
+	}
+
+	addr := uint64(uintptr(ptr))
+	memSync.Lock()
+	block, ok := memMgmt[addr]
+	memSync.Unlock()
+	if !ok {
+		invalidPointer("realloc", ptr, site)
+		panic(fmt.Sprintf("realloc(): invalid pointer %p", ptr))
+	}
+
+	// The block can shrink (or grow into its unused memory) without being
+	// moved.
+	offset := int(uintptr(ptr) - uintptr(unsafe.Pointer(&block.memory[0])))
+	if int(size) <= len(block.memory)-offset {
+		memory := block.memory[offset:]
+		for i := int(size); i < block.size; i++ {
+			memory[i] = 0
+		}
+		block.size = int(size)
+
+		memSync.Lock()
+		defer memSync.Unlock()
+		memMgmt[addr] = block
+		return ptr
+	}
+
+	newPtr := site.Malloc(size)
+	if newPtr == nil {
+		return nil
+	}
+	copy(unsafe.Slice((*byte)(newPtr), size), block.memory[offset:offset+block.size])
+	site.Free(ptr)
+
+	return newPtr
+}
+
+// AlignedAlloc handles aligned_alloc(). It returns a pointer to a memory
+// block of size bytes that is a multiple of the alignment, or nil if the
+// alignment is not a power of two.
+func AlignedAlloc(alignment, size int32) unsafe.Pointer {
+	return Site("").AlignedAlloc(alignment, size)
+}
+
+// AlignedAlloc handles aligned_alloc(). See the function AlignedAlloc.
+func (site Site) AlignedAlloc(alignment, size int32) unsafe.Pointer {
+	if alignment <= 0 || alignment&(alignment-1) != 0 {
+		setCurrentErrno(EINVAL)
+		return nil
+	}
+
+	return site.allocate(int(size), int(alignment))
+}
+
+// PosixMemalign handles posix_memalign(). The pointer to a memory block of
+// size bytes that is a multiple of the alignment is stored in memptr. The
+// alignment must be a power of two and a multiple of the size of a pointer,
+// otherwise EINVAL is returned.
+func PosixMemalign(memptr *unsafe.Pointer, alignment, size int32) int32 {
+	return Site("").PosixMemalign(memptr, alignment, size)
+}
+
+// PosixMemalign handles posix_memalign(). See the function PosixMemalign.
+func (site Site) PosixMemalign(memptr *unsafe.Pointer, alignment, size int32) int32 {
+	if alignment <= 0 || alignment&(alignment-1) != 0 ||
+		uintptr(alignment)%unsafe.Sizeof(uintptr(0)) != 0 {
+		return EINVAL
+	}
+
+	ptr := site.allocate(int(size), int(alignment))
+	if ptr == nil {
+		return ENOMEM
+	}
+	*memptr = ptr
+
+	return 0
+}
+
+// Free removes the reference to this memory address,
+// so that the Go GC can free it.
+func Free(anything unsafe.Pointer) {
+	Site("").Free(anything)
+}
+
+// Free handles free(). See the function Free.
+func (site Site) Free(anything unsafe.Pointer) {
+	if anything == nil {
+		return
+	}
+
+	addr := uint64(uintptr(anything))
+	memSync.Lock()
+	block, ok := memMgmt[addr]
+	delete(memMgmt, addr)
+	memSync.Unlock()
+
+	if !ok {
+		invalidPointer("free", anything, site)
+		return
+	}
+	freed(addr, block, site)
+}
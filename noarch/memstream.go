@@ -0,0 +1,173 @@
+package noarch
+
+import (
+	"io"
+	"unsafe"
+)
+
+// The backends of the memory streams that are opened by fmemopen() and
+// open_memstream(). Like the other streams, the data is buffered by the File,
+// so the memory is only changed when the stream is flushed.
+
+// errnoError is an error that has the message of the errno, so that
+// setCurrentErrnoErr sets errno to its value.
+type errnoError int32
+
+func (e errnoError) Error() string {
+	return errors[int(e)]
+}
+
+// fixedMemory is the memory of a stream that is opened by fmemopen(). It
+// cannot grow beyond the size of the buffer. Like glibc, a write that ends
+// after the current end of the data is followed by a NULL byte if there is
+// space for it.
+type fixedMemory struct {
+	buffer []byte
+
+	// pos is the position of the stream and end is the size of the data that
+	// can be read.
+	pos    int
+	end    int
+	append bool
+}
+
+func (m *fixedMemory) Read(p []byte) (int, error) {
+	if m.pos >= m.end {
+		return 0, io.EOF
+	}
+
+	n := copy(p, m.buffer[m.pos:m.end])
+	m.pos += n
+
+	return n, nil
+}
+
+func (m *fixedMemory) Write(p []byte) (int, error) {
+	pos := m.pos
+	if m.append {
+		pos = m.end
+	}
+
+	n := len(p)
+	addNull := n == 0 || p[n-1] != 0
+	if pos+n > len(m.buffer) {
+		nullSize := 0
+		if addNull {
+			nullSize = 1
+		}
+		if m.pos+nullSize >= len(m.buffer) {
+			return 0, errnoError(ENOSPC)
+		}
+		n = len(m.buffer) - pos
+	}
+
+	copy(m.buffer[pos:], p[:n])
+	m.pos = pos + n
+	if m.pos > m.end {
+		m.end = m.pos
+		if m.end < len(m.buffer) && addNull {
+			m.buffer[m.end] = 0
+		} else if !m.append && addNull {
+			m.buffer[len(m.buffer)-1] = 0
+		}
+	}
+
+	if n < len(p) {
+		return n, errnoError(ENOSPC)
+	}
+
+	return n, nil
+}
+
+func (m *fixedMemory) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += int64(m.pos)
+	case io.SeekEnd:
+		offset += int64(m.end)
+	}
+	if offset < 0 || offset > int64(len(m.buffer)) {
+		return -1, errnoError(EINVAL)
+	}
+	m.pos = int(offset)
+
+	return offset, nil
+}
+
+// dynamicMemory is the memory of a stream that is opened by open_memstream().
+// The memory is allocated by Malloc and grows when it is written, so that the
+// program can free it after the stream is closed. The pointer to the memory
+// and the size of the data are stored in ptr and sizeloc when the stream is
+// flushed or repositioned.
+type dynamicMemory struct {
+	ptr     **byte
+	sizeloc *uint32
+
+	memory   unsafe.Pointer
+	capacity int
+	pos      int
+	end      int
+}
+
+// grow makes sure that the memory has space for size bytes and a NULL byte.
+// The new memory is zeroed by Realloc.
+func (m *dynamicMemory) grow(size int) {
+	if size < m.capacity {
+		return
+	}
+
+	capacity := 2 * m.capacity
+	if capacity <= size {
+		capacity = size + 1
+	}
+	m.memory = Realloc(m.memory, int32(capacity))
+	m.capacity = capacity
+}
+
+// update stores the pointer to the memory and the size of the data.
+func (m *dynamicMemory) update() {
+	*m.ptr = (*byte)(m.memory)
+	if m.pos < m.end {
+		*m.sizeloc = uint32(m.pos)
+	} else {
+		*m.sizeloc = uint32(m.end)
+	}
+}
+
+func (m *dynamicMemory) Write(p []byte) (int, error) {
+	m.grow(m.pos + len(p))
+	copy(toByteSlice((*byte)(m.memory), int32(m.capacity))[m.pos:], p)
+	m.pos += len(p)
+	if m.pos > m.end {
+		m.end = m.pos
+	}
+	m.update()
+
+	return len(p), nil
+}
+
+func (m *dynamicMemory) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += int64(m.pos)
+	case io.SeekEnd:
+		offset += int64(m.end)
+	}
+	if offset < 0 {
+		return -1, errnoError(EINVAL)
+	}
+	m.pos = int(offset)
+	m.update()
+
+	return offset, nil
+}
+
+// Close ends the data at the position of the stream.
+func (m *dynamicMemory) Close() error {
+	m.grow(m.pos)
+	*(*byte)(unsafe.Add(m.memory, m.pos)) = 0
+	m.end = m.pos
+	m.update()
+
+	return nil
+}
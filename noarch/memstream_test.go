@@ -0,0 +1,156 @@
+package noarch
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestFmemopen(t *testing.T) {
+	// The expected values are from glibc.
+	buffer := []byte("xxxxxxxxxx")
+	f := Fmemopen(unsafe.Pointer(&buffer[0]), 10, cString("w"))
+	Fputs(cString("abc"), f)
+	if string(buffer) != "xxxxxxxxxx" {
+		t.Errorf("expected the buffer to be unchanged, got %q", buffer)
+	}
+	Fflush(f)
+	if string(buffer) != "abc\x00xxxxxx" {
+		t.Errorf("got %q", buffer)
+	}
+
+	// Writing beyond the end of the buffer is an error.
+	Fputs(cString("defghijkl"), f)
+	if Fflush(f) != EOF || Ferror(f) == 0 || *Errno() != ENOSPC {
+		t.Error("expected an error")
+	}
+	if string(buffer) != "abcdefghi\x00" || Ftell(f) != 10 {
+		t.Errorf("got %q at %d", buffer, Ftell(f))
+	}
+	Fclose(f)
+
+	// The writes are appended after the string.
+	copy(buffer, "ab\x00")
+	f = Fmemopen(unsafe.Pointer(&buffer[0]), 10, cString("a+"))
+	if Ftell(f) != 2 {
+		t.Errorf("expected the position 2, got %d", Ftell(f))
+	}
+	Fputs(cString("cd"), f)
+	Fclose(f)
+	if CStringToString(&buffer[0]) != "abcd" {
+		t.Errorf("expected %q, got %q", "abcd", CStringToString(&buffer[0]))
+	}
+
+	copy(buffer, "hello")
+	f = Fmemopen(unsafe.Pointer(&buffer[0]), 5, cString("r"))
+	line := make([]byte, 20)
+	Fgets(&line[0], 20, f)
+	if CStringToString(&line[0]) != "hello" || Feof(f) == 0 {
+		t.Errorf("expected %q, got %q", "hello", CStringToString(&line[0]))
+	}
+	if Fseek(f, 6, 0) != -1 || Fseek(f, 0, 2) != 0 || Ftell(f) != 5 {
+		t.Error("expected the end of the buffer to be 5")
+	}
+	Fclose(f)
+
+	f = Fmemopen(nil, 8, cString("w+"))
+	Fputs(cString("hi"), f)
+	Rewind(f)
+	Fgets(&line[0], 20, f)
+	if CStringToString(&line[0]) != "hi" {
+		t.Errorf("expected %q, got %q", "hi", CStringToString(&line[0]))
+	}
+	Fclose(f)
+
+	if Fmemopen(nil, 0, cString("w+")) != nil || Fmemopen(nil, 8, cString("x")) != nil {
+		t.Error("expected the arguments to be invalid")
+	}
+}
+
+func TestOpenMemstream(t *testing.T) {
+	// The expected values are from glibc.
+	var ptr *byte
+	var size uint32
+	f := OpenMemstream(&ptr, &size)
+
+	Fputs(cString("hello"), f)
+	Fflush(f)
+	if size != 5 || CStringToString(ptr) != "hello" {
+		t.Errorf("got %d, %q", size, CStringToString(ptr))
+	}
+
+	Fseek(f, 2, 0)
+	Fputc('X', f)
+	Fflush(f)
+	if size != 3 || CStringToString(ptr) != "heXlo" {
+		t.Errorf("got %d, %q", size, CStringToString(ptr))
+	}
+
+	// The gap is filled with NULL bytes.
+	Fseek(f, 10, 0)
+	Fputc('Z', f)
+	Fflush(f)
+	if size != 11 || CStringToString(ptr) != "heXlo" || *(*byte)(unsafe.Add(unsafe.Pointer(ptr), 10)) != 'Z' {
+		t.Errorf("got %d, %q", size, CStringToString(ptr))
+	}
+
+	// The data ends at the position of the stream when it is closed.
+	Fseek(f, 3, 0)
+	Fclose(f)
+	if size != 3 || CStringToString(ptr) != "heX" {
+		t.Errorf("got %d, %q", size, CStringToString(ptr))
+	}
+	Free(unsafe.Pointer(ptr))
+}
+
+func TestNewFileFromReadWriter(t *testing.T) {
+	var out bytes.Buffer
+	f := NewFileFromReadWriter(strings.NewReader("12 abc"), &out)
+
+	var n int32
+	if Fscanf(f, cString("%d"), &n) != 1 || n != 12 {
+		t.Errorf("expected 12, got %d", n)
+	}
+	Fprintf(f, cString("%d\n"), n+1)
+	if Fflush(f) != 0 || out.String() != "13\n" {
+		t.Errorf("expected %q, got %q", "13\n", out.String())
+	}
+
+	// A strings.Reader can be repositioned.
+	Rewind(f)
+	if c := Fgetc(f); c != '1' {
+		t.Errorf("expected '1', got %q", c)
+	}
+	Fclose(f)
+
+	f = NewFileFromReadWriter(nil, &out)
+	if Fgetc(f) != EOF || Ferror(f) == 0 {
+		t.Error("expected an error")
+	}
+	if Fseek(f, 0, 0) != -1 {
+		t.Error("expected the stream to not be seekable")
+	}
+	Fclose(f)
+}
+
+func TestSetReader(t *testing.T) {
+	f := NewFileFromReadWriter(strings.NewReader("abc"), nil)
+	defer Fclose(f)
+	Fgetc(f)
+	Ungetc('x', f)
+	count := len(openFiles)
+
+	// The pointer to the stream reads from the new reader.
+	f.SetReader(strings.NewReader("7"))
+	if len(openFiles) != count {
+		t.Errorf("expected %d open files, got %d", count, len(openFiles))
+	}
+	var n int32
+	if Fscanf(f, cString("%d"), &n) != 1 || n != 7 {
+		t.Errorf("expected 7, got %d", n)
+	}
+	if Fgetc(f) != EOF || Feof(f) == 0 {
+		t.Error("expected the end of the file")
+	}
+}
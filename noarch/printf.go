@@ -0,0 +1,665 @@
+package noarch
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// The printf functions do not use the Go fmt package because the verbs are
+// different. For example, "%5.2s" or "%lu" are not valid in Go, and "%x" of a
+// negative int prints "-1" rather than "ffffffff". All of the functions use
+// formatPrintf, which produces the same output as glibc.
+//
+// The arguments are passed with their Go types. An integer is converted to the
+// size of its length modifier (like "hh" or "ll") as if it was passed through
+// the C default argument promotions.
+
+// printfSpec is a conversion specification, like "%-08.3lx".
+type printfSpec struct {
+	minus, plus, space, alt, zero bool
+
+	width int
+
+	// precision is -1 if it is not in the conversion specification.
+	precision int
+
+	// length is the length modifier, like "hh" or "l".
+	length string
+
+	conversion byte
+}
+
+// formatPrintf returns the arguments formatted with the C format string.
+func formatPrintf(format *byte, args []interface{}) []byte {
+	f := CStringToString(format)
+	at := func(i int) byte {
+		if i < len(f) {
+			return f[i]
+		}
+		return 0
+	}
+
+	// The arguments can be read in order or by their positions, like "%2$d".
+	next := 0
+	arg := func(position int) interface{} {
+		if position > 0 {
+			next = position - 1
+		}
+		if next >= len(args) {
+			next++
+			return nil
+		}
+		next++
+		return args[next-1]
+	}
+
+	// position returns the position of the argument at f[i], like "2$", or 0
+	// if there is not a position.
+	position := func(i int) (int, int) {
+		n, j := parseDecimal(f, i)
+		if j > i && at(j) == '$' {
+			return n, j + 1
+		}
+		return 0, i
+	}
+
+	var out []byte
+	for i := 0; i < len(f); i++ {
+		if f[i] != '%' {
+			out = append(out, f[i])
+			continue
+		}
+
+		start := i
+		s := printfSpec{precision: -1}
+		var argPosition int
+		argPosition, i = position(i + 1)
+
+	flags:
+		for ; ; i++ {
+			switch at(i) {
+			case '-':
+				s.minus = true
+			case '+':
+				s.plus = true
+			case ' ':
+				s.space = true
+			case '#':
+				s.alt = true
+			case '0':
+				s.zero = true
+			case '\'', 'I':
+				// The grouping of the digits and the locale digits do
+				// nothing in the C locale.
+			default:
+				break flags
+			}
+		}
+
+		if at(i) == '*' {
+			var p int
+			p, i = position(i + 1)
+			s.width = int(int32(integerBits(arg(p))))
+			if s.width < 0 {
+				s.minus = true
+				s.width = -s.width
+			}
+		} else {
+			s.width, i = parseDecimal(f, i)
+		}
+
+		if at(i) == '.' {
+			if at(i+1) == '*' {
+				var p int
+				p, i = position(i + 2)
+				s.precision = int(int32(integerBits(arg(p))))
+				if s.precision < 0 {
+					s.precision = -1
+				}
+			} else {
+				s.precision, i = parseDecimal(f, i+1)
+			}
+		}
+
+		switch at(i) {
+		case 'h', 'l':
+			s.length = f[i : i+1]
+			if at(i+1) == at(i) {
+				s.length = f[i : i+2]
+			}
+		case 'j', 'z', 't', 'L', 'q':
+			s.length = f[i : i+1]
+		}
+		i += len(s.length)
+
+		s.conversion = at(i)
+		switch s.conversion {
+		case 'd', 'i':
+			out = s.appendInteger(out, integerBits(arg(argPosition)), true)
+
+		case 'u', 'o', 'x', 'X':
+			out = s.appendInteger(out, integerBits(arg(argPosition)), false)
+
+		case 'f', 'F', 'e', 'E', 'g', 'G', 'a', 'A':
+			out = s.appendFloat(out, floatArg(arg(argPosition)))
+
+		case 'c':
+			n := integerBits(arg(argPosition))
+			c := []byte{byte(n)}
+			if s.length == "l" {
+				c = utf8.AppendRune(nil, rune(n))
+			}
+			out = s.appendPadded(out, "", c, false)
+
+		case 'C':
+			c := utf8.AppendRune(nil, rune(integerBits(arg(argPosition))))
+			out = s.appendPadded(out, "", c, false)
+
+		case 's', 'S':
+			if s.conversion == 'S' {
+				s.length = "l"
+			}
+			out = s.appendPadded(out, "", stringArg(arg(argPosition), s.precision, s.length == "l"), false)
+
+		case 'p':
+			p := integerBits(arg(argPosition))
+			if p == 0 {
+				out = s.appendPadded(out, "", []byte("(nil)"), false)
+				break
+			}
+			s.alt = true
+			s.conversion = 'x'
+			s.length = "l"
+			out = s.appendInteger(out, p, false)
+
+		case 'n':
+			storeCount(arg(argPosition), len(out))
+
+		case 'm':
+			out = s.appendPadded(out, "", []byte(CStringToString(Strerror(*Errno()))), false)
+
+		case '%':
+			out = append(out, '%')
+
+		default:
+			// glibc prints an unknown conversion as it is.
+			if i >= len(f) {
+				i = len(f) - 1
+			}
+			out = append(out, f[start:i+1]...)
+		}
+	}
+
+	return out
+}
+
+// parseDecimal returns the number at s[i], and the index after it.
+func parseDecimal(s string, i int) (n, _ int) {
+	for ; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+		if n < math.MaxInt32/10 {
+			n = n*10 + int(s[i]-'0')
+		}
+	}
+
+	return n, i
+}
+
+// integerBits returns the bits of an integer or pointer argument. A signed
+// integer is sign extended.
+func integerBits(arg interface{}) uint64 {
+	if arg == nil {
+		return 0
+	}
+
+	v := reflect.ValueOf(arg)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return uint64(int64(v.Float()))
+	case reflect.Bool:
+		if v.Bool() {
+			return 1
+		}
+	case reflect.Ptr, reflect.UnsafePointer, reflect.Slice, reflect.Func,
+		reflect.Map, reflect.Chan:
+		return uint64(v.Pointer())
+	}
+
+	return 0
+}
+
+// floatArg returns a floating-point argument as a double.
+func floatArg(arg interface{}) float64 {
+	if arg == nil {
+		return 0
+	}
+
+	v := reflect.ValueOf(arg)
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint())
+	}
+
+	return 0
+}
+
+// stringArg returns at most precision bytes (or all bytes if precision is -1)
+// of a string argument. A wide string is encoded as UTF-8.
+func stringArg(arg interface{}, precision int, wide bool) []byte {
+	if isNil(arg) {
+		// glibc prints "(null)" unless the precision is too small.
+		if precision < 0 || precision >= 6 {
+			return []byte("(null)")
+		}
+		return nil
+	}
+
+	var s []byte
+	switch v := arg.(type) {
+	case string:
+		s = []byte(v)
+	case []byte:
+		s = v
+		if n := indexByte(s, 0); n >= 0 {
+			s = s[:n]
+		}
+	case *byte:
+		// The string may not have a NULL byte if there is a precision, so no
+		// more than precision bytes are read.
+		n := 0
+		for ; n != precision && *(*byte)(unsafe.Add(unsafe.Pointer(v), n)) != 0; n++ {
+		}
+		s = unsafe.Slice(v, n)
+	case *int32:
+		for p := v; *p != 0; p = (*int32)(unsafe.Add(unsafe.Pointer(p), 4)) {
+			r := utf8.AppendRune(nil, rune(*p))
+			if precision >= 0 && len(s)+len(r) > precision {
+				break
+			}
+			s = append(s, r...)
+		}
+		return s
+	case unsafe.Pointer:
+		if wide {
+			return stringArg((*int32)(v), precision, wide)
+		}
+		return stringArg((*byte)(v), precision, wide)
+	default:
+		rv := reflect.ValueOf(arg)
+		switch {
+		case rv.Kind() == reflect.Ptr:
+			return stringArg(rv.UnsafePointer(), precision, wide)
+		case rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8:
+			s = make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(s), rv)
+			if n := indexByte(s, 0); n >= 0 {
+				s = s[:n]
+			}
+		}
+	}
+
+	if precision >= 0 && len(s) > precision {
+		s = s[:precision]
+	}
+
+	return s
+}
+
+// isNil returns true if the argument is nil or a nil pointer.
+func isNil(arg interface{}) bool {
+	if arg == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(arg)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.UnsafePointer:
+		return v.IsNil()
+	}
+
+	return false
+}
+
+// indexByte returns the index of the first c in s, or -1 if there is not a c.
+func indexByte(s []byte, c byte) int {
+	for i, b := range s {
+		if b == c {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// storeCount handles "%n". The number of bytes that have been written is
+// stored in the integer that the argument points to.
+func storeCount(arg interface{}, n int) {
+	if isNil(arg) {
+		return
+	}
+
+	v := reflect.ValueOf(arg)
+	if v.Kind() == reflect.UnsafePointer {
+		*(*int32)(v.UnsafePointer()) = int32(n)
+		return
+	}
+	if v.Kind() != reflect.Ptr {
+		return
+	}
+
+	switch e := v.Elem(); e.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.SetInt(int64(n))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		e.SetUint(uint64(n))
+	}
+}
+
+// appendPadded appends the prefix (like a sign or "0x") and the body, padded
+// to the width. The padding is zeros between the prefix and the body if
+// zeroPad is true and the "0" flag is used, otherwise it is spaces.
+func (s printfSpec) appendPadded(out []byte, prefix string, body []byte, zeroPad bool) []byte {
+	n := s.width - len(prefix) - len(body)
+	switch {
+	case n <= 0:
+		out = append(append(out, prefix...), body...)
+	case s.minus:
+		out = append(append(out, prefix...), body...)
+		out = appendRepeated(out, ' ', n)
+	case s.zero && zeroPad:
+		out = appendRepeated(append(out, prefix...), '0', n)
+		out = append(out, body...)
+	default:
+		out = appendRepeated(out, ' ', n)
+		out = append(append(out, prefix...), body...)
+	}
+
+	return out
+}
+
+func appendRepeated(out []byte, c byte, n int) []byte {
+	for ; n > 0; n-- {
+		out = append(out, c)
+	}
+
+	return out
+}
+
+// appendInteger handles the d, i, u, o, x and X conversions.
+func (s printfSpec) appendInteger(out []byte, bits uint64, signed bool) []byte {
+	size := 32
+	switch s.length {
+	case "hh":
+		size = 8
+	case "h":
+		size = 16
+	case "l", "ll", "j", "z", "t", "q", "L":
+		size = 64
+	}
+	if size < 64 {
+		bits &= 1<<uint(size) - 1
+		if signed && bits>>uint(size-1) == 1 {
+			bits |= ^uint64(0) << uint(size)
+		}
+	}
+
+	prefix := ""
+	if signed {
+		switch {
+		case int64(bits) < 0:
+			prefix = "-"
+			bits = -bits
+		case s.plus:
+			prefix = "+"
+		case s.space:
+			prefix = " "
+		}
+	}
+
+	base := 10
+	switch s.conversion {
+	case 'o':
+		base = 8
+	case 'x', 'X':
+		base = 16
+		if s.alt && bits != 0 {
+			prefix = "0" + string(s.conversion)
+		}
+	}
+
+	var body []byte
+	if bits != 0 || s.precision != 0 {
+		body = strconv.AppendUint(nil, bits, base)
+	}
+	if s.conversion == 'X' {
+		toUpper(body)
+	}
+	if s.precision > len(body) {
+		body = append(appendRepeated(nil, '0', s.precision-len(body)), body...)
+	}
+	if s.alt && s.conversion == 'o' && (len(body) == 0 || body[0] != '0') {
+		body = append([]byte{'0'}, body...)
+	}
+
+	return s.appendPadded(out, prefix, body, s.precision < 0)
+}
+
+// appendFloat handles the f, F, e, E, g, G, a and A conversions.
+func (s printfSpec) appendFloat(out []byte, v float64) []byte {
+	prefix := ""
+	switch {
+	case math.Signbit(v):
+		prefix = "-"
+		v = math.Abs(v)
+	case s.plus:
+		prefix = "+"
+	case s.space:
+		prefix = " "
+	}
+
+	upper := s.conversion >= 'A' && s.conversion <= 'Z'
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		body := []byte("inf")
+		if math.IsNaN(v) {
+			body = []byte("nan")
+		}
+		if upper {
+			toUpper(body)
+		}
+		return s.appendPadded(out, prefix, body, false)
+	}
+
+	precision := s.precision
+	var body []byte
+	switch s.conversion | 0x20 {
+	case 'f':
+		if precision < 0 {
+			precision = 6
+		}
+		body = strconv.AppendFloat(nil, v, 'f', precision, 64)
+		if s.alt && precision == 0 {
+			body = append(body, '.')
+		}
+
+	case 'e':
+		if precision < 0 {
+			precision = 6
+		}
+		body = strconv.AppendFloat(nil, v, 'e', precision, 64)
+		if s.alt && precision == 0 {
+			body = insertPoint(body)
+		}
+
+	case 'g':
+		if precision < 0 {
+			precision = 6
+		} else if precision == 0 {
+			precision = 1
+		}
+
+		// The style is e if the exponent is less than -4 or is not less
+		// than the precision.
+		body = strconv.AppendFloat(nil, v, 'e', precision-1, 64)
+		exponent, _ := strconv.Atoi(string(body[indexByte(body, 'e')+1:]))
+		if exponent >= -4 && exponent < precision {
+			body = strconv.AppendFloat(nil, v, 'f', precision-1-exponent, 64)
+		}
+
+		if s.alt && exponent == precision && v < math.Pow10(exponent) {
+			// glibc drops the zeros if the value was rounded up to the
+			// exponent that changed the style to e, like "1.e+06" for
+			// printf("%#g", 999999.5).
+			body = insertPoint(trimFractionZeros(body))
+		} else if s.alt {
+			if indexByte(body, '.') < 0 {
+				body = insertPoint(body)
+			}
+		} else {
+			body = trimFractionZeros(body)
+		}
+
+	case 'a':
+		prefix += "0x"
+		body = appendHexFloat(nil, v, precision, s.alt, s.length == "L")
+	}
+
+	if upper {
+		toUpper(body)
+		prefix = strings.ToUpper(prefix)
+	}
+
+	return s.appendPadded(out, prefix, body, true)
+}
+
+// insertPoint inserts a decimal point before the exponent, or at the end if
+// there is not an exponent.
+func insertPoint(body []byte) []byte {
+	e := indexByte(body, 'e')
+	if e < 0 {
+		return append(body, '.')
+	}
+
+	return append(body[:e], append([]byte{'.'}, body[e:]...)...)
+}
+
+// trimFractionZeros removes the trailing zeros of the fraction, and the
+// decimal point if there is no fraction left.
+func trimFractionZeros(body []byte) []byte {
+	if indexByte(body, '.') < 0 {
+		return body
+	}
+
+	var exponent []byte
+	if e := indexByte(body, 'e'); e >= 0 {
+		exponent = append(exponent, body[e:]...)
+		body = body[:e]
+	}
+	for body[len(body)-1] == '0' {
+		body = body[:len(body)-1]
+	}
+	if body[len(body)-1] == '.' {
+		body = body[:len(body)-1]
+	}
+
+	return append(body, exponent...)
+}
+
+// appendHexFloat appends the non-negative v in the style of "%a", without the
+// "0x". There are as many hexadecimal digits after the point as are needed to
+// represent v exactly if precision is -1.
+//
+// A long double ("%La") is printed like glibc prints the 80-bit long double of
+// x86, which has no hidden bit so the leading digit is 8 to f.
+func appendHexFloat(out []byte, v float64, precision int, alt, long bool) []byte {
+	bits := math.Float64bits(v)
+	exponent := int(bits>>52) & 0x7ff
+	mantissa := bits & (1<<52 - 1)
+	if exponent == 0 {
+		// A subnormal number.
+		exponent = -1022
+	} else {
+		mantissa |= 1 << 52
+		exponent -= 1023
+	}
+
+	fractionDigits := 13
+	if long {
+		fractionDigits = 15
+		if mantissa != 0 {
+			for mantissa < 1<<52 {
+				mantissa <<= 1
+				exponent--
+			}
+			mantissa <<= 11
+			exponent -= 3
+		}
+	}
+	if mantissa == 0 {
+		exponent = 0
+	}
+
+	fractionBits := uint(4 * fractionDigits)
+	lead := mantissa >> fractionBits
+	digits := strconv.AppendUint(nil, mantissa&(1<<fractionBits-1)|1<<fractionBits, 16)[1:]
+	switch {
+	case precision < 0:
+		for len(digits) > 0 && digits[len(digits)-1] == '0' {
+			digits = digits[:len(digits)-1]
+		}
+
+	case precision < fractionDigits:
+		// Round to nearest, ties to even. The leading digit of a double may
+		// become 2, but a long double is normalized again.
+		shift := uint(4 * (fractionDigits - precision))
+		n := mantissa >> shift
+		rest := mantissa & (1<<shift - 1)
+		half := uint64(1) << (shift - 1)
+		if rest > half || rest == half && n&1 == 1 {
+			n++
+		}
+		lead = n >> uint(4*precision)
+		if long && lead == 0x10 {
+			lead = 1
+			exponent += 4
+		}
+		mask := uint64(1)<<uint(4*precision) - 1
+		digits = strconv.AppendUint(nil, n&mask|(mask+1), 16)[1:]
+
+	default:
+		digits = appendRepeated(digits, '0', precision-fractionDigits)
+	}
+
+	out = strconv.AppendUint(out, lead, 16)
+	if len(digits) > 0 || alt {
+		out = append(append(out, '.'), digits...)
+	}
+	out = append(out, 'p')
+	if exponent >= 0 {
+		out = append(out, '+')
+	}
+
+	return strconv.AppendInt(out, int64(exponent), 10)
+}
+
+// toUpper converts the lowercase ASCII letters in s to uppercase.
+func toUpper(s []byte) {
+	for i, c := range s {
+		if c >= 'a' && c <= 'z' {
+			s[i] = c - 'a' + 'A'
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package noarch
+
+import (
+	"math"
+	"testing"
+	"unsafe"
+)
+
+func cString(s string) *byte {
+	return &append([]byte(s), 0)[0]
+}
+
+func TestFormatPrintf(t *testing.T) {
+	// The expected outputs are from glibc.
+	tcs := []struct {
+		format   string
+		args     []interface{}
+		expected string
+	}{
+		{"%d %i %u", []interface{}{int32(-42), int32(7), int32(-1)}, "-42 7 4294967295"},
+		{"%hhd %hhu %hd %hu", []interface{}{int32(300), int32(-1), int32(70000), int32(-1)}, "44 255 4464 65535"},
+		{"%ld %lld %lu", []interface{}{int32(-1), int64(-9000000000), int32(-1)}, "-1 -9000000000 18446744073709551615"},
+		{"%zu %jd %td", []interface{}{uint32(12), int64(-7), int32(3)}, "12 -7 3"},
+		{"[%5d] [%-5d] [%05d] [%+d] [% d]", []interface{}{int32(42), int32(42), int32(-42), int32(42), int32(42)}, "[   42] [42   ] [-0042] [+42] [ 42]"},
+		{"[%.3d] [%8.3d] [%08.3d] [%.0d]", []interface{}{int32(7), int32(-7), int32(7), int32(0)}, "[007] [    -007] [     007] []"},
+		{"%x %X %#x %#X %#x", []interface{}{int32(-1), int32(255), int32(255), int32(255), int32(0)}, "ffffffff FF 0xff 0XFF 0"},
+		{"%o %#o %#o %#.0o", []interface{}{int32(8), int32(8), int32(0), int32(0)}, "10 010 0 0"},
+		{"[%*d] [%-*d] [%*d]", []interface{}{int32(5), int32(42), int32(4), int32(7), int32(-4), int32(7)}, "[   42] [7   ] [7   ]"},
+		{"[%.*d] [%.*s] [%*.*f]", []interface{}{int32(4), int32(7), int32(3), cString("abcdef"), int32(8), int32(2), 3.14159}, "[0007] [abc] [    3.14]"},
+		{"%2$s %1$d", []interface{}{int32(5), cString("five")}, "five 5"},
+		{"%f %.0f %#.0f %.0f %.0f", []interface{}{1.5, 2.5, 2.0, 0.5, 1.5}, "1.500000 2 2. 0 2"},
+		{"%e %E %+e %.0e %#.0e", []interface{}{12345.678, 0.000123, 1.0, 5e300, 5.0}, "1.234568e+04 1.230000E-04 +1.000000e+00 5e+300 5.e+00"},
+		{"%g %g %g %g %G", []interface{}{100000.0, 1000000.0, 0.0001, 0.00001, 1e-10}, "100000 1e+06 0.0001 1e-05 1E-10"},
+		{"%#g %#.3g %#g %.0g", []interface{}{1.0, 99.95, 999999.5, 9.5}, "1.00000 100. 1.e+06 1e+01"},
+		{"%a %a %A %a %a", []interface{}{1.5, 0.0, -3.0, 0.1, 5e-324}, "0x1.8p+0 0x0p+0 -0X1.8P+1 0x1.999999999999ap-4 0x0.0000000000001p-1022"},
+		{"%.0a %.1a %#a %10a|%-10a|%010a", []interface{}{1.5, 1.96875, 1.0, 1.0, 1.0, 1.0}, "0x2p+0 0x2.0p+0 0x1.p+0     0x1p+0|0x1p+0    |0x00001p+0"},
+		{"%La %.0La %La", []interface{}{1.0, 1.9999999999999998, 5e-324}, "0x8p-3 0x1p+1 0x8p-1077"},
+		{"%f %e %g %F %010f %-5f|", []interface{}{math.Inf(1), math.Inf(-1), math.NaN(), math.NaN(), math.Inf(1), math.Inf(1)}, "inf -inf nan NAN        inf inf  |"},
+		{"%c%c [%3c] [%-3c]", []interface{}{byte('o'), int32('k'), byte('x'), byte('x')}, "ok [  x] [x  ]"},
+		{"[%s] [%10s] [%-10s] [%.2s]", []interface{}{cString("abc"), cString("abc"), cString("abc"), cString("abc")}, "[abc] [       abc] [abc       ] [ab]"},
+		{"[%s] [%.3s] [%.6s]", []interface{}{(*byte)(nil), (*byte)(nil), (*byte)(nil)}, "[(null)] [] [(null)]"},
+		{"%s %s", []interface{}{"go", []byte("bytes\x00junk")}, "go bytes"},
+		{"%p [%-6p] %p", []interface{}{uintptr(0x1234), unsafe.Pointer(nil), (*int32)(nil)}, "0x1234 [(nil) ] (nil)"},
+		{"100%% %5% %y", nil, "100% % %y"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.format, func(t *testing.T) {
+			got := string(formatPrintf(cString(tc.format), tc.args))
+			if got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestFormatPrintfCount(t *testing.T) {
+	var n int32
+	var hh int8
+	var ll int64
+	got := string(formatPrintf(cString("abc%n def%hhn%lln"), []interface{}{&n, &hh, &ll}))
+	if got != "abc def" || n != 3 || hh != 7 || ll != 7 {
+		t.Errorf("got %q, %d, %d, %d", got, n, hh, ll)
+	}
+}
+
+func TestSnprintf(t *testing.T) {
+	buffer := make([]byte, 8)
+	for i := range buffer {
+		buffer[i] = 'x'
+	}
+
+	n := Snprintf(&buffer[0], 4, cString("%d"), int32(123456))
+	if n != 6 || string(buffer) != "123\x00xxxx" {
+		t.Errorf("got %d, %q", n, buffer)
+	}
+
+	n = Snprintf(nil, 0, cString("%s"), cString("abc"))
+	if n != 3 {
+		t.Errorf("expected 3, got %d", n)
+	}
+}
@@ -0,0 +1,573 @@
+package noarch
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// The scanf functions read the input with the rules of the C standard library
+// (as implemented by glibc) rather than the Go fmt package, which does not
+// support scansets, field widths or the C whitespace rules. All of the
+// functions use scanFormat with a scanReader for the input.
+
+// scanReader is the input of the scanf functions. At most one character that
+// was returned by getc is put back with ungetc. A File is the input of
+// fscanf().
+type scanReader interface {
+	getc() int32
+	ungetc(c int32)
+}
+
+// stringScanReader reads a C string for sscanf().
+type stringScanReader struct {
+	s   *byte
+	pos int
+}
+
+func (r *stringScanReader) getc() int32 {
+	c := *(*byte)(unsafe.Add(unsafe.Pointer(r.s), r.pos))
+	if c == 0 {
+		return EOF
+	}
+	r.pos++
+
+	return int32(c)
+}
+
+func (r *stringScanReader) ungetc(c int32) {
+	r.pos--
+}
+
+// scanner counts the characters that are read, for "%n". If width is not
+// -1 it is the number of characters that are left in the field.
+type scanner struct {
+	r     scanReader
+	count int
+	width int
+}
+
+// next returns the next character of the field, or EOF if there are no more
+// characters in the input or the field.
+func (s *scanner) next() int32 {
+	if s.width == 0 {
+		return EOF
+	}
+	c := s.r.getc()
+	if c != EOF {
+		s.count++
+		if s.width > 0 {
+			s.width--
+		}
+	}
+
+	return c
+}
+
+// back puts back the last character that was returned by next.
+func (s *scanner) back(c int32) {
+	if c != EOF {
+		s.r.ungetc(c)
+		s.count--
+	}
+}
+
+// skipSpace skips the whitespace of the input. It returns false if there are
+// no more characters.
+func (s *scanner) skipSpace() bool {
+	c := s.next()
+	for isSpace(c) {
+		c = s.next()
+	}
+	s.back(c)
+
+	return c != EOF
+}
+
+func isSpace(c int32) bool {
+	return c == ' ' || c >= '\t' && c <= '\r'
+}
+
+// digitValue returns the value of the digit c, or -1 if c is not a digit of
+// the base.
+func digitValue(c int32, base int) int {
+	v := -1
+	switch {
+	case c >= '0' && c <= '9':
+		v = int(c - '0')
+	case c >= 'a' && c <= 'z':
+		v = int(c-'a') + 10
+	case c >= 'A' && c <= 'Z':
+		v = int(c-'A') + 10
+	}
+	if v >= base {
+		return -1
+	}
+
+	return v
+}
+
+// scanFormat reads the input with the C format string and stores the values
+// in the arguments. It returns the number of arguments that were assigned, or
+// EOF if the input ended before the first conversion.
+func scanFormat(r scanReader, format *byte, args []interface{}) int32 {
+	f := CStringToString(format)
+	s := &scanner{r: r, width: -1}
+	assigned := int32(0)
+	converted := false
+
+	next := 0
+	arg := func() interface{} {
+		if next >= len(args) {
+			return nil
+		}
+		next++
+		return args[next-1]
+	}
+
+	// inputFailure is the return value when the input ends.
+	inputFailure := func() int32 {
+		if !converted {
+			return EOF
+		}
+		return assigned
+	}
+
+	for i := 0; i < len(f); i++ {
+		s.width = -1
+
+		// A whitespace in the format matches any amount of whitespace
+		// (including none) in the input.
+		if isSpace(int32(f[i])) {
+			for i+1 < len(f) && isSpace(int32(f[i+1])) {
+				i++
+			}
+			s.skipSpace()
+			continue
+		}
+
+		if f[i] != '%' || i+1 < len(f) && f[i+1] == '%' {
+			if f[i] == '%' {
+				// "%%" is a conversion, so it skips the whitespace.
+				i++
+				s.skipSpace()
+			}
+			c := s.next()
+			if c == EOF {
+				return inputFailure()
+			}
+			if c != int32(f[i]) {
+				s.back(c)
+				return assigned
+			}
+			continue
+		}
+
+		i++
+		suppress := i < len(f) && f[i] == '*'
+		if suppress {
+			i++
+		}
+
+		width, j := parseDecimal(f, i)
+		i = j
+
+		length := ""
+		if i < len(f) {
+			switch f[i] {
+			case 'h', 'l':
+				length = f[i : i+1]
+				if i+1 < len(f) && f[i+1] == f[i] {
+					length = f[i : i+2]
+				}
+			case 'j', 'z', 't', 'L', 'q':
+				length = f[i : i+1]
+			}
+		}
+		i += len(length)
+		if i >= len(f) {
+			return assigned
+		}
+
+		conversion := f[i]
+		if conversion == 'n' {
+			if !suppress {
+				storeInteger(arg(), uint64(s.count))
+			}
+			continue
+		}
+
+		if conversion != 'c' && conversion != '[' && !s.skipSpace() {
+			return inputFailure()
+		}
+
+		if width > 0 {
+			s.width = width
+		}
+
+		var value interface{}
+		switch conversion {
+		case 'd', 'i', 'u', 'o', 'x', 'X', 'p':
+			base := 16
+			switch conversion {
+			case 'd', 'u':
+				base = 10
+			case 'i':
+				base = 0
+			case 'o':
+				base = 8
+			}
+			n, ok := s.scanInteger(base, conversion == 'd' || conversion == 'i')
+			if !ok {
+				return assigned
+			}
+			value = n
+
+		case 'a', 'A', 'e', 'E', 'f', 'F', 'g', 'G':
+			number, ok := s.scanFloat()
+			if !ok {
+				return assigned
+			}
+			value = number
+
+		case 'c':
+			if width == 0 {
+				s.width = 1
+			}
+			var b []byte
+			for c := s.next(); c != EOF; c = s.next() {
+				b = append(b, byte(c))
+			}
+			if len(b) == 0 {
+				return inputFailure()
+			}
+			if !suppress {
+				storeString(arg(), b, false)
+				assigned++
+			}
+			converted = true
+			continue
+
+		case 's':
+			var b []byte
+			c := s.next()
+			for ; c != EOF && !isSpace(c); c = s.next() {
+				b = append(b, byte(c))
+			}
+			s.back(c)
+			value = b
+
+		case '[':
+			var set [256]bool
+			i, set = parseScanset(f, i+1)
+			if i >= len(f) {
+				return assigned
+			}
+			var b []byte
+			c := s.next()
+			for ; c != EOF && set[c]; c = s.next() {
+				b = append(b, byte(c))
+			}
+			s.back(c)
+			if len(b) == 0 {
+				if c == EOF {
+					return inputFailure()
+				}
+				return assigned
+			}
+			value = b
+
+		default:
+			return assigned
+		}
+
+		converted = true
+		if suppress {
+			continue
+		}
+		switch v := value.(type) {
+		case uint64:
+			storeInteger(arg(), v)
+		case string:
+			storeFloat(arg(), v)
+		case []byte:
+			storeString(arg(), v, true)
+		}
+		assigned++
+	}
+
+	return assigned
+}
+
+// parseScanset returns the characters of the scanset that starts at f[i],
+// after the "[", and the index of the "]" at the end of it.
+func parseScanset(f string, i int) (int, [256]bool) {
+	var set [256]bool
+	negate := i < len(f) && f[i] == '^'
+	if negate {
+		i++
+	}
+
+	// A "]" at the start is part of the set.
+	start := i
+	if i < len(f) && f[i] == ']' {
+		set[']'] = true
+		i++
+	}
+	for ; i < len(f) && f[i] != ']'; i++ {
+		// A "-" is a range unless it is at the start or the end of the set.
+		if f[i] == '-' && i > start && i+1 < len(f) && f[i+1] != ']' &&
+			f[i-1] <= f[i+1] {
+			for c := int(f[i-1]); c <= int(f[i+1]); c++ {
+				set[c] = true
+			}
+			i++
+			continue
+		}
+		set[f[i]] = true
+	}
+
+	if negate {
+		for c := range set {
+			set[c] = !set[c]
+		}
+	}
+
+	return i, set
+}
+
+// scanInteger reads an integer like strtol() (or strtoul() if it is not
+// signed) with the base. The base is 0 for "%i", which reads the prefix "0x"
+// or "0" like a C integer constant. It returns false if there are no digits.
+func (s *scanner) scanInteger(base int, signed bool) (uint64, bool) {
+	negative := false
+	c := s.next()
+	if c == '+' || c == '-' {
+		negative = c == '-'
+		c = s.next()
+	}
+
+	digits := ""
+	if (base == 0 || base == 16) && c == '0' {
+		digits = "0"
+		c = s.next()
+		if c == 'x' || c == 'X' {
+			base = 16
+			c = s.next()
+		} else if base == 0 {
+			base = 8
+		}
+	} else if base == 0 {
+		base = 10
+	}
+
+	for ; digitValue(c, base) >= 0; c = s.next() {
+		digits += string(rune(c))
+	}
+	s.back(c)
+	if digits == "" {
+		return 0, false
+	}
+
+	// The value is clamped like strtol() if it overflows, and then it is
+	// truncated to the size of the argument.
+	if signed {
+		if negative {
+			digits = "-" + digits
+		}
+		n, _ := strconv.ParseInt(digits, base, 64)
+		return uint64(n), true
+	}
+
+	n, err := strconv.ParseUint(digits, base, 64)
+	if err != nil {
+		n = math.MaxUint64
+	}
+	if negative {
+		n = -n
+	}
+
+	return n, true
+}
+
+// scanFloat reads a floating-point number like strtod(). The text of the
+// number is returned. It returns false if there is not a number.
+func (s *scanner) scanFloat() (string, bool) {
+	var b strings.Builder
+	c := s.next()
+	if c == '+' || c == '-' {
+		b.WriteByte(byte(c))
+		c = s.next()
+	}
+
+	// matchWord reads the characters of word (in any case), and returns
+	// false if they do not all match.
+	matchWord := func(word string) bool {
+		for i := 0; i < len(word); i++ {
+			if c|0x20 != int32(word[i]) {
+				return false
+			}
+			c = s.next()
+		}
+		return true
+	}
+
+	switch c | 0x20 {
+	case 'i':
+		ok := matchWord("inf")
+		if ok {
+			matchWord("inity")
+		}
+		s.back(c)
+		return b.String() + "inf", ok
+
+	case 'n':
+		// The sign of a NaN is not used.
+		ok := matchWord("nan")
+		s.back(c)
+		return "nan", ok
+	}
+
+	digits := "0123456789"
+	exponent := int32('e')
+	hex := false
+	mantissa := 0
+	if c == '0' {
+		b.WriteByte('0')
+		mantissa++
+		c = s.next()
+		if c == 'x' || c == 'X' {
+			b.WriteByte('x')
+			c = s.next()
+			hex = true
+			digits += "abcdefABCDEF"
+			exponent = 'p'
+		}
+	}
+
+	for ; c != EOF && strings.ContainsRune(digits, c); c = s.next() {
+		b.WriteByte(byte(c))
+		mantissa++
+	}
+	if c == '.' {
+		b.WriteByte('.')
+		for c = s.next(); c != EOF && strings.ContainsRune(digits, c); c = s.next() {
+			b.WriteByte(byte(c))
+			mantissa++
+		}
+	}
+
+	// glibc reads an exponent without digits, like "1e", but it is not part
+	// of the number.
+	number := b.String()
+	if mantissa > 0 && c|0x20 == exponent {
+		b.WriteByte(byte(exponent))
+		c = s.next()
+		if c == '+' || c == '-' {
+			b.WriteByte(byte(c))
+			c = s.next()
+		}
+		exponentDigits := false
+		for ; c >= '0' && c <= '9'; c = s.next() {
+			b.WriteByte(byte(c))
+			exponentDigits = true
+		}
+		if exponentDigits {
+			number = b.String()
+		}
+	}
+	s.back(c)
+
+	if mantissa == 0 {
+		return "", false
+	}
+	if hex {
+		if strings.HasSuffix(number, "x") {
+			number = strings.TrimSuffix(number, "x")
+		} else if !strings.Contains(number, "p") {
+			number += "p0"
+		}
+	}
+
+	return number, true
+}
+
+// storeInteger stores n in the integer that the argument points to. The
+// integer is truncated to the size of the argument.
+func storeInteger(arg interface{}, n uint64) {
+	if isNil(arg) {
+		return
+	}
+
+	v := reflect.ValueOf(arg)
+	if v.Kind() == reflect.UnsafePointer {
+		*(*int32)(v.UnsafePointer()) = int32(n)
+		return
+	}
+	if v.Kind() != reflect.Ptr {
+		return
+	}
+
+	switch e := v.Elem(); e.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.SetInt(int64(n))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		e.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		e.SetFloat(float64(int64(n)))
+	case reflect.UnsafePointer:
+		// "%p" reads a pointer.
+		*(*uintptr)(e.Addr().UnsafePointer()) = uintptr(n)
+	}
+}
+
+// storeFloat stores the number in the float or double that the argument
+// points to.
+func storeFloat(arg interface{}, number string) {
+	if isNil(arg) {
+		return
+	}
+
+	v := reflect.ValueOf(arg)
+	if v.Kind() == reflect.UnsafePointer {
+		f, _ := strconv.ParseFloat(number, 64)
+		*(*float64)(v.UnsafePointer()) = f
+		return
+	}
+	if v.Kind() != reflect.Ptr {
+		return
+	}
+
+	switch e := v.Elem(); e.Kind() {
+	case reflect.Float32:
+		// The number is rounded once to a float, like strtof().
+		f, _ := strconv.ParseFloat(number, 32)
+		e.SetFloat(f)
+	case reflect.Float64:
+		f, _ := strconv.ParseFloat(number, 64)
+		e.SetFloat(f)
+	}
+}
+
+// storeString copies the characters to the array that the argument points
+// to, followed by a NULL byte if nul is true.
+func storeString(arg interface{}, b []byte, nul bool) {
+	if nul {
+		b = append(b, 0)
+	}
+
+	if v, ok := arg.([]byte); ok {
+		copy(v, b)
+		return
+	}
+
+	v := reflect.ValueOf(arg)
+	switch {
+	case isNil(arg):
+	case v.Kind() == reflect.Ptr, v.Kind() == reflect.UnsafePointer:
+		copy(unsafe.Slice((*byte)(v.UnsafePointer()), len(b)), b)
+	}
+}
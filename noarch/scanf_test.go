@@ -0,0 +1,128 @@
+package noarch
+
+import (
+	"math"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestSscanf(t *testing.T) {
+	// The expected values are from glibc. An argument is a new pointer to the
+	// type of its expected value, or a char array for a string.
+	tcs := []struct {
+		input    string
+		format   string
+		result   int32
+		expected []interface{}
+	}{
+		{"", "%d", EOF, []interface{}{int32(0)}},
+		{"   ", "%d", EOF, []interface{}{int32(0)}},
+		{"abc", "x%d", 0, []interface{}{int32(0)}},
+		{"", "abc%d", EOF, []interface{}{int32(0)}},
+		{"", "%n", 0, []interface{}{int32(0)}},
+		{"12 -34 +5", "%d%d%d", 3, []interface{}{int32(12), int32(-34), int32(5)}},
+		{"0xg", "%x%n", 1, []interface{}{int32(0), int32(2)}},
+		{"-0x1f 017 9", "%i%i%n", 2, []interface{}{int32(-31), int32(15), int32(9)}},
+		{"ff 777 1010", "%X %o %hhu", 3, []interface{}{uint32(255), uint32(511), uint8(242)}},
+		{"- 5", "%d", 0, []interface{}{int32(0)}},
+		{"99999999999", "%d", 1, []interface{}{int32(1215752191)}},
+		{"99999999999999999999", "%d", 1, []interface{}{int32(-1)}},
+		{"-1", "%u", 1, []interface{}{uint32(4294967295)}},
+		{"-9000000000", "%lld", 1, []interface{}{int64(-9000000000)}},
+		{"12345", "%3d%d", 2, []interface{}{int32(123), int32(45)}},
+		{"1e", "%lf%n", 1, []interface{}{1.0, int32(2)}},
+		{"1e+x", "%lf%n", 1, []interface{}{1.0, int32(3)}},
+		{"1.5e3x", "%lf%n", 1, []interface{}{1500.0, int32(5)}},
+		{"-infinity", "%lf%n", 1, []interface{}{math.Inf(-1), int32(9)}},
+		{"infx", "%lf%n", 1, []interface{}{math.Inf(1), int32(3)}},
+		{"inx", "%lf", 0, []interface{}{0.0}},
+		{"0x1.8p1 0x1.8", "%lf%lf", 2, []interface{}{3.0, 1.5}},
+		{".5", "%f%n", 1, []interface{}{float32(0.5), int32(2)}},
+		{".", "%lf", 0, []interface{}{0.0}},
+		{"1.2.3", "%4lf%n", 1, []interface{}{1.2, int32(3)}},
+		{"hello world", "%s%n", 1, []interface{}{"hello", int32(5)}},
+		{"hello world", "%3s%s", 2, []interface{}{"hel", "lo"}},
+		{"  abc", "%c%n", 1, []interface{}{" ", int32(1)}},
+		{"ab", "%3c", 1, []interface{}{"ab"}},
+		{"abc123", "%[a-z]%n", 1, []interface{}{"abc", int32(3)}},
+		{"line one\nx", "%[^\n]%n", 1, []interface{}{"line one", int32(8)}},
+		{"]]a", "%[]]%n", 1, []interface{}{"]]", int32(2)}},
+		{"a-b", "%[-a]%n", 1, []interface{}{"a-", int32(2)}},
+		{"123", "%[a-z]", 0, []interface{}{""}},
+		{"key=value;", "%[^=]=%[^;]", 2, []interface{}{"key", "value"}},
+		{"5 6", "%*d %d", 1, []interface{}{int32(6)}},
+		{"  %5", "%%%d", 1, []interface{}{int32(5)}},
+		{"a  b", "a b%n", 0, []interface{}{int32(4)}},
+		{"ab", "a b%n", 0, []interface{}{int32(2)}},
+		{"7", "%d %n", 1, []interface{}{int32(7), int32(1)}},
+		{"7 x", "%d%d", 1, []interface{}{int32(7), int32(0)}},
+		{"", "%*d", EOF, nil},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.input+" "+tc.format, func(t *testing.T) {
+			var args []interface{}
+			for _, e := range tc.expected {
+				if _, ok := e.(string); ok {
+					args = append(args, &make([]byte, 20)[0])
+					continue
+				}
+				args = append(args, reflect.New(reflect.TypeOf(e)).Interface())
+			}
+
+			result := Sscanf(cString(tc.input), cString(tc.format), args...)
+			if result != tc.result {
+				t.Errorf("expected the result %d, got %d", tc.result, result)
+			}
+			for i, e := range tc.expected {
+				var got interface{}
+				if _, ok := e.(string); ok {
+					got = CStringToString(args[i].(*byte))
+				} else {
+					got = reflect.ValueOf(args[i]).Elem().Interface()
+				}
+				if got != e {
+					t.Errorf("expected the argument %d to be %v, got %v", i+1, e, got)
+				}
+			}
+		})
+	}
+}
+
+func TestFscanf(t *testing.T) {
+	f, err := os.CreateTemp("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("3.5 PI\n42 end")
+	f.Seek(0, 0)
+
+	stream := NewFile(f)
+	defer Fclose(stream)
+
+	var d float64
+	var n int32
+	s := make([]byte, 10)
+	if r := Fscanf(stream, cString("%lf %s"), &d, &s[0]); r != 2 {
+		t.Fatalf("expected 2, got %d", r)
+	}
+	if r := Fscanf(stream, cString("%d"), &n); r != 1 {
+		t.Fatalf("expected 1, got %d", r)
+	}
+	if d != 3.5 || CStringToString(&s[0]) != "PI" || n != 42 {
+		t.Errorf("got %v, %q, %d", d, CStringToString(&s[0]), n)
+	}
+
+	// The character after the number is read again.
+	if c := Fgetc(stream); c != ' ' {
+		t.Errorf("expected ' ', got %q", c)
+	}
+	if r := Fscanf(stream, cString("%d"), &n); r != 0 {
+		t.Errorf("expected 0, got %d", r)
+	}
+	if r := Fscanf(stream, cString("end %d"), &n); r != EOF || Feof(stream) == 0 {
+		t.Errorf("expected EOF, got %d", r)
+	}
+}
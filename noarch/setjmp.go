@@ -0,0 +1,68 @@
+package noarch
+
+// JmpBuf is the representation of "jmp_buf" (and "sigjmp_buf").
+//
+// Go cannot return from a function twice so setjmp() is implemented by the
+// transpiler. The function that calls setjmp() is rewritten to run the code
+// after setjmp() in a loop with a deferred call to RecoverLongjmp. Longjmp
+// panics and RecoverLongjmp catches the panic to run the loop again with the
+// new return value of setjmp().
+type JmpBuf struct {
+	// set is true once Setjmp has been called with the buffer.
+	set bool
+}
+
+// longjmpPanic is the value of the panic from Longjmp.
+type longjmpPanic struct {
+	env   *JmpBuf
+	value int32
+}
+
+func (l *longjmpPanic) Error() string {
+	return "longjmp: the function that called setjmp has returned"
+}
+
+// Setjmp handles setjmp(). value is the value that setjmp() returns: zero the
+// first time, or the value passed to Longjmp.
+func Setjmp(env *JmpBuf, value int32) int32 {
+	env.set = true
+	return value
+}
+
+// Sigsetjmp handles sigsetjmp(). Signal masks are not saved so it is the same
+// as Setjmp.
+func Sigsetjmp(env *JmpBuf, saveMask int32, value int32) int32 {
+	return Setjmp(env, value)
+}
+
+// Longjmp handles longjmp() (and siglongjmp()). It does not return. If value
+// is zero then setjmp() will return 1 instead.
+func Longjmp(env *JmpBuf, value int32) {
+	if !env.set {
+		panic("longjmp: jmp_buf has not been set by setjmp")
+	}
+
+	if value == 0 {
+		value = 1
+	}
+
+	panic(&longjmpPanic{env: env, value: value})
+}
+
+// RecoverLongjmp must be deferred by the function that calls setjmp(). If
+// there was a Longjmp to env then value is set to the value passed to Longjmp
+// and jumped is set to true. Any other panic is not recovered.
+func RecoverLongjmp(env *JmpBuf, value *int32, jumped *bool) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	if l, ok := r.(*longjmpPanic); ok && l.env == env {
+		*value = l.value
+		*jumped = true
+		return
+	}
+
+	panic(r)
+}
@@ -0,0 +1,85 @@
+package noarch
+
+import (
+	"testing"
+)
+
+// setjmpLoop is the code generated by the transpiler for:
+//
+//     int r = setjmp(env);
+//     if (r < 3) {
+//         fail(r);
+//     }
+//     return r;
+//
+func setjmpLoop(env *JmpBuf, fail func(int32)) int32 {
+	var value int32
+	for {
+		var jumped bool
+		result := func() int32 {
+			defer RecoverLongjmp(env, &value, &jumped)
+			r := Setjmp(env, value)
+			if r < 3 {
+				fail(r)
+			}
+			return r
+		}()
+		if !jumped {
+			return result
+		}
+	}
+}
+
+func TestLongjmp(t *testing.T) {
+	var env JmpBuf
+	var values []int32
+	r := setjmpLoop(&env, func(r int32) {
+		values = append(values, r)
+		Longjmp(&env, r+1)
+	})
+
+	if r != 3 {
+		t.Errorf("expected 3, got %d", r)
+	}
+	if len(values) != 3 || values[0] != 0 || values[1] != 1 || values[2] != 2 {
+		t.Errorf("unexpected values of setjmp: %v", values)
+	}
+}
+
+func TestLongjmpZero(t *testing.T) {
+	var env JmpBuf
+	r := setjmpLoop(&env, func(r int32) {
+		if r == 0 {
+			Longjmp(&env, 0)
+		}
+		Longjmp(&env, 5)
+	})
+
+	if r != 5 {
+		t.Errorf("expected 5, got %d", r)
+	}
+}
+
+func TestLongjmpOtherBuffer(t *testing.T) {
+	var outer, inner JmpBuf
+	r := setjmpLoop(&outer, func(r int32) {
+		setjmpLoop(&inner, func(int32) {
+			Longjmp(&outer, 4)
+		})
+	})
+
+	if r != 4 {
+		t.Errorf("expected 4, got %d", r)
+	}
+}
+
+func TestLongjmpNotSet(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+
+	var env JmpBuf
+	Longjmp(&env, 1)
+}
@@ -1,6 +1,70 @@
 package noarch
 
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// VaList is the representation of "va_list". Args are the variadic arguments
+// of the function (that has the Go parameter "c2goArgs ...interface{}") and Pos
+// is the index of the next argument to be read by VaArg.
 type VaList struct {
 	Pos  int
 	Args []interface{}
 }
+
+// VaStart handles va_start().
+func VaStart(ap *VaList, args []interface{}) {
+	*ap = VaList{Args: args}
+}
+
+// VaCopy handles va_copy().
+func VaCopy(dest *VaList, src VaList) {
+	*dest = src
+}
+
+// Remaining returns the arguments that have not been read by VaArg. They are
+// the arguments of a function like vsprintf().
+func (ap VaList) Remaining() []interface{} {
+	if ap.Pos >= len(ap.Args) {
+		return nil
+	}
+
+	return ap.Args[ap.Pos:]
+}
+
+// VaArg handles va_arg(). It returns the next argument as a T.
+//
+// The arguments are passed with their Go types rather than with the C default
+// argument promotions, so numbers are converted to T. For example, a char that
+// is read with va_arg(ap, int) is a byte that is converted to an int32.
+// Pointers are converted to T as if they were cast in C.
+func VaArg[T any](ap *VaList) (value T) {
+	if ap.Pos >= len(ap.Args) {
+		panic("va_arg: there are no more arguments")
+	}
+
+	arg := ap.Args[ap.Pos]
+	ap.Pos++
+
+	if v, ok := arg.(T); ok || arg == nil {
+		return v
+	}
+
+	from := reflect.ValueOf(arg)
+	to := reflect.TypeOf(value)
+	switch {
+	case to.Kind() == reflect.UnsafePointer && from.Kind() == reflect.Ptr:
+		return reflect.ValueOf(unsafe.Pointer(from.Pointer())).Convert(to).Interface().(T)
+
+	case to.Kind() == reflect.Ptr && (from.Kind() == reflect.Ptr ||
+		from.Kind() == reflect.UnsafePointer):
+		return reflect.NewAt(to.Elem(), unsafe.Pointer(from.Pointer())).Interface().(T)
+
+	case from.Type().ConvertibleTo(to):
+		return from.Convert(to).Interface().(T)
+	}
+
+	panic(fmt.Sprintf("va_arg: cannot read %T as %T", arg, value))
+}
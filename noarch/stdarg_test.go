@@ -0,0 +1,89 @@
+package noarch
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// sum is the code generated by the transpiler for:
+//
+//     int sum(int count, ...) {
+//         va_list ap;
+//         va_start(ap, count);
+//         int total = 0;
+//         for (int i = 0; i < count; i++) {
+//             total += va_arg(ap, int);
+//         }
+//         va_end(ap);
+//         return total;
+//     }
+//
+func sum(count int32, c2goArgs ...interface{}) int32 {
+	var ap VaList
+	VaStart(&ap, c2goArgs)
+	var total int32
+	for i := int32(0); i < count; i++ {
+		total += VaArg[int32](&ap)
+	}
+	return total
+}
+
+func TestVaArg(t *testing.T) {
+	if got := sum(4, 1, int32(2), byte(3), int64(4)); got != 10 {
+		t.Errorf("expected 10, got %d", got)
+	}
+
+	var ap VaList
+	VaStart(&ap, []interface{}{float32(1.5), 2})
+	if got := VaArg[float64](&ap); got != 1.5 {
+		t.Errorf("expected 1.5, got %v", got)
+	}
+	if got := VaArg[float64](&ap); got != 2 {
+		t.Errorf("expected 2, got %v", got)
+	}
+}
+
+func TestVaArgPointer(t *testing.T) {
+	s := []byte("abc\x00")
+	var ap VaList
+	VaStart(&ap, []interface{}{&s[0], &s[1], nil})
+
+	if got := CStringToString(VaArg[*byte](&ap)); got != "abc" {
+		t.Errorf("expected abc, got %s", got)
+	}
+	if got := VaArg[unsafe.Pointer](&ap); got != unsafe.Pointer(&s[1]) {
+		t.Errorf("expected %p, got %p", &s[1], got)
+	}
+	if got := VaArg[*int32](&ap); got != nil {
+		t.Errorf("expected nil, got %p", got)
+	}
+}
+
+func TestVaCopy(t *testing.T) {
+	var ap, aq VaList
+	VaStart(&ap, []interface{}{1, 2, 3})
+	VaArg[int32](&ap)
+	VaCopy(&aq, ap)
+
+	if got := VaArg[int32](&ap); got != 2 {
+		t.Errorf("expected 2, got %d", got)
+	}
+	if got := VaArg[int32](&aq); got != 2 {
+		t.Errorf("expected 2 from the copy, got %d", got)
+	}
+	if got := len(ap.Remaining()); got != 1 {
+		t.Errorf("expected 1 remaining argument, got %d", got)
+	}
+}
+
+func TestVaArgNoMoreArguments(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+
+	var ap VaList
+	VaStart(&ap, nil)
+	VaArg[int32](&ap)
+}
@@ -2,9 +2,7 @@ package noarch
 
 import (
 	"fmt"
-	"io"
 	"os"
-	"reflect"
 	"strings"
 	"unsafe"
 )
@@ -12,42 +10,7 @@ import (
 // Programs generated by c2go will reference noarch.Stdin instead of os.Stdin
 // directly so that under test these can be replaced. This is required because
 // "go test" does not redirect the stdin to the executable it is testing.
-var (
-	Stdin  = NewFile(os.Stdin)
-	Stdout = NewFile(os.Stdout)
-	Stderr = NewFile(os.Stderr)
-)
-
-// File represents the definition has been translated from the original
-// definition for __sFILE, which is an alias for FILE. Not all of the attributes
-// have been translated. They should be turned on as needed.
-type File struct {
-	// This is not part of the original struct but it is needed for internal
-	// calls in Go.
-	OsFile *os.File
-
-	// unsigned char *_p;
-	// int _r;
-	// int _w;
-	// short _flags;
-	// short _file;
-	// struct __sbuf _bf;
-	// int _lbfsize;
-	// void *_cookie;
-	// int (* _Nullable _close)(void *);
-	// int (* _Nullable _read) (void *, char *, int);
-	// fpos_t (* _Nullable _seek) (void *, fpos_t, int);
-	// int (* _Nullable _write)(void *, const char *, int);
-	// struct __sbuf _ub;
-	// struct __sFILEX *_extra;
-	// int _ur;
-	// unsigned char _ubuf[3];
-	// unsigned char _nbuf[1];
-	// struct __sbuf _lb;
-	// int _blksize;
-	// fpos_t _offset;
-	_flags int32
-}
+var Stdin, Stdout, Stderr = newStandardFiles()
 
 // Fopen handles fopen().
 //
@@ -62,71 +25,270 @@ type File struct {
 // or freopen(). All opened files are automatically closed on normal program
 // termination.
 func Fopen(filePath, mode *byte) *File {
-	var file *os.File
-	var err error
-
-	sFilePath := CStringToString(filePath)
-	var fileExists bool
-	var info os.FileInfo
-	info, err = os.Stat(sFilePath)
-	if info != nil || !os.IsNotExist(err) {
-		fileExists = true
+	file, flags := openFile(filePath, mode)
+	if file == nil {
+		return nil
 	}
 
+	nf := NewFile(file)
+	nf._flags |= flags
+	return nf
+}
+
+// openFile opens the file for fopen() and freopen(). It returns the file and
+// the File flags of the mode, or nil if the file cannot be opened.
+func openFile(filePath, mode *byte) (*os.File, int32) {
+	sFilePath := CStringToString(filePath)
 	m := CStringToString(mode)
-	// no-overwrite flag
-	if strings.Contains(m, "x") {
-		m = strings.Replace(m, "x", "", -1)
-		if strings.Contains(m, "w") && fileExists {
-			// only applies when writing to a file
+	flag, flags := parseMode(m)
+
+	// no-overwrite flag, it only applies when writing to a file
+	if strings.Contains(m, "x") && flag&os.O_CREATE != 0 {
+		if _, err := os.Stat(sFilePath); !os.IsNotExist(err) {
 			setCurrentErrno(EEXIST)
-			return nil
+			return nil, 0
 		}
 	}
-	// binary flag
-	if strings.Contains(m, "b") {
-		m = strings.Replace(m, "b", "", -1)
-		// no other action needed, we are always using binary mode
+
+	file, err := os.OpenFile(sFilePath, flag, 0655)
+	if err != nil {
+		setFopenErrno(err)
+		return nil, 0
 	}
-	var flags int32
+
+	return file, flags
+}
+
+// parseMode returns the flags of os.OpenFile and the File flags of the mode
+// of fopen().
+func parseMode(mode string) (flag int, flags int32) {
+	m := strings.Replace(mode, "x", "", -1)
+	// binary flag, no other action needed, we are always using binary mode
+	m = strings.Replace(m, "b", "", -1)
+
 	switch m {
 	case "r":
-		flags |= io_NO_WRITES
-		file, err = os.OpenFile(sFilePath, os.O_RDONLY, 0655)
+		return os.O_RDONLY, io_NO_WRITES
 	case "r+":
-		file, err = os.OpenFile(sFilePath, os.O_RDWR, 0655)
+		return os.O_RDWR, 0
 	case "a":
-		flags |= io_NO_READS
-		file, err = os.OpenFile(sFilePath, os.O_WRONLY|os.O_APPEND, 0655)
+		return os.O_WRONLY | os.O_APPEND, io_NO_READS | io_IS_APPENDING
 	case "a+":
-		file, err = os.OpenFile(sFilePath, os.O_RDWR|os.O_APPEND, 0655)
+		return os.O_RDWR | os.O_APPEND, io_IS_APPENDING
 	case "w":
-		file, err = os.OpenFile(sFilePath, os.O_RDWR|os.O_CREATE, 0655)
-		if err == nil && fileExists {
-			err = file.Truncate(0)
-		}
+		return os.O_RDWR | os.O_CREATE | os.O_TRUNC, io_NO_READS
 	case "w+":
-		file, err = os.OpenFile(sFilePath, os.O_RDWR|os.O_CREATE, 0655)
-		if err == nil && fileExists {
-			err = file.Truncate(0)
+		return os.O_RDWR | os.O_CREATE | os.O_TRUNC, 0
+	}
+
+	panic(fmt.Sprintf("unsupported file mode: %s", m))
+}
+
+func setFopenErrno(err error) {
+	pe := err.(*os.PathError)
+	setCurrentErrnoErr(pe.Err)
+}
+
+// Freopen handles freopen().
+//
+// Reuses stream to either open the file specified by filePath or to change its
+// access mode.
+//
+// If a new filePath is specified, the function first attempts to close any
+// file already associated with stream and disassociates it. Then,
+// independently of whether that stream was successfuly closed or not, freopen
+// opens the file specified by filePath and associates it with the stream just
+// as fopen would do using the specified mode.
+//
+// If filePath is a null pointer, the function attempts to change the mode of
+// the stream. The file is only opened again if its file descriptor does not
+// allow the mode, and the stream is left unchanged if that fails.
+//
+// The error indicator and eof indicator are automatically cleared.
+func Freopen(filePath, mode *byte, stream *File) *File {
+	stream.flush()
+	if filePath == nil {
+		return changeMode(stream, CStringToString(mode))
+	}
+
+	file, flags := openFile(filePath, mode)
+	if file == nil {
+		stream.close()
+		return nil
+	}
+	reopenStream(stream, file, flags)
+
+	return stream
+}
+
+// changeMode changes the mode of the stream for freopen() without a file
+// name. Like musl, the file descriptor is kept if it allows the new mode, and
+// only its O_APPEND flag is changed. Otherwise, the same file is opened again.
+func changeMode(stream *File, mode string) *File {
+	if stream.OsFile == nil {
+		setCurrentErrno(EBADF)
+		return nil
+	}
+
+	flag, flags := parseMode(mode)
+	access := flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR)
+	if fl, err := fileFlags(stream.OsFile); err == nil {
+		fileAccess := fl & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR)
+		if (fileAccess == os.O_RDWR || fileAccess == access) &&
+			setFileFlags(stream.OsFile, fl&^os.O_APPEND|flag&os.O_APPEND) == nil {
+			stream.discardInput()
+			stream.writing = false
+			stream._flags &^= io_NO_READS | io_NO_WRITES | io_IS_APPENDING |
+				io_EOF_SEEN | io_ERR_SEEN
+			stream._flags |= flags
+			return stream
 		}
-	default:
-		panic(fmt.Sprintf("unsupported file mode: %s", m))
 	}
 
+	path := fdPath(stream.OsFile)
+	if path == "" {
+		setCurrentErrno(EBADF)
+		return nil
+	}
+	file, err := os.OpenFile(path, flag&^os.O_CREATE, 0)
 	if err != nil {
 		setFopenErrno(err)
 		return nil
 	}
+	reopenStream(stream, file, flags)
+
+	return stream
+}
+
+// reopenStream closes the file of the stream and replaces it with file, for
+// freopen().
+func reopenStream(stream *File, file *os.File, flags int32) {
+	// Like glibc, the stream keeps its file descriptor. The new file is
+	// duplicated onto it, so that fileno(stdin) is still 0 after
+	// freopen("in.txt", "r", stdin) and child processes also see the new file.
+	if old := stream.OsFile; old != nil && old.Fd() != file.Fd() &&
+		dupFile(file, old) == nil {
+		file.Close()
+		file = old
+		stream.closer = nil
+	}
+	stream.close()
+
+	// The stream keeps its address, so the new File is copied into it.
+	nf := NewFile(file)
+	removeOpenFile(nf)
+	*stream = *nf
+	stream._flags |= flags
+	addOpenFile(stream)
+}
+
+// Fdopen handles fdopen().
+//
+// Associates a stream with the existing file descriptor fd. The mode of the
+// stream must be compatible with the mode of the file descriptor. The file
+// descriptor is closed when the stream is closed.
+func Fdopen(fd int32, mode *byte) *File {
+	file := os.NewFile(uintptr(fd), "")
+	if file == nil {
+		setCurrentErrno(EBADF)
+		return nil
+	}
+	if _, err := file.Stat(); err != nil {
+		setCurrentErrno(EBADF)
+		return nil
+	}
+
+	var flags int32
+	m := CStringToString(mode)
+	switch {
+	case strings.Contains(m, "+"):
+	case strings.HasPrefix(m, "r"):
+		flags |= io_NO_WRITES
+	case strings.HasPrefix(m, "w"):
+		flags |= io_NO_READS
+	case strings.HasPrefix(m, "a"):
+		flags |= io_NO_READS | io_IS_APPENDING
+	default:
+		setCurrentErrno(EINVAL)
+		return nil
+	}
 
 	nf := NewFile(file)
 	nf._flags |= flags
 	return nf
 }
 
-func setFopenErrno(err error) {
-	pe := err.(*os.PathError)
-	setCurrentErrnoErr(pe.Err)
+// Fmemopen handles fmemopen().
+//
+// Opens a stream that reads from (or writes to) the buffer of size bytes
+// instead of a file. If buffer is a null pointer a new buffer is allocated,
+// which is freed when the stream is closed.
+//
+// The mode is the same as for fopen. The data that can be read is the whole
+// buffer for "r", nothing for "w", and the buffer up to the first NULL byte
+// for "a", where the writes are always appended. A NULL byte is written after
+// the data if there is space for it. Writing beyond the end of the buffer is
+// an error.
+func Fmemopen(buffer unsafe.Pointer, size int32, mode *byte) *File {
+	if size < 0 || buffer == nil && size == 0 {
+		setCurrentErrno(EINVAL)
+		return nil
+	}
+
+	memory := &fixedMemory{}
+	if buffer == nil {
+		memory.buffer = make([]byte, size)
+	} else {
+		memory.buffer = toByteSlice((*byte)(buffer), size)
+	}
+
+	var flags int32
+	m := strings.Replace(CStringToString(mode), "b", "", -1)
+	switch strings.TrimSuffix(m, "+") {
+	case "r":
+		flags |= io_NO_WRITES
+		memory.end = len(memory.buffer)
+	case "w":
+		flags |= io_NO_READS
+	case "a":
+		flags |= io_NO_READS | io_IS_APPENDING
+		memory.end = indexByte(memory.buffer, 0)
+		if memory.end < 0 {
+			memory.end = len(memory.buffer)
+		}
+		memory.pos = memory.end
+		memory.append = true
+	default:
+		setCurrentErrno(EINVAL)
+		return nil
+	}
+	if strings.HasSuffix(m, "+") {
+		flags &^= io_NO_READS | io_NO_WRITES
+	}
+
+	nf := NewFileFromReadWriter(memory, memory)
+	nf._flags |= flags
+	return nf
+}
+
+// OpenMemstream handles open_memstream().
+//
+// Opens a stream for writing to a buffer that grows as it is needed. When the
+// stream is flushed or repositioned, ptr is set to the buffer and sizeloc to
+// the size of the data, which is followed by a NULL byte. The buffer is
+// allocated with malloc, so the program must free it after the stream is
+// closed.
+func OpenMemstream(ptr **byte, sizeloc *uint32) *File {
+	if ptr == nil || sizeloc == nil {
+		setCurrentErrno(EINVAL)
+		return nil
+	}
+
+	memory := &dynamicMemory{ptr: ptr, sizeloc: sizeloc}
+	memory.grow(0)
+	memory.update()
+
+	return NewFileFromReadWriter(nil, memory)
 }
 
 // Fclose handles fclose().
@@ -140,7 +302,7 @@ func setFopenErrno(err error) {
 // Even if the call fails, the stream passed as parameter will no longer be
 // associated with the file nor its buffers.
 func Fclose(f *File) int32 {
-	err := f.OsFile.Close()
+	err := f.close()
 	if err != nil {
 		if err == os.ErrInvalid {
 			setCurrentErrno(EINVAL)
@@ -155,6 +317,19 @@ func Fclose(f *File) int32 {
 	return 0
 }
 
+// Fileno handles fileno().
+//
+// Returns the file descriptor of the stream, or -1 if the stream is not
+// associated with a file.
+func Fileno(stream *File) int32 {
+	if stream.OsFile == nil {
+		setCurrentErrno(EBADF)
+		return -1
+	}
+
+	return int32(stream.OsFile.Fd())
+}
+
 // Remove handles remove().
 //
 // Deletes the file whose name is specified in filePath.
@@ -211,9 +386,9 @@ func Rename(oldName, newName *byte) int32 {
 func Fputs(str *byte, stream *File) int32 {
 	goStr := CStringToString(str)
 
-	n, err := stream.OsFile.WriteString(goStr)
-	if err != nil {
-		panic(err)
+	n := stream.write([]byte(goStr))
+	if n < len(goStr) {
+		return EOF
 	}
 
 	return int32(n)
@@ -254,27 +429,39 @@ func Tmpfile() *File {
 // stream argument, but also allows to specify the maximum size of str and
 // includes in the string any ending newline character.
 func Fgets(str *byte, num int32, stream *File) *byte {
-	buf := make([]byte, num)
-	n, err := stream.OsFile.Read(buf[:num-1])
-	var newlinepos int
-	for ; newlinepos < n-1; newlinepos++ {
-		if buf[newlinepos] == '\n' {
+	if num <= 0 {
+		return nil
+	}
+
+	// Only an error while reading this line makes fgets() fail, but the
+	// error indicator stays set.
+	previousError := stream._flags & io_ERR_SEEN
+	stream._flags &^= io_ERR_SEEN
+
+	buf := toByteSlice(str, num)
+	n := 0
+	for n < int(num)-1 {
+		c := stream.getc()
+		if c == EOF {
 			break
 		}
-	}
-	buf[newlinepos+1] = 0
-	if newlinepos < n-1 {
-		stream.OsFile.Seek(int64(newlinepos-n+1), 1)
-	}
-	if err != nil {
-		if n == 0 && err == io.EOF {
-			stream._flags |= io_EOF_SEEN
-		} else {
-			stream._flags |= io_ERR_SEEN
+		buf[n] = byte(c)
+		n++
+		if c == '\n' {
+			break
 		}
+	}
+
+	failed := stream._flags&io_ERR_SEEN != 0
+	stream._flags |= previousError
+
+	// The contents of str are not changed if there is nothing to read, and
+	// are indeterminate if there is an error.
+	if n == 0 && num > 1 || failed {
 		return nil
 	}
-	copy(toByteSlice(str, num), buf[:newlinepos+2])
+	buf[n] = 0
+
 	return str
 }
 
@@ -304,6 +491,7 @@ func Clearerr(stream *File) {
 // between reading and writing.
 func Rewind(stream *File) {
 	Fseek(stream, 0, 0)
+	Clearerr(stream)
 }
 
 // Feof handles feof().
@@ -342,36 +530,6 @@ func Ferror(stream *File) int32 {
 	return int32(0)
 }
 
-const (
-	// constants for the File flags
-	io_MAGIC             = 0x7BAD0000 // Magic number
-	io_MAGIC_MASK        = 0xFFFF0000
-	io_USER_BUF          = 1 // User owns buffer; don't delete it on close.
-	io_UNBUFFERED        = 2
-	io_NO_READS          = 4 // Reading not allowed
-	io_NO_WRITES         = 8 // Writing not allowed
-	io_EOF_SEEN          = 0x10
-	io_ERR_SEEN          = 0x20
-	io_DELETE_DONT_CLOSE = 0x40 // Don't call close(_fileno) on cleanup.
-	io_LINKED            = 0x80 // Set if linked (using _chain) to streambuf::_list_all.
-	io_IN_BACKUP         = 0x100
-	io_LINE_BUF          = 0x200
-	io_TIED_PUT_GET      = 0x400 // Set if put and get pointer logicly tied.
-	io_CURRENTLY_PUTTING = 0x800
-	io_IS_APPENDING      = 0x1000
-	io_IS_FILEBUF        = 0x2000
-	io_BAD_SEEN          = 0x4000
-	io_USER_LOCK         = 0x8000
-)
-
-// NewFile creates a File pointer from a Go file pointer.
-func NewFile(f *os.File) *File {
-	return &File{
-		OsFile: f,
-		_flags: io_MAGIC,
-	}
-}
-
 // Tmpnam handles tmpnam().
 //
 // Returns a string containing a file name different from the name of any
@@ -415,8 +573,7 @@ func Tmpnam(str *byte) *byte {
 // the last i/o operation was an output operation) any unwritten data in its
 // output buffer is written to the file.
 //
-// If stream is a null pointer, all such streams should be flushed, but this is
-// currently not supported.
+// If stream is a null pointer, all such streams are flushed.
 //
 // The stream remains open after this call.
 //
@@ -424,14 +581,60 @@ func Tmpnam(str *byte) *byte {
 // program terminates, all the buffers associated with it are automatically
 // flushed.
 func Fflush(stream *File) int32 {
-	err := stream.OsFile.Sync()
+	var err error
+	if stream == nil {
+		err = flushAll()
+	} else {
+		err = stream.flush()
+	}
 	if err != nil {
-		return 1
+		return EOF
+	}
+
+	return 0
+}
+
+// Setvbuf handles setvbuf().
+//
+// Specifies a buffer for stream. The function allows to specify the mode and
+// size of the buffer (in bytes).
+//
+// If buffer is a null pointer, the function automatically allocates a buffer.
+// Otherwise, the array pointed by buffer, of at least size bytes, is used as
+// the buffer of the stream.
+//
+// The mode is one of _IOFBF (full buffering), _IOLBF (line buffering) or
+// _IONBF (no buffering). This function should be called once the stream has
+// been associated with an open file, but before any input or output operation
+// is performed with it.
+func Setvbuf(stream *File, buffer *byte, mode, size int32) int32 {
+	if mode != _IOFBF && mode != _IOLBF && mode != _IONBF {
+		setCurrentErrno(EINVAL)
+		return EOF
 	}
 
+	var b []byte
+	if buffer != nil && size > 0 {
+		b = toByteSlice(buffer, size)
+	}
+	stream.setBuffer(b, mode)
+
 	return 0
 }
 
+// Setbuf handles setbuf().
+//
+// Specifies the buffer of BUFSIZ bytes to be used by the stream for its
+// operations. If buffer is a null pointer, the stream is unbuffered.
+func Setbuf(stream *File, buffer *byte) {
+	if buffer == nil {
+		stream.setBuffer(nil, _IONBF)
+		return
+	}
+
+	stream.setBuffer(toByteSlice(buffer, BUFSIZ), _IOFBF)
+}
+
 // Fprintf handles fprintf().
 //
 // Writes the C string pointed by format to the stream. If format includes
@@ -442,24 +645,18 @@ func Fflush(stream *File) int32 {
 // After the format parameter, the function expects at least as many additional
 // arguments as specified by format.
 func Fprintf(f *File, format *byte, args ...interface{}) int32 {
-	realArgs := []interface{}{}
-
-	// Convert any C strings into Go strings.
-	typeOfByteSlice := reflect.TypeOf((*byte)(nil))
-	for _, arg := range args {
-		if reflect.TypeOf(arg) == typeOfByteSlice {
-			realArgs = append(realArgs, CStringToString(arg.(*byte)))
-		} else {
-			realArgs = append(realArgs, arg)
-		}
-	}
-
-	n, err := fmt.Fprintf(f.OsFile, CStringToString(format), realArgs...)
-	if err != nil {
+	result := formatPrintf(format, args)
+	if f.write(result) < len(result) {
 		return -1
 	}
 
-	return int32(n)
+	return int32(len(result))
+}
+
+// Vfprintf handles vfprintf(). It is the same as Fprintf with the arguments
+// of a va_list.
+func Vfprintf(f *File, format *byte, args VaList) int32 {
+	return Fprintf(f, format, args.Remaining()...)
 }
 
 // Fscanf handles fscanf().
@@ -471,62 +668,32 @@ func Fprintf(f *File, format *byte, args ...interface{}) int32 {
 // type specified by their corresponding format specifier within the format
 // string.
 func Fscanf(f *File, format *byte, args ...interface{}) int32 {
-	realArgs := prepareArgsForScanf(args)
-
-	// format is ignored
-	// See https://github.com/elliotchance/c2go/issues/607
-	_ = format
-
-	n, err := fmt.Fscan(f.OsFile, realArgs...)
-	if err != nil {
-		f._flags |= io_EOF_SEEN
-		return EOF
-	}
-
-	finalizeArgsForScanf(realArgs, args)
-
-	return int32(n)
+	return scanFormat(f, format, args)
 }
 
-func finalizeArgsForScanf(realArgs []interface{}, args []interface{}) {
-	typeOfStringRef := reflect.TypeOf(new(string))
-	for i, arg := range realArgs {
-		if reflect.TypeOf(arg) == typeOfStringRef {
-			s := *arg.(*string)
-			byteSlice := toByteSlice(args[i].(*byte), int32(len(s)+1))
-			copy(byteSlice, []byte(s))
-			byteSlice[len(s)] = 0
-		}
-	}
+// Vfscanf handles vfscanf(). It is the same as Fscanf with the arguments of a
+// va_list.
+func Vfscanf(f *File, format *byte, args VaList) int32 {
+	return Fscanf(f, format, args.Remaining()...)
 }
 
-func prepareArgsForScanf(args []interface{}) []interface{} {
-	realArgs := []interface{}{}
-
-	typeOfBytePointer := reflect.TypeOf((*byte)(nil))
-	for _, arg := range args {
-		if reflect.TypeOf(arg) == typeOfBytePointer {
-			realArgs = append(realArgs, new(string))
-		} else {
-			realArgs = append(realArgs, arg)
-		}
-	}
+// Sscanf handles sscanf().
+//
+// Reads data from the C string str and stores them according to the parameter
+// format into the locations pointed by the additional arguments, as if
+// fscanf() was used with a stream that contains the string.
+func Sscanf(str, format *byte, args ...interface{}) int32 {
+	return scanFormat(&stringScanReader{s: str}, format, args)
+}
 
-	return realArgs
+// Vsscanf handles vsscanf(). It is the same as Sscanf with the arguments of a
+// va_list.
+func Vsscanf(str, format *byte, args VaList) int32 {
+	return Sscanf(str, format, args.Remaining()...)
 }
 
 const EOF = -int32(1)
 
-func getc(f *os.File) int32 {
-	buffer := make([]byte, 1)
-	_, err := f.Read(buffer)
-	if err != nil {
-		return EOF
-	}
-
-	return int32(buffer[0])
-}
-
 // Fgetc handles fgetc().
 //
 // Returns the character currently pointed by the internal file position
@@ -541,12 +708,8 @@ func getc(f *os.File) int32 {
 //
 // fgetc and getc are equivalent, except that getc may be implemented as a macro
 // in some libraries.
-func Fgetc(stream *File) (ret int32) {
-	ret = getc(stream.OsFile)
-	if ret == EOF {
-		stream._flags |= io_EOF_SEEN
-	}
-	return
+func Fgetc(stream *File) int32 {
+	return stream.getc()
 }
 
 // Fputc handles fputc().
@@ -555,13 +718,15 @@ func Fgetc(stream *File) (ret int32) {
 //
 // The character is written at the position indicated by the internal position
 // indicator of the stream, which is then automatically advanced by one.
+//
+// On success, the character written is returned. If a writing error occurs,
+// EOF is returned and the error indicator (ferror) is set.
 func Fputc(c int32, f *File) int32 {
-	n, err := f.OsFile.Write([]byte{byte(c)})
-	if err != nil {
-		return 0
+	if f.write([]byte{byte(c)}) == 0 {
+		return EOF
 	}
 
-	return int32(n)
+	return int32(byte(c))
 }
 
 // Getchar handles getchar().
@@ -570,7 +735,30 @@ func Fputc(c int32, f *File) int32 {
 //
 // It is equivalent to calling getc with stdin as argument.
 func Getchar() int32 {
-	return getc(Stdin.OsFile)
+	return Stdin.getc()
+}
+
+// Ungetc handles ungetc().
+//
+// A character is virtually put back into an input stream, decreasing its
+// internal file position as if a previous getc operation was undone.
+//
+// This character may or may not be the one read from the stream in the
+// preceding input operation. In any case, the next character retrieved from
+// stream is the character passed to this function.
+//
+// A successful call clears the end-of-file indicator. A call to fseek,
+// fsetpos or rewind on stream discards any characters previously put back.
+//
+// If character is EOF, the operation fails and the input stream remains
+// unchanged. Otherwise the character put back is returned.
+func Ungetc(character int32, stream *File) int32 {
+	if character == EOF {
+		return EOF
+	}
+	stream.ungetc(character)
+
+	return int32(byte(character))
 }
 
 // Fseek handles fseek().
@@ -594,14 +782,12 @@ func Getchar() int32 {
 // On streams open for update (read+write), a call to fseek allows to switch
 // between reading and writing.
 func Fseek(f *File, offset int32, origin int32) int32 {
-	n, err := f.OsFile.Seek(int64(offset), int(origin))
-	if err != nil {
-		f._flags |= io_EOF_SEEN
-		return EOF
+	if _, err := f.seek(int64(offset), int(origin)); err != nil {
+		setCurrentErrnoErr(err)
+		return -1
 	}
-	Clearerr(f)
 
-	return int32(n)
+	return 0
 }
 
 // Ftell handles ftell().
@@ -616,7 +802,13 @@ func Fseek(f *File, offset int32, origin int32) int32 {
 // are characters put back using ungetc still pending of being read, the
 // behavior is undefined).
 func Ftell(f *File) int32 {
-	return int32(Fseek(f, 0, 1))
+	pos, err := f.tell()
+	if err != nil {
+		setCurrentErrnoErr(err)
+		return -1
+	}
+
+	return int32(pos)
 }
 
 // Fread handles fread().
@@ -628,28 +820,17 @@ func Ftell(f *File) int32 {
 // read.
 //
 // The total amount of bytes read if successful is (size*count).
+//
+// The number of elements that were read is returned. It is less than count if
+// a read error or the end-of-file was encountered, see ferror and feof.
 func Fread(ptr unsafe.Pointer, size1, size2 int32, f *File) int32 {
-	// Create a new buffer so that we can ensure we read up to the correct
-	// number of bytes from the file.
-	newBuffer := make([]byte, size1*size2)
-	ptrSlice := toByteSlice((*byte)(ptr), size1*size2)
-	n, err := f.OsFile.Read(newBuffer)
-
-	// Despite any error we need to make sure the bytes read are copied to the
-	// destination buffer.
-	copy(ptrSlice, newBuffer)
-
-	// Now we can handle the success or failure.
-	if err != nil {
-		if err == io.EOF {
-			f._flags |= io_EOF_SEEN
-		} else {
-			f._flags |= io_ERR_SEEN
-		}
-		return EOF
+	if size1 <= 0 || size2 <= 0 {
+		return 0
 	}
 
-	return int32(n)
+	n := f.read(toByteSlice((*byte)(ptr), size1*size2))
+
+	return int32(n) / size1
 }
 
 // Fwrite handles fwrite().
@@ -663,13 +844,17 @@ func Fread(ptr unsafe.Pointer, size1, size2 int32, f *File) int32 {
 // Internally, the function interprets the block pointed by ptr as if it was an
 // array of (size*count) elements of type unsigned char, and writes them
 // sequentially to stream as if fputc was called for each byte.
+//
+// The number of elements that were written is returned. It is less than count
+// only if a writing error occurred, see ferror.
 func Fwrite(str *byte, size1, size2 int32, stream *File) int32 {
-	n, err := stream.OsFile.Write(toByteSlice(str, size1*size2))
-	if err != nil {
-		return -1
+	if size1 <= 0 || size2 <= 0 {
+		return 0
 	}
 
-	return int32(n)
+	n := stream.write(toByteSlice(str, size1*size2))
+
+	return int32(n) / size1
 }
 
 // Fgetpos handles fgetpos().
@@ -684,12 +869,15 @@ func Fwrite(str *byte, size1, size2 int32, stream *File) int32 {
 // The ftell function can be used to retrieve the current position in the stream
 //as an integer value.
 func Fgetpos(f *File, pos *int32) int32 {
-	absolutePos := Fseek(f, 0, 1)
+	absolutePos := Ftell(f)
+	if absolutePos < 0 {
+		return -1
+	}
 	if pos != nil {
 		*pos = absolutePos
 	}
 
-	return absolutePos
+	return 0
 }
 
 // Fsetpos handles fsetpos().
@@ -720,21 +908,13 @@ func Fsetpos(stream *File, pos *int32) int32 {
 // additional arguments following format are formatted and inserted in the
 // resulting string replacing their respective specifiers.
 func Printf(format *byte, args ...interface{}) int32 {
-	realArgs := []interface{}{}
-
-	// Convert any C strings into Go strings.
-	typeOfByteSlice := reflect.TypeOf((*byte)(nil))
-	for _, arg := range args {
-		if reflect.TypeOf(arg) == typeOfByteSlice {
-			realArgs = append(realArgs, CStringToString(arg.(*byte)))
-		} else {
-			realArgs = append(realArgs, arg)
-		}
-	}
-
-	n, _ := fmt.Printf(CStringToString(format), realArgs...)
+	return Fprintf(Stdout, format, args...)
+}
 
-	return int32(n)
+// Vprintf handles vprintf(). It is the same as Printf with the arguments of a
+// va_list.
+func Vprintf(format *byte, args VaList) int32 {
+	return Printf(format, args.Remaining()...)
 }
 
 // Puts handles puts().
@@ -750,9 +930,12 @@ func Printf(format *byte, args ...interface{}) int32 {
 // destination, but it also appends a newline character at the end automatically
 // (which fputs does not).
 func Puts(str *byte) int32 {
-	n, _ := fmt.Println(CStringToString(str))
+	line := CStringToString(str) + "\n"
+	if Stdout.write([]byte(line)) < len(line) {
+		return EOF
+	}
 
-	return int32(n)
+	return int32(len(line))
 }
 
 // Scanf handles scanf().
@@ -764,14 +947,15 @@ func Puts(str *byte) int32 {
 // type specified by their corresponding format specifier within the format
 // string.
 func Scanf(format *byte, args ...interface{}) int32 {
-	realArgs := prepareArgsForScanf(args)
-
-	// We cannot use fmt.Scanf() here because that would use the real stdin
-	// which does not work under test. See docs for noarch.Stdin.
-	n, _ := fmt.Fscanf(Stdin.OsFile, CStringToString(format), realArgs...)
-	finalizeArgsForScanf(realArgs, args)
+	// We cannot use os.Stdin here because that does not work under test. See
+	// docs for noarch.Stdin.
+	return Fscanf(Stdin, format, args...)
+}
 
-	return int32(n)
+// Vscanf handles vscanf(). It is the same as Scanf with the arguments of a
+// va_list.
+func Vscanf(format *byte, args VaList) int32 {
+	return Scanf(format, args.Remaining()...)
 }
 
 // Putchar handles putchar().
@@ -779,112 +963,56 @@ func Scanf(format *byte, args ...interface{}) int32 {
 // Writes a character to the standard output (stdout).
 //
 // It is equivalent to calling putc with stdout as second argument.
-func Putchar(character int32) {
-	fmt.Printf("%c", character)
+func Putchar(character int32) int32 {
+	return Fputc(character, Stdout)
 }
 
 // Sprintf handles sprintf().
 //
-// Writes the C string pointed by format to the standard output (stdout). If
-// format includes format specifiers (subsequences beginning with %), the
+// Writes the C string pointed by format to the buffer, followed by a NULL
+// byte. If format includes format specifiers (subsequences beginning with %), the
 // additional arguments following format are formatted and inserted in the
 // resulting string replacing their respective specifiers.
 func Sprintf(buffer, format *byte, args ...interface{}) int32 {
-	realArgs := []interface{}{}
-
-	realArgs = append(realArgs, convert(args)...)
-
-	result := fmt.Sprintf(CStringToString(format), realArgs...)
-	var pBuf *byte
-	for i := range []byte(result) {
-		pBuf = (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(buffer)) + uintptr(i)))
-		*pBuf = result[i]
-	}
-	pBuf = (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(buffer)) + uintptr(len(result))))
-	*pBuf = '\x00'
+	result := formatPrintf(format, args)
+	pBuf := toByteSlice(buffer, int32(len(result)+1))
+	copy(pBuf, result)
+	pBuf[len(result)] = 0
 
-	n := len(result)
-	return int32(n)
+	return int32(len(result))
 }
 
-// Vsprintf handles vsprintf().
-//
-// Writes the C string pointed by format to the standard output (stdout). If
-// format includes format specifiers (subsequences beginning with %), the
-// additional arguments following format are formatted and inserted in the
-// resulting string replacing their respective specifiers.
+// Vsprintf handles vsprintf(). It is the same as Sprintf with the arguments of
+// a va_list.
 func Vsprintf(buffer, format *byte, args VaList) int32 {
-	realArgs := []interface{}{}
-
-	realArgs = append(realArgs, convert(args.Args)...)
-
-	result := fmt.Sprintf(CStringToString(format), realArgs...)
-	var pBuf *byte
-	for i := range []byte(result) {
-		pBuf = (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(buffer)) + uintptr(i)))
-		*pBuf = result[i]
-	}
-	pBuf = (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(buffer)) + uintptr(len(result))))
-	*pBuf = '\x00'
-
-	n := len(result)
-	return int32(n)
+	return Sprintf(buffer, format, args.Remaining()...)
 }
 
 // Snprintf handles snprintf().
 //
-// Writes the C string pointed by format to the standard output (stdout). If
-// format includes format specifiers (subsequences beginning with %), the
-// additional arguments following format are formatted and inserted in the
-// resulting string replacing their respective specifiers.
+// It is the same as Sprintf except that no more than n bytes (including the
+// NULL byte) are written to the buffer. The return value is the length of the
+// whole formatted string, which is not less than n if the output was
+// truncated.
 func Snprintf(buffer *byte, n int32, format *byte, args ...interface{}) int32 {
-	return internalVsnprintf(buffer, n, format, args)
-}
-
-// convert - convert va_list
-func convert(arg interface{}) (result []interface{}) {
-	typeOfByteSlice := reflect.TypeOf((*byte)(nil))
-	if reflect.TypeOf(arg) == typeOfByteSlice {
-		return []interface{}{CStringToString(arg.(*byte))}
-	}
-	if reflect.TypeOf(arg).Kind() == reflect.Slice {
-		arg := arg.([]interface{})
-		for j := 0; j < len(arg); j++ {
-			result = append(result, convert(arg[j])...)
+	result := formatPrintf(format, args)
+	if n > 0 {
+		size := len(result)
+		if size > int(n)-1 {
+			size = int(n) - 1
 		}
-		return result
+		pBuf := toByteSlice(buffer, int32(size+1))
+		copy(pBuf, result[:size])
+		pBuf[size] = 0
 	}
-	return []interface{}{arg}
-}
 
-// Vsnprintf handles vsnprintf().
-//
-// Writes the C string pointed by format to the standard output (stdout). If
-// format includes format specifiers (subsequences beginning with %), the
-// additional arguments following format are formatted and inserted in the
-// resulting string replacing their respective specifiers.
-func Vsnprintf(buffer *byte, n int32, format *byte, args VaList) int32 {
-	return internalVsnprintf(buffer, n, format, args.Args)
+	return int32(len(result))
 }
 
-func internalVsnprintf(buffer *byte, n int32, format *byte, args ...interface{}) int32 {
-	realArgs := []interface{}{}
-
-	realArgs = append(realArgs, convert(args)...)
-
-	result := fmt.Sprintf(CStringToString(format), realArgs...)
-	if len(result) > int(n) {
-		result = result[:n]
-	}
-	var pBuf *byte
-	for i, b := range []byte(result) {
-		pBuf = (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(buffer)) + uintptr(i)))
-		*pBuf = b
-	}
-	pBuf = (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(buffer)) + uintptr(len(result))))
-	*pBuf = '\x00'
-
-	return int32(len(result))
+// Vsnprintf handles vsnprintf(). It is the same as Snprintf with the arguments
+// of a va_list.
+func Vsnprintf(buffer *byte, n int32, format *byte, args VaList) int32 {
+	return Snprintf(buffer, n, format, args.Remaining()...)
 }
 
 // Perror handles perror().
@@ -926,5 +1054,5 @@ func Perror(str *byte) {
 	}
 	copy(seek, errstrSlice)
 	seek[len(seek)-1] = '\n'
-	fmt.Fprint(Stderr.OsFile, string(buffer))
+	Stderr.write(buffer)
 }
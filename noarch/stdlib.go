@@ -1,14 +1,16 @@
 package noarch
 
 import (
+	"fmt"
 	"math"
 	"os"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/elliotchance/c2go/util"
 	"math/rand"
-	"sync"
 	"unsafe"
 )
 
@@ -174,6 +176,34 @@ func atoll(str *byte, radix int32) (int64, int) {
 	return v, whitespaceOffset + len(match[1])
 }
 
+// Bsearch handles bsearch().
+//
+// Searches the array of num elements of size bytes that base points to for an
+// element that matches key, and returns a pointer to it. If there is not one
+// a null pointer is returned.
+//
+// The elements must be sorted by compar, which is called with key and a
+// pointer to an element. It returns a negative number, zero or a positive
+// number if key is less than, matches or is greater than the element.
+func Bsearch(key, base unsafe.Pointer, num, size int32, compar interface{}) unsafe.Pointer {
+	cmp := comparator("bsearch", compar)
+	low, high := 0, int(num)
+	for low < high {
+		middle := int(uint(low+high) >> 1)
+		element := unsafe.Add(base, middle*int(size))
+		switch r := cmp(key, element); {
+		case r < 0:
+			high = middle
+		case r > 0:
+			low = middle + 1
+		default:
+			return element
+		}
+	}
+
+	return nil
+}
+
 // Div returns the integral quotient and remainder of the division of numer by
 // denom ( numer/denom ) as a structure of type div_t, ldiv_t or lldiv_t, which
 // has two members: quot and rem.
@@ -184,11 +214,38 @@ func Div(numer, denom int32) DivT {
 	}
 }
 
+// exitFuncs are called when the program exits, in the reverse order that they
+// were added by atExit.
+var exitFuncs []func()
+
+// atExit adds a function that is called when the program exits.
+func atExit(f func()) {
+	exitFuncs = append(exitFuncs, f)
+}
+
+// runExitFuncs calls the exitFuncs and then flushes all of the streams, like
+// the C standard library does before the program exits.
+func runExitFuncs() {
+	for len(exitFuncs) > 0 {
+		f := exitFuncs[len(exitFuncs)-1]
+		exitFuncs = exitFuncs[:len(exitFuncs)-1]
+		f()
+	}
+	flushAll()
+}
+
 // Exit uses os.Exit to stop program execution.
 func Exit(exitCode int32) {
+	runExitFuncs()
 	os.Exit(int(exitCode))
 }
 
+// ReturnFromMain is called when main() returns. It does the same as Exit
+// without stopping the program, so that main() can be called by a test.
+func ReturnFromMain() {
+	runExitFuncs()
+}
+
 // Getenv retrieves a C-string containing the value of the environment variable
 // whose name is specified as argument. If the requested variable is not part of
 // the environment list, the function returns a null pointer.
@@ -253,6 +310,115 @@ func Lldiv(numer, denom int64) LldivT {
 	}
 }
 
+// Qsort handles qsort().
+//
+// Sorts the array of num elements of size bytes that base points to, in the
+// order of compar. It is called with pointers to two elements and returns a
+// negative number, zero or a positive number if the first element is less
+// than, equal to or greater than the second element.
+//
+// Like the qsort() of glibc, the order of equal elements is kept.
+//
+// The elements are swapped as bytes, so they must not contain Go pointers. The
+// transpiler uses QsortOf instead when it knows the type of the elements.
+func Qsort(base unsafe.Pointer, num, size int32, compar interface{}) {
+	if num < 2 || size <= 0 {
+		return
+	}
+
+	sort.Stable(&qsortArray{
+		data: toByteSlice((*byte)(base), num*size),
+		size: int(size),
+		cmp:  comparator("qsort", compar),
+		swap: make([]byte, size),
+	})
+}
+
+// QsortOf is Qsort for an array of elements of the type T, which the
+// transpiler finds from the sizeof() of the size argument. The elements are
+// swapped as values of T, so that they can contain Go pointers like the
+// strings of a "char *" array. Qsort is used if size is not the size of T.
+func QsortOf[T any](base unsafe.Pointer, num, size int32, compar interface{}) {
+	var element T
+	if uintptr(size) != unsafe.Sizeof(element) {
+		Qsort(base, num, size, compar)
+		return
+	}
+	if num < 2 {
+		return
+	}
+
+	cmp := comparator("qsort", compar)
+	elements := unsafe.Slice((*T)(base), num)
+	sort.SliceStable(elements, func(i, j int) bool {
+		return cmp(unsafe.Pointer(&elements[i]), unsafe.Pointer(&elements[j])) < 0
+	})
+}
+
+// qsortArray is the array of Qsort as a sort.Interface.
+type qsortArray struct {
+	data []byte
+	size int
+	cmp  func(a, b unsafe.Pointer) int32
+	swap []byte
+}
+
+func (a *qsortArray) Len() int {
+	return len(a.data) / a.size
+}
+
+func (a *qsortArray) Less(i, j int) bool {
+	return a.cmp(unsafe.Pointer(&a.data[i*a.size]), unsafe.Pointer(&a.data[j*a.size])) < 0
+}
+
+func (a *qsortArray) Swap(i, j int) {
+	x := a.data[i*a.size : (i+1)*a.size]
+	y := a.data[j*a.size : (j+1)*a.size]
+	copy(a.swap, x)
+	copy(x, y)
+	copy(y, a.swap)
+}
+
+// comparator returns a function that calls the comparison function of qsort()
+// or bsearch() with pointers to two elements. A transpiled comparison
+// function has the parameters "const void *", but a comparison function that
+// has been cast from another type may take pointers of the element type, so
+// the pointers are converted to the types of the parameters.
+func comparator(name string, compar interface{}) func(a, b unsafe.Pointer) int32 {
+	if f, ok := compar.(func(unsafe.Pointer, unsafe.Pointer) int32); ok {
+		return f
+	}
+
+	f := reflect.ValueOf(compar)
+	t := f.Type()
+	if t.Kind() != reflect.Func || t.NumIn() != 2 || t.NumOut() != 1 {
+		panic(fmt.Sprintf("%s(): invalid comparison function %T", name, compar))
+	}
+
+	return func(a, b unsafe.Pointer) int32 {
+		result := f.Call([]reflect.Value{
+			pointerValue(t.In(0), a),
+			pointerValue(t.In(1), b),
+		})
+
+		return int32(result[0].Int())
+	}
+}
+
+// pointerValue returns the pointer p as a value of the type t, which is
+// unsafe.Pointer, a pointer or a slice (for a C pointer that is transpiled to a
+// slice).
+func pointerValue(t reflect.Type, p unsafe.Pointer) reflect.Value {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return reflect.NewAt(t.Elem(), p)
+	case reflect.Slice:
+		return reflect.NewAt(reflect.ArrayOf(1, t.Elem()), p).Elem().Slice(0, 1).Convert(t)
+	}
+
+	return reflect.ValueOf(p).Convert(t)
+}
+
 // Rand returns a random number using math/rand.Int().
 func Rand() int32 {
 	return int32(rand.Int())
@@ -357,37 +523,6 @@ func Strtoull(str *byte, endptr **byte, radix int32) uint64 {
 	return uint64(Strtoll(str, endptr, radix))
 }
 
-var (
-	memMgmt map[uint64]interface{}
-	memSync sync.Mutex
-)
-
-func init() {
-	memMgmt = make(map[uint64]interface{})
-}
-
-// Malloc returns a pointer to a memory block of the given length.
-//
-// To prevent the Go garbage collector from collecting this memory,
-// we store the whole block in a map.
-func Malloc(numBytes int32) unsafe.Pointer {
-	memBlock := make([]byte, numBytes)
-	addr := uint64(uintptr(unsafe.Pointer(&memBlock[0])))
-	memSync.Lock()
-	defer memSync.Unlock()
-	memMgmt[addr] = memBlock
-	return unsafe.Pointer(&memBlock[0])
-}
-
-// Free removes the reference to this memory address,
-// so that the Go GC can free it.
-func Free(anything unsafe.Pointer) {
-	addr := uint64(uintptr(anything))
-	memSync.Lock()
-	defer memSync.Unlock()
-	delete(memMgmt, addr)
-}
-
 func atof(str *byte) (float64, int32) {
 	// First start by removing any trailing whitespace. We have to record how
 	// much whitespace is trimmed off to correct for the final length.
@@ -0,0 +1,165 @@
+package noarch
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+	"unsafe"
+)
+
+func TestRealloc(t *testing.T) {
+	p := (*int32)(Malloc(4 * 4))
+	values := unsafe.Slice(p, 4)
+	for i := range values {
+		values[i] = int32(i + 1)
+	}
+
+	// Grow the block.
+	p = (*int32)(Realloc(unsafe.Pointer(p), 100*4))
+	values = unsafe.Slice(p, 100)
+	for i, expected := range []int32{1, 2, 3, 4, 0, 0} {
+		if values[i] != expected {
+			t.Errorf("values[%d]: expected %d, got %d", i, expected, values[i])
+		}
+	}
+	values[99] = 99
+
+	// Shrink the block, and grow it again.
+	p = (*int32)(Realloc(unsafe.Pointer(p), 2*4))
+	p = (*int32)(Realloc(unsafe.Pointer(p), 100*4))
+	values = unsafe.Slice(p, 100)
+	if values[1] != 2 || values[2] != 0 || values[99] != 0 {
+		t.Errorf("expected [1 2 0 ... 0], got %v", values)
+	}
+
+	if got := Realloc(unsafe.Pointer(p), 0); got != nil {
+		t.Errorf("expected nil, got %p", got)
+	}
+	if got := Realloc(nil, 8); got == nil {
+		t.Error("expected a new block")
+	}
+}
+
+func TestReallocInvalidPointer(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+
+	var x int32
+	Realloc(unsafe.Pointer(&x), 8)
+}
+
+func TestCalloc(t *testing.T) {
+	p := (*float64)(Calloc(5, 8))
+	for i, v := range unsafe.Slice(p, 5) {
+		if v != 0 {
+			t.Errorf("element %d: expected 0, got %v", i, v)
+		}
+	}
+
+	if got := Calloc(1<<20, 1<<20); got != nil {
+		t.Errorf("expected nil for an overflow, got %p", got)
+	}
+}
+
+func TestAlignedAlloc(t *testing.T) {
+	for _, alignment := range []int32{1, 8, 16, 64, 4096} {
+		p := AlignedAlloc(alignment, 10)
+		if p == nil || uintptr(p)%uintptr(alignment) != 0 {
+			t.Errorf("%p is not aligned to %d", p, alignment)
+		}
+		Free(p)
+	}
+
+	if got := AlignedAlloc(24, 10); got != nil {
+		t.Errorf("expected nil for an alignment of 24, got %p", got)
+	}
+
+	var p unsafe.Pointer
+	if err := PosixMemalign(&p, 32, 100); err != 0 {
+		t.Fatalf("expected 0, got %d", err)
+	}
+	if uintptr(p)%32 != 0 {
+		t.Errorf("%p is not aligned to 32", p)
+	}
+	if err := PosixMemalign(&p, 2, 100); err != EINVAL {
+		t.Errorf("expected EINVAL, got %d", err)
+	}
+}
+
+func compareInt32(a, b unsafe.Pointer) int32 {
+	return *(*int32)(a) - *(*int32)(b)
+}
+
+func TestQsort(t *testing.T) {
+	values := []int32{40, 10, 100, 90, 20, 25}
+	Qsort(unsafe.Pointer(&values[0]), 5, 4, compareInt32)
+	for i, expected := range []int32{10, 20, 40, 90, 100, 25} {
+		if values[i] != expected {
+			t.Errorf("values[%d]: expected %d, got %d", i, expected, values[i])
+		}
+	}
+
+	// The order of equal elements is kept, and the comparison function can
+	// take typed pointers.
+	type pair struct {
+		key, value int32
+	}
+	pairs := []pair{{2, 0}, {1, 1}, {2, 2}, {1, 3}, {0, 4}}
+	Qsort(unsafe.Pointer(&pairs[0]), 5, int32(unsafe.Sizeof(pair{})), func(a, b *pair) int32 {
+		return a.key - b.key
+	})
+	for i, expected := range []pair{{0, 4}, {1, 1}, {1, 3}, {2, 0}, {2, 2}} {
+		if pairs[i] != expected {
+			t.Errorf("pairs[%d]: expected %v, got %v", i, expected, pairs[i])
+		}
+	}
+}
+
+func TestQsortOf(t *testing.T) {
+	// An array of "char *" has Go pointers, which must be swapped as pointers
+	// so that the garbage collector sees them.
+	words := []*byte{cString("pear"), cString("apple"), cString("fig"), cString("banana")}
+	QsortOf[*byte](unsafe.Pointer(&words[0]), 4, 8, func(a, b unsafe.Pointer) int32 {
+		runtime.GC()
+		return Strcmp(*(**byte)(a), *(**byte)(b))
+	})
+	runtime.GC()
+	var sorted []string
+	for _, word := range words {
+		sorted = append(sorted, CStringToString(word))
+	}
+	if !reflect.DeepEqual(sorted, []string{"apple", "banana", "fig", "pear"}) {
+		t.Errorf("got %q", sorted)
+	}
+
+	// The elements are swapped as bytes if the size is not the size of the
+	// type.
+	values := []int32{3, 1, 2}
+	QsortOf[int64](unsafe.Pointer(&values[0]), 3, 4, compareInt32)
+	if !reflect.DeepEqual(values, []int32{1, 2, 3}) {
+		t.Errorf("got %v", values)
+	}
+}
+
+func TestBsearch(t *testing.T) {
+	values := []int32{10, 20, 25, 40, 90, 100}
+	for i, key := range values {
+		got := Bsearch(unsafe.Pointer(&key), unsafe.Pointer(&values[0]), 6, 4, compareInt32)
+		if got != unsafe.Pointer(&values[i]) {
+			t.Errorf("expected &values[%d], got %p", i, got)
+		}
+	}
+
+	for _, key := range []int32{5, 30, 200} {
+		if got := Bsearch(unsafe.Pointer(&key), unsafe.Pointer(&values[0]), 6, 4, compareInt32); got != nil {
+			t.Errorf("%d: expected nil, got %p", key, got)
+		}
+	}
+	var key int32 = 10
+	if got := Bsearch(unsafe.Pointer(&key), nil, 0, 4, compareInt32); got != nil {
+		t.Errorf("expected nil, got %p", got)
+	}
+}
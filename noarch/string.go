@@ -2,6 +2,7 @@ package noarch
 
 import (
 	"bytes"
+	"math"
 	"strings"
 	"unsafe"
 )
@@ -176,8 +177,6 @@ func Memset(dst unsafe.Pointer, val int32, size int32) unsafe.Pointer {
 // Returns dst.
 // While in C it it is undefined behavior to call memcpy with overlapping regions,
 // in Go we rely on the built-in copy function, which has no such limitation.
-// To copy overlapping regions in C memmove should be used, so we map that function
-// to Memcpy as well.
 func Memcpy(dst unsafe.Pointer, src unsafe.Pointer, size int32) unsafe.Pointer {
 	bDst := toByteSlice((*byte)(dst), size)
 	bSrc := toByteSlice((*byte)(src), size)
@@ -192,3 +191,228 @@ func Memcmp(src1, src2 unsafe.Pointer, n int32) int32 {
 	b2 := toByteSlice((*byte)(src2), n)
 	return int32(bytes.Compare(b1, b2))
 }
+
+// Memmove treats dst and src as binary arrays and copies size bytes from src
+// to dst. Returns dst.
+// Unlike memcpy the regions may overlap, the bytes are copied as if they were
+// first copied to a temporary array.
+func Memmove(dst, src unsafe.Pointer, size int32) unsafe.Pointer {
+	if size <= 0 {
+		return dst
+	}
+	// The built-in copy function handles overlapping slices.
+	copy(toByteSlice((*byte)(dst), size), toByteSlice((*byte)(src), size))
+	return dst
+}
+
+// Memchr - locate a byte in a binary array
+// Returns a pointer to the first occurrence of the byte c in the first n bytes
+// of ptr, or nil if it is not found.
+func Memchr(ptr unsafe.Pointer, c int32, n int32) unsafe.Pointer {
+	if n <= 0 {
+		return nil
+	}
+	index := bytes.IndexByte(toByteSlice((*byte)(ptr), n), byte(c))
+	if index == -1 {
+		return nil
+	}
+	return unsafe.Add(ptr, index)
+}
+
+// Memrchr - like Memchr, but searches backwards from the end of the n bytes.
+func Memrchr(ptr unsafe.Pointer, c int32, n int32) unsafe.Pointer {
+	if n <= 0 {
+		return nil
+	}
+	index := bytes.LastIndexByte(toByteSlice((*byte)(ptr), n), byte(c))
+	if index == -1 {
+		return nil
+	}
+	return unsafe.Add(ptr, index)
+}
+
+// Strrchr - Locate last occurrence of character in string
+// The terminating null character is part of the string, so it can also be
+// located.
+func Strrchr(str *byte, ch int32) *byte {
+	s := CStringToString(str)
+	index := len(s)
+	if byte(ch) != 0 {
+		index = strings.LastIndexByte(s, byte(ch))
+		if index == -1 {
+			return nil
+		}
+	}
+	return (*byte)(unsafe.Add(unsafe.Pointer(str), index))
+}
+
+// Strspn - get span of character set in string
+// Returns the length of the initial portion of str1 which consists only of
+// characters that are part of str2.
+func Strspn(str1, str2 *byte) int32 {
+	s := CStringToString(str1)
+	accept := CStringToString(str2)
+	i := 0
+	for i < len(s) && strings.IndexByte(accept, s[i]) != -1 {
+		i++
+	}
+	return int32(i)
+}
+
+// Strcspn - get span until character in string
+// Returns the length of the initial portion of str1 which consists only of
+// characters that are not part of str2.
+func Strcspn(str1, str2 *byte) int32 {
+	index := strings.IndexAny(CStringToString(str1), CStringToString(str2))
+	if index == -1 {
+		return Strlen(str1)
+	}
+	return int32(index)
+}
+
+// Strpbrk - locate characters in string
+// Returns a pointer to the first character in str1 that is part of str2, or
+// nil if there are none.
+func Strpbrk(str1, str2 *byte) *byte {
+	index := strings.IndexAny(CStringToString(str1), CStringToString(str2))
+	if index == -1 {
+		return nil
+	}
+	return (*byte)(unsafe.Add(unsafe.Pointer(str1), index))
+}
+
+// strtokState is the position that is saved between the calls of strtok().
+var strtokState *byte
+
+// Strtok - split string into tokens
+// The first call gets the string to split and the following calls get nil to
+// continue with the same string. The delimiters in the string are overwritten
+// with null characters.
+func Strtok(str, delim *byte) *byte {
+	return StrtokR(str, delim, &strtokState)
+}
+
+// StrtokR is the reentrant version of Strtok. The position in the string is
+// saved in saveptr instead of a global variable.
+func StrtokR(str, delim *byte, saveptr **byte) *byte {
+	if str == nil {
+		str = *saveptr
+	}
+	if str == nil {
+		return nil
+	}
+
+	// Skip the leading delimiters.
+	str = (*byte)(unsafe.Add(unsafe.Pointer(str), Strspn(str, delim)))
+	if *str == 0 {
+		*saveptr = str
+		return nil
+	}
+
+	end := (*byte)(unsafe.Add(unsafe.Pointer(str), Strcspn(str, delim)))
+	if *end == 0 {
+		*saveptr = end
+	} else {
+		*end = 0
+		*saveptr = (*byte)(unsafe.Add(unsafe.Pointer(end), 1))
+	}
+	return str
+}
+
+// Strsep - extract token from string
+// Unlike Strtok the empty tokens between two delimiters are returned, and the
+// position is stored in stringp, which is set to nil after the last token.
+func Strsep(stringp **byte, delim *byte) *byte {
+	str := *stringp
+	if str == nil {
+		return nil
+	}
+
+	end := (*byte)(unsafe.Add(unsafe.Pointer(str), Strcspn(str, delim)))
+	if *end == 0 {
+		*stringp = nil
+	} else {
+		*end = 0
+		*stringp = (*byte)(unsafe.Add(unsafe.Pointer(end), 1))
+	}
+	return str
+}
+
+// Strnlen returns the length of a string, but at most maxlen.
+func Strnlen(str *byte, maxlen int32) int32 {
+	var i int32
+	for i < maxlen && *(*byte)(unsafe.Add(unsafe.Pointer(str), i)) != 0 {
+		i++
+	}
+	return i
+}
+
+// Strdup - duplicate a string
+// The memory of the new string is allocated with Malloc, so it has to be
+// released with free().
+func Strdup(str *byte) *byte {
+	return Strndup(str, Strlen(str))
+}
+
+// Strndup - like Strdup, but copies at most n characters. The new string is
+// always terminated with a null character.
+func Strndup(str *byte, n int32) *byte {
+	length := Strnlen(str, n)
+	dup := (*byte)(Malloc(length + 1))
+	Memcpy(unsafe.Pointer(dup), unsafe.Pointer(str), length)
+	*(*byte)(unsafe.Add(unsafe.Pointer(dup), length)) = 0
+	return dup
+}
+
+// Strncat - like Strcat, but appends at most n characters of src. A null
+// character is always included at the end of dest.
+func Strncat(dest, src *byte, n int32) *byte {
+	newDest := (*byte)(unsafe.Add(unsafe.Pointer(dest), Strlen(dest)))
+	length := Strnlen(src, n)
+	Memcpy(unsafe.Pointer(newDest), unsafe.Pointer(src), length)
+	*(*byte)(unsafe.Add(unsafe.Pointer(newDest), length)) = 0
+	return dest
+}
+
+// Strcoll - compare two strings using locale
+// Only the "C" locale is supported, so it is the same as Strcmp.
+func Strcoll(str1, str2 *byte) int32 {
+	return Strcmp(str1, str2)
+}
+
+// Strxfrm - transform string using locale
+// In the "C" locale the string is copied unchanged. At most n bytes are
+// written to dest and the length of the transformed string is returned, so
+// dest is only complete if the result is less than n.
+func Strxfrm(dest, src *byte, n int32) int32 {
+	length := Strlen(src)
+	Memcpy(unsafe.Pointer(dest), unsafe.Pointer(src), int32(min(int(length)+1, int(n))))
+	return length
+}
+
+// toLower converts an ASCII upper case letter to lower case.
+func toLower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// Strcasecmp - compare two strings ignoring case
+// Returns the difference of the first characters that differ after they are
+// converted to lower case.
+func Strcasecmp(str1, str2 *byte) int32 {
+	return Strncasecmp(str1, str2, math.MaxInt32)
+}
+
+// Strncasecmp - like Strcasecmp, but compares at most n characters.
+func Strncasecmp(str1, str2 *byte, n int32) int32 {
+	for i := int32(0); i < n; i++ {
+		a := toLower(*(*byte)(unsafe.Add(unsafe.Pointer(str1), i)))
+		b := toLower(*(*byte)(unsafe.Add(unsafe.Pointer(str2), i)))
+		if a != b || a == 0 {
+			return int32(a) - int32(b)
+		}
+	}
+	return 0
+}
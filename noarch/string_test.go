@@ -3,6 +3,7 @@ package noarch
 import (
 	"reflect"
 	"testing"
+	"unsafe"
 )
 
 func TestStringCopy(t *testing.T) {
@@ -24,3 +25,183 @@ func TestStringCopy(t *testing.T) {
 		})
 	}
 }
+
+func TestMemmove(t *testing.T) {
+	buffer := []byte("abcdefgh")
+	Memmove(unsafe.Pointer(&buffer[2]), unsafe.Pointer(&buffer[0]), 5)
+	if string(buffer) != "ababcdeh" {
+		t.Errorf("expected %q, got %q", "ababcdeh", buffer)
+	}
+	Memmove(unsafe.Pointer(&buffer[0]), unsafe.Pointer(&buffer[3]), 5)
+	if string(buffer) != "bcdehdeh" {
+		t.Errorf("expected %q, got %q", "bcdehdeh", buffer)
+	}
+}
+
+func TestMemchr(t *testing.T) {
+	buffer := []byte("ab\x00ab")
+	p := unsafe.Pointer(&buffer[0])
+	if got := Memchr(p, 'b', 5); got != unsafe.Pointer(&buffer[1]) {
+		t.Errorf("expected &buffer[1], got %p", got)
+	}
+	if got := Memrchr(p, 'b', 5); got != unsafe.Pointer(&buffer[4]) {
+		t.Errorf("expected &buffer[4], got %p", got)
+	}
+	if got := Memchr(p, 'b', 1); got != nil {
+		t.Errorf("expected nil, got %p", got)
+	}
+	if got := Memrchr(p, 0, 2); got != nil {
+		t.Errorf("expected nil, got %p", got)
+	}
+}
+
+func TestStrrchr(t *testing.T) {
+	s := cString("a/b/c")
+	if got := CStringToString(Strrchr(s, '/')); got != "/c" {
+		t.Errorf("expected %q, got %q", "/c", got)
+	}
+	if got := Strrchr(s, 0); got != (*byte)(unsafe.Add(unsafe.Pointer(s), 5)) {
+		t.Errorf("expected the end of the string, got %p", got)
+	}
+	if got := Strrchr(s, 'x'); got != nil {
+		t.Errorf("expected nil, got %p", got)
+	}
+}
+
+func TestStrspn(t *testing.T) {
+	tests := []struct {
+		str, set  string
+		spn, cspn int32
+		pbrk      string
+	}{
+		{"129th", "1234567890", 3, 0, "129th"},
+		{"fcba73", "1234567890", 0, 4, "73"},
+		{"", "abc", 0, 0, ""},
+		{"abc", "", 0, 3, ""},
+	}
+	for _, tt := range tests {
+		str, set := cString(tt.str), cString(tt.set)
+		if got := Strspn(str, set); got != tt.spn {
+			t.Errorf("strspn(%q, %q): expected %d, got %d", tt.str, tt.set, tt.spn, got)
+		}
+		if got := Strcspn(str, set); got != tt.cspn {
+			t.Errorf("strcspn(%q, %q): expected %d, got %d", tt.str, tt.set, tt.cspn, got)
+		}
+		got := Strpbrk(str, set)
+		if tt.pbrk == "" && got != nil || tt.pbrk != "" && CStringToString(got) != tt.pbrk {
+			t.Errorf("strpbrk(%q, %q): expected %q, got %p", tt.str, tt.set, tt.pbrk, got)
+		}
+	}
+}
+
+func TestStrtok(t *testing.T) {
+	// The expected tokens are from glibc.
+	var tokens []string
+	for p := Strtok(cString(",,a,,b c,"), cString(", ")); p != nil; p = Strtok(nil, cString(", ")) {
+		tokens = append(tokens, CStringToString(p))
+	}
+	if !reflect.DeepEqual(tokens, []string{"a", "b", "c"}) {
+		t.Errorf("strtok: got %q", tokens)
+	}
+
+	tokens = nil
+	var saveptr *byte
+	for p := StrtokR(cString("x=1"), cString("="), &saveptr); p != nil; p = StrtokR(nil, cString("="), &saveptr) {
+		tokens = append(tokens, CStringToString(p))
+	}
+	if !reflect.DeepEqual(tokens, []string{"x", "1"}) {
+		t.Errorf("strtok_r: got %q", tokens)
+	}
+
+	tokens = nil
+	str := cString("a,,b")
+	for p := Strsep(&str, cString(",")); p != nil; p = Strsep(&str, cString(",")) {
+		tokens = append(tokens, CStringToString(p))
+	}
+	if !reflect.DeepEqual(tokens, []string{"a", "", "b"}) || str != nil {
+		t.Errorf("strsep: got %q", tokens)
+	}
+}
+
+func TestStrdup(t *testing.T) {
+	s := Strdup(cString("hello"))
+	if CStringToString(s) != "hello" {
+		t.Errorf("expected %q, got %q", "hello", CStringToString(s))
+	}
+	Free(unsafe.Pointer(s))
+
+	s = Strndup(cString("hello"), 3)
+	if CStringToString(s) != "hel" {
+		t.Errorf("expected %q, got %q", "hel", CStringToString(s))
+	}
+	Free(unsafe.Pointer(s))
+
+	if got := Strnlen(cString("hello"), 3); got != 3 {
+		t.Errorf("expected 3, got %d", got)
+	}
+	if got := Strnlen(cString("hi"), 3); got != 2 {
+		t.Errorf("expected 2, got %d", got)
+	}
+}
+
+func TestStrncat(t *testing.T) {
+	buffer := make([]byte, 10)
+	copy(buffer, "ab")
+	Strncat(&buffer[0], cString("cdef"), 2)
+	Strncat(&buffer[0], cString("g"), 5)
+	if got := CStringToString(&buffer[0]); got != "abcdg" {
+		t.Errorf("expected %q, got %q", "abcdg", got)
+	}
+}
+
+func TestStrxfrm(t *testing.T) {
+	buffer := []byte("zzzz")
+	if got := Strxfrm(&buffer[0], cString("abcdef"), 3); got != 6 || string(buffer) != "abcz" {
+		t.Errorf("got %d, %q", got, buffer)
+	}
+	if got := Strxfrm(&buffer[0], cString("ab"), 4); got != 2 || CStringToString(&buffer[0]) != "ab" {
+		t.Errorf("got %d, %q", got, buffer)
+	}
+	if Strcoll(cString("a"), cString("b")) >= 0 {
+		t.Error("expected a to be before b")
+	}
+}
+
+func TestStrcasecmp(t *testing.T) {
+	// The expected values are from glibc.
+	tests := []struct {
+		a, b     string
+		n        int32
+		expected int32
+	}{
+		{"Hello", "hELLO", 10, 0},
+		{"abc", "ABD", 10, -1},
+		{"abcX", "ABCy", 3, 0},
+		{"a[", "A_", 10, -4},
+		{"ab", "a", 10, 'b'},
+	}
+	for _, tt := range tests {
+		if tt.n == 10 {
+			if got := Strcasecmp(cString(tt.a), cString(tt.b)); got != tt.expected {
+				t.Errorf("strcasecmp(%q, %q): expected %d, got %d", tt.a, tt.b, tt.expected, got)
+			}
+		}
+		if got := Strncasecmp(cString(tt.a), cString(tt.b), tt.n); got != tt.expected {
+			t.Errorf("strncasecmp(%q, %q, %d): expected %d, got %d", tt.a, tt.b, tt.n, tt.expected, got)
+		}
+	}
+}
+
+func TestStrerrorR(t *testing.T) {
+	// The expected values are from glibc.
+	buffer := make([]byte, 64)
+	if got := StrerrorR(ENOENT, &buffer[0], 64); got != 0 || CStringToString(&buffer[0]) != "No such file or directory" {
+		t.Errorf("got %d, %q", got, CStringToString(&buffer[0]))
+	}
+	if got := StrerrorR(ENOENT, &buffer[0], 8); got != ERANGE || CStringToString(&buffer[0]) != "No such" {
+		t.Errorf("got %d, %q", got, CStringToString(&buffer[0]))
+	}
+	if got := StrerrorR(9999, &buffer[0], 64); got != EINVAL || CStringToString(&buffer[0]) != "Unknown error 9999" {
+		t.Errorf("got %d, %q", got, CStringToString(&buffer[0]))
+	}
+}
@@ -2,10 +2,7 @@
 
 package noarch
 
-import (
-	"fmt"
-	"log/syslog"
-)
+import "log/syslog"
 
 // structure to hold information about an open logger.
 var logger struct {
@@ -40,18 +37,12 @@ func Setlogmask(mask int32) int32 {
 
 // void    syslog(int, const char *, ...);
 func Syslog(priority int32, format *byte, args ...interface{}) {
-	realArgs := []interface{}{}
-	realArgs = append(realArgs, convert(args)...)
-	msg := fmt.Sprintf(CStringToString(format), realArgs...)
-	internalSyslog(priority, msg)
+	internalSyslog(priority, string(formatPrintf(format, args)))
 }
 
 // void    vsyslog(int, const char *, struct __va_list_tag *);
 func Vsyslog(priority int32, format *byte, args VaList) {
-	realArgs := []interface{}{}
-	realArgs = append(realArgs, convert(args.Args)...)
-	msg := fmt.Sprintf(CStringToString(format), realArgs...)
-	internalSyslog(priority, msg)
+	Syslog(priority, format, args.Remaining()...)
 }
 
 func internalSyslog(priority int32, msg string) {
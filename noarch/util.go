@@ -63,13 +63,11 @@ func CPointerToGoPointer(a interface{}) interface{} {
 }
 
 // toByteSlice returns a byte slice to a with the given length.
+//
+// The slice refers to a with a pointer (not a uintptr), so that it stays valid
+// if a is on a goroutine stack that is moved while the slice is used.
 func toByteSlice(a *byte, length int32) []byte {
-	header := reflect.SliceHeader{
-		uintptr(unsafe.Pointer(a)),
-		int(length),
-		int(length),
-	}
-	return (*(*[]byte)(unsafe.Pointer(&header)))[:]
+	return unsafe.Slice(a, length)
 }
 
 // GoPointerToCPointer does the opposite of CPointerToGoPointer.
@@ -0,0 +1,141 @@
+package preprocessor
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/util"
+)
+
+// GetMacros returns the macros that are defined in the user sources of the
+// translation units. Macros that are only defined by headers outside of the
+// project (such as system headers) are not included.
+//
+// A macro that has a different value in different translation units is not
+// included since it cannot be represented by a single Go constant. The same
+// is true for a macro that is redefined or undefined in a translation unit.
+// The clang commands are written to progress if it is not nil.
+func GetMacros(units []TranslationUnit, progress io.Writer) (
+	macros []program.Macro, err error) {
+
+	bodies := map[string]string{}
+	ambiguous := map[string]bool{}
+
+	for _, unit := range units {
+		inputFiles := []string{unit.File}
+
+		var us []string
+		us, err = GetIncludeListWithUserSource(inputFiles, unit.ClangFlags)
+		if err != nil {
+			return
+		}
+
+		// See: https://clang.llvm.org/docs/CommandGuide/clang.html
+		// -dD prints the macro definitions in addition to the normal output.
+		var out bytes.Buffer
+		out, err = runPreprocessor(inputFiles, unit.ClangFlags,
+			[]string{"-E", "-dD"}, progress)
+		if err != nil {
+			return
+		}
+
+		for _, macro := range parseMacros(out.Bytes(), us, ambiguous) {
+			if body, ok := bodies[macro.Name]; ok {
+				if body != macro.Body {
+					ambiguous[macro.Name] = true
+				}
+				continue
+			}
+
+			bodies[macro.Name] = macro.Body
+			macros = append(macros, macro)
+		}
+	}
+
+	// Remove the macros that do not have a single definition.
+	var result []program.Macro
+	for _, macro := range macros {
+		if !ambiguous[macro.Name] {
+			result = append(result, macro)
+		}
+	}
+
+	return result, nil
+}
+
+// parseMacros finds the #define lines that are in the user sources in the
+// output of "clang -E -dD". Macros that are redefined or undefined are added
+// to ambiguous.
+func parseMacros(out []byte, userSources []string, ambiguous map[string]bool) (
+	macros []program.Macro) {
+
+	userSource := map[string]bool{}
+	for _, us := range userSources {
+		userSource[filepath.Clean(us)] = true
+	}
+
+	defined := map[string]bool{}
+	defineRegexp := util.GetRegex(`^#define ([A-Za-z_]\w*)(\(([^)]*)\))?\s*(.*)$`)
+	undefRegexp := util.GetRegex(`^#undef ([A-Za-z_]\w*)`)
+	lineRegexp := util.GetRegex(`^# (\d+) "(.*)"`)
+
+	var (
+		file string
+		line int
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+
+		if groups := lineRegexp.FindStringSubmatch(text); len(groups) > 0 {
+			line = util.Atoi(groups[1])
+			file = groups[2]
+			continue
+		}
+
+		if groups := undefRegexp.FindStringSubmatch(text); len(groups) > 0 {
+			if defined[groups[1]] {
+				ambiguous[groups[1]] = true
+			}
+		}
+
+		if groups := defineRegexp.FindStringSubmatch(text); len(groups) > 0 &&
+			userSource[filepath.Clean(file)] {
+			name, body := groups[1], strings.TrimSpace(groups[4])
+
+			if defined[name] {
+				ambiguous[name] = true
+			}
+			defined[name] = true
+
+			macro := program.Macro{
+				Name:     name,
+				Body:     body,
+				File:     file,
+				Line:     line,
+				Function: groups[2] != "",
+			}
+			if macro.Function && strings.TrimSpace(groups[3]) != "" {
+				for _, param := range strings.Split(groups[3], ",") {
+					macro.Params = append(macro.Params, strings.TrimSpace(param))
+				}
+			}
+
+			// Empty macros (like include guards) and variadic macros cannot
+			// be transpiled.
+			if body != "" && !strings.HasSuffix(groups[3], "...") {
+				macros = append(macros, macro)
+			}
+		}
+
+		line++
+	}
+
+	return
+}
@@ -0,0 +1,50 @@
+package preprocessor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestParseMacros(t *testing.T) {
+	out := `# 1 "main.c"
+# 1 "<built-in>" 1
+#define __STDC__ 1
+# 1 "main.c" 2
+# 1 "/usr/include/stdio.h" 1
+#define EOF (-1)
+# 2 "main.c" 2
+# 1 "config.h" 1
+#define CONFIG_H 
+#define SIZE 1024
+#define MAX(a,b) ((a) > (b) ? (a) : (b))
+#define DEBUG 1
+#undef DEBUG
+#define LOG(fmt, ...) printf(fmt, __VA_ARGS__)
+#define ZERO() 0
+# 3 "main.c" 2
+
+#define NAME "foo"
+int main() { return SIZE; }
+`
+
+	ambiguous := map[string]bool{}
+	macros := parseMacros([]byte(out), []string{"main.c", "./config.h"}, ambiguous)
+
+	expected := []program.Macro{
+		{Name: "SIZE", Body: "1024", File: "config.h", Line: 2},
+		{Name: "MAX", Body: "((a) > (b) ? (a) : (b))", File: "config.h",
+			Line: 3, Function: true, Params: []string{"a", "b"}},
+		{Name: "DEBUG", Body: "1", File: "config.h", Line: 4},
+		{Name: "ZERO", Body: "0", File: "config.h", Line: 7, Function: true},
+		{Name: "NAME", Body: `"foo"`, File: "main.c", Line: 4},
+	}
+	if !reflect.DeepEqual(macros, expected) {
+		t.Errorf("expected %#v, got %#v", expected, macros)
+	}
+
+	if !reflect.DeepEqual(ambiguous, map[string]bool{"DEBUG": true}) {
+		t.Errorf("expected DEBUG to be ambiguous, got %#v", ambiguous)
+	}
+}
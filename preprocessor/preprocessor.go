@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -79,19 +80,31 @@ func (e *entity) isSame(x *entity) bool {
 	return true
 }
 
-// Analyze - separation preprocessor code to part
-func Analyze(inputFiles, clangFlags []string, verbose bool) (pp []byte,
+// TranslationUnit is a C source file and the clang flags that are needed to
+// preprocess it, such as include paths and defines.
+type TranslationUnit struct {
+	File       string
+	ClangFlags []string
+}
+
+// Analyze - separation preprocessor code to part. The clang commands are
+// written to progress if it is not nil.
+func Analyze(inputFiles, clangFlags []string, progress io.Writer) (pp []byte,
 	comments []program.Comment, includes []program.IncludeHeader, err error) {
 
 	var allItems []entity
 
-	allItems, err = analyzeFiles(inputFiles, clangFlags, verbose)
+	allItems, err = analyzeFiles(inputFiles, clangFlags, progress)
+	if err != nil {
+		return
+	}
+
+	err = mangleStaticSymbols(allItems, inputFiles)
 	if err != nil {
 		return
 	}
 
 	// Generate list of user files
-	userSource := map[string]bool{}
 	var us []string
 	us, err = GetIncludeListWithUserSource(inputFiles, clangFlags)
 	if err != nil {
@@ -105,6 +118,83 @@ func Analyze(inputFiles, clangFlags []string, verbose bool) (pp []byte,
 	// Generate C header list
 	includes = generateIncludeList(us, all)
 
+	pp, comments = mergeEntities(allItems, us)
+
+	return
+}
+
+// AnalyzeTranslationUnits - same as Analyze, but each translation unit is
+// preprocessed separately with its own clang flags. The results are merged
+// into one preprocessed file where code that is shared between translation
+// units (such as common headers) only appears once.
+func AnalyzeTranslationUnits(units []TranslationUnit, progress io.Writer) (pp []byte,
+	comments []program.Comment, includes []program.IncludeHeader, err error) {
+
+	var allItems []entity
+	var us, all, files []string
+
+	for _, unit := range units {
+		files = append(files, unit.File)
+
+		inputFiles := []string{unit.File}
+
+		var items []entity
+		items, err = analyzeFiles(inputFiles, unit.ClangFlags, progress)
+		if err != nil {
+			return
+		}
+		allItems = append(allItems, items...)
+
+		var unitUs []string
+		unitUs, err = GetIncludeListWithUserSource(inputFiles, unit.ClangFlags)
+		if err != nil {
+			return
+		}
+		us = appendUnique(us, unitUs)
+
+		var unitAll []string
+		unitAll, err = GetIncludeFullList(inputFiles, unit.ClangFlags)
+		if err != nil {
+			return
+		}
+		all = appendUnique(all, unitAll)
+	}
+
+	err = mangleStaticSymbols(allItems, files)
+	if err != nil {
+		return
+	}
+
+	// Generate C header list
+	includes = generateIncludeList(us, all)
+
+	pp, comments = mergeEntities(allItems, us)
+
+	return
+}
+
+func appendUnique(list, items []string) []string {
+	for _, item := range items {
+		var found bool
+		for _, l := range list {
+			if l == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// mergeEntities - join the entities into the preprocessed code, removing any
+// duplicate entities. Comments are only collected from the user sources.
+func mergeEntities(allItems []entity, us []string) (pp []byte,
+	comments []program.Comment) {
+
+	userSource := map[string]bool{}
 	for j := range us {
 		userSource[us[j]] = true
 	}
@@ -160,11 +250,11 @@ func Analyze(inputFiles, clangFlags []string, verbose bool) (pp []byte,
 }
 
 // analyzeFiles - analyze single file and separation preprocessor code to part
-func analyzeFiles(inputFiles, clangFlags []string, verbose bool) (items []entity, err error) {
+func analyzeFiles(inputFiles, clangFlags []string, progress io.Writer) (items []entity, err error) {
 	// See : https://clang.llvm.org/docs/CommandGuide/clang.html
 	// clang -E <file>    Run the preprocessor stage.
 	var out bytes.Buffer
-	out, err = getPreprocessSources(inputFiles, clangFlags, verbose)
+	out, err = getPreprocessSources(inputFiles, clangFlags, progress)
 	if err != nil {
 		return
 	}
@@ -209,7 +299,13 @@ func analyzeFiles(inputFiles, clangFlags []string, verbose bool) (items []entity
 
 // See : https://clang.llvm.org/docs/CommandGuide/clang.html
 // clang -E <file>    Run the preprocessor stage.
-func getPreprocessSources(inputFiles, clangFlags []string, verbose bool) (out bytes.Buffer, err error) {
+func getPreprocessSources(inputFiles, clangFlags []string, progress io.Writer) (out bytes.Buffer, err error) {
+	return runPreprocessor(inputFiles, clangFlags, []string{"-E", "-C"}, progress)
+}
+
+// runPreprocessor runs clang with the mode arguments (such as "-E") over all
+// of the input files as if they were a single file.
+func runPreprocessor(inputFiles, clangFlags, modeArgs []string, progress io.Writer) (out bytes.Buffer, err error) {
 	// get temp dir
 	dir, err := os.MkdirTemp("", "c2go-union")
 	if err != nil {
@@ -248,14 +344,14 @@ func getPreprocessSources(inputFiles, clangFlags []string, verbose bool) (out by
 	var stderr bytes.Buffer
 
 	var args []string
-	args = append(args, "-E", "-C")
+	args = append(args, modeArgs...)
 	args = append(args, clangFlags...)
 	args = append(args, unionFileName) // All inputFiles
 
 	var outFile bytes.Buffer
-	if verbose {
-		fmt.Println("executing clang:")
-		fmt.Println("clang", strings.Join(args, " "))
+	if progress != nil {
+		fmt.Fprintln(progress, "executing clang:")
+		fmt.Fprintln(progress, "clang", strings.Join(args, " "))
 	}
 	cmd := exec.Command("clang", args...)
 	cmd.Stdout = &outFile
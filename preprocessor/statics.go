@@ -0,0 +1,408 @@
+package preprocessor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// The functions and variables that are declared "static" at file scope have
+// internal linkage. Two C files can have static symbols with the same name,
+// but all of the C files are transpiled into one Go package. The static
+// symbols of each C file are renamed with a prefix from the name of the file so
+// that they do not collide:
+//
+//     // a.c                          // b.c
+//     static int helper(void);        static int helper(void);
+//
+//     func a_helper() int32           func b_helper() int32
+//
+// The symbols that have external linkage are shared between the files and are
+// not renamed.
+
+// cToken is a token of C source code. Comments, whitespace and preprocessor
+// lines are not tokens.
+type cToken struct {
+	text  string
+	start int
+	ident bool
+}
+
+// cKeywords are the keywords (and compiler extensions) that can appear before
+// the name in a declaration.
+var cKeywords = map[string]bool{
+	"auto": true, "char": true, "const": true, "double": true, "enum": true,
+	"extern": true, "float": true, "inline": true, "int": true, "long": true,
+	"register": true, "restrict": true, "short": true, "signed": true,
+	"static": true, "struct": true, "union": true, "unsigned": true,
+	"void": true, "volatile": true, "_Atomic": true, "_Bool": true,
+	"_Complex": true, "_Noreturn": true, "_Thread_local": true,
+	"__const": true, "__extension__": true, "__inline": true,
+	"__inline__": true, "__restrict": true, "__restrict__": true,
+	"__signed__": true, "__thread": true, "__volatile__": true,
+}
+
+// cAttributes are the keywords that are followed by arguments in parentheses
+// that are not part of the declarator, like "__attribute__((unused))".
+var cAttributes = map[string]bool{
+	"__attribute__": true, "__attribute": true, "__declspec": true,
+	"__asm__": true, "__asm": true, "asm": true, "_Alignas": true,
+	"__typeof__": true, "__typeof": true, "typeof": true,
+}
+
+// tokenizeC splits the C source into tokens. It only needs to be good enough
+// to find the identifiers; all punctuators other than "->" are a single
+// character.
+func tokenizeC(src string) (tokens []cToken) {
+	isIdent := func(c byte) bool {
+		return c == '_' || c == '$' || c < 0x80 && unicode.IsLetter(rune(c)) ||
+			c >= '0' && c <= '9'
+	}
+
+	lineStart := true
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			lineStart = true
+			i++
+			continue
+
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+			continue
+
+		case lineStart && c == '#':
+			// A line marker or a pragma.
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return
+			}
+			i += end + 4
+			continue
+		}
+		lineStart = false
+
+		start := i
+		switch {
+		case c == '"' || c == '\'':
+			for i++; i < len(src) && src[i] != c && src[i] != '\n'; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+			i++
+
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) &&
+			src[i+1] >= '0' && src[i+1] <= '9':
+			for i++; i < len(src); i++ {
+				if (src[i] == '+' || src[i] == '-') &&
+					strings.ContainsRune("eEpP", rune(src[i-1])) {
+					continue
+				}
+				if !isIdent(src[i]) && src[i] != '.' {
+					break
+				}
+			}
+
+		case isIdent(c):
+			for i++; i < len(src) && isIdent(src[i]); i++ {
+			}
+			tokens = append(tokens, cToken{text: src[start:i], start: start, ident: true})
+			continue
+
+		case strings.HasPrefix(src[i:], "->"):
+			i += 2
+
+		default:
+			i++
+		}
+
+		if i > len(src) {
+			i = len(src)
+		}
+		tokens = append(tokens, cToken{text: src[start:i], start: start})
+	}
+
+	return
+}
+
+// skipBalanced returns the index of the token that closes the bracket at
+// tokens[i].
+func skipBalanced(tokens []cToken, i int) int {
+	depth := 0
+	for ; i < len(tokens); i++ {
+		switch tokens[i].text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return len(tokens) - 1
+}
+
+// findStaticSymbols returns the names of the functions and variables that are
+// declared static at file scope.
+func findStaticSymbols(tokens []cToken) (names []string) {
+	depth := 0
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i].text {
+		case "{", "(", "[":
+			depth++
+		case "}", ")", "]":
+			depth--
+		case "static":
+			if depth == 0 {
+				var declared []string
+				declared, i = staticDeclarators(tokens, i+1)
+				names = append(names, declared...)
+			}
+		}
+	}
+
+	return
+}
+
+// staticDeclarators returns the names that are declared by the declaration
+// that starts at tokens[i], and the index of the last token of the
+// declaration.
+func staticDeclarators(tokens []cToken, i int) (names []string, _ int) {
+	found := false
+	for ; i < len(tokens); i++ {
+		t := tokens[i]
+		switch {
+		case t.text == ";":
+			return names, i
+
+		case t.text == "{":
+			// The body of a function, or of a struct that is the type.
+			i = skipBalanced(tokens, i)
+			if found {
+				return names, i
+			}
+
+		case t.text == "=" && found:
+			// Skip the initializer.
+			for i++; i < len(tokens); i++ {
+				switch tokens[i].text {
+				case "(", "[", "{":
+					i = skipBalanced(tokens, i)
+				case ",":
+					found = false
+				case ";":
+					return names, i
+				}
+				if !found {
+					break
+				}
+			}
+
+		case t.text == ",":
+			found = false
+
+		case (t.text == "(" || t.text == "[") && found:
+			// The parameters or the size of an array.
+			i = skipBalanced(tokens, i)
+
+		case cAttributes[t.text]:
+			if i+1 < len(tokens) && tokens[i+1].text == "(" {
+				i = skipBalanced(tokens, i+1)
+			}
+
+		case t.ident && !found && !cKeywords[t.text] && isDeclaratorName(tokens, i):
+			names = append(names, t.text)
+			found = true
+		}
+	}
+
+	return names, i
+}
+
+// isDeclaratorName returns true if the identifier at tokens[i] is the name
+// that is declared, rather than a type name or a tag.
+func isDeclaratorName(tokens []cToken, i int) bool {
+	if i > 0 {
+		switch tokens[i-1].text {
+		case "struct", "union", "enum":
+			return false
+		}
+	}
+	if i+1 >= len(tokens) {
+		return false
+	}
+
+	next := tokens[i+1].text
+	switch next {
+	case "[", ")", ",", ";", "=", "{", ":":
+		return true
+
+	case "(":
+		// "size_t (*f)(void)" is the type of a function pointer.
+		if i+2 < len(tokens) {
+			after := tokens[i+2].text
+			return after != "*" && after != "^"
+		}
+		return true
+	}
+
+	return cAttributes[next]
+}
+
+// renameSymbols replaces the identifiers in the source. The names of the
+// fields in struct and union bodies, after "." and "->", and the tags after
+// "struct", "union" and "enum" are not replaced.
+func renameSymbols(src string, tokens []cToken, names map[string]string) string {
+	var out strings.Builder
+	last := 0
+	var records []bool
+	for i, t := range tokens {
+		switch t.text {
+		case "{":
+			isRecord := i > 0 && (tokens[i-1].text == "struct" ||
+				tokens[i-1].text == "union") ||
+				i > 1 && tokens[i-1].ident && (tokens[i-2].text == "struct" ||
+					tokens[i-2].text == "union")
+			records = append(records, isRecord)
+			continue
+		case "}":
+			if len(records) > 0 {
+				records = records[:len(records)-1]
+			}
+			continue
+		}
+
+		name, ok := names[t.text]
+		if !ok || !t.ident {
+			continue
+		}
+		if len(records) > 0 && records[len(records)-1] {
+			continue
+		}
+		if i > 0 {
+			switch tokens[i-1].text {
+			case ".", "->", "struct", "union", "enum":
+				continue
+			}
+		}
+
+		out.WriteString(src[last:t.start])
+		out.WriteString(name)
+		last = t.start + len(t.text)
+	}
+	out.WriteString(src[last:])
+
+	return out.String()
+}
+
+// staticPrefix returns the prefix for the static symbols of a C file, like
+// "foo_" for "/src/foo.c".
+func staticPrefix(file string) string {
+	base := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	prefix := []rune(base)
+	for i, r := range prefix {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			prefix[i] = '_'
+		}
+	}
+	if len(prefix) == 0 || unicode.IsDigit(prefix[0]) {
+		prefix = append([]rune{'_'}, prefix...)
+	}
+
+	return string(prefix) + "_"
+}
+
+// mangleStaticSymbols renames the static symbols at file scope in the code of
+// each of the input files. A number is added to a new name that is already
+// used. There is nothing to do if there is only one input file.
+func mangleStaticSymbols(items []entity, inputFiles []string) error {
+	if len(inputFiles) < 2 {
+		return nil
+	}
+
+	// The new names must not be the names of other symbols, like "a_x" for
+	// the static "x" of a.c if b.c has a global "a_x".
+	identifiers := map[string]bool{}
+	for _, item := range items {
+		var lines []string
+		for _, l := range item.lines[1:] {
+			lines = append(lines, *l)
+		}
+		for _, t := range tokenizeC(strings.Join(lines, "\n")) {
+			if t.ident {
+				identifiers[t.text] = true
+			}
+		}
+	}
+
+	prefixes := map[string]bool{}
+	for _, inputFile := range inputFiles {
+		file, err := filepath.Abs(inputFile)
+		if err != nil {
+			return err
+		}
+
+		prefix := staticPrefix(file)
+		for i := 2; prefixes[prefix]; i++ {
+			prefix = fmt.Sprintf("%s%d_", strings.TrimSuffix(staticPrefix(file), "_"), i)
+		}
+		prefixes[prefix] = true
+
+		// The code of the file is split into several entities by the
+		// includes.
+		var fileItems []*entity
+		var lines []string
+		for i := range items {
+			if items[i].include != file {
+				continue
+			}
+			fileItems = append(fileItems, &items[i])
+			for _, l := range items[i].lines[1:] {
+				lines = append(lines, *l)
+			}
+		}
+
+		src := strings.Join(lines, "\n")
+		tokens := tokenizeC(src)
+		names := map[string]string{}
+		for _, name := range findStaticSymbols(tokens) {
+			newName := prefix + name
+			for i := 2; identifiers[newName]; i++ {
+				newName = fmt.Sprintf("%s%s%d", prefix, name, i)
+			}
+			identifiers[newName] = true
+			names[name] = newName
+		}
+		if len(names) == 0 {
+			continue
+		}
+
+		lines = strings.Split(renameSymbols(src, tokens, names), "\n")
+		for _, item := range fileItems {
+			for i := 1; i < len(item.lines); i++ {
+				l := lines[0]
+				item.lines[i] = &l
+				lines = lines[1:]
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package preprocessor
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindStaticSymbols(t *testing.T) {
+	tcs := []struct {
+		code  string
+		names []string
+	}{
+		{`static int helper(void) { static int local; return 0; }`, []string{"helper"}},
+		{`static int a = 1, b[3] = {1, 2}, *c;`, []string{"a", "b", "c"}},
+		{`static size_t (*handler)(const char *name);`, []string{"handler"}},
+		{`static struct point { int x; } origin = {0};`, []string{"origin"}},
+		{`static struct config config;`, []string{"config"}},
+		{`static const char *names[] = {"a", "b"};`, []string{"names"}},
+		{`static inline __attribute__((unused)) int twice(int x) { return 2 * x; }`, []string{"twice"}},
+		{`int shared; void f(int a[static 3]) {} extern int other;`, nil},
+		{"// static int comment;\nint x = sizeof(\"static int s;\");", nil},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.code, func(t *testing.T) {
+			names := findStaticSymbols(tokenizeC(tc.code))
+			if !reflect.DeepEqual(names, tc.names) {
+				t.Errorf("expected %v, got %v", tc.names, names)
+			}
+		})
+	}
+}
+
+func TestRenameSymbols(t *testing.T) {
+	tcs := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name: "fields",
+			code: `struct counter { int count; };
+static int count = 0;
+static int next(struct counter *c) {
+    c->count++;
+    return ++count; // count
+}`,
+			expected: `struct counter { int count; };
+static int a_count = 0;
+static int a_next(struct counter *c) {
+    c->count++;
+    return ++a_count; // count
+}`,
+		},
+		{
+			// The tags may be defined in a header, which is not renamed.
+			name: "tags",
+			code: `static struct config config;
+static union value value;
+static enum mode mode;
+int size(void) { return sizeof(struct config) + sizeof(config); }`,
+			expected: `static struct config a_config;
+static union value a_value;
+static enum mode a_mode;
+int size(void) { return sizeof(struct config) + sizeof(a_config); }`,
+		},
+	}
+
+	names := map[string]string{
+		"count":  "a_count",
+		"next":   "a_next",
+		"config": "a_config",
+		"value":  "a_value",
+		"mode":   "a_mode",
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got := renameSymbols(tc.code, tokenizeC(tc.code), names)
+			if got != tc.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestMangleStaticSymbols(t *testing.T) {
+	a, err := filepath.Abs("a.c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := filepath.Abs("b.c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newEntity := func(file string, lines ...string) entity {
+		e := entity{include: file, positionInSource: 1}
+		lines = append([]string{`# 1 "` + file + `"`}, lines...)
+		for i := range lines {
+			e.lines = append(e.lines, &lines[i])
+		}
+		return e
+	}
+	items := []entity{
+		newEntity(a, "static int helper(void) { return 1; }", "int a(void) {"),
+		newEntity("header.h", "int helper2(void);"),
+		newEntity(a, "  return helper();", "}"),
+		newEntity(b, "static int helper(void) { return 2; }"),
+		newEntity(b, "int b(void) { return helper(); }"),
+	}
+
+	if err := mangleStaticSymbols(items, []string{"a.c", "b.c"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, item := range items {
+		for _, l := range item.lines[1:] {
+			got = append(got, *l)
+		}
+	}
+	expected := []string{
+		"static int a_helper(void) { return 1; }", "int a(void) {",
+		"int helper2(void);",
+		"  return a_helper();", "}",
+		"static int b_helper(void) { return 2; }",
+		"int b(void) { return b_helper(); }",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"),
+			strings.Join(got, "\n"))
+	}
+
+	// The new name is not the name of another symbol.
+	items = []entity{
+		newEntity(a, "static int x;", "int get(void) { return x; }"),
+		newEntity(b, "int a_x;", "int a_x2 = 1;"),
+	}
+	if err := mangleStaticSymbols(items, []string{"a.c", "b.c"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got = nil
+	for _, item := range items {
+		for _, l := range item.lines[1:] {
+			got = append(got, *l)
+		}
+	}
+	expected = []string{
+		"static int a_x3;", "int get(void) { return a_x3; }",
+		"int a_x;", "int a_x2 = 1;",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"),
+			strings.Join(got, "\n"))
+	}
+}
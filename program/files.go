@@ -0,0 +1,266 @@
+package program
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	goast "go/ast"
+
+	"github.com/elliotchance/c2go/util"
+)
+
+// SharedFileName is the Go file generated by Files() for all of the
+// declarations that do not belong to a C source file. This includes the types
+// and functions from system headers, and from user headers unless they are
+// split into their own files.
+const SharedFileName = "c2go_shared.go"
+
+// SetDeclFile records the C file that top level Go declarations were
+// transpiled from. This is used by Files() to place each declaration in the Go
+// file for that C file.
+func (p *Program) SetDeclFile(cFile string, decls ...goast.Decl) {
+	for _, decl := range decls {
+		p.declFiles[decl] = cFile
+	}
+}
+
+// goFileName returns the name of the Go file that should contain the
+// declarations from a C file.
+func (p *Program) goFileName(cFile string, splitHeaders bool) string {
+	extension := filepath.Ext(cFile)
+	base := strings.TrimSuffix(filepath.Base(cFile), extension)
+
+	switch {
+	case extension == ".c":
+		return goFileBase(base) + ".go"
+
+	case splitHeaders && p.isUserHeader(cFile):
+		return base + "_h.go"
+	}
+
+	return SharedFileName
+}
+
+// goFileSuffixes are the known GOOS and GOARCH values. A Go file with a name
+// that ends in "_GOOS", "_GOARCH" or "_GOOS_GOARCH" has an implicit build
+// constraint. The list is from go/build.
+var goFileSuffixes = map[string]bool{
+	// GOOS
+	"aix": true, "android": true, "darwin": true, "dragonfly": true,
+	"freebsd": true, "hurd": true, "illumos": true, "ios": true, "js": true,
+	"linux": true, "nacl": true, "netbsd": true, "openbsd": true,
+	"plan9": true, "solaris": true, "wasip1": true, "windows": true,
+	"zos": true,
+
+	// GOARCH
+	"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true,
+	"arm64": true, "arm64be": true, "loong64": true, "mips": true,
+	"mipsle": true, "mips64": true, "mips64le": true, "mips64p32": true,
+	"mips64p32le": true, "ppc": true, "ppc64": true, "ppc64le": true,
+	"riscv": true, "riscv64": true, "s390": true, "s390x": true,
+	"sparc": true, "sparc64": true, "wasm": true,
+}
+
+// goFileBase returns the base name of the Go file for a C file. The name of a
+// C file like "parser_test.c" or "io_linux.c" would make the Go file a test
+// or give it a build constraint, so that "go build" would leave it out. "_c"
+// is appended to such names.
+func goFileBase(base string) string {
+	i := strings.LastIndex(base, "_")
+	if i <= 0 {
+		return base
+	}
+
+	suffix := base[i+1:]
+	if suffix == "test" || goFileSuffixes[suffix] {
+		return base + "_c"
+	}
+
+	return base
+}
+
+func (p *Program) isUserHeader(cFile string) bool {
+	for _, header := range p.IncludeHeaders {
+		if header.IsUserSource && (header.HeaderName == cFile ||
+			filepath.Clean(header.HeaderName) == filepath.Clean(cFile)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Files generates the Go package as several files rather than the single file
+// from String(). There is one Go file for each C source file, and the types
+// and functions that came from headers are placed once in SharedFileName. If
+// splitHeaders is true each user header also gets its own Go file.
+//
+// The returned map is the Go file name to the contents of that file. Each file
+// only imports the packages that it uses.
+func (p *Program) Files(splitHeaders bool) (map[string]string, error) {
+	// The C file for each Go file, so that two C files with the same name in
+	// different directories are not placed in the same Go file.
+	cFiles := map[string]string{}
+	goFiles := map[string]string{}
+	goFileFor := func(cFile string) string {
+		if name, ok := goFiles[cFile]; ok {
+			return name
+		}
+
+		name := p.goFileName(cFile, splitHeaders)
+		if name != SharedFileName {
+			base := strings.TrimSuffix(name, ".go")
+			for i := 2; cFiles[name] != "" && cFiles[name] != cFile; i++ {
+				name = fmt.Sprintf("%s_%d.go", base, i)
+			}
+			cFiles[name] = cFile
+		}
+		goFiles[cFile] = name
+
+		return name
+	}
+
+	decls := map[string][]goast.Decl{SharedFileName: nil}
+	for _, decl := range p.File.Decls {
+		if d, ok := decl.(*goast.GenDecl); ok && d.Tok == token.IMPORT {
+			continue
+		}
+
+		name := goFileFor(p.declFiles[decl])
+		decls[name] = append(decls[name], decl)
+	}
+
+	messages := map[string][]string{}
+	for _, message := range p.messages {
+		name := goFileFor(message.Position.File)
+		messages[name] = append(messages[name], message.String())
+	}
+
+	trailingComments := map[string][]string{}
+	for file, beginLine := range p.commentLine {
+		for i := range p.Comments {
+			if p.Comments[i].File == file && beginLine < p.Comments[i].Line {
+				name := goFileFor(file)
+				trailingComments[name] = append(trailingComments[name],
+					p.Comments[i].Comment)
+			}
+		}
+	}
+
+	files := map[string]string{}
+	for name, fileDecls := range decls {
+		var buf bytes.Buffer
+
+		if name == SharedFileName {
+			buf.WriteString(fmt.Sprintf(`/*
+	Package %s - transpiled by c2go version: %s
+
+	If you have found any issues, please raise an issue at:
+	https://github.com/elliotchance/c2go/
+*/
+
+`, p.File.Name.Name, Version))
+		} else {
+			buf.WriteString(fmt.Sprintf(
+				"// Transpiled by c2go version %s from %s\n\n",
+				Version, cFiles[name]))
+		}
+
+		// The double newline afterwards is important so that the messages are
+		// not part of the documentation for the package.
+		if len(messages[name]) > 0 {
+			buf.WriteString(strings.Join(messages[name], "\n") + "\n\n")
+		}
+
+		src, err := p.renderDecls(fileDecls)
+		if err != nil {
+			return nil, fmt.Errorf("cannot generate %s: %v", name, err)
+		}
+		buf.Write(src)
+
+		for _, comment := range trailingComments[name] {
+			buf.WriteString(fmt.Sprintln(comment))
+		}
+
+		files[name] = buf.String()
+	}
+
+	return files, nil
+}
+
+// renderDecls generates the Go source for a file containing only the
+// declarations. The imports are added for the packages that are used by the
+// declarations.
+func (p *Program) renderDecls(decls []goast.Decl) ([]byte, error) {
+	file := &goast.File{
+		Name:  goast.NewIdent(p.File.Name.Name),
+		Decls: decls,
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, p.FileSet, file); err != nil {
+		return nil, err
+	}
+
+	// Many expressions are built as identifiers that contain the package name
+	// (like "noarch.Printf") so the only reliable way to find the packages
+	// that are used is to parse the generated code.
+	parsed, err := parser.ParseFile(token.NewFileSet(), "", buf.Bytes(), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	used := map[string]bool{}
+	goast.Inspect(parsed, func(node goast.Node) bool {
+		if selector, ok := node.(*goast.SelectorExpr); ok {
+			if ident, ok := selector.X.(*goast.Ident); ok {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+
+	imports := []string{}
+	for _, quotedImportPath := range p.imports {
+		importPath, err := strconv.Unquote(quotedImportPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if used[filepath.Base(importPath)] {
+			imports = append(imports, quotedImportPath)
+		}
+	}
+	sort.Strings(imports)
+
+	for i := len(imports) - 1; i >= 0; i-- {
+		file.Decls = append([]goast.Decl{&goast.GenDecl{
+			Tok: token.IMPORT,
+			Specs: []goast.Spec{
+				&goast.ImportSpec{
+					Path: &goast.BasicLit{
+						Kind:  token.IMPORT,
+						Value: imports[i],
+					},
+				},
+			},
+		}}, file.Decls...)
+	}
+
+	buf.Reset()
+	if err := format.Node(&buf, p.FileSet, file); err != nil {
+		return nil, err
+	}
+
+	// See String() for an explanation.
+	reg := util.GetRegex("interface( )?{(\r*)\n(\t*)}")
+
+	return reg.ReplaceAll(buf.Bytes(), []byte("interface {}")), nil
+}
@@ -0,0 +1,125 @@
+package program
+
+import (
+	"errors"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+)
+
+func TestFiles(t *testing.T) {
+	p := NewProgram()
+	p.FileSet = token.NewFileSet()
+	p.IncludeHeaders = []IncludeHeader{
+		{HeaderName: "/src/point.h", IsUserSource: true},
+		{HeaderName: "/usr/include/stdio.h", IsUserSource: false},
+	}
+
+	var err error
+	p.File, err = parser.ParseFile(p.FileSet, "", `package foo
+
+type point struct{ x int32 }
+
+type FILE = noarch.File
+
+func add(a point) int32 { return a.x }
+
+func main() { noarch.Printf([]byte("%d\x00"), add(point{})) }
+
+func init() {}
+`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.AddImport("github.com/elliotchance/c2go/noarch")
+	p.AddImport("unsafe")
+
+	// The init function does not belong to a C file.
+	p.SetDeclFile("/src/point.h", p.File.Decls[0])
+	p.SetDeclFile("/usr/include/stdio.h", p.File.Decls[1])
+	p.SetDeclFile("/src/add.c", p.File.Decls[2])
+	p.SetDeclFile("/src/main.c", p.File.Decls[3])
+
+	p.AddWarning(errors.New("not supported"), &ast.CallExpr{
+		Pos: ast.Position{File: "/src/main.c", Line: 3},
+	})
+
+	for _, splitHeaders := range []bool{false, true} {
+		files, err := p.Files(splitHeaders)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := map[string][]string{
+			"add.go":       {"func add(", "from /src/add.c"},
+			"main.go":      {"func main(", "// Warning (CallExpr)", "\"github.com/elliotchance/c2go/noarch\""},
+			SharedFileName: {"func init(", "type FILE", "Package foo"},
+		}
+		if splitHeaders {
+			expected["point_h.go"] = []string{"type point"}
+		} else {
+			expected[SharedFileName] = append(expected[SharedFileName], "type point")
+		}
+
+		if len(files) != len(expected) {
+			t.Errorf("expected %d files, got %d", len(expected), len(files))
+		}
+
+		for name, contains := range expected {
+			for _, s := range contains {
+				if !strings.Contains(files[name], s) {
+					t.Errorf("%s does not contain %s:\n%s", name, s, files[name])
+				}
+			}
+
+			// Imports must only be in the files that use them.
+			if strings.Contains(files[name], "unsafe") {
+				t.Errorf("%s must not import unsafe", name)
+			}
+			if name == "add.go" && strings.Contains(files[name], "import") {
+				t.Errorf("add.go must not have imports")
+			}
+
+			if _, err := parser.ParseFile(token.NewFileSet(), name,
+				files[name], 0); err != nil {
+				t.Errorf("%s is not valid Go: %v", name, err)
+			}
+		}
+	}
+}
+
+func TestGoFileName(t *testing.T) {
+	p := NewProgram()
+	p.IncludeHeaders = []IncludeHeader{
+		{HeaderName: "/src/io_linux.h", IsUserSource: true},
+	}
+
+	tests := []struct {
+		cFile    string
+		expected string
+	}{
+		{"/src/main.c", "main.go"},
+		{"/src/read_file.c", "read_file.go"},
+		{"/src/linux.c", "linux.go"},
+
+		// These names would make a test file or have a build constraint.
+		{"/src/parser_test.c", "parser_test_c.go"},
+		{"/src/io_linux.c", "io_linux_c.go"},
+		{"/src/x_windows.c", "x_windows_c.go"},
+		{"/src/y_arm64.c", "y_arm64_c.go"},
+		{"/src/z_darwin_amd64.c", "z_darwin_amd64_c.go"},
+
+		{"/src/io_linux.h", "io_linux_h.go"},
+		{"/usr/include/stdio.h", SharedFileName},
+	}
+
+	for _, test := range tests {
+		if actual := p.goFileName(test.cFile, true); actual != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.cFile, test.expected, actual)
+		}
+	}
+}
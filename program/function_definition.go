@@ -140,7 +140,9 @@ var builtInFunctionDefinitions = map[string][]string{
 
 		// stdio.h
 		"int printf(const char*) -> noarch.Printf",
+		"int vprintf(const char*, struct __va_list_tag *) -> noarch.Vprintf",
 		"int scanf(const char*) -> noarch.Scanf",
+		"int vscanf(const char*, struct __va_list_tag *) -> noarch.Vscanf",
 		"int putchar(int) -> noarch.Putchar",
 		"int puts(const char *) -> noarch.Puts",
 		"FILE* fopen(const char *, const char *) -> noarch.Fopen",
@@ -156,7 +158,11 @@ var builtInFunctionDefinitions = map[string][]string{
 		"char* tmpnam(char*) -> noarch.Tmpnam",
 		"int fflush(FILE*) -> noarch.Fflush",
 		"int fprintf(FILE*, const char*) -> noarch.Fprintf",
+		"int vfprintf(FILE*, const char*, struct __va_list_tag *) -> noarch.Vfprintf",
 		"int fscanf(FILE*, const char*) -> noarch.Fscanf",
+		"int vfscanf(FILE*, const char*, struct __va_list_tag *) -> noarch.Vfscanf",
+		"int sscanf(const char*, const char*) -> noarch.Sscanf",
+		"int vsscanf(const char*, const char*, struct __va_list_tag *) -> noarch.Vsscanf",
 		"int fgetc(FILE*) -> noarch.Fgetc",
 		"int fputc(int, FILE*) -> noarch.Fputc",
 		"int getc(FILE*) -> noarch.Fgetc",
@@ -174,6 +180,14 @@ var builtInFunctionDefinitions = map[string][]string{
 		"int vsnprintf(char*, int, const char *, struct __va_list_tag *) -> noarch.Vsnprintf",
 		"void perror(char*) -> noarch.Perror",
 		"void clearerr(FILE*) -> noarch.Clearerr",
+		"int ungetc(int, FILE*) -> noarch.Ungetc",
+		"int setvbuf(FILE*, char*, int, int) -> noarch.Setvbuf",
+		"void setbuf(FILE*, char*) -> noarch.Setbuf",
+		"FILE* fdopen(int, const char*) -> noarch.Fdopen",
+		"int fileno(FILE*) -> noarch.Fileno",
+		"FILE* freopen(const char*, const char*, FILE*) -> noarch.Freopen",
+		"FILE* fmemopen(void*, int, const char*) -> noarch.Fmemopen",
+		"FILE* open_memstream(char**, size_t*) -> noarch.OpenMemstream",
 
 		// darwin/stdio.h
 		"int __builtin___sprintf_chk(char*, int, int, char*) -> darwin.BuiltinSprintfChk",
@@ -208,12 +222,33 @@ var builtInFunctionDefinitions = map[string][]string{
 		// should be: "void* memcpy(void *, void *, size_t) -> noarch.Memcpy"
 		"void* memcpy(void *, void *, int) -> noarch.Memcpy",
 
-		// should be: "void* memmove(void *, void *, size_t) -> noarch.Memcpy"
-		"void* memmove(void *, void *, int) -> noarch.Memcpy",
+		// should be: "void* memmove(void *, void *, size_t) -> noarch.Memmove"
+		"void* memmove(void *, void *, int) -> noarch.Memmove",
 
 		// should be: "int memmove(const void *, const void *, size_t) -> noarch.Memcmp"
 		"int memcmp(void *, void *, int) -> noarch.Memcmp",
 
+		// should be: "void* memchr(const void *, int, size_t) -> noarch.Memchr"
+		"void* memchr(const void *, int, int) -> noarch.Memchr",
+		"void* memrchr(const void *, int, int) -> noarch.Memrchr",
+
+		"char* strrchr(const char *, int) -> noarch.Strrchr",
+		"char* strpbrk(const char *, const char *) -> noarch.Strpbrk",
+		"int strspn(const char *, const char *) -> noarch.Strspn",
+		"int strcspn(const char *, const char *) -> noarch.Strcspn",
+		"char* strtok(char *, const char *) -> noarch.Strtok",
+		"char* strtok_r(char *, const char *, char **) -> noarch.StrtokR",
+		"char* strsep(char **, const char *) -> noarch.Strsep",
+		"char* strdup(const char *) -> noarch.Strdup",
+
+		// should be: size_t for the length arguments and results
+		"char* strndup(const char *, int) -> noarch.Strndup",
+		"int strnlen(const char *, int) -> noarch.Strnlen",
+		"char* strncat(char *, const char *, int) -> noarch.Strncat",
+		"int strcoll(const char *, const char *) -> noarch.Strcoll",
+		"int strxfrm(char *, const char *, int) -> noarch.Strxfrm",
+		"int strerror_r(int, char *, int) -> noarch.StrerrorR",
+
 		// darwin/string.h
 		// should be: const char*, char*, size_t
 		"char* __builtin___strcpy_chk(const char*, char*, int) -> darwin.BuiltinStrcpy",
@@ -230,8 +265,13 @@ var builtInFunctionDefinitions = map[string][]string{
 		"void* __inline_memset_chk(void *, int, int) -> noarch.Memset",
 		"void* __builtin___memcpy_chk(void *, void *, int, int) -> darwin.Memcpy",
 		"void* __inline_memcpy_chk(void *, void *, int) -> noarch.Memcpy",
-		"void* __builtin___memmove_chk(void *, void *, int, int) -> darwin.Memcpy",
-		"void* __inline_memmove_chk(void *, void *, int) -> noarch.Memcpy",
+		"void* __builtin___memmove_chk(void *, void *, int, int) -> darwin.Memmove",
+		"void* __inline_memmove_chk(void *, void *, int) -> noarch.Memmove",
+	},
+	"strings.h": []string{
+		"int strcasecmp(const char *, const char *) -> noarch.Strcasecmp",
+		// should be: "int strncasecmp(const char *, const char *, size_t) -> noarch.Strncasecmp"
+		"int strncasecmp(const char *, const char *, int) -> noarch.Strncasecmp",
 	},
 	"stdlib.h": []string{
 		// stdlib.h
@@ -240,6 +280,9 @@ var builtInFunctionDefinitions = map[string][]string{
 		"int atoi(const char*) -> noarch.Atoi",
 		"long int atol(const char*) -> noarch.Atol",
 		"long long int atoll(const char*) -> noarch.Atoll",
+		"void* bsearch(const void*, const void*, int, int, __compar_fn_t) -> noarch.Bsearch",
+		"void* aligned_alloc(int, int) -> noarch.AlignedAlloc",
+		"void* calloc(int, int) -> noarch.Calloc",
 		"div_t div(int, int) -> noarch.Div",
 		"void exit(int) -> noarch.Exit",
 		"void free(void*) -> noarch.Free",
@@ -248,7 +291,11 @@ var builtInFunctionDefinitions = map[string][]string{
 		"ldiv_t ldiv(long int, long int) -> noarch.Ldiv",
 		"long long int llabs(long long int) -> noarch.Llabs",
 		"lldiv_t lldiv(long long int, long long int) -> noarch.Lldiv",
+		"void* malloc(int) -> noarch.Malloc",
+		"int posix_memalign(void**, int, int) -> noarch.PosixMemalign",
+		"void qsort(void*, int, int, __compar_fn_t) -> noarch.Qsort",
 		"int rand() -> noarch.Rand",
+		"void* realloc(void*, int) -> noarch.Realloc",
 		// The real definition is srand(unsigned int) however the type would be
 		// different. It's easier to change the definition than create a proxy
 		// function in stdlib.go.
@@ -262,6 +309,18 @@ var builtInFunctionDefinitions = map[string][]string{
 		"long long unsigned int strtoull(const char *, char **, int) -> noarch.Strtoull",
 		"void free(void*) -> noarch.Free",
 	},
+	"setjmp.h": []string{
+		// The value that setjmp returns is added as the last argument when the
+		// function that calls setjmp is transpiled. See lowerSetjmp.
+		"int setjmp(struct __jmp_buf_tag *) -> noarch.Setjmp",
+		"int _setjmp(struct __jmp_buf_tag *) -> noarch.Setjmp",
+		"int sigsetjmp(struct __jmp_buf_tag *, int) -> noarch.Sigsetjmp",
+		"int __sigsetjmp(struct __jmp_buf_tag *, int) -> noarch.Sigsetjmp",
+		"void longjmp(struct __jmp_buf_tag *, int) -> noarch.Longjmp",
+		"void _longjmp(struct __jmp_buf_tag *, int) -> noarch.Longjmp",
+		"void siglongjmp(struct __jmp_buf_tag *, int) -> noarch.Longjmp",
+		"void __longjmp_chk(struct __jmp_buf_tag *, int) -> noarch.Longjmp",
+	},
 	"syslog.h": []string{
 		"void openlog(const char *, int, int) -> noarch.Openlog",
 		"int setlogmask(int) -> noarch.Setlogmask",
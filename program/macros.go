@@ -0,0 +1,101 @@
+package program
+
+import (
+	"github.com/elliotchance/c2go/ast"
+)
+
+// Macro is a macro from a user source file. It may be an object-like macro:
+//
+//     #define BUFSIZE 1024
+//
+// Or a function-like macro:
+//
+//     #define MAX(a, b) ((a) > (b) ? (a) : (b))
+//
+type Macro struct {
+	Name string
+
+	// Body is the replacement list, like "1024".
+	Body string
+
+	// File and Line are the location of the #define.
+	File string
+	Line int
+
+	// Function is true for a function-like macro. Params are the names of
+	// its parameters.
+	Function bool
+	Params   []string
+
+	// CType is the C type of the value of an object-like macro. It is only
+	// set if the macro is a constant expression that has been transpiled to
+	// a Go constant.
+	//
+	// For a function-like macro it is "int" if the result is always an int
+	// (like a comparison) rather than the type of the arguments.
+	CType string
+
+	// Value is the exact value of the constant as a Go literal, like "1024".
+	// It is set at the same time as CType.
+	Value string
+
+	// Constraint is the constraint of the type parameter of the generic Go
+	// function for a function-like macro, like "noarch.Number". It is only
+	// set if the macro has been transpiled to a Go function.
+	Constraint string
+}
+
+// macroExpansion is an expression node that is the expansion of a macro.
+type macroExpansion struct {
+	name string
+
+	// args are the nodes of the arguments of a function-like macro.
+	args []ast.Node
+}
+
+// AddMacroExpansion records that an expression node is the complete expansion
+// of the macro. The transpiler can use the name of the Go constant (or a call
+// to the Go function) for the macro instead of transpiling the node.
+//
+// For a function-like macro, args are the nodes of each of the arguments in
+// the expansion. They may be nil if the arguments could not be found.
+func (p *Program) AddMacroExpansion(n ast.Node, name string, args ...ast.Node) {
+	p.macroExpansions[n] = macroExpansion{name: name, args: args}
+}
+
+// GetMacroExpansion returns the macro that the node is an expansion of and the
+// nodes of the arguments of a function-like macro. It will return nil if the
+// node is not the expansion of a macro, or if the macro has not been
+// transpiled.
+func (p *Program) GetMacroExpansion(n ast.Node) (*Macro, []ast.Node) {
+	expansion, ok := p.macroExpansions[n]
+	if !ok {
+		return nil, nil
+	}
+
+	for i := range p.Macros {
+		macro := &p.Macros[i]
+		if macro.Name != expansion.name {
+			continue
+		}
+
+		if (!macro.Function && macro.CType != "") || macro.Constraint != "" {
+			return macro, expansion.args
+		}
+	}
+
+	return nil, nil
+}
+
+// GetMacro returns the object-like macro with the name. It will return nil if
+// there is no macro with that name, or if the macro is not a constant.
+func (p *Program) GetMacro(name string) *Macro {
+	for i := range p.Macros {
+		if p.Macros[i].Name == name && !p.Macros[i].Function &&
+			p.Macros[i].CType != "" {
+			return &p.Macros[i]
+		}
+	}
+
+	return nil
+}
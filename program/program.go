@@ -64,10 +64,10 @@ type Program struct {
 	// comments (so that they do not interfere with the program output).
 	Verbose bool
 
-	// Contains the messages (for example, "// Warning") generated when
-	// transpiling the AST. These messages, which are code comments, are
-	// appended to the very top of the output file. See AddMessage().
-	messages []string
+	// Contains the diagnostics (warnings and errors) generated when
+	// transpiling the AST. These are written as code comments (for example,
+	// "// Warning") to the very top of the output file. See AddDiagnostic().
+	messages []Diagnostic
 
 	// messagePosition - position of slice messages, added like a comment
 	// in output Go code
@@ -112,6 +112,18 @@ type Program struct {
 	// key    - the node address
 	// value  - the node
 	NodeMap map[ast.Address]ast.Node
+
+	// Macros - the macros from the user sources in the order
+	// they were defined.
+	Macros []Macro
+
+	// macroExpansions - expression nodes that are the expansion of a macro.
+	// See AddMacroExpansion().
+	macroExpansions map[ast.Node]macroExpansion
+
+	// declFiles - the C file that each top level Go declaration was
+	// transpiled from. See SetDeclFile().
+	declFiles map[goast.Decl]string
 }
 
 // Comment - position of line comment '//...'
@@ -161,7 +173,7 @@ func NewProgram() (p *Program) {
 		}),
 		Unions:              make(StructRegistry),
 		Verbose:             false,
-		messages:            []string{},
+		messages:            []Diagnostic{},
 		GlobalVariables:     map[string]string{},
 		EnumConstantToEnum:  map[string]string{},
 		EnumTypedefName:     map[string]bool{},
@@ -170,18 +182,21 @@ func NewProgram() (p *Program) {
 		IncludeHeaders:      []IncludeHeader{},
 		functionDefinitions: map[string]FunctionDefinition{},
 		NodeMap:             map[ast.Address]ast.Node{},
+		declFiles:           map[goast.Decl]string{},
+		macroExpansions:     map[ast.Node]macroExpansion{},
 		builtInFunctionDefinitionsHaveBeenLoaded: false,
 	}
 }
 
 // AddMessage adds a message (such as a warning or error) comment to the output
-// file. Usually the message is generated from one of the Generate functions in
-// the ast package.
+// file. It is expected that the message already have the comment ("//")
+// prefix. Messages that start with "// Error" are errors, all other messages
+// are warnings.
 //
-// It is expected that the message already have the comment ("//") prefix.
+// Prefer AddWarning or AddError since those record the node and position of
+// the problem.
 //
-// The message will not be appended if it is blank. This is because the Generate
-// functions return a blank string conditionally when there is no error.
+// The message will not be appended if it is blank.
 //
 // The return value will be true if a message was added, otherwise false.
 func (p *Program) AddMessage(message string) bool {
@@ -189,7 +204,30 @@ func (p *Program) AddMessage(message string) bool {
 		return false
 	}
 
-	p.messages = append(p.messages, message)
+	d := &Diagnostic{
+		Code:     CodeWarning,
+		Severity: SeverityWarning,
+	}
+	if strings.HasPrefix(message, "// Error") {
+		d.Code = CodeError
+		d.Severity = SeverityError
+	}
+	d.Message = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(
+		strings.TrimPrefix(message, "//"), " Warning:"), " Error:"))
+
+	return p.AddDiagnostic(d)
+}
+
+// AddDiagnostic adds a diagnostic that will be placed as a comment in the
+// output file. Nothing is added if d is nil.
+//
+// The return value will be true if a diagnostic was added, otherwise false.
+func (p *Program) AddDiagnostic(d *Diagnostic) bool {
+	if d == nil {
+		return false
+	}
+
+	p.messages = append(p.messages, *d)
 
 	// Compactizarion warnings stack
 	if len(p.messages) > 1 {
@@ -199,9 +237,10 @@ func (p *Program) AddMessage(message string) bool {
 		)
 		// Warning collapsing for minimaze warnings
 		warning := "// Warning"
-		if strings.HasPrefix(p.messages[last], warning) {
-			l := p.messages[last][len(warning):]
-			if strings.HasSuffix(p.messages[new], l) {
+		lastMessage := p.messages[last].String()
+		if strings.HasPrefix(lastMessage, warning) {
+			l := lastMessage[len(warning):]
+			if strings.HasSuffix(p.messages[new].String(), l) {
 				p.messages[last] = p.messages[new]
 				p.messages = p.messages[0:new]
 			}
@@ -211,6 +250,12 @@ func (p *Program) AddMessage(message string) bool {
 	return true
 }
 
+// Messages returns all of the diagnostics (warnings and errors) that have been
+// added so far, in the order they were added.
+func (p *Program) Messages() []Diagnostic {
+	return append([]Diagnostic{}, p.messages...)
+}
+
 // GetMessageComments - get messages "Warnings", "Error" like a comment
 // Location of comments only NEAR of error or warning and
 // don't show directly location
@@ -219,7 +264,7 @@ func (p *Program) GetMessageComments() (_ *goast.CommentGroup) {
 	if p.messagePosition < len(p.messages) {
 		for i := p.messagePosition; i < len(p.messages); i++ {
 			group.List = append(group.List, &goast.Comment{
-				Text: p.messages[i],
+				Text: p.messages[i].String(),
 			})
 		}
 		p.messagePosition = len(p.messages)
@@ -288,6 +333,32 @@ func (p *Program) GetStruct(name string) *Struct {
 	return p.Unions[name]
 }
 
+// GetRecord returns the struct or union of the C type, or nil if the type is
+// not a struct or union. Unlike GetStruct, the typedefs are followed and the
+// "struct " or "union " prefix may be omitted, so "point", "struct point" and
+// "point_t" (for "typedef struct point point_t") are the same struct. The type
+// must not have qualifiers like "const", see types.CleanCType.
+func (p *Program) GetRecord(cType string) *Struct {
+	for i := 0; i < 10; i++ {
+		t, ok := p.TypedefType[cType]
+		if !ok {
+			break
+		}
+		cType = t
+	}
+
+	for _, name := range []string{cType, "struct " + cType, "union " + cType} {
+		if s, ok := p.Structs[name]; ok {
+			return s
+		}
+		if s, ok := p.Unions[name]; ok {
+			return s
+		}
+	}
+
+	return nil
+}
+
 // IsTypeAlreadyDefined will return true if the typeName has already been
 // defined.
 //
@@ -340,7 +411,11 @@ func (p *Program) String() string {
 	// First write all the messages. The double newline afterwards is important
 	// so that the package statement has a newline above it so that the warnings
 	// are not part of the documentation for the package.
-	buf.WriteString(strings.Join(p.messages, "\n") + "\n\n")
+	messages := make([]string, len(p.messages))
+	for i, message := range p.messages {
+		messages[i] = message.String()
+	}
+	buf.WriteString(strings.Join(messages, "\n") + "\n\n")
 
 	if err := format.Node(&buf, p.FileSet, p.File); err != nil {
 		// Printing the entire AST will generate a lot of output. However, it is
@@ -0,0 +1,32 @@
+package program
+
+import "testing"
+
+func TestGetRecord(t *testing.T) {
+	p := NewProgram()
+	point := &Struct{Name: "point"}
+	number := &Struct{Name: "number", IsUnion: true}
+	p.Structs["struct point"] = point
+	p.Unions["union number"] = number
+	p.TypedefType["point_t"] = "struct point"
+	p.TypedefType["position_t"] = "point_t"
+	p.TypedefType["number_t"] = "union number"
+
+	tests := map[string]*Struct{
+		"struct point": point,
+		"point":        point,
+		"point_t":      point,
+		"position_t":   point,
+		"union number": number,
+		"number":       number,
+		"number_t":     number,
+		"int":          nil,
+		"struct other": nil,
+	}
+
+	for cType, expected := range tests {
+		if actual := p.GetRecord(cType); actual != expected {
+			t.Errorf("%s: expected %v, got %v", cType, expected, actual)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package program
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/elliotchance/c2go/ast"
@@ -21,33 +22,128 @@ type Struct struct {
 	// instance of Struct for nested structures.
 	Fields map[string]interface{}
 
-	// Each of the field names in the order they were defined.
+	// Each of the field names in the order they were defined. These are the
+	// fields that are initialized by the values of an initializer list, so
+	// the nested types and the unnamed bit-fields are not included.
 	FieldNames []string
+
+	// Members are the fields in the order they were defined, including the
+	// unnamed bit-fields that are used for padding. They are used to
+	// calculate the layout of the struct.
+	Members []Member
+}
+
+// Member is a field of a struct or union.
+type Member struct {
+	// The name of the field. It is empty for an unnamed bit-field.
+	Name string
+
+	// The C type of the field.
+	Type string
+
+	// BitWidth is the width of a bit-field, like the 3 in "int a : 3", or -1
+	// if the field is not a bit-field.
+	BitWidth int
+}
+
+// IsBitField returns true if the field is a bit-field.
+func (m Member) IsBitField() bool {
+	return m.BitWidth >= 0
+}
+
+// BitField returns the bit-field with the name, or false if the struct does
+// not have a bit-field with that name.
+func (s *Struct) BitField(name string) (Member, bool) {
+	for _, m := range s.Members {
+		if m.Name == name && m.IsBitField() {
+			return m, true
+		}
+	}
+
+	return Member{}, false
+}
+
+// HasBitFields returns true if any of the fields is a bit-field.
+func (s *Struct) HasBitFields() bool {
+	for _, m := range s.Members {
+		if m.IsBitField() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BitWidth returns the width of a bit-field, or -1 if the field is not a
+// bit-field. The width is the child of the FieldDecl:
+//
+//     FieldDecl 0x7f9a <line:3:5, col:18> col:14 flag 'unsigned int'
+//     `-IntegerLiteral 0x7f9b <col:18> 'int' 1
+//
+// Newer versions of clang wrap the IntegerLiteral in a ConstantExpr.
+func BitWidth(f *ast.FieldDecl) int {
+	if len(f.Children()) == 0 {
+		return -1
+	}
+
+	var n ast.Node = f.Children()[0]
+	for {
+		switch v := n.(type) {
+		case *ast.IntegerLiteral:
+			width, err := strconv.Atoi(v.Value)
+			if err != nil {
+				return -1
+			}
+			return width
+
+		case *ast.ConstantExpr, *ast.ParenExpr, *ast.ImplicitCastExpr:
+			if len(v.Children()) != 1 {
+				return -1
+			}
+			n = v.Children()[0]
+
+		default:
+			return -1
+		}
+	}
 }
 
 // NewStruct creates a new Struct definition from an ast.RecordDecl.
 func NewStruct(n *ast.RecordDecl) *Struct {
 	fields := make(map[string]interface{})
 	fieldNames := make([]string, 0, len(n.Children()))
+	var members []Member
 
 	for _, field := range n.Children() {
 		switch f := field.(type) {
 		case *ast.FieldDecl:
+			width := BitWidth(f)
+			members = append(members, Member{
+				Name:     f.Name,
+				Type:     f.Type,
+				BitWidth: width,
+			})
+
+			// An unnamed bit-field is only padding, it cannot be used or
+			// initialized.
+			if f.Name == "" && width >= 0 {
+				continue
+			}
+
 			fields[f.Name] = f.Type
 			fieldNames = append(fieldNames, f.Name)
 
 		case *ast.IndirectFieldDecl:
 			fields[f.Name] = f.Type
-			fieldNames = append(fieldNames, f.Name)
 
 		case *ast.RecordDecl:
 			fields[f.Name] = NewStruct(f)
-			fieldNames = append(fieldNames, f.Name)
 
 		case *ast.MaxFieldAlignmentAttr,
 			*ast.AlignedAttr,
 			*ast.TransparentUnionAttr,
-			*ast.FullComment:
+			*ast.FullComment,
+			*ast.Unknown:
 			// FIXME: Should these really be ignored?
 
 		default:
@@ -60,6 +156,7 @@ func NewStruct(n *ast.RecordDecl) *Struct {
 		IsUnion:    n.Kind == "union",
 		Fields:     fields,
 		FieldNames: fieldNames,
+		Members:    members,
 	}
 }
 
@@ -1,18 +1,163 @@
 package program
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 
 	"github.com/elliotchance/c2go/ast"
 )
 
+// Severity is how serious a diagnostic is.
+type Severity string
+
+// The severities of diagnostics.
+const (
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Diagnostic codes. These are stable between versions of c2go so that they can
+// be used to count, filter or gate on specific diagnostics. Errors are in the
+// 1000 range and warnings are in the 2000 range.
+const (
+	// CodeError is used for errors that do not have a more specific code.
+	CodeError = "C2GO1000"
+
+	// CodeWarning is used for warnings that do not have a more specific code.
+	CodeWarning = "C2GO2000"
+
+	// CodeUnsupportedConstruct is a C construct (usually an unknown AST node)
+	// that was replaced with a panic() in the Go output.
+	CodeUnsupportedConstruct = "C2GO2001"
+
+	// CodeInexactLiteral is a literal that could not be read exactly from the
+	// C source.
+	CodeInexactLiteral = "C2GO2002"
+
+	// CodeUnsafeCast is a conversion that is done with an unsafe slice cast.
+	CodeUnsafeCast = "C2GO2003"
+
+	// CodeInlineAssembly is inline assembly that was ignored.
+	CodeInlineAssembly = "C2GO2004"
+
+	// CodeParseError is a known AST node that could not be parsed, usually
+	// because it is printed differently by a new version of clang. Like
+	// CodeUnsupportedConstruct, it was replaced with a panic() in the Go
+	// output.
+	CodeParseError = "C2GO2005"
+
+	// CodeUnionPointers is a union with fields that contain pointers. These
+	// fields do not share their memory with the other fields of the union.
+	CodeUnionPointers = "C2GO2006"
+
+	// CodeTypeResolution is a C type that does not have a Go type. The type
+	// is usually replaced by unsafe.Pointer.
+	CodeTypeResolution = "C2GO2007"
+
+	// CodeCast is a conversion between two C types that cannot be done, so
+	// the value is used without a conversion.
+	CodeCast = "C2GO2008"
+
+	// CodeUnsupportedBuiltin is a call to a compiler builtin function (like
+	// __builtin_ctz) that does not have an implementation in noarch.
+	CodeUnsupportedBuiltin = "C2GO2009"
+)
+
+// Diagnostic is a single warning or error generated when transpiling the AST.
+type Diagnostic struct {
+	// Code is one of the Code constants.
+	Code     string
+	Severity Severity
+
+	// NodeKind is the name of the AST node, like "CallExpr". For a node that
+	// is not known by c2go, it is the kind of the clang node. It will be empty
+	// if the diagnostic is not for a specific node.
+	NodeKind string
+
+	// Position is the location in the C source.
+	Position ast.Position
+
+	Message string
+}
+
+// String returns the diagnostic as the comment that is placed in the Go
+// output, like:
+//
+//     // Warning (CallExpr):  /tmp/foo.c:12 : message
+func (d Diagnostic) String() string {
+	label := "Warning"
+	if d.Severity == SeverityError {
+		label = "Error"
+	}
+
+	if d.NodeKind == "" {
+		return fmt.Sprintf("// %s: %s", label, d.Message)
+	}
+
+	return fmt.Sprintf("// %s (%s): %s: %s", label, d.NodeKind,
+		d.Position.GetSimpleLocation(), d.Message)
+}
+
+// codedError attaches a diagnostic code to an error. See WithCode.
+type codedError struct {
+	code string
+	err  error
+}
+
+func (e *codedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codedError) Unwrap() error {
+	return e.err
+}
+
+// WithCode attaches a diagnostic code (one of the Code constants) to an error
+// so that the diagnostic generated from the error will use that code.
+func WithCode(code string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &codedError{code: code, err: err}
+}
+
+// NewDiagnostic creates a diagnostic for an error on a node. It will return
+// nil if e is nil.
+func (p *Program) NewDiagnostic(e error, n ast.Node, severity Severity) *Diagnostic {
+	if e == nil {
+		return nil
+	}
+
+	code := CodeWarning
+	if severity == SeverityError {
+		code = CodeError
+	}
+
+	var coded *codedError
+	if errors.As(e, &coded) {
+		code = coded.code
+	}
+
+	kind := reflect.TypeOf(n).Elem().Name()
+	if u, ok := n.(*ast.Unknown); ok {
+		kind = u.Name
+	}
+
+	return &Diagnostic{
+		Code:     code,
+		Severity: severity,
+		NodeKind: kind,
+		Position: n.Position(),
+		Message:  e.Error(),
+	}
+}
+
 // GenerateErrorMessage - generate error message
 func (p *Program) GenerateErrorMessage(e error, n ast.Node) string {
-	if e != nil {
-		structName := reflect.TypeOf(n).Elem().Name()
-		return fmt.Sprintf("// Error (%s): %s: %s", structName,
-			n.Position().GetSimpleLocation(), e.Error())
+	if d := p.NewDiagnostic(e, n, SeverityError); d != nil {
+		return d.String()
 	}
 
 	return ""
@@ -20,10 +165,8 @@ func (p *Program) GenerateErrorMessage(e error, n ast.Node) string {
 
 // GenerateWarningMessage - generate warning message
 func (p *Program) GenerateWarningMessage(e error, n ast.Node) string {
-	if e != nil {
-		structName := reflect.TypeOf(n).Elem().Name()
-		return fmt.Sprintf("// Warning (%s): %s: %s", structName,
-			n.Position().GetSimpleLocation(), e.Error())
+	if d := p.NewDiagnostic(e, n, SeverityWarning); d != nil {
+		return d.String()
 	}
 
 	return ""
@@ -37,3 +180,25 @@ func (p *Program) GenerateWarningOrErrorMessage(e error, n ast.Node, isError boo
 
 	return p.GenerateWarningMessage(e, n)
 }
+
+// AddError adds an error diagnostic for the node. Nothing is added if e is
+// nil. The return value will be true if a diagnostic was added.
+func (p *Program) AddError(e error, n ast.Node) bool {
+	return p.AddDiagnostic(p.NewDiagnostic(e, n, SeverityError))
+}
+
+// AddWarning adds a warning diagnostic for the node. Nothing is added if e is
+// nil. The return value will be true if a diagnostic was added.
+func (p *Program) AddWarning(e error, n ast.Node) bool {
+	return p.AddDiagnostic(p.NewDiagnostic(e, n, SeverityWarning))
+}
+
+// AddWarningOrError adds an error diagnostic if isError is true, otherwise a
+// warning diagnostic.
+func (p *Program) AddWarningOrError(e error, n ast.Node, isError bool) bool {
+	if isError {
+		return p.AddError(e, n)
+	}
+
+	return p.AddWarning(e, n)
+}
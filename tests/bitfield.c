@@ -0,0 +1,89 @@
+// Tests for bit-fields.
+
+#include <stdio.h>
+#include "tests.h"
+
+struct flags
+{
+    unsigned char ready : 1;
+    unsigned char mode : 3;
+    int count : 4;
+    double value;
+    unsigned : 0;
+    unsigned long long big : 40;
+};
+
+struct packed
+{
+    unsigned char a : 5;
+    unsigned char b : 5;
+    unsigned int c : 3;
+    unsigned int : 0;
+    unsigned int d : 1;
+};
+
+typedef struct
+{
+    unsigned int low : 4;
+    unsigned int high : 4;
+} nibbles;
+
+void set_count(struct flags *f, int count)
+{
+    f->count = count;
+}
+
+int main()
+{
+    plan(19);
+
+    diag("sizeof");
+    is_eq(sizeof(struct flags), 24);
+    is_eq(sizeof(struct packed), 8);
+    is_eq(sizeof(nibbles), 4);
+
+    diag("assign and read");
+    struct flags f;
+    f.ready = 1;
+    f.mode = 5;
+    f.count = -3;
+    f.value = 1.5;
+    f.big = 1099511627775ULL;
+    is_eq(f.ready, 1);
+    is_eq(f.mode, 5);
+    is_eq(f.count, -3);
+    is_eq(f.value, 1.5);
+    is_true(f.big == 1099511627775ULL);
+
+    diag("overflow");
+    f.mode = 9;
+    is_eq(f.mode, 1);
+    f.count = 7;
+    f.count++;
+    is_eq(f.count, -8);
+    is_eq(f.ready, 1);
+
+    diag("compound assignment");
+    f.mode += 2;
+    is_eq(f.mode, 3);
+    f.mode |= 4;
+    is_eq(f.mode, 7);
+
+    diag("pointer");
+    set_count(&f, 2);
+    is_eq(f.count, 2);
+
+    diag("initialization");
+    struct packed p = {1, 2, 3, 1};
+    is_eq(p.a + p.b + p.c + p.d, 7);
+    nibbles n = {0xA, 0x5};
+    is_eq(n.low, 10);
+    is_eq(n.high, 5);
+    n.high = n.low;
+    is_eq(n.high, 10);
+
+    int x = (n.low = 3) + 1;
+    is_eq(x, 4);
+
+    done_testing();
+}
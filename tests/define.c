@@ -0,0 +1,37 @@
+// This file tests that object-like macros are transpiled to Go constants.
+
+#include <stdio.h>
+#include "tests.h"
+
+#define SIZE 16
+#define MASK ((1 << 4) - 1)
+#define BIG 4000000000
+#define FLAGS 0x10u
+#define HALF 0.5f
+#define PI 3.14159
+#define NEWLINE '\n'
+#define DOUBLE_SIZE (SIZE * 2)
+#define NAME "c2go"
+
+enum { FIRST = SIZE, SECOND };
+
+int main()
+{
+    plan(10);
+
+    int a[SIZE];
+    a[SIZE - 1] = MASK;
+
+    is_eq(sizeof(a) / sizeof(int), 16);
+    is_eq(a[15], 15);
+    is_eq(BIG, 4000000000);
+    is_eq(FLAGS, 16);
+    is_eq(HALF, 0.5);
+    is_eq(PI, 3.14159);
+    is_eq(NEWLINE, 10);
+    is_eq(DOUBLE_SIZE, 32);
+    is_streq(NAME, "c2go");
+    is_eq(SECOND, 17);
+
+    done_testing();
+}
@@ -0,0 +1,86 @@
+// Tests for designated initializers.
+
+#include <stdio.h>
+#include "tests.h"
+
+struct point
+{
+    int x;
+    int y;
+    int z[3];
+};
+
+struct line
+{
+    struct point from;
+    struct point to;
+    const char *name;
+};
+
+struct option
+{
+    const char *name;
+    int value;
+    double scale;
+};
+
+// A config table.
+struct option options[] = {
+    [2] = {.name = "depth", .value = 3},
+    [0] = {.name = "width", .scale = 1.5},
+    {.value = 7, .name = "height"},
+};
+
+union number
+{
+    int i;
+    double d;
+};
+
+int main()
+{
+    plan(24);
+
+    diag("struct");
+    struct point p = {.y = 2, .z[1] = 5};
+    is_eq(p.x, 0);
+    is_eq(p.y, 2);
+    is_eq(p.z[0], 0);
+    is_eq(p.z[1], 5);
+    is_eq(p.z[2], 0);
+
+    diag("nested struct");
+    struct line l = {.to.y = 4, .from = {1, 2}, .name = "l"};
+    is_eq(l.from.x, 1);
+    is_eq(l.from.y, 2);
+    is_eq(l.to.x, 0);
+    is_eq(l.to.y, 4);
+    is_streq(l.name, "l");
+
+    diag("array");
+    int a[6] = {1, [3] = 7, 8};
+    is_eq(a[0], 1);
+    is_eq(a[1], 0);
+    is_eq(a[3], 7);
+    is_eq(a[4], 8);
+    is_eq(a[5], 0);
+
+    diag("array of structs");
+    is_streq(options[0].name, "width");
+    is_eq(options[0].scale, 1.5);
+    is_streq(options[1].name, "height");
+    is_eq(options[1].value, 7);
+    is_streq(options[2].name, "depth");
+    is_eq(options[2].value, 3);
+
+    diag("union");
+    union number n = {.d = 2.5};
+    is_eq(n.d, 2.5);
+
+    diag("matrix");
+    int m[2][3] = {[1] = {[2] = 9}, [0][0] = 1};
+    is_eq(m[0][0], 1);
+    is_eq(m[1][2], 9);
+
+    done_testing();
+}
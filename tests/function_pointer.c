@@ -0,0 +1,77 @@
+#include <stdio.h>
+#include "tests.h"
+
+int inc(int a) { return a + 1; }
+int dec(int a) { return a - 1; }
+int twice(int a) { return a * 2; }
+
+double half(double a) { return a / 2; }
+
+// A dispatch table.
+int (*handlers[3])(int) = {inc, dec, twice};
+
+struct callbacks {
+    int (*on_value)(int);
+    int (*table[2])(int);
+};
+
+int (*choose(int n))(int)
+{
+    return handlers[n];
+}
+
+double (*halver(int unused))(double)
+{
+    return half;
+}
+
+int apply(int (**handler)(int), int value)
+{
+    return (*handler)(value);
+}
+
+int main()
+{
+    plan(14);
+
+    diag("dispatch table");
+    is_eq(handlers[0](5), 6);
+    is_eq(handlers[1](5), 4);
+    is_eq(handlers[2](5), 10);
+
+    int total = 0;
+    for (int i = 0; i < 3; i++) {
+        total += handlers[i](1);
+    }
+    is_eq(total, 4);
+
+    diag("struct fields");
+    struct callbacks cb;
+    cb.on_value = twice;
+    cb.table[0] = inc;
+    cb.table[1] = dec;
+    is_eq(cb.on_value(21), 42);
+    is_eq(cb.table[0](1), 2);
+    is_eq(cb.table[1](1), 0);
+
+    diag("NULL");
+    int (*h)(int) = NULL;
+    is_null(h);
+    h = choose(2);
+    is_not_null(h);
+    is_eq(h(4), 8);
+
+    diag("pointer to a function pointer");
+    int (**ph)(int) = &handlers[1];
+    is_eq((*ph)(10), 9);
+    is_eq(apply(&cb.on_value, 3), 6);
+
+    diag("function returning a function pointer");
+    double (*g)(double) = halver(0);
+    is_eq(g(3), 1.5);
+    if (g) {
+        pass("%s", "function pointer is true");
+    }
+
+    done_testing();
+}
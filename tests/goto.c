@@ -57,13 +57,57 @@ void test_goto_stmt()
     is_eq(i, 15);
 }
 
+int cleanup(int fail_at)
+{
+    int result = -1;
+    if (fail_at == 1)
+        goto out;
+
+    int a = 10;
+    if (fail_at == 2)
+        goto free_a;
+
+    int b = a * 2;
+    result = a + b;
+
+free_a:
+    a = 0;
+out:
+    return result;
+}
+
+void test_goto_over_declaration()
+{
+    is_eq(cleanup(0), 30);
+    is_eq(cleanup(1), -1);
+    is_eq(cleanup(2), -1);
+}
+
+void test_goto_into_loop()
+{
+    int i = 0;
+    int sum = 0;
+
+    goto inside;
+    while (i < 5) {
+        sum += 10;
+    inside:
+        i++;
+    }
+
+    is_eq(sum, 40);
+    is_eq(i, 5);
+}
+
 int main()
 {
-    plan(4);
+    plan(9);
 
     START_TEST(goto1)
     START_TEST(goto2)
     START_TEST(goto_stmt)
+    START_TEST(goto_over_declaration)
+    START_TEST(goto_into_loop)
     
     done_testing();
 }
@@ -0,0 +1,4 @@
+#define LIMIT 10
+#define MAX(a, b) ((a) > (b) ? (a) : (b))
+#define IS_EVEN(x) ((x) % 2 == 0)
+#define CLAMP(x) ((x) > LIMIT ? LIMIT : (x))
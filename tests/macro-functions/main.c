@@ -0,0 +1,20 @@
+#include <stdio.h>
+#include "macros.h"
+
+int main()
+{
+    int a = 3, b = 7;
+    double d = 2.5;
+    int i = 0;
+
+    printf("%d\n", MAX(a, b));
+    printf("%.1f\n", MAX(d, a));
+    printf("%d\n", IS_EVEN(b));
+    printf("%d\n", CLAMP(a * b));
+
+    // The argument has a side effect so the macro is expanded.
+    b = MAX(i++, 0);
+    printf("%d %d\n", b, i);
+
+    return 0;
+}
@@ -0,0 +1,13 @@
+#include "counter.h"
+
+static int count = 10;
+
+static int helper(void)
+{
+    return ++count;
+}
+
+int next(void)
+{
+    return helper();
+}
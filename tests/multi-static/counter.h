@@ -0,0 +1,8 @@
+#ifndef COUNTER_H
+#define COUNTER_H
+
+// Defined in counter.c. Both of the C files have their own static helper()
+// and count.
+int next(void);
+
+#endif /* COUNTER_H */
@@ -0,0 +1,17 @@
+#include <stdio.h>
+#include "counter.h"
+
+static int count;
+
+static int helper(void)
+{
+    return count += 2;
+}
+
+int main()
+{
+    helper();
+    next();
+    printf("%d %d %d\n", helper(), next(), count);
+    return 0;
+}
@@ -0,0 +1,13 @@
+#ifndef LIB_H
+#define LIB_H
+
+struct point {
+    int x;
+    int y;
+};
+
+extern int counter;
+
+int add(struct point p);
+
+#endif
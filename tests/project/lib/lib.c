@@ -0,0 +1,10 @@
+#include "lib.h"
+
+int counter = 0;
+
+// SCALE is only defined when compiling this file.
+int add(struct point p)
+{
+    counter++;
+    return (p.x + p.y) * SCALE;
+}
@@ -0,0 +1,10 @@
+#include <stdio.h>
+#include "lib.h"
+
+int main()
+{
+    struct point p = {1, 2};
+    printf("%s=%d\n", GREETING, add(p));
+    printf("%d\n", counter);
+    return 0;
+}
@@ -0,0 +1,64 @@
+#include <stdio.h>
+#include <setjmp.h>
+
+#include "tests.h"
+
+static jmp_buf env;
+
+void fail(int code)
+{
+    longjmp(env, code);
+}
+
+int parse(int input)
+{
+    int attempts = 0;
+
+    int r = setjmp(env);
+    attempts++;
+    if (r != 0) {
+        return -r;
+    }
+
+    if (input < 0) {
+        fail(-input);
+    }
+
+    return input * attempts;
+}
+
+int retry(int times)
+{
+    int count = 0;
+
+    if (setjmp(env) < times) {
+        count++;
+        fail(count);
+    }
+
+    return count;
+}
+
+void jump_to(jmp_buf buf, int value)
+{
+    longjmp(buf, value);
+}
+
+int main()
+{
+    plan(5);
+
+    is_eq(parse(3), 3);
+    is_eq(parse(-2), -2);
+    is_eq(parse(-7), -7);
+    is_eq(retry(4), 4);
+
+    jmp_buf local;
+    int value = setjmp(local);
+    if (value == 0) {
+        jump_to(local, 0);
+    }
+    is_eq(value, 1);
+
+    done_testing();
+}
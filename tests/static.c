@@ -0,0 +1,77 @@
+// Tests for static local variables.
+
+#include <stdio.h>
+#include "tests.h"
+
+int next()
+{
+    static int counter;
+    return ++counter;
+}
+
+int next_from(int start)
+{
+    static int counter = 100;
+    counter += start;
+    return counter;
+}
+
+int twice()
+{
+    int total = 0;
+    {
+        static int counter = 10;
+        total += ++counter;
+    }
+    {
+        static int counter = 20;
+        total += ++counter;
+    }
+    return total;
+}
+
+char *history(char c)
+{
+    static char buffer[8];
+    static int length = 0;
+    if (length < 7) {
+        buffer[length++] = c;
+    }
+    return buffer;
+}
+
+int *address()
+{
+    static int value = 42;
+    return &value;
+}
+
+int main()
+{
+    plan(10);
+
+    diag("counter");
+    is_eq(next(), 1);
+    is_eq(next(), 2);
+    is_eq(next(), 3);
+
+    diag("initializer");
+    is_eq(next_from(1), 101);
+    is_eq(next_from(2), 103);
+
+    diag("same name in blocks");
+    is_eq(twice(), 32);
+    is_eq(twice(), 34);
+
+    diag("array");
+    history('a');
+    history('b');
+    is_streq(history('c'), "abc");
+
+    diag("address");
+    *address() = 7;
+    is_eq(*address(), 7);
+    is_true(address() == address());
+
+    done_testing();
+}
@@ -115,9 +115,44 @@ void test_va_list4(func_va *f, char *format, ...) {
 	va_end(args);
 }
 
+int sum(int count, ...)
+{
+	va_list ap;
+	va_start(ap, count);
+	int total = 0;
+	for (int i = 0; i < count; i++) {
+		total += va_arg(ap, int);
+	}
+	va_end(ap);
+	return total;
+}
+
+char log_buffer[100];
+
+int log_msg(const char *fmt, ...)
+{
+	va_list ap;
+	va_start(ap, fmt);
+	int n = vsprintf(log_buffer, fmt, ap);
+	va_end(ap);
+	return n;
+}
+
+double second_twice(int count, ...)
+{
+	va_list ap, aq;
+	va_start(ap, count);
+	va_arg(ap, double);
+	va_copy(aq, ap);
+	double result = va_arg(ap, double) + va_arg(aq, double);
+	va_end(aq);
+	va_end(ap);
+	return result;
+}
+
 int main()
 {
-    plan(16);
+    plan(21);
 
     START_TEST(va_list)
 
@@ -125,6 +160,13 @@ int main()
     test_va_list3(simple2, "dcff", 3, 'a', 1.999, 42.5);
     test_va_list4(simple2, "dcff", 3, 'a', 1.999, 42.5);
 
+    diag("user variadic functions");
+    is_eq(sum(0), 0);
+    is_eq(sum(3, 1, 2, 3), 6);
+    is_eq(log_msg("%s=%d", "answer", 42), 9);
+    is_streq(log_buffer, "answer=42");
+    is_eq(second_twice(2, 1.5, 2.25), 4.5);
+
     done_testing();
 }
 
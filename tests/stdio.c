@@ -2,6 +2,7 @@
 // here for consistency.
 
 #include <stdio.h>
+#include <stdlib.h>
 #include <string.h>
 #include <stdarg.h>
 #include <assert.h>
@@ -13,7 +14,7 @@
     test_##t();
 
 // size of that file
-int filesize = 12820;
+int filesize = 16272;
 
 void test_putchar()
 {
@@ -35,11 +36,8 @@ void test_puts()
 
 void test_printf()
 {
-    // TODO: printf() has a different syntax to Go
-    // https://github.com/elliotchance/c2go/issues/94
-
     printf("# Characters: %c %c \n", 'a', 65);
-    //printf("Decimals: %d %ld\n", 1977, 650000L);
+    printf("# Decimals: %d %ld\n", 1977, 650000L);
     printf("# Preceding with blanks: %10d \n", 1977);
     printf("# Preceding with zeros: %010d \n", 1977);
     printf("# Some different radices: %d %x %o %#x %#o \n", 100, 100, 100, 100, 100);
@@ -258,6 +256,30 @@ void test_fscanf()
     is_eq(remove("/tmp/myfile2.txt"),0)
 }
 
+void test_sscanf()
+{
+    char key[20];
+    char value[20];
+    int a, b, n;
+    double d;
+
+    is_eq(sscanf("key=some value;", "%[^=]=%[^;]%n", key, value, &n), 2);
+    is_streq(key, "key");
+    is_streq(value, "some value");
+    is_eq(n, 14);
+
+    is_eq(sscanf("12345 0x1f", "%3d%d %i", &a, &b, &n), 3);
+    is_eq(a, 123);
+    is_eq(b, 45);
+    is_eq(n, 31);
+
+    is_eq(sscanf("x 1.5e3", "%*s %lf", &d), 1);
+    is_eq(d, 1500);
+
+    is_eq(sscanf("abc", "%d", &a), 0);
+    is_eq(sscanf("   ", "%d", &a), EOF);
+}
+
 void test_fgetc()
 {
     FILE *pFile;
@@ -540,6 +562,104 @@ void test_feof()
     fclose(pFile);
 }
 
+void test_ungetc()
+{
+    FILE *pFile;
+    char buffer[10];
+
+    pFile = fopen("tests/stdio.c", "r");
+    is_not_null(pFile) or_return();
+
+    is_eq(fgetc(pFile), '/');
+    is_eq(ungetc('a', pFile), 'a');
+    is_eq(ungetc('b', pFile), 'b');
+    is_eq(ungetc(EOF, pFile), EOF);
+    fgets(buffer, 5, pFile);
+    is_streq(buffer, "ba/ ");
+
+    // fseek() drops the characters that were put back.
+    ungetc('c', pFile);
+    fseek(pFile, 0, SEEK_SET);
+    is_eq(fgetc(pFile), '/');
+
+    fclose(pFile);
+}
+
+void test_setvbuf()
+{
+    FILE *pFile;
+    char buffer[BUFSIZ];
+    char line[20];
+
+    pFile = fopen("/tmp/setvbuf.txt", "w+");
+    is_not_null(pFile) or_return();
+
+    is_eq(setvbuf(pFile, buffer, _IOFBF, BUFSIZ), 0);
+    fprintf(pFile, "%d", 1);
+    fputc('2', pFile);
+    fputs("3", pFile);
+    is_eq(ftell(pFile), 3);
+
+    setbuf(pFile, NULL);
+    fputs("4\n", pFile);
+    rewind(pFile);
+    fgets(line, 20, pFile);
+    is_streq(line, "1234\n");
+
+    pFile = freopen("/tmp/setvbuf.txt", "r", pFile);
+    is_not_null(pFile) or_return();
+    is_eq(fgetc(pFile), '1');
+    fclose(pFile);
+
+    is_eq(fileno(stderr), 2);
+    is_eq(remove("/tmp/setvbuf.txt"), 0);
+}
+
+void test_fmemopen()
+{
+    FILE *pFile;
+    char buffer[10] = "hello";
+    char line[20];
+
+    pFile = fmemopen(buffer, 5, "r");
+    is_not_null(pFile) or_return();
+    fgets(line, 20, pFile);
+    is_streq(line, "hello");
+    is_true(feof(pFile));
+    fclose(pFile);
+
+    pFile = fmemopen(buffer, 10, "w");
+    is_not_null(pFile) or_return();
+    fprintf(pFile, "%d-%d", 1, 2);
+    fflush(pFile);
+    is_streq(buffer, "1-2");
+    fputs("too long", pFile);
+    is_eq(fflush(pFile), EOF);
+    is_true(ferror(pFile));
+    fclose(pFile);
+}
+
+void test_open_memstream()
+{
+    FILE *pFile;
+    char *ptr;
+    size_t size;
+
+    pFile = open_memstream(&ptr, &size);
+    is_not_null(pFile) or_return();
+    fputs("hello", pFile);
+    fflush(pFile);
+    is_streq(ptr, "hello");
+    is_eq(size, 5);
+
+    fseek(pFile, 2, SEEK_SET);
+    fputs("y!", pFile);
+    fclose(pFile);
+    is_streq(ptr, "hey!");
+    is_eq(size, 4);
+    free(ptr);
+}
+
 void test_sprintf()
 {
 	char buffer [100];
@@ -549,6 +669,31 @@ void test_sprintf()
 	is_eq(cx,20);
 }
 
+void test_printf_conversions()
+{
+    char buffer[100];
+    int n = 0;
+
+    snprintf(buffer, 100, "%i %hhd %hu %lld %zu", 42, 300, 70000, -9000000000LL, (size_t)12);
+    is_streq(buffer, "42 44 4464 -9000000000 12");
+
+    snprintf(buffer, 100, "[%*d] [%-*d] [%.*s]", 5, 42, 4, 7, 3, "abcdef");
+    is_streq(buffer, "[   42] [7   ] [abc]");
+
+    snprintf(buffer, 100, "%#o %#x %+e %a", 8, 255, 12345.678, 1.5);
+    is_streq(buffer, "010 0xff +1.234568e+04 0x1.8p+0");
+
+    snprintf(buffer, 100, "%g %g %#.3g %5.1f%%", 0.0001, 1e-5, 1.0, 99.95);
+    is_streq(buffer, "0.0001 1e-05 1.00 100.0%");
+
+    snprintf(buffer, 100, "abc%n def", &n);
+    is_streq(buffer, "abc def");
+    is_eq(n, 3);
+
+    is_eq(snprintf(buffer, 4, "%d", 123456), 6);
+    is_streq(buffer, "123");
+}
+
 void test_snprintf()
 {
 	char buffer [50];
@@ -614,7 +759,7 @@ void test_eof()
 
 int main()
 {
-    plan(90);
+    plan(137);
 
     START_TEST(putchar)
     START_TEST(puts)
@@ -629,6 +774,7 @@ int main()
     START_TEST(printf)
     START_TEST(fprintf)
     START_TEST(fscanf)
+    START_TEST(sscanf)
     START_TEST(fgetc)
     START_TEST(fgets)
     START_TEST(fgets2)
@@ -644,8 +790,13 @@ int main()
     START_TEST(fsetpos)
     START_TEST(rewind)
     START_TEST(feof)
+    START_TEST(ungetc)
+    START_TEST(setvbuf)
+    START_TEST(fmemopen)
+    START_TEST(open_memstream)
     START_TEST(sprintf)
     START_TEST(snprintf)
+    START_TEST(printf_conversions)
     START_TEST(vsprintf)
     START_TEST(vsnprintf)
 	START_TEST(eof)
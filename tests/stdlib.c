@@ -1,6 +1,7 @@
 #include <assert.h>
 #include <stdio.h>
 #include <stdlib.h>
+#include <string.h>
 #include "tests.h"
 
 #define test_strto0(actual, func, end) \
@@ -214,12 +215,55 @@ void test_free()
 	is_eq(i,3);
 }
 
+// A dynamic array that is grown with realloc().
+void test_realloc()
+{
+    int *a = NULL;
+    int capacity = 0;
+    int i;
+    for (i = 0; i < 100; i++) {
+        if (i == capacity) {
+            capacity = capacity == 0 ? 4 : capacity * 2;
+            a = (int *)realloc(a, capacity * sizeof(int));
+        }
+        a[i] = i * i;
+    }
+    is_eq(capacity, 128);
+    is_eq(a[0], 0);
+    is_eq(a[3], 9);
+    is_eq(a[99], 9801);
+
+    // Shrinking keeps the start of the block.
+    a = realloc(a, 10 * sizeof(int));
+    is_eq(a[9], 81);
+
+    is_null(realloc(a, 0));
+
+    double *d = calloc(4, sizeof(double));
+    is_eq(d[3], 0);
+    free(d);
+
+    void *aligned = aligned_alloc(64, 100);
+    is_true(((unsigned long)aligned) % 64 == 0);
+    free(aligned);
+
+    void *p = NULL;
+    is_eq(posix_memalign(&p, 32, 100), 0);
+    is_true(((unsigned long)p) % 32 == 0);
+    free(p);
+}
+
 int values[] = { 40, 10, 100, 90, 20, 25 };
 int compare (const void * a, const void * b)
 {
   return ( *(int*)a - *(int*)b );
 }
 
+int compare_words (const void * a, const void * b)
+{
+	return strcmp(*(char * const *)a, *(char * const *)b);
+}
+
 void q_sort(){
 	diag("qsort")
 	qsort (values, 6, sizeof(int), compare);
@@ -229,11 +273,60 @@ void q_sort(){
 	is_eq(values[3], 40  );
 	is_eq(values[4], 90  );
 	is_eq(values[5], 100 );
+
+	// Only the first elements are sorted.
+	int numbers[] = { 5, 3, 1, 0 };
+	qsort (numbers, 3, sizeof(int), compare);
+	is_eq(numbers[0], 1);
+	is_eq(numbers[2], 5);
+	is_eq(numbers[3], 0);
+
+	char *words[] = { "pear", "apple", "fig", "banana" };
+	qsort (words, 4, sizeof(char *), compare_words);
+	is_streq(words[0], "apple");
+	is_streq(words[1], "banana");
+	is_streq(words[3], "pear");
+}
+
+struct point {
+	int x;
+	int y;
+};
+
+int compare_points (const void * a, const void * b)
+{
+	const struct point *pa = a;
+	const struct point *pb = b;
+	if (pa->x != pb->x)
+		return pa->x - pb->x;
+	return pa->y - pb->y;
+}
+
+void b_search(){
+	diag("bsearch")
+	int key = 40;
+	int *found = bsearch (&key, values, 6, sizeof(int), compare);
+	is_not_null(found);
+	is_eq(*found, 40);
+	is_true(found == &values[3]);
+
+	key = 30;
+	is_null(bsearch (&key, values, 6, sizeof(int), compare));
+
+	struct point points[] = { {2, 1}, {1, 5}, {1, 2} };
+	qsort (points, 3, sizeof(struct point), compare_points);
+	is_eq(points[0].y, 2);
+	is_eq(points[1].y, 5);
+	is_eq(points[2].x, 2);
+
+	struct point p = {1, 5};
+	struct point *q = bsearch (&p, points, 3, sizeof(struct point), compare_points);
+	is_true(q == &points[1]);
 }
 
 int main()
 {
-    plan(754);
+    plan(778);
 
     char *endptr;
 
@@ -337,6 +430,9 @@ int main()
 	diag("free");
 	test_free();
 
+    diag("realloc");
+    test_realloc();
+
     diag("getenv")
     is_not_null(getenv("PATH"));
     is_not_null(getenv("HOME"));
@@ -493,6 +589,7 @@ int main()
     test_strtol("123abc", 8, 83, "abc");
 
 	q_sort();
+	b_search();
 
     done_testing();
 }
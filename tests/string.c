@@ -1,4 +1,7 @@
+#include <errno.h>
+#include <stdlib.h>
 #include <string.h>
+#include <strings.h>
 #include "tests.h"
 
 typedef struct mem {
@@ -20,7 +23,7 @@ void setarr(int arr[], int val) {
 
 int main()
 {
-    plan(80);
+    plan(127);
 
     diag("TODO: __builtin_object_size")
     // https://github.com/elliotchance/c2go/issues/359
@@ -326,5 +329,114 @@ int main()
         }
     }
 
+    {
+        diag("memmove of overlapping regions");
+        char str[] = "abcdefgh";
+        memmove(&str[2], str, 5);
+        is_streq(str, "ababcdeh");
+        memmove(str, &str[3], 5);
+        is_streq(str, "bcdehdeh");
+    }
+    {
+        diag("memchr");
+        char *a = "abcab";
+        is_streq((char *) memchr(a, 'b', 5), "bcab");
+        is_null(memchr(a, 'c', 2));
+        is_streq((char *) memchr(a, '\0', 6), "");
+    }
+    {
+        diag("strrchr");
+        char *a = "a/b/c";
+        is_streq(strrchr(a, '/'), "/c");
+        is_streq(strrchr(a, '\0'), "");
+        is_null(strrchr(a, 'x'));
+    }
+    {
+        diag("strspn, strcspn & strpbrk");
+        is_eq(strspn("129th", "1234567890"), 3);
+        is_eq(strspn("abc", ""), 0);
+        is_eq(strcspn("fcba73", "1234567890"), 4);
+        is_eq(strcspn("abc", ""), 3);
+        is_streq(strpbrk("fcba73", "1234567890"), "73");
+        is_null(strpbrk("abc", "xyz"));
+    }
+    {
+        diag("strtok");
+        char str[] = ",,a,,b c,";
+        char *p = strtok(str, ", ");
+        is_streq(p, "a");
+        p = strtok(NULL, ", ");
+        is_streq(p, "b");
+        p = strtok(NULL, ", ");
+        is_streq(p, "c");
+        is_null(strtok(NULL, ", "));
+    }
+    {
+        diag("strtok_r");
+        char str[] = "x=1;y=2";
+        char *save1;
+        char *save2;
+        char *pair = strtok_r(str, ";", &save1);
+        is_streq(strtok_r(pair, "=", &save2), "x");
+        is_streq(strtok_r(NULL, "=", &save2), "1");
+        pair = strtok_r(NULL, ";", &save1);
+        is_streq(strtok_r(pair, "=", &save2), "y");
+        is_streq(strtok_r(NULL, "=", &save2), "2");
+        is_null(strtok_r(NULL, ";", &save1));
+    }
+    {
+        diag("strsep");
+        char str[] = "a,,b";
+        char *p = str;
+        is_streq(strsep(&p, ","), "a");
+        is_streq(strsep(&p, ","), "");
+        is_streq(strsep(&p, ","), "b");
+        is_null(p);
+        is_null(strsep(&p, ","));
+    }
+    {
+        diag("strdup, strndup & strnlen");
+        char *a = strdup("hello");
+        is_streq(a, "hello");
+        free(a);
+        a = strndup("hello", 3);
+        is_streq(a, "hel");
+        free(a);
+        is_eq(strnlen("hello", 3), 3);
+        is_eq(strnlen("hi", 3), 2);
+    }
+    {
+        diag("strncat");
+        char str[10] = "ab";
+        strncat(str, "cdef", 2);
+        is_streq(str, "abcd");
+        strncat(str, "g", 5);
+        is_streq(str, "abcdg");
+    }
+    {
+        diag("strcoll & strxfrm");
+        char str[10];
+        is_true(strcoll("a", "b") < 0);
+        is_eq(strcoll("ab", "ab"), 0);
+        is_eq(strxfrm(str, "abc", 10), 3);
+        is_streq(str, "abc");
+    }
+    {
+        diag("strcasecmp & strncasecmp");
+        is_eq(strcasecmp("Hello", "hELLO"), 0);
+        is_true(strcasecmp("abc", "ABD") < 0);
+        is_true(strcasecmp("b", "A") > 0);
+        is_eq(strncasecmp("abcX", "ABCy", 3), 0);
+        is_true(strncasecmp("abcX", "ABCy", 4) < 0);
+    }
+    {
+        diag("strerror_r");
+        char buf[64];
+        is_eq(strerror_r(ENOENT, buf, sizeof(buf)), 0);
+        is_streq(buf, "No such file or directory");
+        is_eq(strerror_r(ENOENT, buf, 8), ERANGE);
+        is_streq(buf, "No such");
+    }
+
     done_testing();
 }
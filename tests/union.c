@@ -249,9 +249,106 @@ void union_with_struct()
 	is_true( u.l > 0 );
 }
 
+union number
+{
+	char c[6];
+	int i;
+	short s;
+	float f;
+	unsigned int bits;
+};
+
+union outer
+{
+	union number n;
+	struct {
+		double d;
+		int tag;
+	} st;
+};
+
+void union_sizeof()
+{
+	diag("Union sizeof")
+	is_eq(sizeof(union number), 8);
+	is_eq(sizeof(union outer), 16);
+	is_eq(sizeof(union un_struct), 8);
+	is_eq(sizeof(union programming), 8);
+}
+
+void union_copy()
+{
+	diag("Union copy")
+	union number a;
+	a.i = 1;
+	union number b = a;
+	b.i = 2;
+	is_eq(a.i, 1);
+	is_eq(b.i, 2);
+	a = b;
+	is_eq(a.i, 2);
+}
+
+void union_in_array()
+{
+	diag("Union in array")
+	union number numbers[3];
+	for (int i = 0; i < 3; i++) {
+		numbers[i].i = i * 10;
+	}
+	is_eq(numbers[0].i, 0);
+	is_eq(numbers[1].i, 10);
+	is_eq(numbers[2].s, 20);
+}
+
+void union_nested()
+{
+	diag("Nested union")
+	union outer o;
+	o.n.i = 7;
+	is_eq(o.n.s, 7);
+	o.st.tag = 3;
+	is_eq(o.st.tag, 3);
+	o.st.d = 2.5;
+	is_eq(o.st.d, 2.5);
+}
+
+void union_init()
+{
+	diag("Union initializer")
+	union number a = {{'a', 'b'}};
+	is_eq(a.c[1], 'b');
+	union number b = {.i = 65};
+	is_eq(b.c[0], 'A');
+	union outer o = {.st = {1.5, 4}};
+	is_eq(o.st.d, 1.5);
+	is_eq(o.st.tag, 4);
+}
+
+void union_address_of_member()
+{
+	diag("Address of member")
+	union number n;
+	int *pi = &n.i;
+	*pi = 258;
+	is_eq(n.i, 258);
+	is_eq(n.c[0], 2);
+	is_eq(n.c[1], 1);
+}
+
+void union_punning()
+{
+	diag("Type punning")
+	union number n;
+	n.f = 1.0f;
+	is_eq(n.bits, 0x3f800000);
+	n.bits = 0x40490fdb;
+	is_true(n.f > 3.14 && n.f < 3.15);
+}
+
 int main()
 {
-    plan(46);
+    plan(68);
 
     union programming variable;
 
@@ -267,6 +364,13 @@ int main()
 	union_array();
 	union_arr_in_str();
 	union_with_struct();
+	union_sizeof();
+	union_copy();
+	union_in_array();
+	union_nested();
+	union_init();
+	union_address_of_member();
+	union_punning();
 
     done_testing();
 }
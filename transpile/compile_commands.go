@@ -0,0 +1,196 @@
+package transpile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/elliotchance/c2go/preprocessor"
+)
+
+// TranslationUnit is a C source file and the clang flags (include paths,
+// defines, etc) that are used to preprocess it.
+type TranslationUnit = preprocessor.TranslationUnit
+
+// compileCommand is one entry in a compilation database. Either Command or
+// Arguments will be set.
+type compileCommand struct {
+	Directory string   `json:"directory"`
+	File      string   `json:"file"`
+	Command   string   `json:"command"`
+	Arguments []string `json:"arguments"`
+}
+
+// ReadCompilationDatabase reads a compile_commands.json file (as generated by
+// CMake with CMAKE_EXPORT_COMPILE_COMMANDS, or by Bear) and returns the C
+// translation units with the flags that affect preprocessing. See:
+// https://clang.llvm.org/docs/JSONCompilationDatabase.html
+//
+// Files that are not C (such as C++ files) are ignored, as are files that
+// appear more than once after the first entry.
+func ReadCompilationDatabase(path string) ([]TranslationUnit, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read compilation database: %v", err)
+	}
+
+	var commands []compileCommand
+	if err := json.Unmarshal(data, &commands); err != nil {
+		return nil, fmt.Errorf("cannot parse compilation database %s: %v",
+			path, err)
+	}
+
+	units := []TranslationUnit{}
+	seen := map[string]bool{}
+
+	for _, command := range commands {
+		file := absPath(command.Directory, command.File)
+		if filepath.Ext(file) != ".c" || seen[file] {
+			continue
+		}
+		seen[file] = true
+
+		arguments := command.Arguments
+		if len(arguments) == 0 {
+			arguments, err = splitCommandLine(command.Command)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse command for %s: %v",
+					command.File, err)
+			}
+		}
+
+		units = append(units, TranslationUnit{
+			File:       file,
+			ClangFlags: preprocessorFlags(command.Directory, arguments),
+		})
+	}
+
+	if len(units) == 0 {
+		return nil, fmt.Errorf("no C files in compilation database %s", path)
+	}
+
+	return units, nil
+}
+
+// preprocessorFlags returns only the compiler arguments that change the
+// result of the preprocessor. Relative paths are made absolute since the
+// preprocessor is not run from the directory of the compile command.
+//
+// The first argument is the compiler, it is always ignored.
+func preprocessorFlags(directory string, arguments []string) []string {
+	flags := []string{}
+
+	for i := 1; i < len(arguments); i++ {
+		arg := arguments[i]
+
+		// Flags that take a path, either joined ("-Ifoo") or as the next
+		// argument ("-I foo").
+		isPathFlag := false
+		for _, flag := range []string{"-I", "-isystem", "-iquote",
+			"-idirafter", "-include"} {
+			if arg == flag && i+1 < len(arguments) {
+				flags = append(flags, flag+absPath(directory, arguments[i+1]))
+				i++
+				isPathFlag = true
+				break
+			}
+			if arg != flag && strings.HasPrefix(arg, flag) {
+				flags = append(flags, flag+absPath(directory, arg[len(flag):]))
+				isPathFlag = true
+				break
+			}
+		}
+		if isPathFlag {
+			continue
+		}
+
+		switch {
+		case arg == "-D" || arg == "-U":
+			if i+1 < len(arguments) {
+				flags = append(flags, arg+arguments[i+1])
+				i++
+			}
+
+		case strings.HasPrefix(arg, "-D"), strings.HasPrefix(arg, "-U"),
+			strings.HasPrefix(arg, "-std="), arg == "-ansi",
+			arg == "-m32", arg == "-m64", arg == "-trigraphs",
+			strings.HasPrefix(arg, "-funsigned-char"),
+			strings.HasPrefix(arg, "-fsigned-char"):
+			flags = append(flags, arg)
+
+		case arg == "-o" || arg == "-MF" || arg == "-MT" || arg == "-MQ" ||
+			arg == "-x":
+			// Skip the value as well.
+			i++
+		}
+	}
+
+	return flags
+}
+
+func absPath(directory, path string) string {
+	if filepath.IsAbs(path) || directory == "" {
+		return filepath.Clean(path)
+	}
+
+	return filepath.Join(directory, path)
+}
+
+// splitCommandLine splits a shell command into its arguments. Single quotes,
+// double quotes and backslash escapes are supported, which is enough for the
+// commands written by build systems.
+func splitCommandLine(command string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, c := range command {
+		switch {
+		case escaped:
+			current.WriteRune(c)
+			escaped = false
+
+		case c == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				current.WriteRune(c)
+			}
+
+		case c == '"' || c == '\'':
+			quote = c
+			inArg = true
+
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+
+		default:
+			current.WriteRune(c)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in: %s", command)
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
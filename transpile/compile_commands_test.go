@@ -0,0 +1,103 @@
+package transpile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadCompilationDatabase(t *testing.T) {
+	dir := t.TempDir()
+	database := filepath.Join(dir, "compile_commands.json")
+	err := os.WriteFile(database, []byte(`[
+		{
+			"directory": "/src/lib",
+			"arguments": ["cc", "-I", "include", "-I/usr/local/include",
+				"-DSCALE=2", "-Wall", "-O2", "-std=c99", "-c", "-o", "lib.o",
+				"lib.c"],
+			"file": "lib.c"
+		},
+		{
+			"directory": "/src/main",
+			"command": "cc -isystem ../vendor -D 'NAME=\"a b\"' -UDEBUG -c main.c",
+			"file": "main.c"
+		},
+		{
+			"directory": "/src/main",
+			"command": "c++ -c other.cpp",
+			"file": "other.cpp"
+		},
+		{
+			"directory": "/src/lib",
+			"command": "cc -DSCALE=3 -c lib.c",
+			"file": "/src/lib/lib.c"
+		}
+	]`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	units, err := ReadCompilationDatabase(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []TranslationUnit{
+		{
+			File: "/src/lib/lib.c",
+			ClangFlags: []string{"-I/src/lib/include", "-I/usr/local/include",
+				"-DSCALE=2", "-std=c99"},
+		},
+		{
+			File: "/src/main/main.c",
+			ClangFlags: []string{"-isystem/src/vendor", `-DNAME="a b"`,
+				"-UDEBUG"},
+		},
+	}
+
+	if !reflect.DeepEqual(expected, units) {
+		t.Errorf("expected %#v, got %#v", expected, units)
+	}
+}
+
+func TestReadCompilationDatabaseWithoutCFiles(t *testing.T) {
+	dir := t.TempDir()
+	database := filepath.Join(dir, "compile_commands.json")
+	err := os.WriteFile(database, []byte(`[]`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ReadCompilationDatabase(database)
+	if err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := map[string][]string{
+		`cc -c main.c`:               {"cc", "-c", "main.c"},
+		`cc  -DA="b c"   main.c`:     {"cc", "-DA=b c", "main.c"},
+		`cc -DA=\"b\" main.c`:        {"cc", `-DA="b"`, "main.c"},
+		`cc '-DA="b"' main.c`:        {"cc", `-DA="b"`, "main.c"},
+		`cc -I"dir with spaces" a.c`: {"cc", "-Idir with spaces", "a.c"},
+		`cc ''`:                      {"cc", ""},
+	}
+
+	for command, expected := range tests {
+		t.Run(command, func(t *testing.T) {
+			actual, err := splitCommandLine(command)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(expected, actual) {
+				t.Errorf("expected %#v, got %#v", expected, actual)
+			}
+		})
+	}
+
+	if _, err := splitCommandLine(`cc "main.c`); err == nil {
+		t.Error("expected error for unterminated quote")
+	}
+}
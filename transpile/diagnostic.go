@@ -0,0 +1,191 @@
+package transpile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/elliotchance/c2go/program"
+)
+
+// Diagnostic is a single warning or error produced while transpiling. Each
+// diagnostic has a stable code (see the Code constants in the program package)
+// that can be used to filter or gate on specific problems.
+type Diagnostic = program.Diagnostic
+
+// DiagnosticsFormat is the file format used by WriteDiagnostics.
+type DiagnosticsFormat string
+
+// The supported formats for WriteDiagnostics.
+const (
+	// DiagnosticsJSON is a JSON array of objects with the keys "code",
+	// "severity", "file", "line", "column", "nodeKind" and "message".
+	DiagnosticsJSON DiagnosticsFormat = "json"
+
+	// DiagnosticsSARIF is the Static Analysis Results Interchange Format
+	// (version 2.1.0) that is understood by code scanning tools and editors.
+	DiagnosticsSARIF DiagnosticsFormat = "sarif"
+)
+
+// WriteDiagnostics writes the diagnostics to w in the requested format.
+func WriteDiagnostics(w io.Writer, format DiagnosticsFormat, diagnostics []Diagnostic) error {
+	var v interface{}
+	switch format {
+	case DiagnosticsJSON:
+		v = newJSONDiagnostics(diagnostics)
+	case DiagnosticsSARIF:
+		v = newSARIFLog(diagnostics)
+	default:
+		return fmt.Errorf("unknown diagnostics format: %s", format)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
+
+type jsonDiagnostic struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	NodeKind string `json:"nodeKind,omitempty"`
+	Message  string `json:"message"`
+}
+
+func newJSONDiagnostics(diagnostics []Diagnostic) []jsonDiagnostic {
+	result := make([]jsonDiagnostic, len(diagnostics))
+	for i, d := range diagnostics {
+		result[i] = jsonDiagnostic{
+			Code:     d.Code,
+			Severity: string(d.Severity),
+			File:     d.Position.File,
+			Line:     d.Position.Line,
+			Column:   d.Position.Column,
+			NodeKind: d.NodeKind,
+			Message:  d.Message,
+		}
+	}
+
+	return result
+}
+
+// The SARIF types only contain the properties that c2go uses. See:
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+func newSARIFLog(diagnostics []Diagnostic) sarifLog {
+	driver := sarifDriver{
+		Name:           "c2go",
+		Version:        program.Version,
+		InformationURI: "https://github.com/elliotchance/c2go",
+		Rules:          []sarifRule{},
+	}
+	results := []sarifResult{}
+	seenRules := map[string]bool{}
+
+	for _, d := range diagnostics {
+		if !seenRules[d.Code] {
+			seenRules[d.Code] = true
+			driver.Rules = append(driver.Rules, sarifRule{ID: d.Code})
+		}
+
+		result := sarifResult{
+			RuleID:  d.Code,
+			Level:   string(d.Severity),
+			Message: sarifMessage{Text: d.Message},
+		}
+
+		if d.NodeKind != "" {
+			result.Properties = map[string]string{"nodeKind": d.NodeKind}
+		}
+
+		if d.Position.File != "" {
+			location := sarifLocation{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: d.Position.File},
+				},
+			}
+
+			// SARIF lines and columns start at 1 so an unknown line cannot
+			// be represented.
+			if d.Position.Line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{
+					StartLine:   d.Position.Line,
+					StartColumn: d.Position.Column,
+				}
+			}
+
+			result.Locations = []sarifLocation{location}
+		}
+
+		results = append(results, result)
+	}
+
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{
+			{
+				Tool:    sarifTool{Driver: driver},
+				Results: results,
+			},
+		},
+	}
+}
@@ -0,0 +1,128 @@
+package transpile
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+var testDiagnostics = []Diagnostic{
+	{
+		Code:     program.CodeUnsupportedConstruct,
+		Severity: program.SeverityWarning,
+		NodeKind: "AtomicExpr",
+		Position: ast.Position{File: "foo.c", Line: 12, Column: 5},
+		Message:  "unsupported C construct AtomicExpr at foo.c:12",
+	},
+	{
+		Code:     program.CodeUnsafeCast,
+		Severity: program.SeverityWarning,
+		Message:  "using unsafe slice cast to convert from int to char",
+	},
+}
+
+func TestDiagnosticString(t *testing.T) {
+	tests := map[string]Diagnostic{
+		"// Warning (AtomicExpr):  foo.c:12 : unsupported C construct AtomicExpr at foo.c:12": testDiagnostics[0],
+		"// Warning: using unsafe slice cast to convert from int to char":                     testDiagnostics[1],
+		"// Error (CallExpr):  foo.c:3 : function not found": {
+			Code:     program.CodeError,
+			Severity: program.SeverityError,
+			NodeKind: "CallExpr",
+			Position: ast.Position{File: "foo.c", Line: 3},
+			Message:  "function not found",
+		},
+	}
+
+	for expected, d := range tests {
+		t.Run(expected, func(t *testing.T) {
+			if d.String() != expected {
+				t.Errorf("expected '%s', got '%s'", expected, d.String())
+			}
+		})
+	}
+}
+
+func TestWriteDiagnosticsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteDiagnostics(&buf, DiagnosticsJSON, testDiagnostics)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actual []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &actual); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(actual) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %d", len(actual))
+	}
+
+	expected := map[string]interface{}{
+		"code":     "C2GO2001",
+		"severity": "warning",
+		"file":     "foo.c",
+		"line":     12.0,
+		"column":   5.0,
+		"nodeKind": "AtomicExpr",
+		"message":  "unsupported C construct AtomicExpr at foo.c:12",
+	}
+	for key, value := range expected {
+		if actual[0][key] != value {
+			t.Errorf("%s: expected %v, got %v", key, value, actual[0][key])
+		}
+	}
+
+	if _, ok := actual[1]["file"]; ok {
+		t.Errorf("file must be omitted for a diagnostic without a position")
+	}
+}
+
+func TestWriteDiagnosticsSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteDiagnostics(&buf, DiagnosticsSARIF, testDiagnostics)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actual sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &actual); err != nil {
+		t.Fatal(err)
+	}
+
+	if actual.Version != "2.1.0" || len(actual.Runs) != 1 {
+		t.Fatalf("unexpected log: %#v", actual)
+	}
+
+	run := actual.Runs[0]
+	if len(run.Tool.Driver.Rules) != 2 || len(run.Results) != 2 {
+		t.Fatalf("unexpected run: %#v", run)
+	}
+
+	result := run.Results[0]
+	if result.RuleID != "C2GO2001" || result.Level != "warning" ||
+		len(result.Locations) != 1 {
+		t.Fatalf("unexpected result: %#v", result)
+	}
+
+	location := result.Locations[0].PhysicalLocation
+	if location.ArtifactLocation.URI != "foo.c" ||
+		location.Region.StartLine != 12 || location.Region.StartColumn != 5 {
+		t.Errorf("unexpected location: %#v", location)
+	}
+
+	if len(run.Results[1].Locations) != 0 {
+		t.Errorf("expected no location, got %#v", run.Results[1].Locations)
+	}
+}
+
+func TestWriteDiagnosticsUnknownFormat(t *testing.T) {
+	err := WriteDiagnostics(&bytes.Buffer{}, "xml", testDiagnostics)
+	if err == nil {
+		t.Error("expected error")
+	}
+}
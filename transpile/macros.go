@@ -0,0 +1,472 @@
+package transpile
+
+import (
+	"os"
+	"strings"
+	"text/scanner"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/cc"
+	"github.com/elliotchance/c2go/program"
+)
+
+// macroToken is a token from a line of C source.
+type macroToken struct {
+	text   string
+	column int
+
+	// arg is the (1-based) index of the argument of a function-like macro
+	// that the token was substituted from.
+	arg int
+}
+
+// macroDefinition is a macro that has been split into tokens.
+type macroDefinition struct {
+	function bool
+	params   []string
+	body     []macroToken
+}
+
+// macroRange is the first and last column of a range of tokens.
+type macroRange struct {
+	column    int
+	columnEnd int
+}
+
+// macroExpansion is the range of tokens on a preprocessed line that came from
+// a macro.
+type macroExpansion struct {
+	name string
+	macroRange
+
+	// args is the range of the first use of each argument of a function-like
+	// macro. It is nil if any of the arguments are not used in the expansion.
+	args []macroRange
+}
+
+// findMacroExpansions finds the expression nodes that are the complete
+// expansion of one of the macros in p.Macros and records them with
+// p.AddMacroExpansion.
+//
+// The AST is generated from the preprocessed file so it does not know about
+// macros. Instead, each line of the original source that uses a macro is
+// compared token by token with the same line in the preprocessed file. Where
+// the original source has the name of a macro the preprocessed line must have
+// the (fully expanded) tokens of the macro body. The columns of those tokens
+// are then matched to the positions of the nodes.
+//
+// If the lines cannot be matched (for example, because a macro that is not in
+// p.Macros was also used on the line) the rest of the line is ignored.
+func findMacroExpansions(p *program.Program, root ast.Node, ppFilePath string) {
+	if len(p.Macros) == 0 {
+		return
+	}
+
+	macros := map[string]*macroDefinition{}
+	for _, macro := range p.Macros {
+		macros[macro.Name] = &macroDefinition{
+			function: macro.Function,
+			params:   macro.Params,
+			body:     tokenizeMacroLine(macro.Body),
+		}
+	}
+
+	// Group the candidate nodes by line so that each line is only compared
+	// once.
+	type fileLine struct {
+		file string
+		line int
+	}
+	nodesByLine := map[fileLine][]ast.Node{}
+	var walk func(node ast.Node)
+	walk = func(node ast.Node) {
+		if node == nil {
+			return
+		}
+
+		switch node.(type) {
+		case *ast.IntegerLiteral, *ast.FloatingLiteral, *ast.CharacterLiteral,
+			*ast.ParenExpr, *ast.UnaryOperator, *ast.BinaryOperator,
+			*ast.ConditionalOperator:
+			pos := node.Position()
+			if pos.Line != 0 && (pos.LineEnd == 0 || pos.LineEnd == pos.Line) {
+				key := fileLine{pos.File, pos.Line}
+				nodesByLine[key] = append(nodesByLine[key], node)
+			}
+		}
+
+		for _, child := range node.Children() {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	sources := map[string][]string{}
+	for key, nodes := range nodesByLine {
+		if _, ok := sources[key.file]; !ok {
+			data, err := os.ReadFile(key.file)
+			if err != nil {
+				sources[key.file] = nil
+			} else {
+				sources[key.file] = strings.Split(string(data), "\n")
+			}
+		}
+
+		lines := sources[key.file]
+		if key.line > len(lines) {
+			continue
+		}
+
+		original := tokenizeMacroLine(lines[key.line-1])
+		if !hasMacroToken(original, macros) {
+			continue
+		}
+
+		ppLine, err := cc.GetLineFromPreprocessedFile(ppFilePath, key.file,
+			key.line)
+		if err != nil {
+			continue
+		}
+
+		for _, expansion := range matchMacroExpansions(original,
+			tokenizeMacroLine(ppLine), macros) {
+			for _, node := range nodes {
+				if nodeRange(node) != expansion.macroRange {
+					continue
+				}
+
+				var args []ast.Node
+				for _, arg := range expansion.args {
+					args = append(args, findMacroArgument(node, arg))
+				}
+
+				p.AddMacroExpansion(node, expansion.name, args...)
+			}
+		}
+	}
+}
+
+// nodeRange returns the first and last column of a node that is on a single
+// line.
+func nodeRange(node ast.Node) macroRange {
+	pos := node.Position()
+	columnEnd := pos.ColumnEnd
+	if columnEnd == 0 {
+		columnEnd = pos.Column
+	}
+
+	return macroRange{pos.Column, columnEnd}
+}
+
+// findMacroArgument returns the outermost node that covers exactly the tokens
+// of an argument, or nil if there is no such node.
+func findMacroArgument(node ast.Node, arg macroRange) ast.Node {
+	for _, child := range node.Children() {
+		if child == nil {
+			continue
+		}
+
+		if nodeRange(child) == arg {
+			return child
+		}
+
+		if found := findMacroArgument(child, arg); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+func hasMacroToken(tokens []macroToken, macros map[string]*macroDefinition) bool {
+	for _, token := range tokens {
+		if _, ok := macros[token.text]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchMacroExpansions compares the tokens of a line of the original source
+// with the same line after it has been preprocessed.
+func matchMacroExpansions(original, preprocessed []macroToken,
+	macros map[string]*macroDefinition) (expansions []macroExpansion) {
+
+	i, j := 0, 0
+	for i < len(original) && j < len(preprocessed) {
+		name := original[i].text
+		macro, ok := macros[name]
+		if !ok {
+			if name != preprocessed[j].text {
+				break
+			}
+
+			i++
+			j++
+			continue
+		}
+
+		var (
+			body, arg []macroToken
+			rawArgs   [][]macroToken
+			args      [][]macroToken
+			next      = i + 1
+		)
+		if macro.function {
+			var end int
+			rawArgs, end, ok = splitMacroArguments(original, i+1)
+			if !ok || len(rawArgs) != macroArity(macro) {
+				break
+			}
+			next = end + 1
+
+			if len(macro.params) == 0 {
+				rawArgs = nil
+			}
+			for _, rawArg := range rawArgs {
+				arg, ok = expandMacroTokens(rawArg, macros, map[string]bool{})
+				if !ok {
+					break
+				}
+				args = append(args, arg)
+			}
+			if !ok {
+				break
+			}
+
+			body, ok = expandMacroTokens(substituteMacroArguments(macro, args),
+				macros, map[string]bool{name: true})
+		} else {
+			body, ok = expandMacroTokens(macro.body, macros,
+				map[string]bool{name: true})
+		}
+
+		if !ok || len(body) == 0 || j+len(body) > len(preprocessed) ||
+			!tokensEqual(body, preprocessed[j:j+len(body)]) {
+			break
+		}
+
+		expansion := macroExpansion{
+			name: name,
+			macroRange: macroRange{
+				column:    preprocessed[j].column,
+				columnEnd: preprocessed[j+len(body)-1].column,
+			},
+		}
+
+		// The tokens of each argument are also compared so that any macros
+		// used in the arguments are found.
+		for k := range args {
+			start := findMacroArgumentTokens(body, k+1, len(args[k]))
+			if start < 0 {
+				expansion.args = nil
+				break
+			}
+
+			ppArg := preprocessed[j+start : j+start+len(args[k])]
+			expansion.args = append(expansion.args, macroRange{
+				column:    ppArg[0].column,
+				columnEnd: ppArg[len(ppArg)-1].column,
+			})
+
+			expansions = append(expansions,
+				matchMacroExpansions(rawArgs[k], ppArg, macros)...)
+		}
+
+		expansions = append(expansions, expansion)
+		i = next
+		j += len(body)
+	}
+
+	return
+}
+
+// macroArity is the number of arguments that a function-like macro takes. A
+// macro without parameters still has one (empty) argument between the
+// parentheses.
+func macroArity(macro *macroDefinition) int {
+	if len(macro.params) == 0 {
+		return 1
+	}
+
+	return len(macro.params)
+}
+
+// splitMacroArguments returns the tokens of each of the arguments to a
+// function-like macro. start is the index of the opening parenthesis and end is
+// the index of the closing parenthesis.
+func splitMacroArguments(tokens []macroToken, start int) (
+	args [][]macroToken, end int, ok bool) {
+
+	if start >= len(tokens) || tokens[start].text != "(" {
+		return nil, 0, false
+	}
+
+	depth := 0
+	args = [][]macroToken{nil}
+	for end = start + 1; end < len(tokens); end++ {
+		switch tokens[end].text {
+		case "(":
+			depth++
+		case ")":
+			if depth == 0 {
+				return args, end, true
+			}
+			depth--
+		case ",":
+			if depth == 0 {
+				args = append(args, nil)
+				continue
+			}
+		}
+
+		args[len(args)-1] = append(args[len(args)-1], tokens[end])
+	}
+
+	return nil, 0, false
+}
+
+// substituteMacroArguments replaces the parameters in the body of a
+// function-like macro with the tokens of the arguments. Each of the argument
+// tokens is marked with the index of the argument.
+func substituteMacroArguments(macro *macroDefinition, args [][]macroToken) (
+	tokens []macroToken) {
+
+	params := map[string]int{}
+	for i, param := range macro.params {
+		params[param] = i + 1
+	}
+
+	for _, token := range macro.body {
+		index, ok := params[token.text]
+		if !ok {
+			tokens = append(tokens, token)
+			continue
+		}
+
+		for _, arg := range args[index-1] {
+			arg.arg = index
+			tokens = append(tokens, arg)
+		}
+	}
+
+	return
+}
+
+// findMacroArgumentTokens returns the index of the first use of the argument
+// in the expanded tokens, or -1 if it is not used.
+func findMacroArgumentTokens(tokens []macroToken, arg, length int) int {
+	if length == 0 {
+		return -1
+	}
+
+	for i := range tokens {
+		if tokens[i].arg != arg {
+			continue
+		}
+
+		for k := 0; k < length; k++ {
+			if i+k >= len(tokens) || tokens[i+k].arg != arg {
+				return -1
+			}
+		}
+
+		return i
+	}
+
+	return -1
+}
+
+// expandMacroTokens expands all of the macros in the tokens. Tokens that were
+// substituted from an argument have already been expanded. seen is the macros
+// that are being expanded, they are not expanded again.
+func expandMacroTokens(tokens []macroToken, macros map[string]*macroDefinition,
+	seen map[string]bool) (expanded []macroToken, ok bool) {
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		macro, isMacro := macros[token.text]
+		if !isMacro || token.arg != 0 || seen[token.text] {
+			expanded = append(expanded, token)
+			continue
+		}
+
+		var body []macroToken
+		if macro.function {
+			// The name of a function-like macro is not expanded unless it
+			// is followed by the arguments.
+			if i+1 >= len(tokens) || tokens[i+1].text != "(" {
+				expanded = append(expanded, token)
+				continue
+			}
+
+			args, end, ok := splitMacroArguments(tokens, i+1)
+			if !ok || len(args) != macroArity(macro) {
+				return nil, false
+			}
+
+			for k := range args {
+				args[k], ok = expandMacroTokens(args[k], macros, seen)
+				if !ok {
+					return nil, false
+				}
+			}
+
+			body = substituteMacroArguments(macro, args)
+			i = end
+		} else {
+			body = macro.body
+		}
+
+		seen[token.text] = true
+		body, ok = expandMacroTokens(body, macros, seen)
+		delete(seen, token.text)
+		if !ok {
+			return nil, false
+		}
+
+		// Only the arguments of the outermost macro are marked.
+		for _, t := range body {
+			t.arg = 0
+			expanded = append(expanded, t)
+		}
+	}
+
+	return expanded, true
+}
+
+func tokensEqual(a, b []macroToken) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].text != b[i].text {
+			return false
+		}
+	}
+
+	return true
+}
+
+// tokenizeMacroLine splits a line of C into tokens. The Go scanner is close
+// enough to C for the tokens to be compared. The column of each token is the
+// byte offset (starting at 1) to match the columns in the clang AST.
+func tokenizeMacroLine(line string) (tokens []macroToken) {
+	var s scanner.Scanner
+	s.Init(strings.NewReader(line))
+	s.Mode = scanner.ScanIdents | scanner.ScanInts | scanner.ScanFloats |
+		scanner.ScanChars | scanner.ScanStrings | scanner.ScanComments |
+		scanner.SkipComments
+	s.Error = func(*scanner.Scanner, string) {}
+
+	for tok := s.Scan(); tok != scanner.EOF; tok = s.Scan() {
+		tokens = append(tokens, macroToken{
+			text:   s.TokenText(),
+			column: s.Position.Offset + 1,
+		})
+	}
+
+	return
+}
@@ -0,0 +1,60 @@
+package transpile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testMacroDefinitions() map[string]*macroDefinition {
+	return map[string]*macroDefinition{
+		"SIZE":   {body: tokenizeMacroLine("1024")},
+		"DOUBLE": {body: tokenizeMacroLine("(SIZE * 2)")},
+		"MAX": {
+			function: true,
+			params:   []string{"a", "b"},
+			body:     tokenizeMacroLine("((a) > (b) ? (a) : (b))"),
+		},
+	}
+}
+
+func TestMatchMacroExpansions(t *testing.T) {
+	macros := testMacroDefinitions()
+
+	original := tokenizeMacroLine("    int a[SIZE] = {DOUBLE};")
+	preprocessed := tokenizeMacroLine("    int a[1024] = {(1024 * 2)};")
+
+	expected := []macroExpansion{
+		{name: "SIZE", macroRange: macroRange{11, 11}},
+		{name: "DOUBLE", macroRange: macroRange{20, 29}},
+	}
+	actual := matchMacroExpansions(original, preprocessed, macros)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %#v, got %#v", expected, actual)
+	}
+
+	// The rest of the line is ignored when the tokens cannot be matched.
+	preprocessed = tokenizeMacroLine("    int b[1024] = {(1024 * 2)};")
+	if actual := matchMacroExpansions(original, preprocessed, macros); len(actual) != 0 {
+		t.Errorf("expected no expansions, got %#v", actual)
+	}
+}
+
+func TestMatchMacroFunctionExpansions(t *testing.T) {
+	macros := testMacroDefinitions()
+
+	original := tokenizeMacroLine("x = MAX(y, SIZE) + 1;")
+	preprocessed := tokenizeMacroLine("x = ((y) > (1024) ? (y) : (1024)) + 1;")
+
+	expected := []macroExpansion{
+		{name: "SIZE", macroRange: macroRange{13, 13}},
+		{
+			name:       "MAX",
+			macroRange: macroRange{5, 33},
+			args:       []macroRange{{7, 7}, {13, 13}},
+		},
+	}
+	actual := matchMacroExpansions(original, preprocessed, macros)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %#v, got %#v", expected, actual)
+	}
+}
@@ -0,0 +1,538 @@
+// Package transpile is the public API for converting C source files to Go. It
+// can be used to embed c2go in other tools (such as code generators run by "go
+// generate") without shelling out to the c2go command.
+//
+// A minimal example:
+//
+//     result, err := transpile.Transpile(transpile.Options{
+//         InputFiles:  []string{"foo.c"},
+//         PackageName: "foo",
+//     })
+//     if err != nil {
+//         return err
+//     }
+//
+//     for _, d := range result.Diagnostics {
+//         fmt.Println(d)
+//     }
+//
+//     fmt.Println(result.GoCode)
+//
+// c2go keeps some state in package variables while it transpiles, so calls to
+// Transpile and AST are serialized. They are safe to use from more than one
+// goroutine but they do not run in parallel.
+package transpile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/cc"
+	"github.com/elliotchance/c2go/preprocessor"
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/transpiler"
+)
+
+// Options controls how the C files are transpiled. There is no constructor
+// since the zeroed out values are the appropriate defaults - you need only set
+// the options you need.
+type Options struct {
+	// InputFiles are the C source files. All of the files are transpiled into
+	// a single Go package.
+	InputFiles []string
+
+	// ClangFlags are extra arguments passed to clang, for example include
+	// paths ("-I...") or defines ("-D..."). They are also added to the flags
+	// of each of the TranslationUnits.
+	ClangFlags []string
+
+	// TranslationUnits are used instead of InputFiles when each C file needs
+	// different clang flags. Each file is preprocessed separately and the
+	// results are merged into a single Go package. See
+	// ReadCompilationDatabase.
+	TranslationUnits []TranslationUnit
+
+	// PackageName is the name of the generated Go package. If it is empty
+	// "main" will be used.
+	PackageName string
+
+	// Output, if not nil, receives the generated Go source. The same source is
+	// always available in Result.GoCode.
+	Output io.Writer
+
+	// Diagnostic, if not nil, is called for each warning or error once the
+	// transpilation has finished. All of the diagnostics are also returned in
+	// Result.Diagnostics.
+	Diagnostic func(Diagnostic)
+
+	// Verbose adds progress comments to the generated Go code and writes
+	// progress messages to VerboseOutput.
+	Verbose bool
+
+	// VerboseOutput receives the progress messages when Verbose is set. The
+	// messages are discarded if it is nil.
+	VerboseOutput io.Writer
+
+	// JSONAST reads the JSON output of the clang AST dump rather than the
+	// text output. The JSON output is more stable between versions of clang
+	// and contains exact literal values.
+	JSONAST bool
+
+	// SplitFiles also generates the Go package as one file for each C file.
+	// See Result.Files.
+	SplitFiles bool
+
+	// SplitHeaders generates a Go file for each user header, rather than
+	// placing the declarations from headers in the shared Go file. It is only
+	// used with SplitFiles.
+	SplitHeaders bool
+
+	// MacroFunctions transpiles the function-like macros in user headers that
+	// do not have side effects (like "#define MAX(a, b) ...") to generic Go
+	// functions. Expansions of the macro are replaced with calls to the
+	// function where the arguments also do not have side effects.
+	MacroFunctions bool
+
+	// OutputAsTest generates the Go code as a *_test.go file. This is used by
+	// the integration tests of c2go itself.
+	OutputAsTest bool
+}
+
+// Result is the output of a successful transpilation.
+type Result struct {
+	// GoCode is the complete generated Go source file.
+	GoCode string
+
+	// Diagnostics contains all of the warnings and errors in the order that
+	// they were produced. The same messages also appear as comments in
+	// GoCode.
+	Diagnostics []Diagnostic
+
+	// Files is only set if Options.SplitFiles is enabled. It maps each Go file
+	// name (like "foo.go" for "foo.c") to its contents. Declarations that do
+	// not belong to a C file are placed in program.SharedFileName.
+	Files map[string]string
+}
+
+// transpileMutex serializes Transpile and AST since they reset and use the
+// package variables of the cc and ast packages.
+var transpileMutex sync.Mutex
+
+// Transpile preprocesses, parses and transpiles the input files. An error is
+// only returned if the transpilation could not be completed. Problems with
+// individual parts of the C source are reported as diagnostics instead.
+func Transpile(opts Options) (*Result, error) {
+	transpileMutex.Lock()
+	defer transpileMutex.Unlock()
+
+	// The preprocessed file is cached while the literals and macros are read
+	// from it.
+	cc.ResetCache()
+
+	pp, err := preprocess(&opts)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(path.Dir(pp.filePath)) // clean up
+
+	astPP, err := dumpAST(&opts, pp.filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	p := program.NewProgram()
+	p.Verbose = opts.Verbose
+	p.OutputAsTest = opts.OutputAsTest
+	p.Comments = pp.comments
+	p.IncludeHeaders = pp.includes
+	p.Macros = pp.macros
+
+	var tree []ast.Node
+	if opts.JSONAST {
+		tree, err = parseJSONTree(p, astPP, opts.progress())
+	} else {
+		tree = parseTextTree(p, astPP, pp.filePath, opts.progress())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	findMacroExpansions(p, tree[0], pp.filePath)
+
+	// transpile ast tree
+	opts.printProgress("Transpiling tree...")
+
+	packageName := opts.PackageName
+	if packageName == "" {
+		packageName = "main"
+	}
+
+	err = transpiler.TranspileAST("", packageName, p, tree[0])
+	if err != nil {
+		return nil, fmt.Errorf("cannot transpile AST : %v", err)
+	}
+
+	result := &Result{
+		GoCode:      p.String(),
+		Diagnostics: p.Messages(),
+	}
+
+	if opts.SplitFiles {
+		result.Files, err = p.Files(opts.SplitHeaders)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Diagnostic != nil {
+		for _, d := range result.Diagnostics {
+			opts.Diagnostic(d)
+		}
+	}
+
+	if opts.Output != nil {
+		if _, err = io.WriteString(opts.Output, result.GoCode); err != nil {
+			return nil, fmt.Errorf("writing Go output failed: %v", err)
+		}
+	}
+
+	return result, nil
+}
+
+// AST returns the output of the clang AST dump for the input files after they
+// have been preprocessed. The dump will be JSON if Options.JSONAST is set.
+func AST(opts Options) ([]byte, error) {
+	transpileMutex.Lock()
+	defer transpileMutex.Unlock()
+
+	pp, err := preprocess(&opts)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(path.Dir(pp.filePath)) // clean up
+
+	return dumpAST(&opts, pp.filePath)
+}
+
+type preprocessed struct {
+	filePath string
+	comments []program.Comment
+	includes []program.IncludeHeader
+	macros   []program.Macro
+}
+
+// preprocess runs the clang preprocessor and writes the result to a new
+// temporary folder. The caller is responsible for removing the folder.
+func preprocess(opts *Options) (*preprocessed, error) {
+	opts.printProgress("Start tanspiling ...")
+
+	var units []TranslationUnit
+	for _, unit := range opts.TranslationUnits {
+		units = append(units, TranslationUnit{
+			File: unit.File,
+			ClangFlags: append(append([]string{}, unit.ClangFlags...),
+				opts.ClangFlags...),
+		})
+	}
+	if len(units) == 0 {
+		for _, in := range opts.InputFiles {
+			units = append(units, TranslationUnit{File: in})
+		}
+	}
+
+	if len(units) == 0 {
+		return nil, errors.New("no input files")
+	}
+
+	// 1. Compile it first (checking for errors)
+	for _, unit := range units {
+		_, err := os.Stat(unit.File)
+		if err != nil {
+			return nil, fmt.Errorf("Input file %s is not found", unit.File)
+		}
+	}
+
+	// 2. Preprocess
+	opts.printProgress("Running clang preprocessor...")
+
+	var (
+		pp       []byte
+		comments []program.Comment
+		includes []program.IncludeHeader
+		err      error
+	)
+	if len(opts.TranslationUnits) > 0 {
+		pp, comments, includes, err = preprocessor.AnalyzeTranslationUnits(
+			units, opts.progress())
+	} else {
+		pp, comments, includes, err = preprocessor.Analyze(opts.InputFiles,
+			opts.ClangFlags, opts.progress())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("issue running preprocessor: %w", err)
+	}
+
+	allMacros, err := preprocessor.GetMacros(units, opts.progress())
+	if err != nil {
+		return nil, fmt.Errorf("issue running preprocessor: %w", err)
+	}
+
+	// Function-like macros are only used if they are enabled, and only if
+	// they are in a header.
+	var macros []program.Macro
+	for _, macro := range allMacros {
+		if macro.Function && (!opts.MacroFunctions || isUnitFile(units, macro.File)) {
+			continue
+		}
+		macros = append(macros, macro)
+	}
+
+	opts.printProgress("Writing preprocessor ...")
+	dir, err := os.MkdirTemp("", "c2go")
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create temp folder: %v", err)
+	}
+
+	ppFilePath := path.Join(dir, "pp.c")
+	err = os.WriteFile(ppFilePath, pp, 0644)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("writing to %s failed: %v", ppFilePath, err)
+	}
+
+	return &preprocessed{
+		filePath: ppFilePath,
+		comments: comments,
+		includes: includes,
+		macros:   macros,
+	}, nil
+}
+
+// progress returns the writer for the progress messages, or nil if they are
+// not printed.
+func (opts *Options) progress() io.Writer {
+	if !opts.Verbose {
+		return nil
+	}
+
+	return opts.VerboseOutput
+}
+
+// printProgress writes a progress message if Verbose is set.
+func (opts *Options) printProgress(message string) {
+	printProgress(opts.progress(), message)
+}
+
+// printProgress writes a progress message to w, unless it is nil.
+func printProgress(w io.Writer, message string) {
+	if w != nil {
+		fmt.Fprintln(w, message)
+	}
+}
+
+// isUnitFile returns true if the file is the C file of one of the translation
+// units, rather than a header.
+func isUnitFile(units []TranslationUnit, file string) bool {
+	for _, unit := range units {
+		path, err := filepath.Abs(unit.File)
+		if err == nil && path == filepath.Clean(file) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dumpAST runs clang to generate the AST of the preprocessed file.
+func dumpAST(opts *Options, ppFilePath string) ([]byte, error) {
+	opts.printProgress("Running clang for AST tree...")
+	astDumpFlag := "-ast-dump"
+	if opts.JSONAST {
+		astDumpFlag = "-ast-dump=json"
+	}
+	astPP, err := exec.Command("clang", "-Xclang", astDumpFlag,
+		"-fsyntax-only", "-fno-color-diagnostics", ppFilePath).Output()
+	if err != nil {
+		// If clang fails it still prints out the AST, so we have to run it
+		// again to get the real error.
+		errBody, _ := exec.Command("clang", ppFilePath).CombinedOutput()
+
+		return nil, fmt.Errorf("clang failed: %v:\n\n%s", err, errBody)
+	}
+
+	return astPP, nil
+}
+
+func readAST(data []byte) []string {
+	return strings.Split(string(data), "\n")
+}
+
+type treeNode struct {
+	indent int
+	node   ast.Node
+}
+
+func convertLinesToNodes(lines []string) []treeNode {
+	nodes := make([]treeNode, len(lines))
+	var counter int
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		// It is tempting to discard null AST nodes, but these may
+		// have semantic importance: for example, they represent omitted
+		// for-loop conditions, as in for(;;).
+		line = strings.Replace(line, "<<<NULL>>>", "NullStmt", 1)
+		trimmed := strings.TrimLeft(line, "|\\- `")
+		node := ast.Parse(trimmed)
+		indentLevel := (len(line) - len(trimmed)) / 2
+		nodes[counter] = treeNode{indentLevel, node}
+		counter++
+	}
+	nodes = nodes[0:counter]
+
+	return nodes
+}
+
+func convertLinesToNodesParallel(lines []string) []treeNode {
+	// function f separate full list on 2 parts and
+	// then each part can recursive run function f
+	var f func([]string, int) []treeNode
+
+	f = func(lines []string, deep int) []treeNode {
+		deep = deep - 2
+		part := len(lines) / 2
+
+		var tr1 = make(chan []treeNode)
+		var tr2 = make(chan []treeNode)
+
+		go func(lines []string, deep int) {
+			if deep <= 0 || len(lines) < deep {
+				tr1 <- convertLinesToNodes(lines)
+				return
+			}
+			tr1 <- f(lines, deep)
+		}(lines[0:part], deep)
+
+		go func(lines []string, deep int) {
+			if deep <= 0 || len(lines) < deep {
+				tr2 <- convertLinesToNodes(lines)
+				return
+			}
+			tr2 <- f(lines, deep)
+		}(lines[part:], deep)
+
+		defer close(tr1)
+		defer close(tr2)
+
+		return append(<-tr1, <-tr2...)
+	}
+
+	// Parameter of deep - can be any, but effective to use
+	// same amount of CPU
+	return f(lines, runtime.NumCPU())
+}
+
+// buildTree converts an array of nodes, each prefixed with a depth into a tree.
+func buildTree(nodes []treeNode, depth int) []ast.Node {
+	if len(nodes) == 0 {
+		return []ast.Node{}
+	}
+
+	// Split the list into sections, treat each section as a tree with its own
+	// root.
+	sections := [][]treeNode{}
+	for _, node := range nodes {
+		if node.indent == depth {
+			sections = append(sections, []treeNode{node})
+		} else {
+			sections[len(sections)-1] = append(sections[len(sections)-1], node)
+		}
+	}
+
+	results := []ast.Node{}
+	for _, section := range sections {
+		slice := []treeNode{}
+		for _, n := range section {
+			if n.indent > depth {
+				slice = append(slice, n)
+			}
+		}
+
+		children := buildTree(slice, depth+1)
+		for _, child := range children {
+			section[0].node.AddChild(child)
+		}
+		results = append(results, section[0].node)
+	}
+
+	return results
+}
+
+// parseTextTree converts the text output of the clang AST dump into the tree.
+// Some literal values are not printed accurately by clang so they are read
+// again from the preprocessed file.
+func parseTextTree(p *program.Program, astPP []byte, ppFilePath string, progress io.Writer) []ast.Node {
+	lines := readAST(astPP)
+
+	// Converting to nodes
+	printProgress(progress, "Converting to nodes...")
+	nodes := convertLinesToNodesParallel(lines)
+
+	// build tree
+	printProgress(progress, "Building tree...")
+	tree := buildTree(nodes, 0)
+	ast.FixPositions(tree)
+	p.SetNodes(tree)
+
+	// Repair the character literals. See RepairCharacterLiteralsFromSource for
+	// more information.
+	characterErrors := ast.RepairCharacterLiteralsFromSource(tree[0], ppFilePath)
+
+	for _, cErr := range characterErrors {
+		message := fmt.Sprintf("could not read exact character literal: %s",
+			cErr.Err.Error())
+		p.AddWarning(program.WithCode(program.CodeInexactLiteral,
+			errors.New(message)), cErr.Node)
+	}
+
+	// Repair the floating literals. See RepairFloatingLiteralsFromSource for
+	// more information.
+	floatingErrors := ast.RepairFloatingLiteralsFromSource(tree[0], ppFilePath)
+
+	for _, fErr := range floatingErrors {
+		message := fmt.Sprintf("could not read exact floating literal: %s",
+			fErr.Err.Error())
+		p.AddWarning(program.WithCode(program.CodeInexactLiteral,
+			errors.New(message)), fErr.Node)
+	}
+
+	return tree
+}
+
+// parseJSONTree converts the JSON output of the clang AST dump into the tree.
+// The JSON output already contains exact literal values.
+func parseJSONTree(p *program.Program, astPP []byte, progress io.Writer) ([]ast.Node, error) {
+	printProgress(progress, "Converting JSON to nodes...")
+	root, err := ast.ParseJSON(bytes.NewReader(astPP))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse JSON AST: %v", err)
+	}
+
+	tree := []ast.Node{root}
+	ast.FixPositions(tree)
+	p.SetNodes(tree)
+
+	return tree, nil
+}
@@ -0,0 +1,33 @@
+package transpile
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrintProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		verbose  bool
+		expected string
+	}{
+		{"verbose", true, "Transpiling tree...\n"},
+		{"quiet", false, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output bytes.Buffer
+			opts := Options{Verbose: test.verbose, VerboseOutput: &output}
+			opts.printProgress("Transpiling tree...")
+
+			if actual := output.String(); actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+
+	// Without an output the messages are discarded.
+	opts := Options{Verbose: true}
+	opts.printProgress("Transpiling tree...")
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	goast "go/ast"
 	"go/token"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/elliotchance/c2go/ast"
@@ -26,7 +28,7 @@ func transpileBinaryOperatorComma(n *ast.BinaryOperator, p *program.Program) (
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("Cannot transpile operator comma : err = %v", err)
-			p.AddMessage(p.GenerateWarningMessage(err, n))
+			p.AddWarning(err, n)
 		}
 	}()
 
@@ -62,7 +64,7 @@ func transpileBinaryOperator(n *ast.BinaryOperator, p *program.Program, exprIsSt
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("Cannot transpile BinaryOperator with type '%s' : result type = {%s}. Error: %v", n.Type, eType, err)
-			p.AddMessage(p.GenerateWarningMessage(err, n))
+			p.AddWarning(err, n)
 		}
 	}()
 
@@ -206,12 +208,22 @@ func transpileBinaryOperator(n *ast.BinaryOperator, p *program.Program, exprIsSt
 		// Theoretically , we don't have any preStmts or postStmts
 		// from n.Children()[1]
 		if len(newPre) > 0 || len(newPost) > 0 {
-			p.AddMessage(p.GenerateWarningMessage(
-				fmt.Errorf("Not support length pre or post stmts: {%d,%d}", len(newPre), len(newPost)), n))
+			p.AddWarning(
+				fmt.Errorf("Not support length pre or post stmts: {%d,%d}", len(newPre), len(newPost)), n)
 		}
 		return stmts, st, preStmts, postStmts, nil
 	}
 
+	// An assignment to a bit-field is a call of its setter.
+	switch n.Operator {
+	case "=", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "<<=", ">>=":
+		if m, ok := n.Children()[0].(*ast.MemberExpr); ok {
+			if bitField, ok := getBitField(p, m); ok {
+				return transpileBitFieldAssign(p, m, bitField, n.Operator, n.Children()[1])
+			}
+		}
+	}
+
 	left, leftType, newPre, newPost, err := atomicOperation(n.Children()[0], p)
 	if err != nil {
 		return nil, "unknown52", nil, nil, err
@@ -234,11 +246,11 @@ func transpileBinaryOperator(n *ast.BinaryOperator, p *program.Program, exprIsSt
 		}
 		left, leftType, err = GetUintptrForPointer(p, left, leftType)
 		if err != nil {
-			p.AddMessage(p.GenerateWarningMessage(err, n))
+			p.AddWarning(err, n)
 		}
 		right, rightType, err = GetUintptrForPointer(p, right, rightType)
 		if err != nil {
-			p.AddMessage(p.GenerateWarningMessage(err, n))
+			p.AddWarning(err, n)
 		}
 	}
 	if types.IsPointer(p, leftType) && types.IsPointer(p, rightType) &&
@@ -246,11 +258,11 @@ func transpileBinaryOperator(n *ast.BinaryOperator, p *program.Program, exprIsSt
 		leftType != "NullPointerType *" && rightType != "NullPointerType *" {
 		left, leftType, err = GetUintptrForPointer(p, left, leftType)
 		if err != nil {
-			p.AddMessage(p.GenerateWarningMessage(err, n))
+			p.AddWarning(err, n)
 		}
 		right, rightType, err = GetUintptrForPointer(p, right, rightType)
 		if err != nil {
-			p.AddMessage(p.GenerateWarningMessage(err, n))
+			p.AddWarning(err, n)
 		}
 	}
 
@@ -260,20 +272,20 @@ func transpileBinaryOperator(n *ast.BinaryOperator, p *program.Program, exprIsSt
 
 	if operator == token.LAND || operator == token.LOR {
 		left, err = types.CastExpr(p, left, leftType, "bool")
-		p.AddMessage(p.GenerateWarningOrErrorMessage(err, n, left == nil))
+		p.AddWarningOrError(err, n, left == nil)
 		if left == nil {
 			left = util.NewNil()
 		}
 
 		right, err = types.CastExpr(p, right, rightType, "bool")
-		p.AddMessage(p.GenerateWarningOrErrorMessage(err, n, right == nil))
+		p.AddWarningOrError(err, n, right == nil)
 		if right == nil {
 			right = util.NewNil()
 		}
 
 		resolvedLeftType, err := types.ResolveType(p, leftType)
 		if err != nil {
-			p.AddMessage(p.GenerateWarningMessage(err, n))
+			p.AddWarning(err, n)
 		}
 
 		expr := util.NewBinaryExpr(left, operator, right, resolvedLeftType, exprIsStmt)
@@ -287,7 +299,7 @@ func transpileBinaryOperator(n *ast.BinaryOperator, p *program.Program, exprIsSt
 	// To handle this, cast the shift count to a uint64.
 	if operator == token.SHL || operator == token.SHR {
 		right, err = types.CastExpr(p, right, rightType, "unsigned long long")
-		p.AddMessage(p.GenerateWarningOrErrorMessage(err, n, right == nil))
+		p.AddWarningOrError(err, n, right == nil)
 		if right == nil {
 			right = util.NewNil()
 		}
@@ -333,7 +345,7 @@ func transpileBinaryOperator(n *ast.BinaryOperator, p *program.Program, exprIsSt
 		if rightType != types.NullPointer {
 			right, err = types.CastExpr(p, right, rightType, leftType)
 			rightType = leftType
-			p.AddMessage(p.GenerateWarningOrErrorMessage(err, n, right == nil))
+			p.AddWarningOrError(err, n, right == nil)
 		}
 
 	}
@@ -343,9 +355,9 @@ func transpileBinaryOperator(n *ast.BinaryOperator, p *program.Program, exprIsSt
 		allocSize := getAllocationSizeNode(p, n.Children()[1])
 
 		if allocSize != nil {
-			right, newPre, newPost, err = generateAlloc(p, allocSize, leftType)
+			right, newPre, newPost, err = generateAlloc(p, n, allocSize, leftType)
 			if err != nil {
-				p.AddMessage(p.GenerateWarningMessage(err, n))
+				p.AddWarning(err, n)
 				return nil, "", nil, nil, err
 			}
 
@@ -354,7 +366,7 @@ func transpileBinaryOperator(n *ast.BinaryOperator, p *program.Program, exprIsSt
 		} else {
 			right, err = types.CastExpr(p, right, rightType, returnType)
 
-			if p.AddMessage(p.GenerateWarningMessage(err, n)) && right == nil {
+			if p.AddWarning(err, n) && right == nil {
 				right = util.NewNil()
 			}
 
@@ -369,7 +381,7 @@ func transpileBinaryOperator(n *ast.BinaryOperator, p *program.Program, exprIsSt
 	if !types.IsFunction(n.Type) && !types.IsTypedefFunction(p, n.Type) {
 		resolvedLeftType, err = types.ResolveType(p, leftType)
 		if err != nil {
-			p.AddMessage(p.GenerateWarningMessage(err, n))
+			p.AddWarning(err, n)
 		}
 	}
 
@@ -377,7 +389,7 @@ func transpileBinaryOperator(n *ast.BinaryOperator, p *program.Program, exprIsSt
 	if operator != token.ASSIGN && strings.Contains(leftType, "enum") {
 		left, err = types.CastExpr(p, left, leftType, "int")
 		if err != nil {
-			p.AddMessage(p.GenerateWarningMessage(err, n))
+			p.AddWarning(err, n)
 		}
 	}
 
@@ -385,19 +397,19 @@ func transpileBinaryOperator(n *ast.BinaryOperator, p *program.Program, exprIsSt
 	if operator != token.ASSIGN && strings.Contains(rightType, "enum") {
 		right, err = types.CastExpr(p, right, rightType, "int")
 		if err != nil {
-			p.AddMessage(p.GenerateWarningMessage(err, n))
+			p.AddWarning(err, n)
 		}
 	}
 
 	if left == nil {
 		err = fmt.Errorf("left part of binary operation is nil. left : %#v", n.Children()[0])
-		p.AddMessage(p.GenerateWarningMessage(err, n))
+		p.AddWarning(err, n)
 		return nil, "", nil, nil, err
 	}
 
 	if right == nil {
 		err = fmt.Errorf("right part of binary operation is nil. right : %#v", n.Children()[1])
-		p.AddMessage(p.GenerateWarningMessage(err, n))
+		p.AddWarning(err, n)
 		return nil, "", nil, nil, err
 	}
 
@@ -432,10 +444,7 @@ func foundCallExpr(n ast.Node) *ast.CallExpr {
 // Would return the node that represents the "sizeof(int)".
 //
 // If the node does not represent an allocation operation (such as calling
-// malloc, calloc, realloc, etc.) then nil is returned.
-//
-// In the case of calloc() it will return a new BinaryExpr that multiplies both
-// arguments.
+// malloc) then nil is returned.
 func getAllocationSizeNode(p *program.Program, node ast.Node) ast.Node {
 	expr := foundCallExpr(node)
 
@@ -451,27 +460,15 @@ func getAllocationSizeNode(p *program.Program, node ast.Node) ast.Node {
 		return expr.Children()[1]
 	}
 
-	if functionName == "calloc" {
-		return &ast.BinaryOperator{
-			Type:       "int",
-			Operator:   "*",
-			ChildNodes: expr.Children()[1:],
-		}
-	}
-
-	// TODO: realloc() is not supported
-	// https://github.com/elliotchance/c2go/issues/118
-	//
-	// Realloc will be treated as calloc which will almost certainly cause
-	// bugs in your code.
-	if functionName == "realloc" {
-		return expr.Children()[2]
-	}
+	// calloc() is transpiled as a call of noarch.Calloc, which checks that the
+	// size of the block does not overflow. realloc() is not an allocation of a
+	// new block because it must keep the contents of the block. It is
+	// transpiled as a call of noarch.Realloc.
 
 	return nil
 }
 
-func generateAlloc(p *program.Program, allocSize ast.Node, leftType string) (
+func generateAlloc(p *program.Program, site ast.Node, allocSize ast.Node, leftType string) (
 	right goast.Expr, preStmts []goast.Stmt, postStmts []goast.Stmt, err error) {
 
 	allocSizeExpr, allocType, newPre, newPost, err := transpileToExpr(allocSize, p, false)
@@ -491,10 +488,7 @@ func generateAlloc(p *program.Program, allocSize ast.Node, leftType string) (
 		return nil, preStmts, postStmts, err
 	}
 
-	right = util.NewCallExpr(
-		"noarch.Malloc",
-		allocSizeExpr,
-	)
+	right = newMemoryCall(site, "noarch.Malloc", allocSizeExpr)
 	if toType != "unsafe.Pointer" {
 		right = &goast.CallExpr{
 			Fun: &goast.ParenExpr{
@@ -505,3 +499,35 @@ func generateAlloc(p *program.Program, allocSize ast.Node, leftType string) (
 	}
 	return
 }
+
+// memoryFunctions are the functions of noarch that are also methods of
+// noarch.Site.
+var memoryFunctions = map[string]bool{
+	"noarch.AlignedAlloc":  true,
+	"noarch.Calloc":        true,
+	"noarch.Free":          true,
+	"noarch.Malloc":        true,
+	"noarch.PosixMemalign": true,
+	"noarch.Realloc":       true,
+}
+
+// newMemoryCall returns a call of the function. The calls of the
+// memoryFunctions have the C position of the node so that it can be reported
+// by the checked allocator (see noarch/memcheck.go):
+//
+//     noarch.Malloc(10)  ->  noarch.Site("main.c:12").Malloc(10)
+func newMemoryCall(n ast.Node, functionName string, args ...goast.Expr) *goast.CallExpr {
+	call := util.NewCallExpr(functionName, args...)
+	pos := n.Position()
+	if !memoryFunctions[functionName] || pos.File == "" || pos.Line == 0 {
+		return call
+	}
+
+	site := fmt.Sprintf("%s:%d", filepath.Base(pos.File), pos.Line)
+	call.Fun = &goast.SelectorExpr{
+		X:   util.NewCallExpr("noarch.Site", util.NewStringLit(strconv.Quote(site))),
+		Sel: util.NewIdent(strings.TrimPrefix(functionName, "noarch.")),
+	}
+
+	return call
+}
@@ -0,0 +1,115 @@
+package transpiler
+
+import (
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+// newLibraryCall returns the CallExpr of a function from a system header.
+func newLibraryCall(pos ast.Position, name, cType string, args ...ast.Node) *ast.CallExpr {
+	return &ast.CallExpr{
+		Pos:  pos,
+		Type: "void *",
+		ChildNodes: append([]ast.Node{&ast.ImplicitCastExpr{
+			Type:       cType,
+			Kind:       "FunctionToPointerDecay",
+			ChildNodes: []ast.Node{&ast.DeclRefExpr{Type: cType, For: "Function", Name: name}},
+		}}, args...),
+	}
+}
+
+func TestMemorySite(t *testing.T) {
+	p := program.NewProgram()
+	p.IncludeHeaders = []program.IncludeHeader{{HeaderName: "/usr/include/stdlib.h"}}
+	pos := ast.Position{File: "/src/main.c", Line: 12}
+	pointer := func() ast.Node {
+		return &ast.DeclRefExpr{Type: "int *", For: "Var", Name: "p"}
+	}
+	voidPointer := func() ast.Node {
+		return &ast.ImplicitCastExpr{
+			Type: "void *",
+			Kind: "BitCast",
+			ChildNodes: []ast.Node{&ast.ImplicitCastExpr{
+				Type:       "int *",
+				Kind:       "LValueToRValue",
+				ChildNodes: []ast.Node{pointer()},
+			}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		node     ast.Node
+		expected string
+	}{
+		{
+			// p = (int *)malloc(40);
+			name: "malloc",
+			node: &ast.BinaryOperator{
+				Pos:      pos,
+				Type:     "int *",
+				Operator: "=",
+				ChildNodes: []ast.Node{pointer(), &ast.CStyleCastExpr{
+					Type: "int *",
+					Kind: "BitCast",
+					ChildNodes: []ast.Node{newLibraryCall(pos, "malloc",
+						"void *(unsigned long)", intLiteral("40"))},
+				}},
+			},
+			expected: `p = (*int32)(noarch.Site("main.c:12").Malloc(int32(40)))`,
+		},
+		{
+			// p = calloc(10, 4);
+			name: "calloc",
+			node: &ast.BinaryOperator{
+				Pos:      pos,
+				Type:     "int *",
+				Operator: "=",
+				ChildNodes: []ast.Node{pointer(), &ast.ImplicitCastExpr{
+					Type: "int *",
+					Kind: "BitCast",
+					ChildNodes: []ast.Node{newLibraryCall(pos, "calloc",
+						"void *(unsigned long, unsigned long)", intLiteral("10"), intLiteral("4"))},
+				}},
+			},
+			expected: `p = (*int32)(noarch.Site("main.c:12").Calloc(int32(10), int32(4)))`,
+		},
+		{
+			// p = realloc(p, 80);
+			name: "realloc",
+			node: &ast.BinaryOperator{
+				Pos:      pos,
+				Type:     "int *",
+				Operator: "=",
+				ChildNodes: []ast.Node{pointer(), &ast.ImplicitCastExpr{
+					Type: "int *",
+					Kind: "BitCast",
+					ChildNodes: []ast.Node{newLibraryCall(pos, "realloc",
+						"void *(void *, unsigned long)", voidPointer(), intLiteral("80"))},
+				}},
+			},
+			expected: `p = (*int32)(noarch.Site("main.c:12").Realloc(unsafe.Pointer(p), int32(80)))`,
+		},
+		{
+			// free(p);
+			name:     "free",
+			node:     newLibraryCall(pos, "free", "void (void *)", voidPointer()),
+			expected: `noarch.Site("main.c:12").Free(unsafe.Pointer(p))`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, _, _, _, err := transpileToExpr(tc.node, p, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := formatNodes(t, expr); got != tc.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tc.expected, got)
+			}
+		})
+	}
+}
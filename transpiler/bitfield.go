@@ -0,0 +1,383 @@
+// This file contains the transpiling of bit-fields.
+//
+// Go does not have bit-fields so the bit-fields of a struct are packed into
+// unsigned integer fields (named c2goBitField0, c2goBitField1, ...) in the
+// same way that the C compiler packs them. Each bit-field has a getter and a
+// setter method:
+//
+//     struct flags {                 type flags struct {
+//         unsigned ready : 1;            c2goBitField0 uint8
+//         int count : 4;             }
+//     };
+//                                    func (structVar flags) ready() uint32
+//                                    func (structVar *flags) set_ready(value uint32) uint32
+//                                    func (structVar flags) count() int32
+//                                    func (structVar *flags) set_count(value int32) int32
+//
+// And the usage of the bit-fields is transpiled to calls of the methods:
+//
+//     f.count = 3;    ->  f.set_count(3)
+//     x = f.count;    ->  x = f.count()
+//     f.count += 2;   ->  f.set_count(f.count() + 2)
+
+package transpiler
+
+import (
+	"bytes"
+	"fmt"
+	goast "go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"text/template"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
+	"github.com/elliotchance/c2go/util"
+)
+
+// bitFieldPrefix is the prefix of the names of the fields that contain the
+// bit-fields.
+const bitFieldPrefix = "c2goBitField"
+
+// bitFieldUnit is a field that contains one or more bit-fields.
+type bitFieldUnit struct {
+	Name string
+	Type string
+	Bits int
+
+	// start and end are the offsets (in bits) of the first bit and after the
+	// last bit of the unit in the struct.
+	start     int
+	end       int
+	BitFields []bitField
+}
+
+// bitField is a bit-field that is stored in a bitFieldUnit.
+type bitField struct {
+	Name   string
+	Type   string
+	Offset int
+	Width  int
+	Mask   string
+	Signed bool
+	IsBool bool
+}
+
+// bitFieldUnits returns the units that contain the bit-fields of the struct.
+// There is a slice of units for each sequence of bit-fields that are not
+// separated by other fields.
+//
+// The bit-fields are stored in the unit of the size of their type, in the
+// same way as the C compiler. The units of bit-fields that share some bits
+// (like an "unsigned char" bit-field in the padding of an "int" bit-field) are
+// merged.
+func bitFieldUnits(p *program.Program, s *program.Struct) (runs [][]*bitFieldUnit, err error) {
+	layout, err := types.LayoutOf(p, s)
+	if err != nil {
+		return nil, err
+	}
+
+	var units []*bitFieldUnit
+	runStart := 0
+	for i, m := range s.Members {
+		if !m.IsBitField() {
+			if units != nil {
+				runs = append(runs, units)
+				units = nil
+			}
+			continue
+		}
+		if m.BitWidth == 0 {
+			continue
+		}
+
+		size, err := types.SizeOf(p, m.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		// The unit cannot start before the first byte of the bit-fields,
+		// because there could be another field.
+		offset := layout.BitOffsets[i]
+		if units == nil {
+			runStart = offset / 8 * 8
+		}
+		u := &bitFieldUnit{
+			start: offset / (size * 8) * size * 8,
+			end:   offset + m.BitWidth,
+			BitFields: []bitField{
+				{Name: m.Name, Type: m.Type, Offset: offset, Width: m.BitWidth},
+			},
+		}
+		if u.start < runStart {
+			u.start = runStart
+		}
+
+		// Merge the units that share some bits with the new unit.
+		for len(units) > 0 && units[len(units)-1].end > u.start {
+			last := units[len(units)-1]
+			if last.start < u.start {
+				u.start = last.start
+			}
+			if last.end > u.end {
+				u.end = last.end
+			}
+			u.BitFields = append(last.BitFields, u.BitFields...)
+			units = units[:len(units)-1]
+		}
+		units = append(units, u)
+	}
+	if units != nil {
+		runs = append(runs, units)
+	}
+
+	// The type of the unit is large enough for all of its bit-fields.
+	n := 0
+	for _, units := range runs {
+		for _, u := range units {
+			u.Name = fmt.Sprintf("%s%d", bitFieldPrefix, n)
+			n++
+
+			for u.Bits = 8; u.Bits < u.end-u.start; u.Bits *= 2 {
+			}
+			if u.Bits > 64 {
+				return nil, fmt.Errorf("bit-fields of %d bits are not supported", u.Bits)
+			}
+			u.Type = fmt.Sprintf("uint%d", u.Bits)
+
+			named := u.BitFields[:0]
+			for _, f := range u.BitFields {
+				// An unnamed bit-field is only padding.
+				if f.Name == "" {
+					continue
+				}
+
+				goType, err := types.ResolveType(p, f.Type)
+				if err != nil {
+					return nil, err
+				}
+
+				// TODO: The name of a variable or field cannot be a reserved word
+				// https://github.com/elliotchance/c2go/issues/83
+				if util.IsGoKeyword(f.Name) {
+					f.Name += "_"
+				}
+				f.Offset -= u.start
+				f.Mask = fmt.Sprintf("%#x", uint64(1)<<uint(f.Width)-1)
+				// A _Bool bit-field is unsigned.
+				cType := types.CleanCType(f.Type)
+				f.IsBool = goType == "bool"
+				f.Signed = strings.HasPrefix(goType, "int") &&
+					cType != "_Bool" && cType != "bool"
+				f.Type = goType
+				named = append(named, f)
+			}
+			u.BitFields = named
+		}
+	}
+
+	return
+}
+
+// transpileBitFields returns the fields that contain the bit-fields and the
+// methods for each of the bit-fields of the struct.
+func transpileBitFields(p *program.Program, name string, s *program.Struct) (
+	fields [][]*goast.Field, methods []goast.Decl, err error) {
+	runs, err := bitFieldUnits(p, s)
+	if err != nil {
+		return
+	}
+
+	src := `package main
+
+{{ range .Units }}{{ $unit := . }}{{ range .BitFields }}
+func (structVar {{ $.Name }}) {{ .Name }}() {{ .Type }} {
+{{- if .IsBool }}
+	return structVar.{{ $unit.Name }}>>{{ .Offset }}&{{ .Mask }} != 0
+{{- else if .Signed }}
+	return {{ .Type }}(int64(uint64(structVar.{{ $unit.Name }}>>{{ .Offset }})<<(64-{{ .Width }})) >> (64 - {{ .Width }}))
+{{- else }}
+	return {{ .Type }}(structVar.{{ $unit.Name }} >> {{ .Offset }} & {{ .Mask }})
+{{- end }}
+}
+
+func (structVar *{{ $.Name }}) set_{{ .Name }}(value {{ .Type }}) {{ .Type }} {
+{{- if .IsBool }}
+	var bits {{ $unit.Type }}
+	if value {
+		bits = 1
+	}
+{{- else }}
+	bits := {{ $unit.Type }}(value)
+{{- end }}
+	structVar.{{ $unit.Name }} = structVar.{{ $unit.Name }}&^({{ .Mask }}<<{{ .Offset }}) | (bits&{{ .Mask }})<<{{ .Offset }}
+	return structVar.{{ .Name }}()
+}
+{{ end }}{{ end }}
+`
+
+	data := struct {
+		Name  string
+		Units []*bitFieldUnit
+	}{Name: name}
+	for _, units := range runs {
+		var run []*goast.Field
+		for _, u := range units {
+			run = append(run, &goast.Field{
+				Names: []*goast.Ident{util.NewIdent(u.Name)},
+				Type:  util.NewTypeIdent(u.Type),
+			})
+			data.Units = append(data.Units, u)
+		}
+		fields = append(fields, run)
+	}
+
+	tmpl := template.Must(template.New("").Parse(src))
+	var source bytes.Buffer
+	err = tmpl.Execute(&source, data)
+	if err != nil {
+		err = fmt.Errorf("cannot execute template for bit-fields of %s : %v", name, err)
+		return
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), "", source.String(), 0)
+	if err != nil {
+		err = fmt.Errorf("cannot parse source \"%s\" : %v", source.String(), err)
+		return
+	}
+
+	return fields, f.Decls, nil
+}
+
+// getBitField returns the bit-field that is used by the member expression, or
+// false if the member is not a bit-field.
+func getBitField(p *program.Program, n *ast.MemberExpr) (_ program.Member, ok bool) {
+	s := p.GetRecord(getMemberBaseType(n))
+	if s == nil || s.IsUnion {
+		return
+	}
+
+	return s.BitField(n.Name)
+}
+
+// transpileBitFieldAssign transpiles an assignment to a bit-field. The
+// operator is "=" or a compound assignment like "+=":
+//
+//     f.count = 3;    ->  f.set_count(3)
+//     f.count += 2;   ->  f.set_count(f.count() + 2)
+func transpileBitFieldAssign(p *program.Program, member *ast.MemberExpr,
+	bitField program.Member, operator string, right ast.Node) (
+	_ goast.Expr, _ string, preStmts []goast.Stmt, postStmts []goast.Stmt, err error) {
+
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("Cannot transpile assignment to bit-field '%s'. err = %v",
+				bitField.Name, err)
+		}
+	}()
+
+	getter, _, newPre, newPost, err := transpileMemberExpr(member, p)
+	if err != nil {
+		return
+	}
+	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
+
+	call, ok := getter.(*goast.CallExpr)
+	if !ok {
+		err = fmt.Errorf("expected a call of the getter, got %T", getter)
+		return
+	}
+	sel, ok := call.Fun.(*goast.SelectorExpr)
+	if !ok {
+		err = fmt.Errorf("expected a method of the getter, got %T", call.Fun)
+		return
+	}
+
+	// A compound assignment is the operation with the value of the bit-field.
+	if operator != "=" {
+		value := &ast.ImplicitCastExpr{
+			Type:       member.Type,
+			Kind:       "LValueToRValue",
+			ChildNodes: []ast.Node{member},
+		}
+		right = &ast.BinaryOperator{
+			Type:       member.Type,
+			Operator:   strings.TrimSuffix(operator, "="),
+			ChildNodes: []ast.Node{value, right},
+		}
+	}
+
+	value, valueType, newPre, newPost, err := transpileToExpr(right, p, false)
+	if err != nil {
+		return
+	}
+	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
+
+	value, err = types.CastExpr(p, value, valueType, bitField.Type)
+	if err != nil {
+		return
+	}
+
+	return &goast.CallExpr{
+		Fun: &goast.SelectorExpr{
+			X:   sel.X,
+			Sel: util.NewIdent("set_" + sel.Sel.Name),
+		},
+		Args: []goast.Expr{value},
+	}, member.Type, preStmts, postStmts, nil
+}
+
+// transpileBitFieldInitListExpr transpiles the initialization of a struct that
+// has bit-fields. The bit-fields cannot be set in a composite literal so the
+// fields (the C names of the members) are set to the values one by one:
+//
+//     struct flags f = {1, 3};
+//
+//     var f flags = func() flags {
+//         var c2goStruct flags
+//         c2goStruct.set_ready(1)
+//         c2goStruct.set_count(3)
+//         return c2goStruct
+//     }()
+func transpileBitFieldInitListExpr(p *program.Program, s *program.Struct,
+	goType string, names []string, values []goast.Expr) goast.Expr {
+	const structName = "c2goStruct"
+
+	body := []goast.Stmt{&goast.DeclStmt{Decl: &goast.GenDecl{
+		Tok: token.VAR,
+		Specs: []goast.Spec{&goast.ValueSpec{
+			Names: []*goast.Ident{util.NewIdent(structName)},
+			Type:  util.NewTypeIdent(goType),
+		}},
+	}}}
+
+	for i, name := range names {
+		_, isBitField := s.BitField(name)
+
+		// TODO: The name of a variable or field cannot be a reserved word
+		// https://github.com/elliotchance/c2go/issues/83
+		if util.IsGoKeyword(name) {
+			name += "_"
+		}
+
+		if isBitField {
+			body = append(body, &goast.ExprStmt{
+				X: util.NewCallExpr(structName+".set_"+name, values[i]),
+			})
+			continue
+		}
+
+		body = append(body, &goast.AssignStmt{
+			Lhs: []goast.Expr{&goast.SelectorExpr{
+				X:   util.NewIdent(structName),
+				Sel: util.NewIdent(name),
+			}},
+			Tok: token.ASSIGN,
+			Rhs: []goast.Expr{values[i]},
+		})
+	}
+
+	return util.NewAnonymousFunction(body, nil, util.NewIdent(structName), goType)
+}
@@ -0,0 +1,222 @@
+package transpiler
+
+import (
+	"bytes"
+	"go/format"
+	"go/token"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+// newBitFieldStruct returns the RecordDecl of:
+//
+//     struct flags {
+//         unsigned char ready : 1;
+//         int count : 4;
+//         double value;
+//         unsigned : 0;
+//     };
+func newBitFieldStruct() *ast.RecordDecl {
+	n := &ast.RecordDecl{Name: "flags", Kind: "struct", Definition: true}
+	for _, f := range []struct{ name, cType, width string }{
+		{"ready", "unsigned char", "1"},
+		{"count", "int", "4"},
+		{"value", "double", ""},
+		{"", "unsigned int", "0"},
+	} {
+		field := &ast.FieldDecl{Name: f.name, Type: f.cType}
+		if f.width != "" {
+			field.AddChild(&ast.ConstantExpr{
+				Type: "int",
+				ChildNodes: []ast.Node{
+					&ast.IntegerLiteral{Type: "int", Value: f.width},
+				},
+			})
+		}
+		n.AddChild(field)
+	}
+
+	return n
+}
+
+func formatNodes(t *testing.T, nodes ...interface{}) string {
+	var buf bytes.Buffer
+	for i, n := range nodes {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		if err := format.Node(&buf, token.NewFileSet(), n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return buf.String()
+}
+
+func TestBitFieldStruct(t *testing.T) {
+	p := program.NewProgram()
+	decls, err := transpileRecordDecl(p, newBitFieldStruct())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nodes []interface{}
+	for _, d := range decls {
+		nodes = append(nodes, d)
+	}
+
+	expected := `type flags struct {
+	c2goBitField0 uint8
+	value         float64
+}
+func (structVar flags) ready() uint8 {
+	return uint8(structVar.c2goBitField0 >> 0 & 0x1)
+}
+func (structVar *flags) set_ready(value uint8) uint8 {
+	bits := uint8(value)
+	structVar.c2goBitField0 = structVar.c2goBitField0&^(0x1<<0) | (bits&0x1)<<0
+	return structVar.ready()
+}
+func (structVar flags) count() int32 {
+	return int32(int64(uint64(structVar.c2goBitField0>>1)<<(64-4)) >> (64 - 4))
+}
+func (structVar *flags) set_count(value int32) int32 {
+	bits := uint8(value)
+	structVar.c2goBitField0 = structVar.c2goBitField0&^(0xf<<1) | (bits&0xf)<<1
+	return structVar.count()
+}`
+	if actual := formatNodes(t, nodes...); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+	if p.Structs["struct flags"].FieldNames[1] != "count" {
+		t.Errorf("unexpected field names: %v", p.Structs["struct flags"].FieldNames)
+	}
+}
+
+func TestBitFieldAccess(t *testing.T) {
+	p := program.NewProgram()
+	if _, err := transpileRecordDecl(p, newBitFieldStruct()); err != nil {
+		t.Fatal(err)
+	}
+
+	member := func() *ast.MemberExpr {
+		return &ast.MemberExpr{
+			Type: "int",
+			Name: "count",
+			ChildNodes: []ast.Node{
+				&ast.DeclRefExpr{Type: "struct flags", Name: "f", For: "Var"},
+			},
+		}
+	}
+	literal := &ast.IntegerLiteral{Type: "int", Value: "3"}
+
+	tests := []struct {
+		name     string
+		node     ast.Node
+		expected string
+	}{
+		{
+			name:     "read",
+			node:     member(),
+			expected: `f.count()`,
+		},
+		{
+			name: "assign",
+			node: &ast.BinaryOperator{
+				Type:       "int",
+				Operator:   "=",
+				ChildNodes: []ast.Node{member(), literal},
+			},
+			expected: `f.set_count(int32(3))`,
+		},
+		{
+			name: "compound assign",
+			node: &ast.CompoundAssignOperator{
+				Type:       "int",
+				Opcode:     "+=",
+				ChildNodes: []ast.Node{member(), literal},
+			},
+			expected: `f.set_count(f.count() + int32(3))`,
+		},
+		{
+			name: "increment",
+			node: &ast.UnaryOperator{
+				Type:       "int",
+				Operator:   "++",
+				IsPrefix:   true,
+				ChildNodes: []ast.Node{member()},
+			},
+			expected: `f.set_count(f.count() + int32(1))`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expr, _, _, _, err := transpileToExpr(test.node, p, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual := formatNodes(t, expr); actual != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, actual)
+			}
+		})
+	}
+
+}
+
+func TestBitFieldInitListExpr(t *testing.T) {
+	// struct marks {
+	//     unsigned reserved_ : 3;
+	//     int type : 2;
+	//     int range;
+	// };
+	n := &ast.RecordDecl{Name: "marks", Kind: "struct", Definition: true}
+	for _, f := range []struct{ name, cType, width string }{
+		{"reserved_", "unsigned int", "3"},
+		{"type", "int", "2"},
+		{"range", "int", ""},
+	} {
+		field := &ast.FieldDecl{Name: f.name, Type: f.cType}
+		if f.width != "" {
+			field.AddChild(&ast.ConstantExpr{
+				Type: "int",
+				ChildNodes: []ast.Node{
+					&ast.IntegerLiteral{Type: "int", Value: f.width},
+				},
+			})
+		}
+		n.AddChild(field)
+	}
+
+	p := program.NewProgram()
+	if _, err := transpileRecordDecl(p, n); err != nil {
+		t.Fatal(err)
+	}
+
+	// struct marks m = {5, 1, 7};
+	expr, _, _, _, err := transpileToExpr(&ast.InitListExpr{
+		Type1: "struct marks",
+		ChildNodes: []ast.Node{
+			&ast.IntegerLiteral{Type: "unsigned int", Value: "5"},
+			&ast.IntegerLiteral{Type: "int", Value: "1"},
+			&ast.IntegerLiteral{Type: "int", Value: "7"},
+		},
+	}, p, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `func() marks {
+	var c2goStruct marks
+	c2goStruct.set_reserved_(uint32(5))
+	c2goStruct.set_type_(int32(1))
+	c2goStruct.range_ = int32(7)
+	return c2goStruct
+}()`
+	if actual := formatNodes(t, expr); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
@@ -84,7 +84,7 @@ func transpileIfStmt(n *ast.IfStmt, p *program.Program) (
 
 	// The condition in Go must always be a bool.
 	boolCondition, err := types.CastExpr(p, conditional, conditionalType, "bool")
-	p.AddMessage(p.GenerateWarningOrErrorMessage(err, n, boolCondition == nil))
+	p.AddWarningOrError(err, n, boolCondition == nil)
 
 	if boolCondition == nil {
 		boolCondition = util.NewNil()
@@ -139,7 +139,7 @@ func transpileForStmt(n *ast.ForStmt, p *program.Program) (
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("Cannot tranpile ForStmt: err = %v", err)
-			p.AddMessage(p.GenerateWarningMessage(err, n))
+			p.AddWarning(err, n)
 		}
 	}()
 
@@ -347,7 +347,7 @@ func transpileForStmt(n *ast.ForStmt, p *program.Program) (
 		preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
 
 		condition, err = types.CastExpr(p, condition, conditionType, "bool")
-		p.AddMessage(p.GenerateWarningOrErrorMessage(err, n, condition == nil))
+		p.AddWarningOrError(err, n, condition == nil)
 
 		if condition == nil {
 			condition = util.NewNil()
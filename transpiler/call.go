@@ -13,7 +13,6 @@ import (
 	"github.com/elliotchance/c2go/util"
 
 	goast "go/ast"
-	"go/parser"
 	"go/printer"
 	"go/token"
 )
@@ -69,6 +68,16 @@ func getName(p *program.Program, firstChild ast.Node) (name string, err error) {
 		return getName(p, fc.Children()[0])
 
 	case *ast.UnaryOperator:
+		// Dereferencing a pointer to a function pointer, like "(*pp)(1)".
+		// Dereferencing a function pointer does nothing.
+		if fc.Operator == "*" && types.IsFunctionPointer(fc.Type) {
+			var n string
+			n, err = getName(p, fc.Children()[0])
+			if err != nil {
+				return
+			}
+			return "(*" + n + ")", nil
+		}
 		return getName(p, fc.Children()[0])
 
 	case *ast.ImplicitCastExpr:
@@ -127,120 +136,12 @@ func transpileCallExpr(n *ast.CallExpr, p *program.Program) (
 	}
 	functionName = util.ConvertFunctionNameFromCtoGo(functionName)
 
-	if functionName == "__builtin_va_start" ||
-		functionName == "__builtin_va_end" {
-		// ignore function __builtin_va_start, __builtin_va_end
+	switch functionName {
+	case "__builtin_va_start", "__builtin_va_end", "__builtin_va_copy":
 		// see "Variadic functions"
-		return nil, "", nil, nil, nil
-	}
-
-	// function "calloc" from stdlib.c
-	if functionName == "calloc" && len(n.Children()) == 3 {
-		var allocType string
-		size, _, preStmts, postStmts, err := transpileToExpr(n.Children()[1], p, false)
-		if err != nil {
-			return nil, "", nil, nil, err
-		}
-		if v, ok := n.Children()[2].(*ast.UnaryExprOrTypeTraitExpr); ok {
-			allocType = v.Type2
-		} else {
-			return nil, "", nil, nil,
-				fmt.Errorf("Unsupport type '%T' in function calloc", n.Children()[2])
-		}
-		goType, err := types.ResolveType(p, allocType)
-		if err != nil {
-			return nil, "", nil, nil, err
-		}
-		return &goast.CallExpr{
-			Fun: util.NewIdent("make"),
-			Args: []goast.Expr{
-				&goast.ArrayType{Elt: goast.NewIdent(goType)},
-				size,
-			},
-		}, allocType + " *", preStmts, postStmts, nil
-	}
-
-	// function "qsort" from stdlib.h
-	if functionName == "qsort" && len(n.Children()) == 5 {
-		defer func() {
-			if err != nil {
-				err = fmt.Errorf("Function: qsort. err = %v", err)
-			}
-		}()
-		/*
-			CallExpr 0x2c6b1b0 <line:182:2, col:40> 'void'
-			|-ImplicitCastExpr 0x2c6b198 <col:2> 'void (*)(void *, size_t, size_t, __compar_fn_t)' <FunctionToPointerDecay>
-			| `-DeclRefExpr 0x2c6b070 <col:2> 'void (void *, size_t, size_t, __compar_fn_t)' Function 0x2bec110 'qsort' 'void (void *, size_t, size_t, __compar_fn_t)'
-			|-ImplicitCastExpr 0x2c6b210 <col:9> 'void *' <BitCast>
-			| `-ImplicitCastExpr 0x2c6b1f8 <col:9> 'int *' <ArrayToPointerDecay>
-			|   `-DeclRefExpr 0x2c6b098 <col:9> 'int [6]' lvalue Var 0x2c6a6c0 'values' 'int [6]'
-			|-ImplicitCastExpr 0x2c6b228 <col:17> 'size_t':'unsigned long' <IntegralCast>
-			| `-IntegerLiteral 0x2c6b0c0 <col:17> 'int' 6
-			|-UnaryExprOrTypeTraitExpr 0x2c6b0f8 <col:20, col:30> 'unsigned long' sizeof 'int'
-			`-ImplicitCastExpr 0x2c6b240 <col:33> 'int (*)(const void *, const void *)' <FunctionToPointerDecay>
-			  `-DeclRefExpr 0x2c6b118 <col:33> 'int (const void *, const void *)' Function 0x2c6aa70 'compare' 'int (const void *, const void *)'
-		*/
-		var element [4]goast.Expr
-		for i := 1; i < 5; i++ {
-			el, _, newPre, newPost, err := transpileToExpr(n.Children()[i], p, false)
-			if err != nil {
-				return nil, "", nil, nil, err
-			}
-			element[i-1] = el
-			preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
-		}
-		// found the C type
-		t := n.Children()[3].(*ast.UnaryExprOrTypeTraitExpr).Type2
-		t, err := types.ResolveType(p, t)
-		if err != nil {
-			return nil, "", nil, nil, err
-		}
-
-		var compareFunc string
-		if v, ok := element[3].(*goast.Ident); ok {
-			compareFunc = v.Name
-		} else {
-			return nil, "", nil, nil,
-				fmt.Errorf("golang ast for compare function have type %T, expect ast.Ident", element[3])
-		}
-
-		var varName string
-		id := extractArray(element[0])
-		if id != nil {
-			varName = id.Name
-		} else {
-			return nil, "", nil, nil, fmt.Errorf("cannot determine variable to be sorted")
-		}
-
-		p.AddImport("sort")
-		src := fmt.Sprintf(`package main
-		var %s func(a,b interface{})int
-		var temp = func(i, j int) bool {
-			c2goTempVarA := unsafe.Pointer(&%s[i])
-			c2goTempVarB := unsafe.Pointer(&%s[j])
-			return %s(c2goTempVarA, c2goTempVarB) <= 0
-		}`, compareFunc, varName, varName, compareFunc)
-
-		// Create the AST by parsing src.
-		fset := token.NewFileSet() // positions are relative to fset
-		f, err := parser.ParseFile(fset, "", src, 0)
-		if err != nil {
-			return nil, "", nil, nil, err
-		}
-
-		// AST tree part of code after "var temp = ..."
-		convertExpr := f.Decls[1].(*goast.GenDecl).Specs[0].(*goast.ValueSpec).Values[0]
-
-		return &goast.CallExpr{
-			Fun: &goast.SelectorExpr{
-				X:   goast.NewIdent("sort"),
-				Sel: goast.NewIdent("SliceStable"),
-			},
-			Args: []goast.Expr{
-				id,
-				convertExpr,
-			},
-		}, "", preStmts, postStmts, nil
+		var call *goast.CallExpr
+		call, preStmts, postStmts, err = transpileVaListCall(n, p, functionName)
+		return call, "void", preStmts, postStmts, err
 	}
 
 	// Get the function definition from it's name. The case where it is not
@@ -256,6 +157,12 @@ func transpileCallExpr(n *ast.CallExpr, p *program.Program) (
 		// definitions, so each time we see the CallExpr it will run this every
 		// time. This is so if we come across the real prototype later it will
 		// be handled correctly. Or at least "more" correctly.
+		//
+		// Builtin functions are never declared, so the call will not compile.
+		if strings.HasPrefix(functionName, "__builtin_") {
+			p.AddWarning(program.WithCode(program.CodeUnsupportedBuiltin,
+				fmt.Errorf("builtin function %s is not supported", functionName)), n)
+		}
 		functionDef = &program.FunctionDefinition{
 			Name: functionName,
 		}
@@ -272,9 +179,16 @@ func transpileCallExpr(n *ast.CallExpr, p *program.Program) (
 				}
 				fields, returns, err := types.ParseFunction(t)
 				if err != nil {
-					p.AddMessage(p.GenerateWarningMessage(fmt.Errorf("Cannot resolve function : %v", err), n))
+					p.AddWarning(fmt.Errorf("Cannot resolve function : %v", err), n)
 					return nil, "", nil, nil, err
 				}
+				// The variadic arguments are not cast.
+				if len(fields) > 0 && fields[len(fields)-1] == "..." {
+					fields = fields[:len(fields)-1]
+				}
+				if len(fields) == 1 && fields[0] == "void" {
+					fields = nil
+				}
 				functionDef.ReturnType = returns[0]
 				functionDef.ArgumentTypes = fields
 			}
@@ -378,9 +292,7 @@ func transpileCallExpr(n *ast.CallExpr, p *program.Program) (
 			} else {
 				realArg, err = types.CastExpr(p, realArg, argTypes[i],
 					functionDef.ArgumentTypes[i])
-				p.AddMessage(
-					p.GenerateWarningOrErrorMessage(err, n, realArg == nil),
-				)
+				p.AddWarningOrError(err, n, realArg == nil)
 
 				if realArg == nil {
 					realArg = util.NewNil()
@@ -405,7 +317,7 @@ func transpileCallExpr(n *ast.CallExpr, p *program.Program) (
 				a, err = types.CastExpr(p, a, argTypes[i],
 					functionDef.ArgumentTypes[i])
 
-				if p.AddMessage(p.GenerateWarningMessage(err, n)) {
+				if p.AddWarning(err, n) {
 					a = util.NewNil()
 				}
 			}
@@ -415,20 +327,6 @@ func transpileCallExpr(n *ast.CallExpr, p *program.Program) (
 					fmt.Errorf("Argument is nil in function : %s", functionName)
 			}
 
-			if len(functionDef.ArgumentTypes) > i {
-				if !types.IsPointer(p, functionDef.ArgumentTypes[i]) {
-					if strings.HasPrefix(functionDef.ArgumentTypes[i], "union ") {
-						a = &goast.CallExpr{
-							Fun: &goast.SelectorExpr{
-								X:   a,
-								Sel: goast.NewIdent("copy"),
-							},
-							Lparen: 1,
-						}
-					}
-				}
-			}
-
 			realArgs = append(realArgs, a)
 		}
 	}
@@ -449,33 +347,47 @@ func transpileCallExpr(n *ast.CallExpr, p *program.Program) (
 		return nil, "", preStmts, postStmts, nil
 	}
 
-	return util.NewCallExpr(functionName, realArgs...),
-		functionDef.ReturnType, preStmts, postStmts, nil
-}
-
-func extractArray(expr goast.Expr) *goast.Ident {
-	if v, ok := expr.(*goast.Ident); ok {
-		return v
-	}
-	if se, ok := expr.(*goast.SliceExpr); ok {
-		if v, ok2 := se.X.(*goast.Ident); ok2 {
-			return v
+	call := newMemoryCall(n, functionName, realArgs...)
+
+	// The elements of an array that is sorted by qsort() must be swapped with
+	// their Go type, because they may contain pointers:
+	//
+	//     qsort(words, 3, sizeof(char *), compare)
+	//     noarch.QsortOf[*byte](unsafe.Pointer(&words[0]), 3, 8, compare)
+	if functionName == "noarch.Qsort" && len(n.Children()) == 5 {
+		if t := getQsortElementType(p, n.Children()[3]); t != "" {
+			call.Fun = &goast.IndexExpr{
+				X:     goast.NewIdent("noarch.QsortOf"),
+				Index: goast.NewIdent(t),
+			}
 		}
 	}
-	if ce, ok2 := expr.(*goast.CallExpr); ok2 && len(ce.Args) == 1 {
-		if fid, ok3 := ce.Fun.(*goast.Ident); !ok3 || fid.Name != "unsafe.Pointer" {
-			return nil
-		}
-		if ue, ok3 := ce.Args[0].(*goast.UnaryExpr); !ok3 || ue.Op != token.AND {
-			return nil
-		} else if idx, ok4 := ue.X.(*goast.IndexExpr); ok4 {
-			if index, ok5 := idx.Index.(*goast.BasicLit); !ok5 || index.Value != "0" {
-				return nil
+
+	return call, functionDef.ReturnType, preStmts, postStmts, nil
+}
+
+// getQsortElementType returns the Go type of the elements that qsort() sorts,
+// if the size argument is a sizeof(). Otherwise an empty string is returned.
+func getQsortElementType(p *program.Program, size ast.Node) string {
+	for {
+		switch v := size.(type) {
+		case *ast.ImplicitCastExpr:
+			size = v.Children()[0]
+			continue
+		case *ast.ParenExpr:
+			size = v.Children()[0]
+			continue
+		case *ast.UnaryExprOrTypeTraitExpr:
+			if v.Function != "sizeof" {
+				return ""
 			}
-			if id, ok5 := idx.X.(*goast.Ident); ok5 {
-				return id
+			t, err := types.ResolveType(p, getSizeofType(v))
+			if err != nil || t == "" || strings.Contains(t, "interface{}") {
+				return ""
 			}
+			return t
 		}
+
+		return ""
 	}
-	return nil
 }
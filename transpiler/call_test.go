@@ -0,0 +1,117 @@
+package transpiler
+
+import (
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestQsortElementType(t *testing.T) {
+	p := program.NewProgram()
+	p.IncludeHeaders = []program.IncludeHeader{{HeaderName: "/usr/include/stdlib.h"}}
+
+	qsort := func(size ast.Node) *ast.CallExpr {
+		return newLibraryCall(ast.Position{}, "qsort",
+			"void (void *, unsigned long, unsigned long, __compar_fn_t)",
+			&ast.ImplicitCastExpr{
+				Type: "void *",
+				Kind: "BitCast",
+				ChildNodes: []ast.Node{&ast.ImplicitCastExpr{
+					Type: "char **",
+					Kind: ast.ImplicitCastExprArrayToPointerDecay,
+					ChildNodes: []ast.Node{
+						&ast.DeclRefExpr{Type: "char *[3]", For: "Var", Name: "words"},
+					},
+				}},
+			},
+			intLiteral("3"),
+			size,
+			&ast.ImplicitCastExpr{
+				Type: "int (*)(const void *, const void *)",
+				Kind: ast.ImplicitCastExprFunctionToPointerDecay,
+				ChildNodes: []ast.Node{&ast.DeclRefExpr{
+					Type: "int (const void *, const void *)", For: "Function", Name: "compare",
+				}},
+			},
+		)
+	}
+
+	tests := []struct {
+		name     string
+		node     ast.Node
+		expected string
+	}{
+		{
+			// qsort(words, 3, sizeof(char *), compare);
+			name: "sizeof type",
+			node: qsort(&ast.UnaryExprOrTypeTraitExpr{
+				Type1: "unsigned long", Function: "sizeof", Type2: "char *",
+			}),
+			expected: `noarch.QsortOf[*byte](unsafe.Pointer(&words[0]), int32(3), int32(8), compare)`,
+		},
+		{
+			// qsort(words, 3, sizeof(words[0]), compare);
+			name: "sizeof expression",
+			node: qsort(&ast.UnaryExprOrTypeTraitExpr{
+				Type1:    "unsigned long",
+				Function: "sizeof",
+				ChildNodes: []ast.Node{&ast.ParenExpr{
+					Type: "char *",
+					ChildNodes: []ast.Node{&ast.ArraySubscriptExpr{
+						Type: "char *",
+						ChildNodes: []ast.Node{
+							&ast.DeclRefExpr{Type: "char *[3]", For: "Var", Name: "words"},
+							intLiteral("0"),
+						},
+					}},
+				}},
+			}),
+			expected: `noarch.QsortOf[*byte](unsafe.Pointer(&words[0]), int32(3), int32(8), compare)`,
+		},
+		{
+			// qsort(words, 3, size, compare);
+			name: "unknown type",
+			node: qsort(&ast.ImplicitCastExpr{
+				Type: "unsigned long",
+				Kind: "LValueToRValue",
+				ChildNodes: []ast.Node{
+					&ast.DeclRefExpr{Type: "unsigned long", For: "Var", Name: "size"},
+				},
+			}),
+			expected: `noarch.Qsort(unsafe.Pointer(&words[0]), int32(3), int32(size), compare)`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, _, _, _, err := transpileToExpr(tc.node, p, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := formatNodes(t, expr); got != tc.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestUnsupportedBuiltinCall(t *testing.T) {
+	p := program.NewProgram()
+
+	// __builtin_ctz(8);
+	call := newLibraryCall(ast.Position{Line: 3}, "__builtin_ctz",
+		"int (unsigned int)", intLiteral("8"))
+	if _, _, _, _, err := transpileCallExpr(call, p); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := p.Messages()
+	if len(messages) != 1 || messages[0].Code != program.CodeUnsupportedBuiltin {
+		t.Fatalf("expected a %s warning, got %v", program.CodeUnsupportedBuiltin, messages)
+	}
+	if messages[0].Position.Line != 3 {
+		t.Errorf("expected the warning on line 3, got %d", messages[0].Position.Line)
+	}
+}
@@ -46,12 +46,13 @@ func newFunctionField(p *program.Program, name, cType string) (_ *goast.Field, e
 	funcType.Params = &goast.FieldList{
 		List: argFieldList,
 	}
-	funcType.Results = &goast.FieldList{
-		List: []*goast.Field{
+	funcType.Results = &goast.FieldList{}
+	if ret[0] != "" {
+		funcType.Results.List = []*goast.Field{
 			&goast.Field{
 				Type: goast.NewIdent(ret[0]),
 			},
-		},
+		}
 	}
 	field.Type = funcType
 
@@ -81,7 +82,7 @@ func transpileFieldDecl(p *program.Program, n *ast.FieldDecl) (field *goast.Fiel
 	}
 
 	fieldType, err := types.ResolveType(p, n.Type)
-	p.AddMessage(p.GenerateWarningMessage(err, n))
+	p.AddWarning(err, n)
 
 	// TODO: The name of a variable or field cannot be a reserved word
 	// https://github.com/elliotchance/c2go/issues/83
@@ -93,7 +94,7 @@ func transpileFieldDecl(p *program.Program, n *ast.FieldDecl) (field *goast.Fiel
 	arrayType, arraySize := types.GetArrayTypeAndSize(n.Type)
 	if arraySize != -1 {
 		fieldType, err = types.ResolveType(p, arrayType)
-		p.AddMessage(p.GenerateWarningMessage(err, n))
+		p.AddWarning(err, n)
 		fieldType = fmt.Sprintf("[%d]%s", arraySize, fieldType)
 		err = nil
 	}
@@ -126,14 +127,27 @@ func transpileRecordDecl(p *program.Program, n *ast.RecordDecl) (decls []goast.D
 
 	var fields []*goast.Field
 
+	// The bit-fields of a struct are stored in the fields that are inserted
+	// at the position of the first bit-field of each sequence (marked with
+	// nil).
+	var isBitField bool
+
 	for pos := range n.Children() {
 		switch field := n.Children()[pos].(type) {
 		case *ast.FieldDecl:
 			field.Type = types.GenerateCorrectType(field.Type)
 			field.Type2 = types.GenerateCorrectType(field.Type2)
+			if n.Kind != "union" && program.BitWidth(field) >= 0 {
+				if !isBitField {
+					fields = append(fields, nil)
+				}
+				isBitField = true
+				continue
+			}
+			isBitField = false
 			f, err := transpileFieldDecl(p, field)
 			if err != nil {
-				p.AddMessage(p.GenerateWarningMessage(err, field))
+				p.AddWarning(err, field)
 			} else {
 				fields = append(fields, f)
 			}
@@ -146,7 +160,7 @@ func transpileRecordDecl(p *program.Program, n *ast.RecordDecl) (decls []goast.D
 					field.Name = string(([]byte(inField.Type))[len("union "):])
 					declUnion, err := transpileRecordDecl(p, field)
 					if err != nil {
-						p.AddMessage(p.GenerateWarningMessage(err, field))
+						p.AddWarning(err, field)
 					}
 					pos++
 					decls = append(decls, declUnion...)
@@ -173,13 +187,13 @@ func transpileRecordDecl(p *program.Program, n *ast.RecordDecl) (decls []goast.D
 					}
 
 					if strings.Contains(field.Name, "[") {
-						p.AddMessage(p.GenerateWarningMessage(
-							fmt.Errorf("Not acceptable name of struct %s", field.Name), n))
+						p.AddWarning(
+							fmt.Errorf("Not acceptable name of struct %s", field.Name), n)
 						continue
 					}
 					declStruct, err := transpileRecordDecl(p, field)
 					if err != nil {
-						p.AddMessage(p.GenerateWarningMessage(err, field))
+						p.AddWarning(err, field)
 					}
 					pos++
 					decls = append(decls, declStruct...)
@@ -188,7 +202,7 @@ func transpileRecordDecl(p *program.Program, n *ast.RecordDecl) (decls []goast.D
 				decls, err = transpileRecordDecl(p, field)
 				if err != nil {
 					message := fmt.Sprintf("could not parse %v", field)
-					p.AddMessage(p.GenerateWarningMessage(errors.New(message), field))
+					p.AddWarning(errors.New(message), field)
 				}
 			}
 
@@ -200,7 +214,7 @@ func transpileRecordDecl(p *program.Program, n *ast.RecordDecl) (decls []goast.D
 
 		default:
 			message := fmt.Sprintf("could not parse %v", field)
-			p.AddMessage(p.GenerateWarningMessage(errors.New(message), field))
+			p.AddWarning(errors.New(message), field)
 		}
 	}
 
@@ -211,28 +225,38 @@ func transpileRecordDecl(p *program.Program, n *ast.RecordDecl) (decls []goast.D
 		p.Structs["struct "+s.Name] = s
 	}
 	if s.IsUnion {
-		// Union size
-		var size int
-		size, err = types.SizeOf(p, "union "+name)
+		// Declaration for implementing union type
+		d, err2 := transpileUnion(p, n, name, s, fields)
+		if err2 != nil {
+			// The union cannot be used, but the rest of the program can
+			// still be transpiled.
+			message := fmt.Sprintf("could not transpile the type `union %s` for that reason: %s", name, err2)
+			p.AddWarning(errors.New(message), n)
+			return
+		}
+		decls = append(decls, d...)
+		return
+	}
 
-		// In normal case no error is returned,
+	var methods []goast.Decl
+	if s.HasBitFields() {
+		var bitFields [][]*goast.Field
+		bitFields, methods, err = transpileBitFields(p, name, s)
 		if err != nil {
-			// but if we catch one, send it as a warning
-			message := fmt.Sprintf("could not determine the size of type `union %s` for that reason: %s", name, err)
-			p.AddMessage(p.GenerateWarningMessage(errors.New(message), n))
-		} else {
-			// So, we got size, then
-			// Add imports needed
-			p.AddImports("unsafe")
-
-			// Declaration for implementing union type
-			d, err2 := transpileUnion(name, size, fields)
-			if err2 != nil {
-				return nil, err2
+			p.AddWarning(err, n)
+			err = nil
+		}
+
+		var all []*goast.Field
+		for _, f := range fields {
+			if f != nil {
+				all = append(all, f)
+			} else if len(bitFields) > 0 {
+				all = append(all, bitFields[0]...)
+				bitFields = bitFields[1:]
 			}
-			decls = append(decls, d...)
 		}
-		return
+		fields = all
 	}
 
 	decls = append(decls, &goast.GenDecl{
@@ -248,6 +272,7 @@ func transpileRecordDecl(p *program.Program, n *ast.RecordDecl) (decls []goast.D
 			},
 		},
 	})
+	decls = append(decls, methods...)
 
 	return
 }
@@ -270,7 +295,7 @@ func transpileTypedefDecl(p *program.Program, n *ast.TypedefDecl) (decls []goast
 		var field *goast.Field
 		field, err = newFunctionField(p, n.Name, n.Type)
 		if err != nil {
-			p.AddMessage(p.GenerateWarningMessage(err, n))
+			p.AddWarning(err, n)
 		} else {
 			// registration type
 			p.TypedefType[n.Name] = n.Type
@@ -319,7 +344,7 @@ func transpileTypedefDecl(p *program.Program, n *ast.TypedefDecl) (decls []goast
 
 	resolvedType, err := types.ResolveType(p, n.Type)
 	if err != nil {
-		p.AddMessage(p.GenerateWarningMessage(err, n))
+		p.AddWarning(err, n)
 	}
 
 	// There is a case where the name of the type is also the definition,
@@ -368,6 +393,11 @@ func transpileTypedefDecl(p *program.Program, n *ast.TypedefDecl) (decls []goast
 				"quot": intType,
 				"rem":  intType,
 			},
+			FieldNames: []string{"quot", "rem"},
+			Members: []program.Member{
+				{Name: "quot", Type: intType, BitWidth: -1},
+				{Name: "rem", Type: intType, BitWidth: -1},
+			},
 		}
 	}
 
@@ -471,14 +501,15 @@ func transpileVarDecl(p *program.Program, n *ast.VarDecl) (
 		// DeclStmt 0x2fd87e0 <line:442:2, col:14>
 		// `-VarDecl 0x2fd8780 <col:2, col:10> col:10 used args 'va_list':'struct __va_list_tag [1]'
 		// Result:
-		// ... - convert to - c2goArgs ...interface{}
-		// var args = c2goArgs
+		// var args noarch.VaList
+		// The va_list is started by va_start(). See transpileVaListCall.
+		p.AddImport("github.com/elliotchance/c2go/noarch")
 		return []goast.Decl{&goast.GenDecl{
 			Tok: token.VAR,
 			Specs: []goast.Spec{
 				&goast.ValueSpec{
-					Names:  []*goast.Ident{util.NewIdent("c2goVaList")},
-					Values: []goast.Expr{util.NewVaListTag()},
+					Names: []*goast.Ident{util.NewIdent(n.Name)},
+					Type:  util.NewTypeIdent("noarch.VaList"),
 				},
 			},
 		}}, "", nil
@@ -540,18 +571,33 @@ func transpileVarDecl(p *program.Program, n *ast.VarDecl) (
 		var fields, returns []string
 		fields, returns, err = types.SeparateFunction(p, n.Type)
 		if err != nil {
-			p.AddMessage(p.GenerateErrorMessage(fmt.Errorf("Cannot resolve function : %v", err), n))
+			p.AddError(fmt.Errorf("Cannot resolve function : %v", err), n)
 			err = nil // Error is ignored
 			return
 		}
 		functionType := GenerateFuncType(fields, returns)
 		nameVar1 := n.Name
+
+		// The function pointer may be initialized with a function, like:
+		// int (*f)(int) = abs;
+		var defaultValue []goast.Expr
+		var newPre, newPost []goast.Stmt
+		defaultValue, _, newPre, newPost, err = getDefaultValueForVar(p, n)
+		if err != nil {
+			p.AddError(err, n)
+			defaultValue = nil
+		}
+		if len(newPre) != 0 || len(newPost) != 0 {
+			p.AddError(fmt.Errorf("Not acceptable length of Stmt : pre(%d), post(%d)", len(newPre), len(newPost)), n)
+		}
+
 		decls = append(decls, &goast.GenDecl{
 			Tok: token.VAR,
 			Specs: []goast.Spec{&goast.ValueSpec{
-				Names: []*goast.Ident{{Name: nameVar1}},
-				Type:  functionType,
-				Doc:   p.GetMessageComments(),
+				Names:  []*goast.Ident{{Name: nameVar1}},
+				Type:   functionType,
+				Values: defaultValue,
+				Doc:    p.GetMessageComments(),
 			},
 			}})
 		err = nil
@@ -563,7 +609,7 @@ func transpileVarDecl(p *program.Program, n *ast.VarDecl) (
 
 	theType, err = types.ResolveType(p, n.Type)
 	if err != nil {
-		p.AddMessage(p.GenerateErrorMessage(fmt.Errorf("Cannot resolve type %s : %v", theType, err), n))
+		p.AddError(fmt.Errorf("Cannot resolve type %s : %v", theType, err), n)
 		err = nil // Error is ignored
 	}
 
@@ -587,7 +633,7 @@ func transpileVarDecl(p *program.Program, n *ast.VarDecl) (
 
 	defaultValue, _, newPre, newPost, err := getDefaultValueForVar(p, n)
 	if err != nil {
-		p.AddMessage(p.GenerateErrorMessage(err, n))
+		p.AddError(err, n)
 		err = nil // Error is ignored
 	}
 	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
@@ -600,7 +646,7 @@ func transpileVarDecl(p *program.Program, n *ast.VarDecl) (
 			var goArrayType string
 			goArrayType, err = types.ResolveType(p, arrayType)
 			if err != nil {
-				p.AddMessage(p.GenerateErrorMessage(err, n))
+				p.AddError(err, n)
 				err = nil // Error is ignored
 			}
 
@@ -619,7 +665,7 @@ func transpileVarDecl(p *program.Program, n *ast.VarDecl) (
 				var list goast.Expr
 				list, _, err = transpileInitListExpr(iniList, p)
 				if err != nil {
-					p.AddMessage(p.GenerateErrorMessage(err, n))
+					p.AddError(err, n)
 					err = nil // Error is ignored
 				} else {
 					defaultValue = []goast.Expr{list}
@@ -629,7 +675,7 @@ func transpileVarDecl(p *program.Program, n *ast.VarDecl) (
 	}
 
 	if len(preStmts) != 0 || len(postStmts) != 0 {
-		p.AddMessage(p.GenerateErrorMessage(fmt.Errorf("Not acceptable length of Stmt : pre(%d), post(%d)", len(preStmts), len(postStmts)), n))
+		p.AddError(fmt.Errorf("Not acceptable length of Stmt : pre(%d), post(%d)", len(preStmts), len(postStmts)), n)
 	}
 
 	var typeResult goast.Expr
@@ -125,7 +125,7 @@ func transpileEnumDecl(p *program.Program, n *ast.EnumDecl) (decls []goast.Decl,
 				val, newPre, newPost = transpileEnumConstantDecl(p, c)
 
 				if len(newPre) > 0 || len(newPost) > 0 {
-					p.AddMessage(p.GenerateWarningMessage(fmt.Errorf("Check - added in code : (%d)(%d)", len(newPre), len(newPost)), n))
+					p.AddWarning(fmt.Errorf("Check - added in code : (%d)(%d)", len(newPre), len(newPost)), n)
 				}
 
 				preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
@@ -146,6 +146,18 @@ func transpileEnumDecl(p *program.Program, n *ast.EnumDecl) (decls []goast.Decl,
 
 				switch v := val.Values[0].(type) {
 				case *goast.Ident:
+					// The value is the name of a constant from a macro.
+					if macro := p.GetMacro(v.Name); macro != nil {
+						e = val
+						counter, err = strconv.Atoi(macro.Value)
+						if err != nil {
+							p.AddWarning(fmt.Errorf("Cannot parse '%s' in macro %s",
+								macro.Value, macro.Name), n)
+						}
+						counter++
+						break
+					}
+
 					e = &goast.ValueSpec{
 						Names:  []*goast.Ident{{Name: c.Name}},
 						Values: []goast.Expr{&goast.BasicLit{Kind: token.INT, Value: strconv.Itoa(counter)}},
@@ -160,8 +172,8 @@ func transpileEnumDecl(p *program.Program, n *ast.EnumDecl) (decls []goast.Decl,
 					if err != nil {
 						e = val
 						counter++
-						p.AddMessage(p.GenerateWarningMessage(
-							fmt.Errorf("Cannot parse '%s' in BasicLit", v.Value), n))
+						p.AddWarning(
+							fmt.Errorf("Cannot parse '%s' in BasicLit", v.Value), n)
 						break
 					}
 					counter = value
@@ -171,7 +183,7 @@ func transpileEnumDecl(p *program.Program, n *ast.EnumDecl) (decls []goast.Decl,
 					e = val
 					if id, ok := v.Fun.(*goast.Ident); !ok || len(v.Args) != 1 ||
 						!types.IsGoIntegerType(id.Name) {
-						p.AddMessage(p.GenerateWarningMessage(fmt.Errorf("Add support of continues counter for type : *goast.CallExpr != integer cast"), n))
+						p.AddWarning(fmt.Errorf("Add support of continues counter for type : *goast.CallExpr != integer cast"), n)
 						break
 					}
 					if lit, ok := v.Args[0].(*goast.BasicLit); ok {
@@ -180,18 +192,18 @@ func transpileEnumDecl(p *program.Program, n *ast.EnumDecl) (decls []goast.Decl,
 						if err != nil {
 							e = val
 							counter++
-							p.AddMessage(p.GenerateWarningMessage(
-								fmt.Errorf("Cannot parse '%s' in BasicLit", lit.Value), n))
+							p.AddWarning(
+								fmt.Errorf("Cannot parse '%s' in BasicLit", lit.Value), n)
 							break
 						}
 						counter = value
 						counter++
 					} else {
-						p.AddMessage(p.GenerateWarningMessage(fmt.Errorf("Add support of continues counter for type : *goast.CallExpr (integer cast) with argument type : %T", v), n))
+						p.AddWarning(fmt.Errorf("Add support of continues counter for type : *goast.CallExpr (integer cast) with argument type : %T", v), n)
 					}
 				default:
 					e = val
-					p.AddMessage(p.GenerateWarningMessage(fmt.Errorf("Add support of continues counter for type : %T", v), n))
+					p.AddWarning(fmt.Errorf("Add support of continues counter for type : %T", v), n)
 				}
 
 				decls = append(decls, &goast.GenDecl{
@@ -210,7 +222,7 @@ func transpileEnumDecl(p *program.Program, n *ast.EnumDecl) (decls []goast.Decl,
 	theType, err := types.ResolveType(p, "int")
 	if err != nil {
 		// by defaults enum in C is INT
-		p.AddMessage(p.GenerateWarningMessage(err, n))
+		p.AddWarning(err, n)
 	}
 
 	// Create alias of enum for int
@@ -278,7 +290,7 @@ func transpileEnumDecl(p *program.Program, n *ast.EnumDecl) (decls []goast.Decl,
 			if integr, ok := c.(*ast.EnumConstantDecl).ChildNodes[0].(*ast.IntegerLiteral); ok {
 				is, err := strconv.ParseInt(integr.Value, 10, 64)
 				if err != nil {
-					p.AddMessage(p.GenerateWarningMessage(err, n))
+					p.AddWarning(err, n)
 				}
 				counter = int(is)
 			}
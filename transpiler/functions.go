@@ -81,11 +81,14 @@ func transpileFunctionDecl(n *ast.FunctionDecl, p *program.Program) (
 	// is a CompoundStmt (since it is not valid to have a function body without
 	// curly brackets).
 	functionBody := getFunctionBody(n)
+	var staticDecls []goast.Decl
 	if functionBody != nil {
+		staticDecls = hoistStaticLocals(p, n, functionBody)
+
 		var pre, post []goast.Stmt
 		body, pre, post, err = transpileToBlockStmt(functionBody, p)
 		if err != nil || len(pre) > 0 || len(post) > 0 {
-			p.AddMessage(p.GenerateErrorMessage(fmt.Errorf("Not correct result in function %s body: err = %v", n.Name, err), n))
+			p.AddError(fmt.Errorf("Not correct result in function %s body: err = %v", n.Name, err), n)
 			err = nil // Error is ignored
 		}
 	}
@@ -122,7 +125,15 @@ func transpileFunctionDecl(n *ast.FunctionDecl, p *program.Program) (
 		}
 
 		t, err := types.ResolveType(p, f.ReturnType)
-		p.AddMessage(p.GenerateWarningMessage(err, n))
+		p.AddWarning(err, n)
+
+		var params []string
+		for _, field := range fieldList.List {
+			for _, name := range field.Names {
+				params = append(params, name.Name)
+			}
+		}
+		lowerGotoStmts(p, n, body, params)
 
 		if p.Function != nil && p.Function.Name == "main" {
 			// main() function does not have a return type.
@@ -238,10 +249,22 @@ func transpileFunctionDecl(n *ast.FunctionDecl, p *program.Program) (
 			// Prepend statements for main().
 			body.List = append(prependStmtsInMain, body.List...)
 
+			// Returning from the end of main() is the same as "return 0".
+			var isReturn bool
+			if len(body.List) > 0 {
+				_, isReturn = body.List[len(body.List)-1].(*goast.ReturnStmt)
+			}
+			if !isReturn {
+				p.AddImport("github.com/elliotchance/c2go/noarch")
+				body.List = append(body.List, returnFromMain())
+			}
+
 			// The main() function does not have arguments or a return value.
 			fieldList = &goast.FieldList{}
 		}
 
+		lowerSetjmp(p, n, body, t)
+
 		// Each function MUST have "ReturnStmt",
 		// except function without return type
 		var addReturnName bool
@@ -258,12 +281,21 @@ func transpileFunctionDecl(n *ast.FunctionDecl, p *program.Program) (
 			Type: util.NewFuncType(fieldList, t, addReturnName),
 			Body: body,
 		})
+
+		// The static local variables are declared after the function.
+		decls = append(decls, staticDecls...)
 	}
 
 	err = nil
 	return
 }
 
+// returnFromMain returns the statement that finishes the program when main()
+// returns. It runs the same cleanup as exit(), see noarch.ReturnFromMain.
+func returnFromMain() goast.Stmt {
+	return util.NewExprStmt(util.NewCallExpr("noarch.ReturnFromMain"))
+}
+
 // getFieldList returns the parameters of a C function as a Go AST FieldList.
 func getFieldList(f *ast.FunctionDecl, p *program.Program) (_ *goast.FieldList, err error) {
 	defer func() {
@@ -277,18 +309,14 @@ func getFieldList(f *ast.FunctionDecl, p *program.Program) (_ *goast.FieldList,
 			if types.IsFunction(v.Type) {
 				field, err := newFunctionField(p, v.Name, v.Type)
 				if err != nil {
-					p.AddMessage(p.GenerateWarningMessage(err, v))
+					p.AddWarning(err, v)
 					continue
 				}
 				r = append(r, field)
 				continue
 			}
-			// when passing va_list to a function, always name it c2goVaList
-			if v.Type == "struct __va_list_tag *" {
-				v.Name = "c2goVaList"
-			}
 			t, err := types.ResolveType(p, v.Type)
-			p.AddMessage(p.GenerateWarningMessage(err, f))
+			p.AddWarning(err, f)
 
 			r = append(r, &goast.Field{
 				Names: []*goast.Ident{util.NewIdent(v.Name)},
@@ -298,9 +326,9 @@ func getFieldList(f *ast.FunctionDecl, p *program.Program) (_ *goast.FieldList,
 	}
 
 	// for function argument: ...
-	if strings.Contains(f.Type, "...") {
+	if isVariadicFunction(f.Type) {
 		r = append(r, &goast.Field{
-			Names: []*goast.Ident{util.NewIdent("c2goArgs")},
+			Names: []*goast.Ident{util.NewIdent(variadicArgsName)},
 			Type: &goast.Ellipsis{
 				Ellipsis: 1,
 				Elt: &goast.InterfaceType{
@@ -329,7 +357,11 @@ func transpileReturnStmt(n *ast.ReturnStmt, p *program.Program) (
 	// There may not be a return value. Then we don't have to both ourselves
 	// with all the rest of the logic below.
 	if len(n.Children()) == 0 {
-		return &goast.ReturnStmt{}, nil, nil, nil
+		if p.Function != nil && p.Function.Name == "main" {
+			p.AddImport("github.com/elliotchance/c2go/noarch")
+			preStmts = append(preStmts, returnFromMain())
+		}
+		return &goast.ReturnStmt{}, preStmts, nil, nil
 	}
 
 	var eType string
@@ -345,21 +377,22 @@ func transpileReturnStmt(n *ast.ReturnStmt, p *program.Program) (
 	f := p.GetFunctionDefinition(p.Function.Name)
 
 	t, err := types.CastExpr(p, e, eType, f.ReturnType)
-	if p.AddMessage(p.GenerateWarningMessage(err, n)) {
+	if p.AddWarning(err, n) {
 		t = util.NewNil()
 	}
 
 	results := []goast.Expr{t}
 
-	// main() function is not allowed to return a result. Use os.Exit if
+	// main() function is not allowed to return a result. Use noarch.Exit if
 	// non-zero.
 	if p.Function != nil && p.Function.Name == "main" {
+		p.AddImport("github.com/elliotchance/c2go/noarch")
 		litExpr, isLiteral := getReturnLiteral(e)
 		if !isLiteral || (isLiteral && litExpr.Value != "0") {
-			p.AddImport("os")
-			return util.NewExprStmt(util.NewCallExpr("os.Exit", util.NewCallExpr("int", results...))),
+			return util.NewExprStmt(util.NewCallExpr("noarch.Exit", results...)),
 				preStmts, postStmts, nil
 		}
+		preStmts = append(preStmts, returnFromMain())
 		results = []goast.Expr{}
 	}
 
@@ -396,7 +429,12 @@ func getFunctionReturnType(f string) string {
 	//     int (float)
 	//
 	// The arguments will handle themselves, we only care about the return type
-	// ('int' in this case)
+	// ('int' in this case). A function that returns a function pointer has a C
+	// type like "int (*(float))(double)".
+	if _, returns, err := types.ParseFunction(f); err == nil {
+		return returns[0]
+	}
+
 	returnType := strings.TrimSpace(strings.Split(f, "(")[0])
 
 	if returnType == "" {
@@ -3,12 +3,16 @@
 package transpiler
 
 import (
+	"fmt"
 	goast "go/ast"
 	"go/token"
+	"sort"
+	"strconv"
 
 	"github.com/elliotchance/c2go/ast"
 	"github.com/elliotchance/c2go/program"
 	"github.com/elliotchance/c2go/util"
+	"golang.org/x/tools/go/ast/astutil"
 )
 
 func transpileLabelStmt(n *ast.LabelStmt, p *program.Program) (*goast.LabeledStmt, []goast.Stmt, []goast.Stmt, error) {
@@ -35,3 +39,1012 @@ func transpileGotoStmt(n *ast.GotoStmt, p *program.Program) (*goast.BranchStmt,
 		Tok:   token.GOTO,
 	}, nil
 }
+
+// lowerGotoStmts makes the gotos in the body of a function valid Go. params
+// are the names of the parameters of the function.
+//
+// C allows a goto to jump forward over declarations and into blocks, but Go
+// does not. This is very common for error handling:
+//
+//     if (open() < 0) goto out;
+//     int fd = ...;
+//     ...
+//     out:
+//     return result;
+//
+// If any of the gotos are not valid in Go then the declarations in the
+// function are moved to the top of the function (the declaration is replaced
+// with an assignment). If a goto still jumps into a block the statements
+// leading to the label are rewritten into a loop over a switch of states:
+//
+//     var c2goGotoState int
+//     c2goGotoLoop:
+//     for {
+//         switch c2goGotoState {
+//         case 0:
+//             ...
+//             c2goGotoState = 2
+//             continue c2goGotoLoop
+//         ...
+//         }
+//         break
+//     }
+//
+// Labels that are not used are also removed because they are not allowed in
+// Go.
+func lowerGotoStmts(p *program.Program, n ast.Node, body *goast.BlockStmt,
+	params []string) {
+	if body == nil {
+		return
+	}
+
+	a := analyzeGotoStmts(body)
+	removeUnusedLabels(body, a)
+	if len(a.invalidLabels()) == 0 {
+		return
+	}
+
+	hoistDeclarations(body, params)
+
+	a = analyzeGotoStmts(body)
+	invalid := a.invalidLabels()
+	if len(invalid) == 0 {
+		return
+	}
+
+	if err := flattenGotoStmts(body, a, invalid); err != nil {
+		p.AddWarning(fmt.Errorf("cannot transpile goto: %v", err), n)
+	}
+}
+
+// gotoBlock is a list of statements that is a block in Go.
+type gotoBlock struct {
+	list []goast.Stmt
+
+	// parent is the block that contains the block, and index is the index of
+	// the statement in the parent that contains the block.
+	parent *gotoBlock
+	index  int
+}
+
+// gotoPosition is the location of a statement.
+type gotoPosition struct {
+	block *gotoBlock
+	index int
+}
+
+// gotoAnalysis is the location of the labels and gotos in a function.
+type gotoAnalysis struct {
+	labels map[string]gotoPosition
+	gotos  map[string][]gotoPosition
+
+	// branches are the labels used by a break or continue.
+	branches map[string]bool
+}
+
+func analyzeGotoStmts(body *goast.BlockStmt) *gotoAnalysis {
+	a := &gotoAnalysis{
+		labels:   map[string]gotoPosition{},
+		gotos:    map[string][]gotoPosition{},
+		branches: map[string]bool{},
+	}
+	a.block(&gotoBlock{list: body.List, index: -1})
+
+	return a
+}
+
+func (a *gotoAnalysis) block(b *gotoBlock) {
+	for i, stmt := range b.list {
+		a.stmt(stmt, b, i)
+	}
+}
+
+func (a *gotoAnalysis) stmt(stmt goast.Stmt, b *gotoBlock, i int) {
+	nested := func(list []goast.Stmt) {
+		a.block(&gotoBlock{list: list, parent: b, index: i})
+	}
+
+	switch s := stmt.(type) {
+	case *goast.LabeledStmt:
+		a.labels[s.Label.Name] = gotoPosition{b, i}
+		a.stmt(s.Stmt, b, i)
+
+	case *goast.BranchStmt:
+		if s.Label == nil {
+			return
+		}
+		if s.Tok == token.GOTO {
+			a.gotos[s.Label.Name] = append(a.gotos[s.Label.Name],
+				gotoPosition{b, i})
+		} else {
+			a.branches[s.Label.Name] = true
+		}
+
+	case *goast.BlockStmt:
+		nested(s.List)
+
+	case *goast.IfStmt:
+		nested(s.Body.List)
+		if s.Else != nil {
+			nested([]goast.Stmt{s.Else})
+		}
+
+	case *goast.ForStmt:
+		nested(s.Body.List)
+
+	case *goast.RangeStmt:
+		nested(s.Body.List)
+
+	case *goast.SwitchStmt:
+		for _, c := range s.Body.List {
+			nested(c.(*goast.CaseClause).Body)
+		}
+
+	case *goast.TypeSwitchStmt:
+		for _, c := range s.Body.List {
+			nested(c.(*goast.CaseClause).Body)
+		}
+
+	case *goast.SelectStmt:
+		for _, c := range s.Body.List {
+			nested(c.(*goast.CommClause).Body)
+		}
+	}
+}
+
+// invalidLabels returns the labels that have at least one goto that is not
+// valid in Go, in a stable order.
+func (a *gotoAnalysis) invalidLabels() (labels []string) {
+	for name, gotos := range a.gotos {
+		label, ok := a.labels[name]
+		if !ok {
+			continue
+		}
+
+		for _, g := range gotos {
+			if !isValidGoto(g, label) {
+				labels = append(labels, name)
+				break
+			}
+		}
+	}
+	sort.Strings(labels)
+
+	return
+}
+
+// isValidGoto checks the rules for a goto in Go: it cannot jump into a block
+// and it cannot jump over a variable declaration.
+func isValidGoto(g, label gotoPosition) bool {
+	b, i := g.block, g.index
+	for b != nil && b != label.block {
+		b, i = b.parent, b.index
+	}
+
+	// The goto is not inside the block of the label.
+	if b == nil {
+		return false
+	}
+
+	for k := i + 1; k < label.index; k++ {
+		if declaresVariable(b.list[k]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func declaresVariable(stmt goast.Stmt) bool {
+	switch s := stmt.(type) {
+	case *goast.DeclStmt:
+		decl, ok := s.Decl.(*goast.GenDecl)
+		return ok && decl.Tok == token.VAR
+	case *goast.AssignStmt:
+		return s.Tok == token.DEFINE
+	case *goast.LabeledStmt:
+		return declaresVariable(s.Stmt)
+	}
+
+	return false
+}
+
+// removeUnusedLabels removes the labels that are not used by a goto, break or
+// continue.
+func removeUnusedLabels(body *goast.BlockStmt, a *gotoAnalysis) {
+	astutil.Apply(body, func(cursor *astutil.Cursor) bool {
+		switch n := cursor.Node().(type) {
+		case *goast.FuncLit:
+			return false
+		case *goast.LabeledStmt:
+			if _, ok := a.gotos[n.Label.Name]; !ok && !a.branches[n.Label.Name] {
+				cursor.Replace(n.Stmt)
+			}
+		}
+		return true
+	}, nil)
+}
+
+// declarationHoister moves the variable declarations in a function to the top
+// of the function. The body is walked twice: the first time to find the names
+// that are used and the second time to rename and move the declarations.
+type declarationHoister struct {
+	rewrite bool
+
+	// scopes map the name of each variable in scope to its new name.
+	scopes []map[string]string
+
+	// funcLits and noHoist are greater than zero when the declarations
+	// cannot be moved (inside a closure or a statement that is not in a
+	// list).
+	funcLits, noHoist int
+
+	// free are the names that are used but are not declared in the function
+	// and locals are the variables that will not be moved.
+	free, locals map[string]bool
+
+	// hoistable are the variables that will be moved and names are their new
+	// names.
+	hoistable []*goast.Ident
+	names     map[*goast.Ident]string
+
+	hoisted []goast.Spec
+}
+
+// hoistDeclarations moves the declarations of variables with an explicit type
+// to the top of the function so that a goto cannot jump over them. Variables
+// are renamed if there are several with the same name in the function.
+func hoistDeclarations(body *goast.BlockStmt, params []string) {
+	h := &declarationHoister{
+		free:   map[string]bool{},
+		locals: map[string]bool{},
+		names:  map[*goast.Ident]string{},
+	}
+	h.block(body)
+
+	claimed := map[string]bool{"c2goDefaultReturn": true}
+	for _, name := range params {
+		claimed[name] = true
+	}
+	for name := range h.locals {
+		claimed[name] = true
+	}
+
+	for _, ident := range h.hoistable {
+		name := ident.Name
+		for i := 2; name != "_" && (claimed[name] || h.free[name]); i++ {
+			name = fmt.Sprintf("%s_%d", ident.Name, i)
+		}
+		claimed[name] = true
+		h.names[ident] = name
+	}
+
+	h.rewrite = true
+	h.block(body)
+
+	if len(h.hoisted) == 0 {
+		return
+	}
+
+	decl := &goast.GenDecl{Tok: token.VAR, Specs: h.hoisted}
+	if len(h.hoisted) > 1 {
+		decl.Lparen = 1
+	}
+	body.List = append([]goast.Stmt{&goast.DeclStmt{Decl: decl}}, body.List...)
+}
+
+func (h *declarationHoister) push() {
+	h.scopes = append(h.scopes, map[string]string{})
+}
+
+func (h *declarationHoister) pop() {
+	h.scopes = h.scopes[:len(h.scopes)-1]
+}
+
+func (h *declarationHoister) declare(ident *goast.Ident, hoist bool) {
+	if ident == nil || ident.Name == "_" {
+		return
+	}
+
+	name := ident.Name
+	if hoist && h.rewrite {
+		name = h.names[ident]
+	}
+	if !hoist && !h.rewrite {
+		h.locals[name] = true
+	}
+	h.scopes[len(h.scopes)-1][ident.Name] = name
+}
+
+func (h *declarationHoister) use(ident *goast.Ident) {
+	for i := len(h.scopes) - 1; i >= 0; i-- {
+		if name, ok := h.scopes[i][ident.Name]; ok {
+			if h.rewrite {
+				ident.Name = name
+			}
+			return
+		}
+	}
+
+	h.free[ident.Name] = true
+}
+
+func (h *declarationHoister) block(b *goast.BlockStmt) {
+	if b == nil {
+		return
+	}
+
+	h.push()
+	b.List = h.stmts(b.List)
+	h.pop()
+}
+
+func (h *declarationHoister) stmts(list []goast.Stmt) (out []goast.Stmt) {
+	for _, stmt := range list {
+		out = append(out, h.stmt(stmt)...)
+	}
+
+	return
+}
+
+// single walks a statement that is not in a list, so it cannot be replaced
+// with several statements.
+func (h *declarationHoister) single(stmt goast.Stmt) goast.Stmt {
+	if stmt == nil {
+		return nil
+	}
+
+	h.noHoist++
+	stmt = h.stmt(stmt)[0]
+	h.noHoist--
+
+	return stmt
+}
+
+func (h *declarationHoister) stmt(stmt goast.Stmt) []goast.Stmt {
+	switch s := stmt.(type) {
+	case *goast.DeclStmt:
+		return h.declStmt(s)
+
+	case *goast.AssignStmt:
+		h.exprs(s.Rhs)
+		if s.Tok != token.DEFINE {
+			h.exprs(s.Lhs)
+			break
+		}
+		for _, lhs := range s.Lhs {
+			if ident, ok := lhs.(*goast.Ident); ok {
+				h.declare(ident, false)
+			}
+		}
+
+	case *goast.ExprStmt:
+		h.expr(s.X)
+
+	case *goast.IncDecStmt:
+		h.expr(s.X)
+
+	case *goast.ReturnStmt:
+		h.exprs(s.Results)
+
+	case *goast.SendStmt:
+		h.expr(s.Chan)
+		h.expr(s.Value)
+
+	case *goast.DeferStmt:
+		h.expr(s.Call)
+
+	case *goast.GoStmt:
+		h.expr(s.Call)
+
+	case *goast.LabeledStmt:
+		s.Stmt = h.single(s.Stmt)
+
+	case *goast.BlockStmt:
+		h.block(s)
+
+	case *goast.IfStmt:
+		h.push()
+		s.Init = h.single(s.Init)
+		h.expr(s.Cond)
+		h.block(s.Body)
+		s.Else = h.single(s.Else)
+		h.pop()
+
+	case *goast.ForStmt:
+		h.push()
+		s.Init = h.single(s.Init)
+		h.expr(s.Cond)
+		s.Post = h.single(s.Post)
+		h.block(s.Body)
+		h.pop()
+
+	case *goast.RangeStmt:
+		h.expr(s.X)
+		h.push()
+		if s.Tok == token.DEFINE {
+			key, _ := s.Key.(*goast.Ident)
+			value, _ := s.Value.(*goast.Ident)
+			h.declare(key, false)
+			h.declare(value, false)
+		} else {
+			h.expr(s.Key)
+			h.expr(s.Value)
+		}
+		h.block(s.Body)
+		h.pop()
+
+	case *goast.SwitchStmt:
+		h.push()
+		s.Init = h.single(s.Init)
+		h.expr(s.Tag)
+		h.caseClauses(s.Body)
+		h.pop()
+
+	case *goast.TypeSwitchStmt:
+		h.push()
+		s.Init = h.single(s.Init)
+		s.Assign = h.single(s.Assign)
+		h.caseClauses(s.Body)
+		h.pop()
+
+	case *goast.SelectStmt:
+		for _, c := range s.Body.List {
+			c := c.(*goast.CommClause)
+			h.push()
+			c.Comm = h.single(c.Comm)
+			c.Body = h.stmts(c.Body)
+			h.pop()
+		}
+	}
+
+	return []goast.Stmt{stmt}
+}
+
+func (h *declarationHoister) caseClauses(body *goast.BlockStmt) {
+	for _, c := range body.List {
+		c := c.(*goast.CaseClause)
+		h.exprs(c.List)
+		h.push()
+		c.Body = h.stmts(c.Body)
+		h.pop()
+	}
+}
+
+// declStmt moves the variables of a var declaration with a type. The
+// declaration is replaced with an assignment of the initial values.
+func (h *declarationHoister) declStmt(s *goast.DeclStmt) []goast.Stmt {
+	decl, ok := s.Decl.(*goast.GenDecl)
+	if !ok {
+		return []goast.Stmt{s}
+	}
+
+	hoist := decl.Tok == token.VAR && h.funcLits == 0 && h.noHoist == 0
+	for _, spec := range decl.Specs {
+		if v, ok := spec.(*goast.ValueSpec); !ok || v.Type == nil {
+			hoist = false
+		}
+	}
+
+	var out []goast.Stmt
+	for _, spec := range decl.Specs {
+		switch spec := spec.(type) {
+		case *goast.TypeSpec:
+			h.declare(spec.Name, false)
+
+		case *goast.ValueSpec:
+			h.exprs(spec.Values)
+			for _, name := range spec.Names {
+				if hoist && !h.rewrite {
+					h.hoistable = append(h.hoistable, name)
+				}
+				h.declare(name, hoist)
+			}
+
+			if !hoist || !h.rewrite {
+				continue
+			}
+
+			var lhs []goast.Expr
+			for _, name := range spec.Names {
+				if name.Name != "_" {
+					name.Name = h.names[name]
+				}
+				lhs = append(lhs, name)
+			}
+
+			h.hoisted = append(h.hoisted, &goast.ValueSpec{
+				Names: spec.Names,
+				Type:  spec.Type,
+			})
+			if len(spec.Values) > 0 {
+				out = append(out, &goast.AssignStmt{
+					Lhs: lhs,
+					Tok: token.ASSIGN,
+					Rhs: spec.Values,
+				})
+			}
+		}
+	}
+
+	if hoist && h.rewrite {
+		return out
+	}
+
+	return []goast.Stmt{s}
+}
+
+func (h *declarationHoister) exprs(exprs []goast.Expr) {
+	for _, e := range exprs {
+		h.expr(e)
+	}
+}
+
+// expr walks the variables used in an expression. Types are not walked.
+func (h *declarationHoister) expr(expr goast.Expr) {
+	switch e := expr.(type) {
+	case *goast.Ident:
+		h.use(e)
+
+	case *goast.SelectorExpr:
+		h.expr(e.X)
+
+	case *goast.KeyValueExpr:
+		if _, ok := e.Key.(*goast.Ident); !ok {
+			h.expr(e.Key)
+		}
+		h.expr(e.Value)
+
+	case *goast.CompositeLit:
+		h.exprs(e.Elts)
+
+	case *goast.FuncLit:
+		h.funcLits++
+		h.push()
+		for _, fields := range []*goast.FieldList{e.Type.Params, e.Type.Results} {
+			if fields == nil {
+				continue
+			}
+			for _, field := range fields.List {
+				for _, name := range field.Names {
+					h.declare(name, false)
+				}
+			}
+		}
+		h.block(e.Body)
+		h.pop()
+		h.funcLits--
+
+	case *goast.CallExpr:
+		h.expr(e.Fun)
+		h.exprs(e.Args)
+
+	case *goast.BinaryExpr:
+		h.expr(e.X)
+		h.expr(e.Y)
+
+	case *goast.UnaryExpr:
+		h.expr(e.X)
+
+	case *goast.ParenExpr:
+		h.expr(e.X)
+
+	case *goast.StarExpr:
+		h.expr(e.X)
+
+	case *goast.IndexExpr:
+		h.expr(e.X)
+		h.expr(e.Index)
+
+	case *goast.IndexListExpr:
+		h.expr(e.X)
+		h.exprs(e.Indices)
+
+	case *goast.SliceExpr:
+		h.expr(e.X)
+		h.expr(e.Low)
+		h.expr(e.High)
+		h.expr(e.Max)
+
+	case *goast.TypeAssertExpr:
+		h.expr(e.X)
+	}
+}
+
+// gotoTarget is a state of the loop created by flattenGotoStmts. The state
+// is not known until the statements before it have been flattened, so lits
+// are the jumps to be updated when it is marked.
+type gotoTarget struct {
+	state  int
+	marked bool
+	lits   []*goast.BasicLit
+}
+
+// gotoContext is the targets of a break or continue.
+type gotoContext struct {
+	breakTarget, continueTarget *gotoTarget
+}
+
+// gotoFlattener rewrites the statements in a function into states.
+type gotoFlattener struct {
+	analysis *gotoAnalysis
+
+	// chain are the statements that contain (or are) a label that needs to
+	// be flattened.
+	chain map[goast.Stmt]bool
+
+	// targets are the labels in the flattened statements and loops are the
+	// labelled loops that have been flattened.
+	targets map[string]*gotoTarget
+	loops   map[string]gotoContext
+
+	states [][]goast.Stmt
+}
+
+const (
+	gotoStateName = "c2goGotoState"
+	gotoLoopName  = "c2goGotoLoop"
+)
+
+// flattenGotoStmts rewrites the body of a function into a loop over a switch
+// of states so that the labels (and the statements that contain them) can be
+// jumped to. An error is returned (and the body is not changed) if there is a
+// statement that cannot be flattened.
+func flattenGotoStmts(body *goast.BlockStmt, a *gotoAnalysis, labels []string) error {
+	f := &gotoFlattener{
+		analysis: a,
+		chain:    map[goast.Stmt]bool{},
+		targets:  map[string]*gotoTarget{},
+		loops:    map[string]gotoContext{},
+	}
+
+	for _, name := range labels {
+		label := a.labels[name]
+		f.chain[label.block.list[label.index]] = true
+		for b := label.block; b.parent != nil; b = b.parent {
+			f.addChain(b.parent.list[b.index])
+		}
+	}
+
+	// The declarations that were moved to the top of the function are kept
+	// outside of the loop.
+	start := 0
+	for start < len(body.List) && declaresVariable(body.List[start]) {
+		start++
+	}
+
+	if err := f.collect(body.List[start:]); err != nil {
+		return err
+	}
+
+	f.newState()
+	f.flattenList(body.List[start:], gotoContext{})
+
+	var clauses []goast.Stmt
+	for i, state := range f.states {
+		if i < len(f.states)-1 && !isTerminatingStmt(state) {
+			state = append(state, &goast.BranchStmt{Tok: token.FALLTHROUGH})
+		}
+		clauses = append(clauses, &goast.CaseClause{
+			List: []goast.Expr{util.NewIntLit(i)},
+			Body: state,
+		})
+	}
+
+	body.List = append(body.List[:start:start],
+		&goast.DeclStmt{Decl: &goast.GenDecl{
+			Tok: token.VAR,
+			Specs: []goast.Spec{&goast.ValueSpec{
+				Names: []*goast.Ident{util.NewIdent(gotoStateName)},
+				Type:  util.NewTypeIdent("int"),
+			}},
+		}},
+		&goast.LabeledStmt{
+			Label: util.NewIdent(gotoLoopName),
+			Stmt: &goast.ForStmt{
+				Body: &goast.BlockStmt{List: []goast.Stmt{
+					&goast.SwitchStmt{
+						Tag:  util.NewIdent(gotoStateName),
+						Body: &goast.BlockStmt{List: clauses},
+					},
+					&goast.BranchStmt{Tok: token.BREAK},
+				}},
+			},
+		},
+	)
+
+	return nil
+}
+
+func (f *gotoFlattener) addChain(stmt goast.Stmt) {
+	f.chain[stmt] = true
+	if s, ok := stmt.(*goast.LabeledStmt); ok {
+		f.addChain(s.Stmt)
+	}
+}
+
+// collect finds the labels in the statements that will be flattened and
+// checks that all of the statements can be flattened.
+func (f *gotoFlattener) collect(list []goast.Stmt) error {
+	for _, stmt := range list {
+		for {
+			s, ok := stmt.(*goast.LabeledStmt)
+			if !ok {
+				break
+			}
+			f.targets[s.Label.Name] = &gotoTarget{}
+			stmt = s.Stmt
+		}
+
+		// Each state is a separate block so a variable declared in one state
+		// cannot be used in the next state.
+		if declaresVariable(stmt) {
+			return fmt.Errorf("cannot flatten a variable declaration without a type")
+		}
+
+		if !f.chain[stmt] {
+			continue
+		}
+
+		switch s := stmt.(type) {
+		case *goast.BlockStmt:
+			if err := f.collect(s.List); err != nil {
+				return err
+			}
+
+		case *goast.IfStmt:
+			if s.Init != nil {
+				return fmt.Errorf("cannot jump into an if with an init statement")
+			}
+			if err := f.collect(s.Body.List); err != nil {
+				return err
+			}
+			if s.Else != nil && f.chain[s.Else] {
+				if err := f.collect([]goast.Stmt{s.Else}); err != nil {
+					return err
+				}
+			}
+
+		case *goast.ForStmt:
+			if s.Init != nil && declaresVariable(s.Init) {
+				return fmt.Errorf("cannot jump into a for with a declaration")
+			}
+			if err := f.collect(s.Body.List); err != nil {
+				return err
+			}
+
+		case *goast.SwitchStmt:
+			return fmt.Errorf("cannot jump into a switch")
+
+		default:
+			return fmt.Errorf("cannot jump into %T", s)
+		}
+	}
+
+	return nil
+}
+
+func (f *gotoFlattener) newState() {
+	f.states = append(f.states, nil)
+}
+
+func (f *gotoFlattener) emit(stmts ...goast.Stmt) {
+	last := len(f.states) - 1
+	f.states[last] = append(f.states[last], stmts...)
+}
+
+// mark starts a new state for the target.
+func (f *gotoFlattener) mark(target *gotoTarget) {
+	f.newState()
+	target.state = len(f.states) - 1
+	target.marked = true
+	for _, lit := range target.lits {
+		lit.Value = strconv.Itoa(target.state)
+	}
+}
+
+// jump returns the statements to jump to the state of the target.
+func (f *gotoFlattener) jump(target *gotoTarget) []goast.Stmt {
+	lit := &goast.BasicLit{Kind: token.INT}
+	if target.marked {
+		lit.Value = strconv.Itoa(target.state)
+	} else {
+		target.lits = append(target.lits, lit)
+	}
+
+	return []goast.Stmt{
+		&goast.AssignStmt{
+			Lhs: []goast.Expr{util.NewIdent(gotoStateName)},
+			Tok: token.ASSIGN,
+			Rhs: []goast.Expr{lit},
+		},
+		&goast.BranchStmt{
+			Label: util.NewIdent(gotoLoopName),
+			Tok:   token.CONTINUE,
+		},
+	}
+}
+
+// jumpUnless jumps to the target if the condition is false.
+func (f *gotoFlattener) jumpUnless(cond goast.Expr, target *gotoTarget) {
+	f.emit(&goast.IfStmt{
+		Cond: &goast.UnaryExpr{
+			Op: token.NOT,
+			X:  &goast.ParenExpr{X: cond},
+		},
+		Body: &goast.BlockStmt{List: f.jump(target)},
+	})
+}
+
+func (f *gotoFlattener) flattenList(list []goast.Stmt, ctx gotoContext) {
+	for _, stmt := range list {
+		f.flattenStmt(stmt, ctx)
+	}
+}
+
+func (f *gotoFlattener) flattenStmt(stmt goast.Stmt, ctx gotoContext) {
+	if s, ok := stmt.(*goast.LabeledStmt); ok {
+		target := f.targets[s.Label.Name]
+		f.mark(target)
+
+		// The label is still needed if it is used by a break or continue of
+		// a loop that is not flattened.
+		if f.analysis.branches[s.Label.Name] {
+			if loop, ok := s.Stmt.(*goast.ForStmt); ok && f.chain[loop] {
+				f.flattenFor(loop, s.Label.Name, ctx)
+				return
+			}
+			if !f.chain[s.Stmt] {
+				s.Stmt = f.rewriteSingle(s.Stmt, ctx)
+				f.emit(s)
+				return
+			}
+		}
+
+		f.flattenStmt(s.Stmt, ctx)
+		return
+	}
+
+	if !f.chain[stmt] {
+		f.emit(f.rewrite(stmt, ctx)...)
+		return
+	}
+
+	switch s := stmt.(type) {
+	case *goast.BlockStmt:
+		f.flattenList(s.List, ctx)
+
+	case *goast.IfStmt:
+		end := &gotoTarget{}
+		next := end
+		if s.Else != nil {
+			next = &gotoTarget{}
+		}
+
+		f.jumpUnless(s.Cond, next)
+		f.flattenList(s.Body.List, ctx)
+		if s.Else != nil {
+			f.emit(f.jump(end)...)
+			f.mark(next)
+			f.flattenStmt(s.Else, ctx)
+		}
+		f.mark(end)
+
+	case *goast.ForStmt:
+		f.flattenFor(s, "", ctx)
+	}
+}
+
+func (f *gotoFlattener) flattenFor(s *goast.ForStmt, label string, ctx gotoContext) {
+	if s.Init != nil {
+		f.emit(f.rewriteSingle(s.Init, ctx))
+	}
+
+	cond, post, end := &gotoTarget{}, &gotoTarget{}, &gotoTarget{}
+	inner := gotoContext{breakTarget: end, continueTarget: post}
+	if label != "" {
+		f.loops[label] = inner
+	}
+
+	f.mark(cond)
+	if s.Cond != nil {
+		f.jumpUnless(s.Cond, end)
+	}
+	f.flattenList(s.Body.List, inner)
+
+	f.mark(post)
+	if s.Post != nil {
+		f.emit(f.rewriteSingle(s.Post, ctx))
+	}
+	f.emit(f.jump(cond)...)
+	f.mark(end)
+}
+
+func (f *gotoFlattener) rewriteSingle(stmt goast.Stmt, ctx gotoContext) goast.Stmt {
+	stmts := f.rewrite(stmt, ctx)
+	if len(stmts) == 1 {
+		return stmts[0]
+	}
+
+	return &goast.BlockStmt{List: stmts}
+}
+
+// rewrite replaces the gotos to the flattened labels (and any break or
+// continue for a flattened loop) with a jump to the state.
+func (f *gotoFlattener) rewrite(stmt goast.Stmt, ctx gotoContext) []goast.Stmt {
+	var loops, breakables int
+	block := &goast.BlockStmt{List: []goast.Stmt{stmt}}
+
+	astutil.Apply(block, func(cursor *astutil.Cursor) bool {
+		var target *gotoTarget
+		switch n := cursor.Node().(type) {
+		case *goast.FuncLit:
+			return false
+
+		case *goast.ForStmt, *goast.RangeStmt:
+			loops++
+			breakables++
+
+		case *goast.SwitchStmt, *goast.TypeSwitchStmt, *goast.SelectStmt:
+			breakables++
+
+		case *goast.BranchStmt:
+			switch {
+			case n.Tok == token.GOTO:
+				target = f.targets[n.Label.Name]
+			case n.Label != nil:
+				if loop, ok := f.loops[n.Label.Name]; ok {
+					target = loop.continueTarget
+					if n.Tok == token.BREAK {
+						target = loop.breakTarget
+					}
+				}
+			case n.Tok == token.BREAK && breakables == 0:
+				target = ctx.breakTarget
+			case n.Tok == token.CONTINUE && loops == 0:
+				target = ctx.continueTarget
+			}
+		}
+
+		if target == nil {
+			return true
+		}
+
+		jump := f.jump(target)
+		if cursor.Index() < 0 {
+			cursor.Replace(&goast.BlockStmt{List: jump})
+			return false
+		}
+		cursor.InsertBefore(jump[0])
+		cursor.Replace(jump[1])
+		return false
+	}, func(cursor *astutil.Cursor) bool {
+		switch cursor.Node().(type) {
+		case *goast.ForStmt, *goast.RangeStmt:
+			loops--
+			breakables--
+		case *goast.SwitchStmt, *goast.TypeSwitchStmt, *goast.SelectStmt:
+			breakables--
+		}
+		return true
+	})
+
+	return block.List
+}
+
+// isTerminatingStmt returns true if the last statement of a state does not
+// continue to the next state.
+func isTerminatingStmt(stmts []goast.Stmt) bool {
+	if len(stmts) == 0 {
+		return false
+	}
+
+	switch s := stmts[len(stmts)-1].(type) {
+	case *goast.ReturnStmt:
+		return true
+	case *goast.BranchStmt:
+		return s.Tok != token.FALLTHROUGH
+	}
+
+	return false
+}
@@ -0,0 +1,194 @@
+package transpiler
+
+import (
+	"bytes"
+	goast "go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	gotypes "go/types"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestLowerGotoStmts(t *testing.T) {
+	tests := []struct {
+		name     string
+		function string
+		expected string
+	}{
+		{
+			name: "backward goto",
+			function: `func f() int32 {
+	var i int32 = 0
+loop:
+	i++
+	if i < 5 {
+		goto loop
+	}
+	return i
+}`,
+			expected: `func f() int32 {
+	var i int32 = 0
+loop:
+	i++
+	if i < 5 {
+		goto loop
+	}
+	return i
+}`,
+		},
+		{
+			name: "unused label",
+			function: `func f() int32 {
+	var i int32 = 0
+unused:
+	;
+	return i
+}`,
+			expected: `func f() int32 {
+	var i int32 = 0
+	return i
+}`,
+		},
+		{
+			name: "jump over declarations",
+			function: `func f(a int32) int32 {
+	var result int32 = -1
+	if a == 1 {
+		goto out
+	}
+	var b int32 = a * 2
+	{
+		var b int32 = 3
+		result = b
+	}
+	result += b
+out:
+	;
+	return result
+}`,
+			expected: `func f(a int32) int32 {
+	var (
+		result int32
+		b      int32
+		b_2    int32
+	)
+	result = -1
+	if a == 1 {
+		goto out
+	}
+	b = a * 2
+	{
+		b_2 = 3
+		result = b_2
+	}
+	result += b
+out:
+	;
+	return result
+}`,
+		},
+		{
+			name: "jump into a loop",
+			function: `func f() {
+	var i int32 = 0
+	var sum int32 = 0
+	goto inside
+	for i < 5 {
+		if i == 3 {
+			break
+		}
+		sum += 10
+	inside:
+		;
+		i++
+	}
+	println(sum + i)
+}`,
+			expected: `func f() {
+	var (
+		i   int32
+		sum int32
+	)
+	var c2goGotoState int
+c2goGotoLoop:
+	for {
+		switch c2goGotoState {
+		case 0:
+			i = 0
+			sum = 0
+			c2goGotoState = 2
+			continue c2goGotoLoop
+		case 1:
+			if !(i < 5) {
+				c2goGotoState = 4
+				continue c2goGotoLoop
+			}
+			if i == 3 {
+				c2goGotoState = 4
+				continue c2goGotoLoop
+			}
+			sum += 10
+			fallthrough
+		case 2:
+			i++
+			fallthrough
+		case 3:
+			c2goGotoState = 1
+			continue c2goGotoLoop
+		case 4:
+			println(sum + i)
+		}
+		break
+	}
+}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "", "package p\n\n"+test.function, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			decl := file.Decls[0].(*goast.FuncDecl)
+			var params []string
+			for _, field := range decl.Type.Params.List {
+				for _, name := range field.Names {
+					params = append(params, name.Name)
+				}
+			}
+
+			p := program.NewProgram()
+			lowerGotoStmts(p, &ast.FunctionDecl{}, decl.Body, params)
+			if messages := p.Messages(); len(messages) > 0 {
+				t.Errorf("unexpected warnings: %v", messages)
+			}
+
+			var buf bytes.Buffer
+			if err := format.Node(&buf, token.NewFileSet(), decl); err != nil {
+				t.Fatal(err)
+			}
+			if actual := buf.String(); actual != test.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", test.expected, actual)
+			}
+
+			// The result must also be valid Go.
+			source := "package p\n\n" + buf.String()
+			fset = token.NewFileSet()
+			checked, err := parser.ParseFile(fset, "", source, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			conf := gotypes.Config{}
+			if _, err := conf.Check("p", fset, []*goast.File{checked}, nil); err != nil {
+				t.Errorf("invalid Go: %v\n%s", err, source)
+			}
+		})
+	}
+}
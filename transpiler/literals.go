@@ -114,7 +114,7 @@ func transpileConstantExpr(n *ast.ConstantExpr, p *program.Program) (goast.Expr,
 	children := n.Children()
 	expr, t, _, _, err := transpileToExpr(children[0], p, false)
 	if len(children) != 1 {
-		p.AddMessage(p.GenerateWarningMessage(fmt.Errorf("ConstantExpr has %d children, expected 1 child", len(children)), n))
+		p.AddWarning(fmt.Errorf("ConstantExpr has %d children, expected 1 child", len(children)), n)
 	}
 	if len(n.Type) > 0 {
 		t = n.Type
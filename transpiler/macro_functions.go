@@ -0,0 +1,769 @@
+// This file contains the transpiling of function-like macros into generic Go
+// functions.
+
+package transpiler
+
+import (
+	"errors"
+	"fmt"
+	goast "go/ast"
+	"go/constant"
+	"go/token"
+	"math"
+	"strings"
+	"text/scanner"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
+	"github.com/elliotchance/c2go/util"
+)
+
+// The constraints for the type parameter of a function-like macro. See the
+// noarch package.
+const (
+	macroInteger = "noarch.Integer"
+	macroFloat   = "noarch.Float"
+	macroNumber  = "noarch.Number"
+)
+
+// macroTypes are the types in each of the constraints, in the order of the
+// usual arithmetic conversions.
+var macroTypes = map[string][]string{
+	macroInteger: {"int32", "uint32", "int64", "uint64"},
+	macroFloat:   {"float32", "float64"},
+	macroNumber:  {"int32", "uint32", "int64", "uint64", "float32", "float64"},
+}
+
+// macroCTypes are the C types of each of the Go types in macroTypes.
+var macroCTypes = map[string]string{
+	"int32":   "int",
+	"uint32":  "unsigned int",
+	"int64":   "long long",
+	"uint64":  "unsigned long long",
+	"float32": "float",
+	"float64": "double",
+}
+
+// cOperatorPrecedence is the precedence of the binary operators that can be
+// used in a function-like macro.
+var cOperatorPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"|":  3,
+	"^":  4,
+	"&":  5,
+	"==": 6, "!=": 6,
+	"<": 7, ">": 7, "<=": 7, ">=": 7,
+	"<<": 8, ">>": 8,
+	"+": 9, "-": 9,
+	"*": 10, "/": 10, "%": 10,
+}
+
+// cOperators are the C operators that are more than one character.
+var cOperators = []string{
+	"<<=", ">>=",
+	"<<", ">>", "<=", ">=", "==", "!=", "&&", "||", "++", "--", "->",
+	"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "##",
+}
+
+// macroExpr is a part of the body of a function-like macro that has been
+// converted to Go.
+type macroExpr struct {
+	expr goast.Expr
+
+	// isBool is true if the expression is a Go bool (like a comparison)
+	// rather than the type parameter.
+	isBool bool
+
+	// value is the value of a constant expression.
+	value constant.Value
+
+	// cond, then and els are set for a conditional operator. It becomes an
+	// "if" statement (or a closure) rather than an expression.
+	cond, then, els *macroExpr
+}
+
+// macroParser converts the body of a function-like macro to Go.
+type macroParser struct {
+	p         *program.Program
+	tokens    []string
+	pos       int
+	params    map[string]bool
+	used      map[string]bool
+	typeParam string
+
+	usesInteger bool
+	usesFloat   bool
+}
+
+// transpileMacroFunction converts a function-like macro that has no side
+// effects into a generic Go function, like:
+//
+//     #define MAX(a, b) ((a) > (b) ? (a) : (b))
+//
+// Becomes:
+//
+//     func MAX[T noarch.Number](a, b T) T {
+//         if a > b {
+//             return a
+//         }
+//         return b
+//     }
+//
+// Only macros that use their parameters, constants (including other macros
+// that are constants) and operators can be converted. Calls, assignments,
+// casts and increments are not supported.
+//
+// The constraint and result type of the function are saved in the macro.
+func transpileMacroFunction(p *program.Program, macro *program.Macro) (
+	*goast.FuncDecl, error) {
+
+	if len(macro.Params) == 0 {
+		return nil, errors.New("macro has no parameters")
+	}
+
+	tokens, err := tokenizeMacroBody(macro.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	mp := &macroParser{
+		p:      p,
+		tokens: tokens,
+		params: map[string]bool{},
+		used:   map[string]bool{},
+	}
+	for _, param := range macro.Params {
+		mp.params[param] = true
+	}
+
+	// The name of the type parameter must not be the same as a parameter or
+	// a constant.
+	mp.typeParam = "T"
+	for i := 1; mp.params[mp.typeParam] || p.GetMacro(mp.typeParam) != nil; i++ {
+		mp.typeParam = fmt.Sprintf("T%d", i)
+	}
+
+	result, err := mp.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	if mp.pos != len(mp.tokens) {
+		return nil, fmt.Errorf("unexpected token: %s", mp.tokens[mp.pos])
+	}
+
+	for _, param := range macro.Params {
+		if !mp.used[param] {
+			return nil, fmt.Errorf("parameter %s is not used", param)
+		}
+	}
+
+	constraint := macroNumber
+	switch {
+	case mp.usesInteger && mp.usesFloat:
+		return nil, errors.New("macro uses integer operators with floats")
+	case mp.usesInteger:
+		constraint = macroInteger
+	case mp.usesFloat:
+		constraint = macroFloat
+	}
+
+	resultType := mp.typeParam
+	if result.isBool {
+		resultType, err = types.ResolveType(p, "int")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var params []*goast.Ident
+	for _, param := range macro.Params {
+		params = append(params, util.NewIdent(param))
+	}
+
+	if result.isBool {
+		macro.CType = "int"
+	}
+	macro.Constraint = constraint
+	p.AddImport("github.com/elliotchance/c2go/noarch")
+
+	return &goast.FuncDecl{
+		Name: util.NewIdent(macro.Name),
+		Type: &goast.FuncType{
+			TypeParams: &goast.FieldList{
+				List: []*goast.Field{{
+					Names: []*goast.Ident{util.NewIdent(mp.typeParam)},
+					Type:  util.NewTypeIdent(constraint),
+				}},
+			},
+			Params: &goast.FieldList{
+				List: []*goast.Field{{
+					Names: params,
+					Type:  util.NewTypeIdent(mp.typeParam),
+				}},
+			},
+			Results: &goast.FieldList{
+				List: []*goast.Field{{
+					Type: util.NewTypeIdent(resultType),
+				}},
+			},
+		},
+		Body: &goast.BlockStmt{
+			List: mp.returnStmts(result, result.isBool),
+		},
+	}, nil
+}
+
+// returnStmts returns the statements that return the value of the expression.
+// Conditional operators become "if" statements.
+func (mp *macroParser) returnStmts(e *macroExpr, asInt bool) []goast.Stmt {
+	if e.cond != nil {
+		return append([]goast.Stmt{&goast.IfStmt{
+			Cond: mp.toBool(e.cond).expr,
+			Body: &goast.BlockStmt{List: mp.returnStmts(e.then, asInt)},
+		}}, mp.returnStmts(e.els, asInt)...)
+	}
+
+	if asInt && e.isBool {
+		return []goast.Stmt{
+			&goast.IfStmt{
+				Cond: e.expr,
+				Body: &goast.BlockStmt{List: []goast.Stmt{
+					&goast.ReturnStmt{Results: []goast.Expr{util.NewIntLit(1)}},
+				}},
+			},
+			&goast.ReturnStmt{Results: []goast.Expr{util.NewIntLit(0)}},
+		}
+	}
+
+	return []goast.Stmt{&goast.ReturnStmt{Results: []goast.Expr{mp.expr(e)}}}
+}
+
+// expr returns the Go expression. Conditional operators become closures.
+func (mp *macroParser) expr(e *macroExpr) goast.Expr {
+	if e.cond == nil {
+		return e.expr
+	}
+
+	resultType := mp.typeParam
+	if e.isBool {
+		resultType = "bool"
+	}
+
+	return util.NewFuncClosure(resultType, mp.returnStmts(e, false)...)
+}
+
+// toNumber converts a bool to 1 or 0 in the type parameter.
+func (mp *macroParser) toNumber(e *macroExpr) *macroExpr {
+	if !e.isBool {
+		return e
+	}
+
+	return &macroExpr{
+		expr: util.NewFuncClosure(mp.typeParam, mp.returnStmts(e, true)...),
+	}
+}
+
+// toBool compares a number with zero.
+func (mp *macroParser) toBool(e *macroExpr) *macroExpr {
+	if e.isBool {
+		return &macroExpr{expr: mp.expr(e), isBool: true}
+	}
+
+	return &macroExpr{
+		expr:   macroBinaryExpr(mp.expr(e), token.NEQ, util.NewIntLit(0)),
+		isBool: true,
+	}
+}
+
+func (mp *macroParser) peek() string {
+	if mp.pos < len(mp.tokens) {
+		return mp.tokens[mp.pos]
+	}
+
+	return ""
+}
+
+func (mp *macroParser) next() string {
+	token := mp.peek()
+	mp.pos++
+	return token
+}
+
+// parseConditional parses "a ? b : c", or any expression with a higher
+// precedence.
+func (mp *macroParser) parseConditional() (*macroExpr, error) {
+	cond, err := mp.parseBinary(1)
+	if err != nil || mp.peek() != "?" {
+		return cond, err
+	}
+	mp.next()
+
+	then, err := mp.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := mp.next(); t != ":" {
+		return nil, fmt.Errorf("expected ':' but got '%s'", t)
+	}
+
+	els, err := mp.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+
+	// Both sides must be the same type.
+	if then.isBool != els.isBool {
+		then, els = mp.toNumber(then), mp.toNumber(els)
+	}
+
+	return &macroExpr{
+		cond:   cond,
+		then:   then,
+		els:    els,
+		isBool: then.isBool,
+	}, nil
+}
+
+// parseBinary parses the binary operators that have at least the precedence.
+func (mp *macroParser) parseBinary(precedence int) (*macroExpr, error) {
+	left, err := mp.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		operator := mp.peek()
+		operatorPrecedence := cOperatorPrecedence[operator]
+		if operatorPrecedence == 0 || operatorPrecedence < precedence {
+			return left, nil
+		}
+		mp.next()
+
+		right, err := mp.parseBinary(operatorPrecedence + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		left, err = mp.binary(operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (mp *macroParser) binary(operator string, left, right *macroExpr) (
+	*macroExpr, error) {
+
+	op := getTokenForOperator(operator)
+
+	switch operator {
+	case "&&", "||":
+		left, right = mp.toBool(left), mp.toBool(right)
+		return &macroExpr{
+			expr:   macroBinaryExpr(left.expr, op, right.expr),
+			isBool: true,
+		}, nil
+
+	case "%", "<<", ">>", "&", "|", "^":
+		mp.usesInteger = true
+	}
+
+	left, right = mp.toNumber(left), mp.toNumber(right)
+
+	e := &macroExpr{
+		expr:   macroBinaryExpr(mp.expr(left), op, mp.expr(right)),
+		isBool: cOperatorPrecedence[operator] == 6 || cOperatorPrecedence[operator] == 7,
+	}
+
+	// The result of an operation on constants is also a constant. It must
+	// be possible to convert it to any of the types of the type parameter.
+	if left.value != nil && right.value != nil && !e.isBool {
+		switch operator {
+		case "<<", ">>":
+			shift, ok := constant.Uint64Val(right.value)
+			if !ok || shift > 63 {
+				return nil, fmt.Errorf("invalid shift: %s", right.value)
+			}
+			e.value = constant.Shift(left.value, op, uint(shift))
+
+		case "/":
+			if constant.Sign(right.value) == 0 {
+				return nil, errors.New("division by zero")
+			}
+			if left.value.Kind() == constant.Int &&
+				right.value.Kind() == constant.Int {
+				op = token.QUO_ASSIGN
+			}
+			e.value = constant.BinaryOp(left.value, op, right.value)
+
+		default:
+			e.value = constant.BinaryOp(left.value, op, right.value)
+		}
+
+		if err := checkMacroConstant(e.value); err != nil {
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
+
+// checkMacroConstant returns an error if the constant cannot be converted to
+// all of the types of the type parameter.
+func checkMacroConstant(value constant.Value) error {
+	if value.Kind() != constant.Int {
+		return nil
+	}
+
+	v, ok := constant.Int64Val(value)
+	if !ok || v < 0 || v > math.MaxInt32 {
+		return fmt.Errorf("constant %s is out of range", value)
+	}
+
+	return nil
+}
+
+// macroBinaryExpr creates a binary expression. Parentheses are added to the
+// operands because the precedence of the operators in Go is different from C.
+func macroBinaryExpr(left goast.Expr, op token.Token, right goast.Expr) goast.Expr {
+	if b, ok := left.(*goast.BinaryExpr); ok && b.Op.Precedence() < op.Precedence() {
+		left = &goast.ParenExpr{X: left}
+	}
+	if b, ok := right.(*goast.BinaryExpr); ok && b.Op.Precedence() <= op.Precedence() {
+		right = &goast.ParenExpr{X: right}
+	}
+
+	return &goast.BinaryExpr{X: left, Op: op, Y: right}
+}
+
+// parseUnary parses the unary operators.
+func (mp *macroParser) parseUnary() (*macroExpr, error) {
+	operator := mp.peek()
+	switch operator {
+	case "-", "+", "!", "~":
+		mp.next()
+	default:
+		return mp.parsePrimary()
+	}
+
+	operand, err := mp.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	if operator == "!" {
+		operand = mp.toBool(operand)
+		return &macroExpr{
+			expr:   &goast.UnaryExpr{Op: token.NOT, X: macroOperand(operand.expr)},
+			isBool: true,
+		}, nil
+	}
+
+	op := token.SUB
+	switch operator {
+	case "+":
+		op = token.ADD
+	case "~":
+		op = token.XOR
+		mp.usesInteger = true
+	}
+
+	operand = mp.toNumber(operand)
+	x := macroOperand(mp.expr(operand))
+
+	// A negative constant cannot be converted to an unsigned type parameter
+	// so the constant is converted before it is negated.
+	if operand.value != nil && op != token.ADD {
+		x = &goast.CallExpr{
+			Fun:  util.NewIdent(mp.typeParam),
+			Args: []goast.Expr{x},
+		}
+	}
+
+	return &macroExpr{expr: &goast.UnaryExpr{Op: op, X: x}}, nil
+}
+
+// macroOperand adds parentheses to an operand of a unary operator if needed.
+func macroOperand(e goast.Expr) goast.Expr {
+	if _, ok := e.(*goast.BinaryExpr); ok {
+		return &goast.ParenExpr{X: e}
+	}
+
+	return e
+}
+
+// parsePrimary parses literals, identifiers and parentheses.
+func (mp *macroParser) parsePrimary() (*macroExpr, error) {
+	t := mp.next()
+	switch {
+	case t == "(":
+		// The parentheses from C are not needed because they are added
+		// where they are needed in Go.
+		e, err := mp.parseConditional()
+		if err != nil {
+			return nil, err
+		}
+		if t := mp.next(); t != ")" {
+			return nil, fmt.Errorf("expected ')' but got '%s'", t)
+		}
+		return e, nil
+
+	case mp.params[t]:
+		mp.used[t] = true
+		return &macroExpr{expr: util.NewIdent(t)}, nil
+
+	case t != "" && (t[0] >= '0' && t[0] <= '9' || t[0] == '.'):
+		kind := token.INT
+		if strings.ContainsAny(t, ".") ||
+			(!strings.HasPrefix(t, "0x") && strings.ContainsAny(t, "eE")) {
+			kind = token.FLOAT
+			mp.usesFloat = true
+		}
+
+		value := constant.MakeFromLiteral(t, kind, 0)
+		if value.Kind() == constant.Unknown {
+			return nil, fmt.Errorf("invalid number: %s", t)
+		}
+		if err := checkMacroConstant(value); err != nil {
+			return nil, err
+		}
+
+		return &macroExpr{
+			expr:  &goast.BasicLit{Kind: kind, Value: t},
+			value: value,
+		}, nil
+
+	case strings.HasPrefix(t, "'"):
+		c, err := parseCharacterConstant(t)
+		if err != nil {
+			return nil, err
+		}
+		return &macroExpr{
+			expr:  util.NewIntLit(c),
+			value: constant.MakeInt64(int64(c)),
+		}, nil
+	}
+
+	// Other macros that are constants are converted to the type parameter.
+	if macro := mp.p.GetMacro(t); macro != nil {
+		if macro.CType == "float" || macro.CType == "double" {
+			mp.usesFloat = true
+		} else {
+			value := constant.MakeFromLiteral(macro.Value, token.INT, 0)
+			if err := checkMacroConstant(value); err != nil {
+				return nil, err
+			}
+		}
+
+		return &macroExpr{
+			expr: &goast.CallExpr{
+				Fun:  util.NewIdent(mp.typeParam),
+				Args: []goast.Expr{util.NewIdent(macro.Name)},
+			},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported token: %s", t)
+}
+
+// tokenizeMacroBody splits the body of a macro into C tokens. The suffixes of
+// numbers are removed and strings are not supported.
+func tokenizeMacroBody(body string) (tokens []string, err error) {
+	var s scanner.Scanner
+	s.Init(strings.NewReader(body))
+	s.Mode = scanner.ScanIdents | scanner.ScanInts | scanner.ScanFloats |
+		scanner.ScanChars | scanner.ScanStrings | scanner.ScanComments |
+		scanner.SkipComments
+	s.Error = func(_ *scanner.Scanner, msg string) {
+		err = fmt.Errorf("cannot scan macro: %s", msg)
+	}
+
+	lastEnd := -1
+	var lastTok rune
+	for tok := s.Scan(); tok != scanner.EOF; tok = s.Scan() {
+		text := s.TokenText()
+		start := s.Position.Offset
+		adjacent := start == lastEnd
+		lastEnd = start + len(text)
+
+		switch {
+		case tok == scanner.String:
+			return nil, errors.New("strings are not supported")
+
+		case tok == scanner.Ident && adjacent &&
+			(lastTok == scanner.Int || lastTok == scanner.Float) &&
+			strings.Trim(strings.ToLower(text), "ulf") == "":
+			// Suffixes of numbers, like "10UL" or "1.5f".
+			continue
+
+		case tok != scanner.Ident && tok != scanner.Int &&
+			tok != scanner.Float && tok != scanner.Char && adjacent &&
+			len(tokens) > 0 && isCOperator(tokens[len(tokens)-1]+text):
+			tokens[len(tokens)-1] += text
+			lastTok = tok
+			continue
+		}
+
+		tokens = append(tokens, text)
+		lastTok = tok
+	}
+
+	return tokens, err
+}
+
+func isCOperator(s string) bool {
+	for _, operator := range cOperators {
+		if strings.HasPrefix(operator, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// transpileMacroCall replaces the expansion of a function-like macro with a
+// call to the Go function for the macro. ok is false if the expansion cannot
+// be replaced, for example because one of the arguments may have side effects.
+func transpileMacroCall(p *program.Program, macro *program.Macro,
+	args []ast.Node, cType string) (
+	expr goast.Expr, exprType string, preStmts []goast.Stmt,
+	postStmts []goast.Stmt, ok bool) {
+
+	if len(args) != len(macro.Params) {
+		return
+	}
+	for _, arg := range args {
+		if arg == nil || !isPureMacroArgument(arg) {
+			return
+		}
+	}
+
+	var (
+		argExprs []goast.Expr
+		argTypes []string
+	)
+	for _, arg := range args {
+		e, eType, newPre, newPost, err := transpileToExpr(arg, p, false)
+		if err != nil {
+			return
+		}
+
+		preStmts, postStmts = combinePreAndPostStmts(
+			preStmts, postStmts, newPre, newPost)
+		argExprs = append(argExprs, e)
+		argTypes = append(argTypes, eType)
+	}
+
+	// The type parameter is the type of the result, unless the result is
+	// always an int. In that case it is the common type of the arguments.
+	typeArg := cType
+	if macro.CType == "int" {
+		typeArg = commonMacroType(p, argTypes)
+	}
+
+	goType, err := types.ResolveType(p, typeArg)
+	if err != nil || !util.InStrings(goType, macroTypes[macro.Constraint]) {
+		return
+	}
+
+	for i := range argExprs {
+		argExprs[i], err = types.CastExpr(p, argExprs[i], argTypes[i], typeArg)
+		if err != nil {
+			return
+		}
+	}
+
+	exprType = typeArg
+	if macro.CType != "" {
+		exprType = macro.CType
+	}
+
+	return &goast.CallExpr{
+		Fun: &goast.IndexExpr{
+			X:     util.NewIdent(macro.Name),
+			Index: util.NewTypeIdent(goType),
+		},
+		Args: argExprs,
+	}, exprType, preStmts, postStmts, true
+}
+
+// commonMacroType returns the C type that all of the types are converted to by
+// the usual arithmetic conversions. It returns an empty string if any of the
+// types are not numbers.
+func commonMacroType(p *program.Program, cTypes []string) string {
+	common := 0
+	for _, cType := range cTypes {
+		goType, err := types.ResolveType(p, cType)
+		if err != nil {
+			return ""
+		}
+
+		rank := -1
+		switch goType {
+		case "int8", "int16", "uint8", "uint16", "byte":
+			// Promoted to int.
+			rank = 0
+		default:
+			for i, t := range macroTypes[macroNumber] {
+				if t == goType {
+					rank = i
+				}
+			}
+		}
+		if rank < 0 {
+			return ""
+		}
+
+		if rank > common {
+			common = rank
+		}
+	}
+
+	return macroCTypes[macroTypes[macroNumber][common]]
+}
+
+// isPureMacroArgument returns true if the argument of a macro can be evaluated
+// once when the function is called, rather than each time (or never) it is
+// used in the body of the macro. Arguments that have side effects, or that
+// could fail (like dereferencing a pointer) are not pure.
+func isPureMacroArgument(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.DeclRefExpr, *ast.IntegerLiteral, *ast.FloatingLiteral,
+		*ast.CharacterLiteral:
+		return true
+
+	case *ast.ParenExpr, *ast.ImplicitCastExpr, *ast.CStyleCastExpr,
+		*ast.ConstantExpr:
+
+	case *ast.UnaryOperator:
+		switch n.Operator {
+		case "++", "--", "*", "&":
+			return false
+		}
+
+	case *ast.BinaryOperator:
+		switch n.Operator {
+		case "=", ",", "/", "%":
+			return false
+		}
+
+	case *ast.MemberExpr:
+		if n.IsPointer {
+			return false
+		}
+
+	default:
+		return false
+	}
+
+	for _, child := range node.Children() {
+		if child == nil || !isPureMacroArgument(child) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,162 @@
+package transpiler
+
+import (
+	"bytes"
+	"go/printer"
+	"go/token"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestTranspileMacroFunction(t *testing.T) {
+	tests := []struct {
+		params     []string
+		body       string
+		expected   string
+		constraint string
+		cType      string
+	}{
+		{
+			params: []string{"a", "b"},
+			body:   "((a) > (b) ? (a) : (b))",
+			expected: `func MAX[T noarch.Number](a, b T) T {
+	if a > b {
+		return a
+	}
+	return b
+}`,
+			constraint: "noarch.Number",
+		},
+		{
+			params: []string{"x"},
+			body:   "((x) % 2 == 0)",
+			expected: `func MAX[T noarch.Integer](x T) int32 {
+	if x%2 == 0 {
+		return 1
+	}
+	return 0
+}`,
+			constraint: "noarch.Integer",
+			cType:      "int",
+		},
+		{
+			params: []string{"x"},
+			body:   "((x) * SIZE + 0.5f)",
+			expected: `func MAX[T noarch.Float](x T) T {
+	return x*T(SIZE) + 0.5
+}`,
+			constraint: "noarch.Float",
+		},
+		{
+			params: []string{"x", "y"},
+			body:   "((x) & ((y) | 1) ? -1 : ~(x))",
+			expected: `func MAX[T noarch.Integer](x, y T) T {
+	if x&(y|1) != 0 {
+		return -T(1)
+	}
+	return ^x
+}`,
+			constraint: "noarch.Integer",
+		},
+		{
+			params: []string{"T"},
+			body:   "(!(T))",
+			expected: `func MAX[T1 noarch.Number](T T1) int32 {
+	if !(T != 0) {
+		return 1
+	}
+	return 0
+}`,
+			constraint: "noarch.Number",
+			cType:      "int",
+		},
+		{params: []string{"x"}, body: "foo(x)"},
+		{params: []string{"x"}, body: "((x)++)"},
+		{params: []string{"x"}, body: "((x) = 1)"},
+		{params: []string{"x"}, body: "((int)(x))"},
+		{params: []string{"x", "y"}, body: "(x)"},
+		{params: []string{"x"}, body: `(x ? "a" : "b")`},
+		{params: []string{"x"}, body: "((x) % 1.5)"},
+		{params: []string{"x"}, body: "((x) + (1 - 2))"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.body, func(t *testing.T) {
+			p := program.NewProgram()
+			p.Macros = []program.Macro{
+				{Name: "SIZE", Body: "16"},
+				{Name: "MAX", Body: test.body, Params: test.params,
+					Function: true},
+			}
+			transpileMacros(p, nil)
+
+			macro := &p.Macros[1]
+			f, err := transpileMacroFunction(p, macro)
+			if test.expected == "" {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			printer.Fprint(&buf, token.NewFileSet(), f)
+			if buf.String() != test.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", test.expected, buf.String())
+			}
+
+			if macro.Constraint != test.constraint {
+				t.Errorf("expected constraint %s, got %s", test.constraint,
+					macro.Constraint)
+			}
+			if macro.CType != test.cType {
+				t.Errorf("expected C type %s, got %s", test.cType, macro.CType)
+			}
+		})
+	}
+}
+
+func TestTranspileMacroCall(t *testing.T) {
+	p := program.NewProgram()
+	p.Macros = []program.Macro{
+		{Name: "MAX", Body: "((a) > (b) ? (a) : (b))", Params: []string{"a", "b"},
+			Function: true},
+	}
+	transpileMacros(p, nil)
+
+	a := &ast.IntegerLiteral{Type: "int", Value: "1"}
+	b := &ast.CharacterLiteral{Type: "char", Value: 'x'}
+	node := &ast.ConditionalOperator{Type: "int"}
+	p.AddMacroExpansion(node, "MAX", a, b)
+
+	expr, exprType, _, _, err := transpileToExpr(node, p, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	printer.Fprint(&buf, token.NewFileSet(), expr)
+	expected := "MAX[int32](int32(1), int32('x'))"
+	if buf.String() != expected {
+		t.Errorf("expected %s, got %s", expected, buf.String())
+	}
+	if exprType != "int" {
+		t.Errorf("expected int, got %s", exprType)
+	}
+
+	// Arguments that have side effects are not moved into the call.
+	increment := &ast.UnaryOperator{Type: "int", Operator: "++",
+		ChildNodes: []ast.Node{a}}
+	other := &ast.ConditionalOperator{Type: "int"}
+	p.AddMacroExpansion(other, "MAX", increment, b)
+
+	_, _, _, _, ok := transpileMacroExpansion(p, other)
+	if ok {
+		t.Errorf("expected the expansion to not be replaced")
+	}
+}
@@ -0,0 +1,484 @@
+// This file contains the transpiling of object-like macros (#define) into Go
+// constants.
+
+package transpiler
+
+import (
+	"fmt"
+	goast "go/ast"
+	"go/constant"
+	"go/token"
+	gotypes "go/types"
+	"math"
+	"strconv"
+	"strings"
+	"text/scanner"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
+	"github.com/elliotchance/c2go/util"
+)
+
+// evaluatedMacro is the result of evaluating the body of a macro as a Go
+// constant expression.
+type evaluatedMacro struct {
+	cType string
+	value constant.Value
+
+	// goExpr is the body of the macro as a Go expression, like "1 << 4".
+	goExpr string
+
+	// references are the names of other macros used in the body.
+	references []string
+}
+
+// transpileMacros evaluates each of the object-like macros in p.Macros and
+// returns a Go constant declaration for each of the macros that is a constant
+// expression, like:
+//
+//     #define BUFSIZE 1024
+//
+// Becomes:
+//
+//     const BUFSIZE int32 = 1024
+//
+// Program.Macro.CType is set for each of the macros that are constants so
+// that the expansions of the macro can be replaced with the name of the
+// constant.
+//
+// Macros that are not constant expressions (like strings, casts or anything
+// that refers to a variable or function) are ignored. Macros that have the
+// same name as a declaration in the C source or a predeclared Go identifier
+// are also ignored.
+//
+// Function-like macros are only in p.Macros if they were enabled with
+// transpile.Options.MacroFunctions. See transpileMacroFunction.
+func transpileMacros(p *program.Program, root ast.Node) (decls []goast.Decl) {
+	if len(p.Macros) == 0 {
+		return nil
+	}
+
+	declared := map[string]bool{}
+	if root != nil {
+		for _, name := range topLevelNames(root) {
+			declared[name] = true
+		}
+	}
+
+	pkg := gotypes.NewPackage("macros", "macros")
+	evaluated := map[string]*evaluatedMacro{}
+
+	var (
+		decl     *goast.GenDecl
+		declFile string
+	)
+	for i := range p.Macros {
+		macro := &p.Macros[i]
+		if macro.Function || declared[macro.Name] ||
+			token.IsKeyword(macro.Name) ||
+			gotypes.Universe.Lookup(macro.Name) != nil {
+			continue
+		}
+
+		m, err := evaluateMacro(pkg, macro.Body, evaluated)
+		if err != nil {
+			continue
+		}
+
+		goType, err := types.ResolveType(p, m.cType)
+		if err != nil {
+			continue
+		}
+
+		// The expression can only be used if it does not need conversions
+		// between the types of the other constants. Otherwise the exact
+		// value is used.
+		value := m.goExpr
+		for _, reference := range m.references {
+			if evaluated[reference].cType != m.cType {
+				value = constantString(m.value)
+				break
+			}
+		}
+
+		untypedKind := gotypes.UntypedInt
+		if m.value.Kind() == constant.Float {
+			untypedKind = gotypes.UntypedFloat
+		}
+		pkg.Scope().Insert(gotypes.NewConst(token.NoPos, pkg, macro.Name,
+			gotypes.Typ[untypedKind], m.value))
+		evaluated[macro.Name] = m
+		macro.CType = m.cType
+		macro.Value = constantString(m.value)
+
+		spec := &goast.ValueSpec{
+			Names:  []*goast.Ident{util.NewIdent(macro.Name)},
+			Type:   util.NewTypeIdent(goType),
+			Values: []goast.Expr{&goast.Ident{Name: value}},
+		}
+
+		// Consecutive macros from the same file are grouped into one const
+		// block.
+		if decl == nil || declFile != macro.File {
+			decl = &goast.GenDecl{Tok: token.CONST}
+			declFile = macro.File
+			decls = append(decls, decl)
+			p.SetDeclFile(declFile, decl)
+		}
+		decl.Specs = append(decl.Specs, spec)
+		if len(decl.Specs) > 1 {
+			decl.Lparen = 1
+		}
+	}
+
+	// The function-like macros are transpiled after all of the constants
+	// because they can use constants that are defined after them.
+	for i := range p.Macros {
+		macro := &p.Macros[i]
+		if !macro.Function || declared[macro.Name] ||
+			token.IsKeyword(macro.Name) ||
+			gotypes.Universe.Lookup(macro.Name) != nil {
+			continue
+		}
+
+		f, err := transpileMacroFunction(p, macro)
+		if err != nil {
+			continue
+		}
+
+		decls = append(decls, f)
+		p.SetDeclFile(macro.File, f)
+	}
+
+	return
+}
+
+// transpileMacroExpansion returns the name of the constant if the node is the
+// expansion of a macro with the same type, or a call to the Go function for a
+// function-like macro.
+func transpileMacroExpansion(p *program.Program, node ast.Node) (
+	expr goast.Expr, exprType string, preStmts []goast.Stmt,
+	postStmts []goast.Stmt, ok bool) {
+
+	macro, args := p.GetMacroExpansion(node)
+	if macro == nil {
+		return
+	}
+
+	var cType string
+	switch n := node.(type) {
+	case *ast.IntegerLiteral:
+		cType = n.Type
+	case *ast.FloatingLiteral:
+		cType = n.Type
+	case *ast.CharacterLiteral:
+		cType = n.Type
+	case *ast.ParenExpr:
+		cType = n.Type
+	case *ast.UnaryOperator:
+		cType = n.Type
+	case *ast.BinaryOperator:
+		cType = n.Type
+	case *ast.ConditionalOperator:
+		cType = n.Type
+	}
+	cType = types.CleanCType(cType)
+
+	if macro.Function {
+		return transpileMacroCall(p, macro, args, cType)
+	}
+
+	if cType != macro.CType {
+		return
+	}
+
+	return util.NewIdent(macro.Name), macro.CType, nil, nil, true
+}
+
+// evaluateMacro converts the body of a C macro to a Go expression and
+// evaluates it. An error is returned if the body is not a numeric constant
+// expression.
+func evaluateMacro(pkg *gotypes.Package, body string,
+	evaluated map[string]*evaluatedMacro) (*evaluatedMacro, error) {
+
+	var (
+		s                   scanner.Scanner
+		goExpr              strings.Builder
+		lastEnd, lastTokEnd int
+		lastTok             rune
+		isUnsigned, isLong  bool
+		floats, floatSuffix int
+		hasFloat, hasDouble bool
+		references          []string
+
+		// operandType is the widest C integer type of the operands, and
+		// lastInt is the value of the last integer literal for its suffix.
+		operandType string
+		lastInt     constant.Value
+	)
+
+	s.Init(strings.NewReader(body))
+	s.Mode = scanner.ScanIdents | scanner.ScanInts | scanner.ScanFloats |
+		scanner.ScanChars | scanner.ScanStrings | scanner.ScanComments |
+		scanner.SkipComments
+	var scanErr error
+	s.Error = func(_ *scanner.Scanner, msg string) {
+		scanErr = fmt.Errorf("cannot scan macro: %s", msg)
+	}
+
+	for tok := s.Scan(); tok != scanner.EOF; tok = s.Scan() {
+		text := s.TokenText()
+		start := s.Position.Offset
+		replacement := text
+
+		switch tok {
+		case scanner.Int:
+			lastInt = constant.MakeFromLiteral(text, token.INT, 0)
+			t, err := integerMacroType(lastInt, false, false)
+			if err != nil {
+				return nil, err
+			}
+			operandType = widerIntegerType(operandType, t)
+
+		case scanner.Float:
+			// The suffix is scanned separately as an identifier.
+			floats++
+
+		case scanner.Ident:
+			suffix := strings.ToLower(text)
+			isSuffix := start == lastTokEnd &&
+				(lastTok == scanner.Int || lastTok == scanner.Float)
+
+			switch {
+			case isSuffix && lastTok == scanner.Int &&
+				strings.Trim(suffix, "ul") == "":
+				isUnsigned = isUnsigned || strings.Contains(suffix, "u")
+				isLong = isLong || strings.Contains(suffix, "l")
+				replacement = ""
+
+				t, err := integerMacroType(lastInt,
+					strings.Contains(suffix, "u"), strings.Contains(suffix, "l"))
+				if err != nil {
+					return nil, err
+				}
+				if strings.Contains(suffix, "ll") {
+					t = strings.Replace(t, "long", "long long", 1)
+				}
+				operandType = widerIntegerType(operandType, t)
+
+			case isSuffix && lastTok == scanner.Float &&
+				(suffix == "f" || suffix == "l"):
+				if suffix == "f" {
+					floatSuffix++
+				}
+				replacement = ""
+
+			case evaluated[text] != nil:
+				references = append(references, text)
+				if _, ok := integerRanks[evaluated[text].cType]; ok {
+					operandType = widerIntegerType(operandType, evaluated[text].cType)
+				}
+				switch evaluated[text].cType {
+				case "unsigned int":
+					isUnsigned = true
+				case "long", "long long":
+					isLong = true
+				case "unsigned long", "unsigned long long":
+					isUnsigned, isLong = true, true
+				case "float":
+					hasFloat = true
+				case "double":
+					hasDouble = true
+				}
+
+			default:
+				return nil, fmt.Errorf("unknown identifier: %s", text)
+			}
+
+		case scanner.Char:
+			c, err := parseCharacterConstant(text)
+			if err != nil {
+				return nil, err
+			}
+			replacement = strconv.Itoa(c)
+			operandType = widerIntegerType(operandType, "int")
+
+		case '~':
+			replacement = "^"
+
+		case scanner.String:
+			return nil, fmt.Errorf("strings are not supported")
+		}
+
+		goExpr.WriteString(body[lastEnd:start])
+		goExpr.WriteString(replacement)
+		lastEnd = start + len(text)
+		lastTok, lastTokEnd = tok, lastEnd
+	}
+	goExpr.WriteString(body[lastEnd:])
+
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	expr := strings.TrimSpace(goExpr.String())
+	tv, err := gotypes.Eval(token.NewFileSet(), pkg, token.NoPos, expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if tv.Value == nil {
+		return nil, fmt.Errorf("not a constant: %s", expr)
+	}
+
+	m := &evaluatedMacro{
+		value:      tv.Value,
+		goExpr:     expr,
+		references: references,
+	}
+
+	switch tv.Value.Kind() {
+	case constant.Int:
+		m.cType, err = integerMacroType(tv.Value, isUnsigned, isLong)
+		if err != nil {
+			return nil, err
+		}
+
+		// The Go constant has an arbitrary precision, but in C the result
+		// has the type of the operands. For example "1 << 31" is an int that
+		// overflows, so its value is not 2147483648.
+		if floats == 0 && operandType != "" &&
+			integerRanks[m.cType] > integerRanks[operandType] {
+			return nil, fmt.Errorf("%s overflows %s", expr, operandType)
+		}
+
+	case constant.Float:
+		// The value is only a float if every floating literal (and every
+		// other macro) is a float.
+		hasFloat = hasFloat || (floats > 0 && floats == floatSuffix)
+		hasDouble = hasDouble || floats != floatSuffix
+		m.cType = "double"
+		if hasFloat && !hasDouble {
+			m.cType = "float"
+		}
+
+	default:
+		return nil, fmt.Errorf("not a number: %s", expr)
+	}
+
+	return m, nil
+}
+
+// integerMacroType returns the smallest C integer type that can hold the
+// value, in the same way that C chooses the type of an integer literal. The
+// types are chosen so that the value also fits in the Go type from
+// types.ResolveType (where long is 32 bits).
+func integerMacroType(value constant.Value, isUnsigned, isLong bool) (string, error) {
+	if isUnsigned {
+		v, ok := constant.Uint64Val(value)
+		switch {
+		case !ok:
+			return "", fmt.Errorf("%s does not fit in unsigned long long", value)
+		case v > math.MaxUint32:
+			return "unsigned long long", nil
+		case isLong:
+			return "unsigned long", nil
+		}
+		return "unsigned int", nil
+	}
+
+	v, ok := constant.Int64Val(value)
+	switch {
+	case !ok:
+		return "", fmt.Errorf("%s does not fit in long long", value)
+	case v < math.MinInt32 || v > math.MaxInt32:
+		return "long long", nil
+	case isLong:
+		return "long", nil
+	}
+	return "int", nil
+}
+
+// integerRanks orders the C integer types of integerMacroType by the values
+// that they can hold.
+var integerRanks = map[string]int{
+	"int":                0,
+	"unsigned int":       1,
+	"long":               2,
+	"unsigned long":      3,
+	"long long":          4,
+	"unsigned long long": 5,
+}
+
+// widerIntegerType returns the C integer type of a and b that can hold the
+// most values. Either type may be empty.
+func widerIntegerType(a, b string) string {
+	if a == "" || integerRanks[b] > integerRanks[a] {
+		return b
+	}
+
+	return a
+}
+
+// constantString returns the exact value of a constant as a Go literal.
+func constantString(value constant.Value) string {
+	if value.Kind() == constant.Float {
+		f, _ := constant.Float64Val(value)
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+
+	return value.ExactString()
+}
+
+// parseCharacterConstant returns the value of a C character constant like
+// 'a', '\n' or '\0'.
+func parseCharacterConstant(s string) (int, error) {
+	if len(s) < 3 || s[0] != '\'' || s[len(s)-1] != '\'' {
+		return 0, fmt.Errorf("not a character constant: %s", s)
+	}
+
+	inner := s[1 : len(s)-1]
+
+	// C allows octal escapes with fewer than three digits, Go does not.
+	if len(inner) > 1 && inner[0] == '\\' && inner[1] >= '0' && inner[1] <= '7' {
+		v, err := strconv.ParseInt(inner[1:], 8, 32)
+		return int(v), err
+	}
+
+	v, _, tail, err := strconv.UnquoteChar(inner, '\'')
+	if err != nil {
+		return 0, err
+	}
+	if tail != "" {
+		return 0, fmt.Errorf("multi-character constants are not supported: %s", s)
+	}
+
+	return int(v), nil
+}
+
+// topLevelNames returns the names of the declarations (and enum constants) in
+// the translation unit.
+func topLevelNames(root ast.Node) (names []string) {
+	for _, node := range root.Children() {
+		switch n := node.(type) {
+		case *ast.FunctionDecl:
+			names = append(names, n.Name)
+		case *ast.VarDecl:
+			names = append(names, n.Name)
+		case *ast.TypedefDecl:
+			names = append(names, n.Name)
+		case *ast.RecordDecl:
+			names = append(names, n.Name)
+		case *ast.EnumDecl:
+			names = append(names, n.Name)
+			for _, child := range n.Children() {
+				if c, ok := child.(*ast.EnumConstantDecl); ok {
+					names = append(names, c.Name)
+				}
+			}
+		}
+	}
+
+	return
+}
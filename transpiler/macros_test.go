@@ -0,0 +1,148 @@
+package transpiler
+
+import (
+	"bytes"
+	"go/printer"
+	"go/token"
+	"testing"
+
+	goast "go/ast"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestTranspileMacros(t *testing.T) {
+	p := program.NewProgram()
+	p.Macros = []program.Macro{
+		{Name: "SIZE", Body: "1024", File: "a.h"},
+		{Name: "MASK", Body: "(1 << 4) - 1", File: "a.h"},
+		{Name: "BIG", Body: "4000000000", File: "a.h"},
+		{Name: "FLAGS", Body: "0x10u", File: "a.h"},
+		{Name: "NOT", Body: "~0", File: "a.h"},
+		{Name: "PI", Body: "3.14159", File: "a.h"},
+		{Name: "HALF", Body: "0.5f", File: "a.h"},
+		{Name: "NEWLINE", Body: `'\n'`, File: "a.h"},
+		{Name: "DOUBLE_SIZE", Body: "SIZE * 2", File: "a.h"},
+		{Name: "BIG_SIZE", Body: "SIZE + BIG", File: "a.h"},
+		{Name: "NAME", Body: `"foo"`, File: "a.h"},
+		{Name: "CALL", Body: "foo(1)", File: "a.h"},
+		{Name: "CAST", Body: "(char)1", File: "a.h"},
+		{Name: "main", Body: "1", File: "a.h"},
+		{Name: "len", Body: "1", File: "a.h"},
+		{Name: "HIGH_BIT", Body: "(1 << 31)", File: "a.h"},
+		{Name: "HIGH_BIT_U", Body: "(1u << 31)", File: "a.h"},
+		{Name: "HIGH_BIT_LL", Body: "(1LL << 31)", File: "a.h"},
+		{Name: "TOO_BIG", Body: "SIZE * 4194304", File: "a.h"},
+		{Name: "OTHER", Body: "2", File: "b.h"},
+	}
+
+	root := &ast.TranslationUnitDecl{}
+	root.AddChild(&ast.FunctionDecl{Name: "main"})
+
+	decls := transpileMacros(p, root)
+	if len(decls) != 2 {
+		t.Fatalf("expected 2 declarations, got %d", len(decls))
+	}
+
+	var buf bytes.Buffer
+	for _, decl := range decls {
+		printer.Fprint(&buf, token.NewFileSet(), decl)
+		buf.WriteString("\n")
+	}
+
+	expected := `const (
+	SIZE		int32	= 1024
+	MASK		int32	= (1 << 4) - 1
+	BIG		int64	= 4000000000
+	FLAGS		uint32	= 0x10
+	NOT		int32	= ^0
+	PI		float64	= 3.14159
+	HALF		float32	= 0.5
+	NEWLINE		int32	= 10
+	DOUBLE_SIZE	int32	= SIZE * 2
+	BIG_SIZE	int64	= 4000001024
+	HIGH_BIT_U	uint32	= (1 << 31)
+	HIGH_BIT_LL	int64	= (1 << 31)
+)
+const OTHER int32 = 2
+`
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	for _, macro := range p.Macros {
+		isConst := macro.CType != ""
+		shouldBeConst := true
+		switch macro.Name {
+		case "NAME", "CALL", "CAST", "main", "len":
+			shouldBeConst = false
+
+		// In C these overflow an int, so the values are not the same as the
+		// Go constants.
+		case "HIGH_BIT", "TOO_BIG":
+			shouldBeConst = false
+		}
+
+		if isConst != shouldBeConst {
+			t.Errorf("%s: expected constant to be %v", macro.Name, shouldBeConst)
+		}
+	}
+}
+
+func TestTranspileMacroExpansion(t *testing.T) {
+	p := program.NewProgram()
+	p.Macros = []program.Macro{
+		{Name: "SIZE", Body: "1024", File: "a.h"},
+	}
+	transpileMacros(p, nil)
+
+	literal := &ast.IntegerLiteral{Type: "int", Value: "1024"}
+	p.AddMacroExpansion(literal, "SIZE")
+
+	expr, exprType, _, _, err := transpileToExpr(literal, p, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ident, ok := expr.(*goast.Ident); !ok || ident.Name != "SIZE" {
+		t.Errorf("expected SIZE, got %#v", expr)
+	}
+	if exprType != "int" {
+		t.Errorf("expected int, got %s", exprType)
+	}
+
+	// The expansion is not replaced if the type is different, for example if
+	// the macro was used as the size of a long.
+	other := &ast.IntegerLiteral{Type: "long", Value: "1024"}
+	p.AddMacroExpansion(other, "SIZE")
+	expr, _, _, _, err = transpileToExpr(other, p, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ident, ok := expr.(*goast.Ident); ok && ident.Name == "SIZE" {
+		t.Errorf("expected literal, got %#v", expr)
+	}
+}
+
+func TestParseCharacterConstant(t *testing.T) {
+	tests := map[string]int{
+		`'a'`:    97,
+		`'\n'`:   10,
+		`'\0'`:   0,
+		`'\033'`: 27,
+		`'\x41'`: 65,
+		`'\''`:   39,
+	}
+
+	for in, expected := range tests {
+		actual, err := parseCharacterConstant(in)
+		if err != nil {
+			t.Errorf("%s: %v", in, err)
+			continue
+		}
+		if actual != expected {
+			t.Errorf("%s: expected %d, got %d", in, expected, actual)
+		}
+	}
+}
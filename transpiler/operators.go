@@ -69,8 +69,8 @@ func transpileConditionalOperator(n *ast.ConditionalOperator, p *program.Program
 	}
 	// Theoretically, length is must be zero
 	if len(newPre) > 0 || len(newPost) > 0 {
-		p.AddMessage(p.GenerateWarningMessage(
-			fmt.Errorf("length of pre or post in body must be zero. {%d,%d}", len(newPre), len(newPost)), n))
+		p.AddWarning(
+			fmt.Errorf("length of pre or post in body must be zero. {%d,%d}", len(newPre), len(newPost)), n)
 	}
 	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
 
@@ -153,7 +153,7 @@ func transpileParenExpr(n *ast.ParenExpr, p *program.Program) (
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("Cannot transpile ParenExpr. err = %v", err)
-			p.AddMessage(p.GenerateWarningMessage(err, n))
+			p.AddWarning(err, n)
 		}
 	}()
 
@@ -301,6 +301,13 @@ func transpileCompoundAssignOperator(
 
 	operator := getTokenForOperator(n.Opcode)
 
+	// A compound assignment to a bit-field is a call of its setter.
+	if m, ok := n.Children()[0].(*ast.MemberExpr); ok {
+		if bitField, ok := getBitField(p, m); ok {
+			return transpileBitFieldAssign(p, m, bitField, n.Opcode, n.Children()[1])
+		}
+	}
+
 	right, rightType, newPre, newPost, err := atomicOperation(n.Children()[1], p)
 	if err != nil {
 		return nil, "", nil, nil, err
@@ -341,7 +348,7 @@ func transpileCompoundAssignOperator(
 	// To handle this, cast the shift count to a uint64.
 	if operator == token.SHL_ASSIGN || operator == token.SHR_ASSIGN {
 		right, err = types.CastExpr(p, right, rightType, "unsigned long long")
-		p.AddMessage(p.GenerateWarningOrErrorMessage(err, n, right == nil))
+		p.AddWarningOrError(err, n, right == nil)
 		if right == nil {
 			right = util.NewNil()
 		}
@@ -350,12 +357,12 @@ func transpileCompoundAssignOperator(
 	switch operator {
 	case token.AND_ASSIGN, token.OR_ASSIGN, token.XOR_ASSIGN, token.AND_NOT_ASSIGN:
 		right, err = types.CastExpr(p, right, rightType, leftType)
-		p.AddMessage(p.GenerateWarningMessage(err, n))
+		p.AddWarning(err, n)
 	}
 
 	resolvedLeftType, err := types.ResolveType(p, leftType)
 	if err != nil {
-		p.AddMessage(p.GenerateWarningMessage(err, n))
+		p.AddWarning(err, n)
 	}
 
 	if right == nil {
@@ -369,7 +376,7 @@ func transpileCompoundAssignOperator(
 
 	right, err = types.CastExpr(p, right, rightType, leftType)
 	if err != nil {
-		p.AddMessage(p.GenerateWarningMessage(err, n))
+		p.AddWarning(err, n)
 	}
 
 	return util.NewBinaryExpr(left, operator, right, resolvedLeftType, exprIsStmt),
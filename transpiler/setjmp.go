@@ -0,0 +1,229 @@
+// This file contains the transpiling of setjmp() and longjmp().
+
+package transpiler
+
+import (
+	"fmt"
+	goast "go/ast"
+	"go/token"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/util"
+)
+
+// setjmpFunctions are the Go functions that setjmp() and sigsetjmp() are
+// substituted with. See program/function_definition.go.
+var setjmpFunctions = map[string]bool{
+	"noarch.Setjmp":    true,
+	"noarch.Sigsetjmp": true,
+}
+
+// lowerSetjmp rewrites the body of a function that calls setjmp() so that a
+// longjmp() returns to the setjmp() again. returnType is the Go return type of
+// the function.
+//
+// Go functions cannot return twice. Instead, the statement that calls setjmp()
+// and all of the statements after it are run in a closure that recovers the
+// panic from noarch.Longjmp. If there was a longjmp the closure is run again
+// with the value that setjmp() returns:
+//
+//     var c2goSetjmpValue0 int32
+//     for {
+//         var c2goLongjmp1 bool
+//         c2goSetjmpResult2 := func() (c2goDefaultReturn int32) {
+//             defer noarch.RecoverLongjmp(&env, &c2goSetjmpValue0, &c2goLongjmp1)
+//             if noarch.Setjmp(&env, c2goSetjmpValue0) != 0 {
+//                 ...
+//             }
+//             ...
+//         }()
+//         if !c2goLongjmp1 {
+//             return c2goSetjmpResult2
+//         }
+//     }
+//
+// The setjmp() must be in a statement at the top level of the function (such
+// as the condition of an if statement), which is where it is used in practice.
+func lowerSetjmp(p *program.Program, n ast.Node, body *goast.BlockStmt,
+	returnType string) {
+	if body == nil {
+		return
+	}
+
+	index := -1
+	for i, stmt := range body.List {
+		if len(findSetjmpCalls(stmt, true)) > 0 {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return
+	}
+
+	calls := findSetjmpCalls(body.List[index], false)
+	err := checkSetjmp(body, index, calls)
+	if err != nil {
+		p.AddWarning(fmt.Errorf("cannot transpile setjmp: %v", err), n)
+
+		// The setjmp will always return 0 so the code still compiles.
+		for _, stmt := range body.List {
+			for _, call := range findSetjmpCalls(stmt, true) {
+				call.Args = append(call.Args, util.NewIntLit(0))
+			}
+		}
+		return
+	}
+
+	valueName := p.GetNextIdentifier("c2goSetjmpValue")
+	jumpedName := p.GetNextIdentifier("c2goLongjmp")
+	resultName := p.GetNextIdentifier("c2goSetjmpResult")
+
+	call := calls[0]
+	call.Args = append(call.Args, util.NewIdent(valueName))
+
+	// Any setjmp after this one is run inside of this closure.
+	rest := &goast.BlockStmt{List: body.List[index+1:]}
+	lowerSetjmp(p, n, rest, returnType)
+
+	stmts := []goast.Stmt{
+		&goast.DeferStmt{
+			Call: util.NewCallExpr("noarch.RecoverLongjmp",
+				call.Args[0],
+				&goast.UnaryExpr{Op: token.AND, X: util.NewIdent(valueName)},
+				&goast.UnaryExpr{Op: token.AND, X: util.NewIdent(jumpedName)},
+			),
+		},
+		body.List[index],
+	}
+	stmts = append(stmts, rest.List...)
+
+	addReturn := false
+	if _, ok := stmts[len(stmts)-1].(*goast.ReturnStmt); !ok && returnType != "" {
+		stmts = append(stmts, &goast.ReturnStmt{})
+		addReturn = true
+	}
+
+	closure := &goast.CallExpr{
+		Fun: &goast.FuncLit{
+			Type: util.NewFuncType(&goast.FieldList{}, returnType, addReturn),
+			Body: &goast.BlockStmt{List: stmts},
+		},
+	}
+
+	var callStmt goast.Stmt = &goast.ExprStmt{X: closure}
+	ret := &goast.ReturnStmt{}
+	if returnType != "" {
+		callStmt = &goast.AssignStmt{
+			Lhs: []goast.Expr{util.NewIdent(resultName)},
+			Tok: token.DEFINE,
+			Rhs: []goast.Expr{closure},
+		}
+		ret.Results = []goast.Expr{util.NewIdent(resultName)}
+	}
+
+	p.AddImport("github.com/elliotchance/c2go/noarch")
+	body.List = append(body.List[:index:index],
+		&goast.DeclStmt{Decl: &goast.GenDecl{
+			Tok: token.VAR,
+			Specs: []goast.Spec{&goast.ValueSpec{
+				Names: []*goast.Ident{util.NewIdent(valueName)},
+				Type:  util.NewTypeIdent("int32"),
+			}},
+		}},
+		&goast.ForStmt{
+			Body: &goast.BlockStmt{List: []goast.Stmt{
+				&goast.DeclStmt{Decl: &goast.GenDecl{
+					Tok: token.VAR,
+					Specs: []goast.Spec{&goast.ValueSpec{
+						Names: []*goast.Ident{util.NewIdent(jumpedName)},
+						Type:  util.NewTypeIdent("bool"),
+					}},
+				}},
+				callStmt,
+				&goast.IfStmt{
+					Cond: &goast.UnaryExpr{
+						Op: token.NOT,
+						X:  util.NewIdent(jumpedName),
+					},
+					Body: &goast.BlockStmt{List: []goast.Stmt{ret}},
+				},
+			}},
+		},
+	)
+}
+
+// checkSetjmp checks that the statement at index in the body (that calls
+// setjmp) can be run again after a longjmp.
+func checkSetjmp(body *goast.BlockStmt, index int,
+	calls []*goast.CallExpr) error {
+
+	if len(calls) == 0 {
+		return fmt.Errorf("setjmp must be called at the top level of a function")
+	}
+
+	if len(calls) > 1 {
+		return fmt.Errorf("only one setjmp can be called in a statement")
+	}
+
+	// The statements before the setjmp are not in the closure, so a goto
+	// cannot jump between them and the statements after the setjmp.
+	a := analyzeGotoStmts(body)
+	for name, gotos := range a.gotos {
+		label, ok := a.labels[name]
+		if !ok {
+			continue
+		}
+
+		for _, g := range gotos {
+			if (topLevelIndex(g) < index) != (topLevelIndex(label) < index) {
+				return fmt.Errorf("goto %s jumps over the setjmp", name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// topLevelIndex returns the index of the statement in the function body that
+// contains the position.
+func topLevelIndex(position gotoPosition) int {
+	b, i := position.block, position.index
+	for b.parent != nil {
+		b, i = b.parent, b.index
+	}
+
+	return i
+}
+
+// findSetjmpCalls returns the calls to setjmp in a statement. Unless deep is
+// true the bodies of nested statements (and closures) are not searched since
+// they cannot be run again from the setjmp.
+func findSetjmpCalls(stmt goast.Stmt, deep bool) (calls []*goast.CallExpr) {
+	goast.Inspect(stmt, func(node goast.Node) bool {
+		switch n := node.(type) {
+		case *goast.BlockStmt, *goast.CaseClause:
+			return deep || node == stmt
+		case *goast.CallExpr:
+			if setjmpFunctions[goExprString(n.Fun)] && len(n.Args) > 0 {
+				calls = append(calls, n)
+			}
+		}
+		return true
+	})
+
+	return
+}
+
+// goExprString returns the name of a function like "noarch.Setjmp".
+func goExprString(e goast.Expr) string {
+	switch e := e.(type) {
+	case *goast.Ident:
+		return e.Name
+	case *goast.SelectorExpr:
+		return goExprString(e.X) + "." + e.Sel.Name
+	}
+
+	return ""
+}
@@ -0,0 +1,130 @@
+package transpiler
+
+import (
+	"bytes"
+	goast "go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestLowerSetjmp(t *testing.T) {
+	tests := []struct {
+		name       string
+		returnType string
+		function   string
+		expected   string
+		warning    bool
+	}{
+		{
+			name:       "no setjmp",
+			returnType: "int32",
+			function: `func f() int32 {
+	return 0
+}`,
+			expected: `func f() int32 {
+	return 0
+}`,
+		},
+		{
+			name:       "setjmp in if",
+			returnType: "int32",
+			function: `func f() int32 {
+	var n int32 = 0
+	if noarch.Setjmp(&env) != 0 {
+		return -1
+	}
+	n = parse()
+	return n
+}`,
+			expected: `func f() int32 {
+	var n int32 = 0
+	var c2goSetjmpValue0 int32
+	for {
+		var c2goLongjmp1 bool
+		c2goSetjmpResult2 := func() int32 {
+			defer noarch.RecoverLongjmp(&env, &c2goSetjmpValue0, &c2goLongjmp1)
+			if noarch.Setjmp(&env, c2goSetjmpValue0) != 0 {
+				return -1
+			}
+			n = parse()
+			return n
+		}()
+		if !c2goLongjmp1 {
+			return c2goSetjmpResult2
+		}
+	}
+}`,
+		},
+		{
+			name: "void function",
+			function: `func f() {
+	var r int32 = noarch.Sigsetjmp(env, 1)
+	run(r)
+}`,
+			expected: `func f() {
+	var c2goSetjmpValue0 int32
+	for {
+		var c2goLongjmp1 bool
+		func() {
+			defer noarch.RecoverLongjmp(env, &c2goSetjmpValue0, &c2goLongjmp1)
+			var r int32 = noarch.Sigsetjmp(env, 1, c2goSetjmpValue0)
+			run(r)
+		}()
+		if !c2goLongjmp1 {
+			return
+		}
+	}
+}`,
+		},
+		{
+			name: "setjmp in a loop",
+			function: `func f() {
+	for {
+		if noarch.Setjmp(&env) == 0 {
+			run()
+		}
+	}
+}`,
+			expected: `func f() {
+	for {
+		if noarch.Setjmp(&env, 0) == 0 {
+			run()
+		}
+	}
+}`,
+			warning: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file, err := parser.ParseFile(token.NewFileSet(), "",
+				"package p\n\n"+test.function, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			decl := file.Decls[0].(*goast.FuncDecl)
+			p := program.NewProgram()
+			lowerSetjmp(p, &ast.FunctionDecl{}, decl.Body, test.returnType)
+
+			if warning := len(p.Messages()) > 0; warning != test.warning {
+				t.Errorf("expected warning to be %v, got %v", test.warning,
+					p.Messages())
+			}
+
+			var buf bytes.Buffer
+			if err := format.Node(&buf, token.NewFileSet(), decl); err != nil {
+				t.Fatal(err)
+			}
+			if actual := buf.String(); actual != test.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// This file contains the transpiling of static local variables.
+//
+// A static local variable keeps its value between the calls of the function.
+// Go does not have static local variables so each one is moved out of the
+// function to a package-level variable with a unique name:
+//
+//     int next() {                   func next() int32 {
+//         static int counter = 0;        next_counter_0++
+//         return ++counter;              return next_counter_0
+//     }                              }
+//
+//                                    var next_counter_0 int32 = 0
+//
+// The initializer of a static variable must be a constant expression in C, so
+// it is evaluated only once at the package level.
+
+package transpiler
+
+import (
+	"fmt"
+	goast "go/ast"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+// hoistStaticLocals moves the static local variables of the function body to
+// package-level variables and returns the declarations of these variables.
+//
+// The VarDecl nodes are removed from the body and the variables (and all of
+// the references to them) are renamed, so the body must be transpiled after
+// this.
+func hoistStaticLocals(p *program.Program, f *ast.FunctionDecl,
+	body *ast.CompoundStmt) (decls []goast.Decl) {
+
+	var statics []*ast.VarDecl
+	names := map[ast.Address]string{}
+	forEachNode(body, func(node ast.Node) {
+		declStmt, ok := node.(*ast.DeclStmt)
+		if !ok {
+			return
+		}
+
+		children := declStmt.ChildNodes[:0]
+		for _, c := range declStmt.ChildNodes {
+			v, ok := c.(*ast.VarDecl)
+			if !ok || !v.IsStatic {
+				children = append(children, c)
+				continue
+			}
+
+			v.Name = p.GetNextIdentifier(fmt.Sprintf("%s_%s_", f.Name, v.Name))
+			names[v.Addr] = v.Name
+			statics = append(statics, v)
+		}
+		declStmt.ChildNodes = children
+	})
+	if len(statics) == 0 {
+		return nil
+	}
+
+	forEachNode(body, func(node ast.Node) {
+		if ref, ok := node.(*ast.DeclRefExpr); ok {
+			if name, ok := names[ast.ParseAddress(ref.Address2)]; ok {
+				ref.Name = name
+			}
+		}
+	})
+
+	// The variables are transpiled as global variables.
+	function := p.Function
+	p.Function = nil
+	defer func() {
+		p.Function = function
+	}()
+
+	for _, v := range statics {
+		d, _, err := transpileVarDecl(p, v)
+		if err != nil {
+			p.AddError(err, v)
+			continue
+		}
+		decls = append(decls, d...)
+	}
+
+	return decls
+}
+
+// forEachNode calls visit for the node and all of its descendants.
+func forEachNode(node ast.Node, visit func(ast.Node)) {
+	if node == nil {
+		return
+	}
+
+	visit(node)
+	for _, c := range node.Children() {
+		forEachNode(c, visit)
+	}
+}
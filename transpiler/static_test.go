@@ -0,0 +1,54 @@
+package transpiler
+
+import (
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestStaticLocalVariable(t *testing.T) {
+	p := program.NewProgram()
+
+	// int next() {
+	//     static int counter = 5;
+	//     return counter;
+	// }
+	counter := &ast.VarDecl{
+		Addr:       0x10,
+		Name:       "counter",
+		Type:       "int",
+		IsStatic:   true,
+		ChildNodes: []ast.Node{intLiteral("5")},
+	}
+	f := &ast.FunctionDecl{
+		Name: "next",
+		Type: "int (void)",
+		ChildNodes: []ast.Node{&ast.CompoundStmt{ChildNodes: []ast.Node{
+			&ast.DeclStmt{ChildNodes: []ast.Node{counter}},
+			&ast.ReturnStmt{ChildNodes: []ast.Node{&ast.ImplicitCastExpr{
+				Type: "int",
+				Kind: "LValueToRValue",
+				ChildNodes: []ast.Node{&ast.DeclRefExpr{
+					Type:     "int",
+					For:      "Var",
+					Address2: "0x10",
+					Name:     "counter",
+				}},
+			}}},
+		}}},
+	}
+
+	decls, err := transpileFunctionDecl(f, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `func next() int32 {
+	return next_counter_0
+}
+var next_counter_0 int32 = int32(5)`
+	if got := formatNodes(t, decls[0], decls[1]); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
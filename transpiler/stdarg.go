@@ -0,0 +1,109 @@
+// This file contains the transpiling of variadic functions (stdarg.h).
+//
+// The variadic arguments of a C function are the Go parameter:
+//
+//     c2goArgs ...interface{}
+//
+// A va_list is a noarch.VaList that is started from c2goArgs and va_arg() reads
+// the arguments from it one at a time.
+
+package transpiler
+
+import (
+	"fmt"
+	goast "go/ast"
+	"go/token"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
+	"github.com/elliotchance/c2go/util"
+)
+
+// variadicArgsName is the name of the Go parameter with the variadic arguments.
+const variadicArgsName = "c2goArgs"
+
+// isVariadicFunction returns true if the C type of the function (like
+// "int (const char *, ...)") has variadic arguments.
+func isVariadicFunction(cType string) bool {
+	fields, _, err := types.ParseFunction(cType)
+	return err == nil && len(fields) > 0 && fields[len(fields)-1] == "..."
+}
+
+// transpileVaListCall transpiles the calls to va_start(), va_end() and
+// va_copy(). The va_list arguments are pointers to the noarch.VaList:
+//
+//     va_start(ap, format);  ->  noarch.VaStart(&ap, c2goArgs)
+//     va_copy(aq, ap);       ->  noarch.VaCopy(&aq, ap)
+//
+// va_end() does nothing so nil is returned.
+func transpileVaListCall(n *ast.CallExpr, p *program.Program, functionName string) (
+	_ *goast.CallExpr, preStmts []goast.Stmt, postStmts []goast.Stmt, err error) {
+
+	if functionName == "__builtin_va_end" {
+		return nil, nil, nil, nil
+	}
+
+	var args []goast.Expr
+	for _, arg := range n.Children()[1:] {
+		e, _, newPre, newPost, err := transpileToExpr(arg, p, false)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
+		args = append(args, e)
+	}
+	if len(args) != 2 {
+		return nil, nil, nil, fmt.Errorf("%s must have 2 arguments, got %d",
+			functionName, len(args))
+	}
+
+	p.AddImport("github.com/elliotchance/c2go/noarch")
+	dest := &goast.UnaryExpr{Op: token.AND, X: args[0]}
+
+	if functionName == "__builtin_va_start" {
+		return util.NewCallExpr("noarch.VaStart", dest,
+			util.NewIdent(variadicArgsName)), preStmts, postStmts, nil
+	}
+
+	return util.NewCallExpr("noarch.VaCopy", dest, args[1]),
+		preStmts, postStmts, nil
+}
+
+// transpileVAArgExpr transpiles va_arg() into a typed read of the next
+// argument:
+//
+//     va_arg(ap, int)  ->  noarch.VaArg[int32](&ap)
+func transpileVAArgExpr(n *ast.VAArgExpr, p *program.Program) (
+	_ goast.Expr, exprType string, preStmts []goast.Stmt,
+	postStmts []goast.Stmt, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("Cannot transpile VAArgExpr. err = %v", err)
+		}
+	}()
+
+	if len(n.Children()) != 1 {
+		return nil, "", nil, nil,
+			fmt.Errorf("expected 1 child, got %d", len(n.Children()))
+	}
+
+	ap, _, preStmts, postStmts, err := transpileToExpr(n.Children()[0], p, false)
+	if err != nil {
+		return
+	}
+
+	goType, err := types.ResolveType(p, n.Type)
+	if err != nil {
+		return
+	}
+
+	p.AddImport("github.com/elliotchance/c2go/noarch")
+	return &goast.CallExpr{
+		Fun: &goast.IndexExpr{
+			X:     util.NewTypeIdent("noarch.VaArg"),
+			Index: util.NewTypeIdent(goType),
+		},
+		Args: []goast.Expr{&goast.UnaryExpr{Op: token.AND, X: ap}},
+	}, n.Type, preStmts, postStmts, nil
+}
@@ -139,12 +139,12 @@ func transpileSwitchStmt(n *ast.SwitchStmt, p *program.Program) (
 					// goto to last iteration
 					i--
 				} else {
-					p.AddMessage(p.GenerateWarningMessage(
-						fmt.Errorf("Unexpected element"), n))
+					p.AddWarning(
+						fmt.Errorf("Unexpected element"), n)
 				}
 			} else {
-				p.AddMessage(p.GenerateWarningMessage(
-					fmt.Errorf("Unsupport case"), n))
+				p.AddWarning(
+					fmt.Errorf("Unsupport case"), n)
 			}
 
 		}
@@ -53,9 +53,10 @@ func transpileTranslationUnitDecl(p *program.Program, n *ast.TranslationUnitDecl
 								var d []goast.Decl
 								d, err = transpileToNode(rec, p)
 								if err != nil {
-									p.AddMessage(p.GenerateErrorMessage(err, n))
+									p.AddError(err, n)
 									err = nil
 								} else {
+									p.SetDeclFile(rec.Pos.File, d...)
 									decls = append(decls, d...)
 								}
 							}
@@ -72,9 +73,10 @@ func transpileTranslationUnitDecl(p *program.Program, n *ast.TranslationUnitDecl
 								var d []goast.Decl
 								d, err = transpileToNode(recNode, p)
 								if err != nil {
-									p.AddMessage(p.GenerateErrorMessage(err, n))
+									p.AddError(err, n)
 									err = nil
 								} else {
+									p.SetDeclFile(recNode.Pos.File, d...)
 									decls = append(decls, d...)
 								}
 							}
@@ -99,7 +101,7 @@ func transpileTranslationUnitDecl(p *program.Program, n *ast.TranslationUnitDecl
 							}
 						}
 						if typeToDeclare == nil {
-							p.AddMessage(p.GenerateWarningMessage(fmt.Errorf("could not lookup type definition for : %v", rec.Name), rec))
+							p.AddWarning(fmt.Errorf("could not lookup type definition for : %v", rec.Name), rec)
 							typeToDeclare = rec
 						}
 						p.DeclareType(typeToDeclare, types.GenerateCorrectType(rec.Name))
@@ -115,9 +117,10 @@ func transpileTranslationUnitDecl(p *program.Program, n *ast.TranslationUnitDecl
 		var d []goast.Decl
 		d, err = transpileToNode(presentNode, p)
 		if err != nil {
-			p.AddMessage(p.GenerateErrorMessage(err, n))
+			p.AddError(err, n)
 			err = nil
 		} else {
+			p.SetDeclFile(presentNode.Position().File, d...)
 			decls = append(decls, d...)
 			if runAfter != nil {
 				runAfter()
@@ -28,18 +28,22 @@ func TranspileAST(fileName, packageName string, p *program.Program, root ast.Nod
 		return err
 	}
 
+	// The macros must be transpiled first so that the expansions of the
+	// constants can be replaced with their names.
+	p.File.Decls = append(p.File.Decls, transpileMacros(p, root)...)
+
 	// Now begin building the Go AST.
 	decls, err := transpileToNode(root, p)
 	if err != nil {
-		p.AddMessage(p.GenerateErrorMessage(fmt.Errorf("Error of transpiling: err = %v", err), root))
+		p.AddError(fmt.Errorf("Error of transpiling: err = %v", err), root)
 		err = nil // Error is ignored
 	}
 	p.File.Decls = append(p.File.Decls, decls...)
 
 	if p.OutputAsTest {
 		p.AddImport("testing")
-		p.AddImport("io/ioutil")
 		p.AddImport("os")
+		p.AddImport("strings")
 
 		// TODO: There should be a cleaner way to add a function to the program.
 		// This code was taken from the end of transpileFunctionDecl.
@@ -61,17 +65,12 @@ func TranspileAST(fileName, packageName string, p *program.Program, root ast.Nod
 
 					// "go test" does not redirect stdin to the executable
 					// running the test so we need to override them in the test
-					// itself. See documentation for noarch.Stdin.
-					util.NewExprStmt(&goast.Ident{Name: "ioutil.WriteFile(\"build/stdin\", []byte{'7'}, 0777)"}),
-					util.NewExprStmt(
-						&goast.Ident{Name: "stdin, _ := os.Open(\"build/stdin\")"},
-					),
-					util.NewExprStmt(util.NewBinaryExpr(
-						&goast.Ident{Name: "noarch.Stdin"},
-						token.ASSIGN,
-						&goast.Ident{Name: "noarch.NewFile(stdin)"},
-						"*noarch.File",
-						true,
+					// itself. See documentation for noarch.Stdin. The stream
+					// is replaced in place because the "stdin" variable of the
+					// program has already been set by init().
+					util.NewExprStmt(util.NewCallExpr(
+						"noarch.Stdin.SetReader",
+						&goast.Ident{Name: "strings.NewReader(\"7\")"},
 					)),
 
 					util.NewExprStmt(util.NewCallExpr("main")),
@@ -144,6 +143,10 @@ func transpileToExpr(node ast.Node, p *program.Program, exprIsStmt bool) (
 		postStmts = nilFilterStmts(postStmts)
 	}()
 
+	if e, t, pre, post, ok := transpileMacroExpansion(p, node); ok {
+		return e, t, pre, post, nil
+	}
+
 	switch n := node.(type) {
 	case *ast.StringLiteral:
 		expr = transpileStringLiteral(n)
@@ -211,6 +214,9 @@ func transpileToExpr(node ast.Node, p *program.Program, exprIsStmt bool) (
 	case *ast.StmtExpr:
 		return transpileStmtExpr(n, p)
 
+	case *ast.VAArgExpr:
+		expr, exprType, preStmts, postStmts, err = transpileVAArgExpr(n, p)
+
 	case *ast.ImplicitValueInitExpr:
 		cType := n.Type1
 
@@ -236,8 +242,7 @@ func transpileToExpr(node ast.Node, p *program.Program, exprIsStmt bool) (
 		}
 
 	default:
-		p.AddMessage(p.GenerateWarningMessage(errors.New("cannot transpile to expr"), node))
-		expr = util.NewNil()
+		expr, exprType = transpileUnsupportedExpr(p, node)
 	}
 
 	// Real return is through named arguments.
@@ -256,7 +261,7 @@ func transpileToStmts(node ast.Node, p *program.Program) (stmts []goast.Stmt, er
 	case *ast.DeclStmt:
 		stmts, err = transpileDeclStmt(n, p)
 		if err != nil {
-			p.AddMessage(p.GenerateErrorMessage(fmt.Errorf("Error in DeclStmt: %v", err), n))
+			p.AddError(fmt.Errorf("Error in DeclStmt: %v", err), n)
 			err = nil // Error is ignored
 		}
 		return
@@ -269,7 +274,7 @@ func transpileToStmts(node ast.Node, p *program.Program) (stmts []goast.Stmt, er
 	)
 	stmt, preStmts, postStmts, err = transpileToStmt(node, p)
 	if err != nil {
-		p.AddMessage(p.GenerateErrorMessage(fmt.Errorf("Error in DeclStmt: %v", err), node))
+		p.AddError(fmt.Errorf("Error in DeclStmt: %v", err), node)
 		err = nil // Error is ignored
 	}
 	return stripParentheses(combineStmts(stmt, preStmts, postStmts)), err
@@ -298,7 +303,7 @@ func transpileToStmt(node ast.Node, p *program.Program) (
 
 	defer func() {
 		if err != nil {
-			p.AddMessage(p.GenerateErrorMessage(err, node))
+			p.AddError(err, node)
 			err = nil // Error is ignored
 		}
 	}()
@@ -369,11 +374,17 @@ func transpileToStmt(node ast.Node, p *program.Program) (
 	case *ast.GCCAsmStmt:
 		// Go does not support inline assembly. See:
 		// https://github.com/elliotchance/c2go/issues/228
-		p.AddMessage(p.GenerateWarningMessage(
-			errors.New("cannot transpile asm, will be ignored"), n))
+		p.AddWarning(
+			program.WithCode(program.CodeInlineAssembly,
+				errors.New("cannot transpile asm, will be ignored")), n)
 
 		stmt = &goast.EmptyStmt{}
 		return
+
+	case *ast.Unknown:
+		stmt = transpileUnsupportedStmt(p, n)
+		return
+
 	case *ast.DeclStmt:
 		var stmts []goast.Stmt
 		stmts, err = transpileDeclStmt(n, p)
@@ -435,7 +446,7 @@ func transpileToStmt(node ast.Node, p *program.Program) (
 func transpileToNode(node ast.Node, p *program.Program) (decls []goast.Decl, err error) {
 	defer func() {
 		if err != nil {
-			p.AddMessage(p.GenerateErrorMessage(err, node))
+			p.AddError(err, node)
 			err = nil // Error is ignored
 		}
 	}()
@@ -478,13 +489,13 @@ func transpileToNode(node ast.Node, p *program.Program) (decls []goast.Decl, err
 			// ignore if length is zero, for avoid
 			// mistake warning
 		} else {
-			p.AddMessage(p.GenerateWarningMessage(fmt.Errorf("EmptyDecl is not transpiled"), n))
+			p.AddWarning(fmt.Errorf("EmptyDecl is not transpiled"), n)
 		}
 		err = nil
 		return
 
 	default:
-		panic(fmt.Sprintf("cannot transpile to node: %#v", node))
+		decls = []goast.Decl{transpileUnsupportedDecl(p, node)}
 	}
 
 	return
@@ -493,7 +504,7 @@ func transpileToNode(node ast.Node, p *program.Program) (decls []goast.Decl, err
 func transpileStmts(nodes []ast.Node, p *program.Program) (stmts []goast.Stmt, err error) {
 	defer func() {
 		if err != nil {
-			p.AddMessage(p.GenerateErrorMessage(fmt.Errorf("Error in transpileToStmts: %v", err), nodes[0]))
+			p.AddError(fmt.Errorf("Error in transpileToStmts: %v", err), nodes[0])
 			err = nil // Error is ignored
 		}
 	}()
@@ -199,7 +199,7 @@ func transpileUnaryOperatorNot(n *ast.UnaryOperator, p *program.Program) (
 	}
 
 	t, err := types.ResolveType(p, eType)
-	p.AddMessage(p.GenerateWarningMessage(err, n))
+	p.AddWarning(err, n)
 
 	if t == "*byte" {
 		return util.NewUnaryExpr(
@@ -250,7 +250,10 @@ func transpileUnaryOperatorAmpersant(n *ast.UnaryOperator, p *program.Program) (
 		return
 	}
 
-	if types.IsFunction(eType) {
+	// The address of a function is the function itself in Go, but a
+	// function pointer in a variable, field or array element is an lvalue
+	// like any other.
+	if isFunctionDesignator(n.Children()[0]) {
 		return
 	}
 
@@ -280,7 +283,7 @@ func transpileUnaryOperatorAmpersant(n *ast.UnaryOperator, p *program.Program) (
 	// value.
 	_, err = types.ResolveType(p, eType)
 	if err != nil {
-		p.AddMessage(p.GenerateWarningMessage(err, n))
+		p.AddWarning(err, n)
 		return
 	}
 
@@ -291,10 +294,31 @@ func transpileUnaryOperatorAmpersant(n *ast.UnaryOperator, p *program.Program) (
 	}
 
 	// We now have a pointer to the original type.
-	eType += " *"
+	if types.IsFunction(eType) {
+		eType = n.Type
+	} else {
+		eType += " *"
+	}
 	return
 }
 
+// isFunctionDesignator returns true if the node is the name of a function,
+// like "f" in "&f", rather than an expression that has a function pointer
+// type.
+func isFunctionDesignator(n ast.Node) bool {
+	switch v := n.(type) {
+	case *ast.ParenExpr:
+		return isFunctionDesignator(v.Children()[0])
+	case *ast.ImplicitCastExpr:
+		return v.Kind == ast.ImplicitCastExprFunctionToPointerDecay &&
+			isFunctionDesignator(v.Children()[0])
+	case *ast.DeclRefExpr:
+		return v.For == "Function"
+	}
+
+	return false
+}
+
 // transpilePointerArith - transpile pointer arithmetic
 // Example of using:
 // *(t + 1) = ...
@@ -642,7 +666,7 @@ func transpileUnaryOperator(n *ast.UnaryOperator, p *program.Program, exprIsStmt
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("Cannot transpile UnaryOperator: err = %v", err)
-			p.AddMessage(p.GenerateWarningMessage(err, n))
+			p.AddWarning(err, n)
 		}
 	}()
 
@@ -680,6 +704,16 @@ func transpileUnaryOperator(n *ast.UnaryOperator, p *program.Program, exprIsStmt
 
 func transpileUnaryExprOrTypeTraitExpr(n *ast.UnaryExprOrTypeTraitExpr, p *program.Program) (
 	*goast.BasicLit, string, []goast.Stmt, []goast.Stmt, error) {
+	t := getSizeofType(n)
+	sizeInBytes, err := types.SizeOf(p, t)
+	p.AddWarning(err, n)
+
+	return util.NewIntLit(sizeInBytes), n.Type1, nil, nil, nil
+}
+
+// getSizeofType returns the C type that a sizeof() is the size of, either the
+// type in "sizeof(int)" or the type of the expression in "sizeof(x)".
+func getSizeofType(n *ast.UnaryExprOrTypeTraitExpr) string {
 	t := n.Type2
 
 	// It will have children if the sizeof() is referencing a variable.
@@ -738,10 +772,7 @@ func transpileUnaryExprOrTypeTraitExpr(n *ast.UnaryExprOrTypeTraitExpr, p *progr
 		}
 	}
 
-	sizeInBytes, err := types.SizeOf(p, t)
-	p.AddMessage(p.GenerateWarningMessage(err, n))
-
-	return util.NewIntLit(sizeInBytes), n.Type1, nil, nil, nil
+	return t
 }
 
 func transpileStmtExpr(n *ast.StmtExpr, p *program.Program) (
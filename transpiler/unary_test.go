@@ -0,0 +1,149 @@
+package transpiler
+
+import (
+	goast "go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	gotypes "go/types"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+// The declarations of:
+//
+//     int (*handlers[3])(int);
+//     struct callbacks { int (*on_value)(int); } cb;
+//     int (**ph)(int);
+//     int apply(int (**handler)(int), int value);
+const functionPointerDecls = `package p
+
+import "unsafe"
+
+var _ unsafe.Pointer
+
+type callbacks struct {
+	on_value func(int32) int32
+}
+
+var handlers [3]func(int32) int32
+var cb callbacks
+var ph *func(int32) int32
+
+func apply(handler *func(int32) int32, value int32) int32 {
+	return (*handler)(value)
+}
+`
+
+func handlersElement(index string) *ast.ArraySubscriptExpr {
+	return &ast.ArraySubscriptExpr{
+		Type: "int (*)(int)",
+		ChildNodes: []ast.Node{
+			&ast.ImplicitCastExpr{
+				Type: "int (**)(int)",
+				Kind: ast.ImplicitCastExprArrayToPointerDecay,
+				ChildNodes: []ast.Node{
+					&ast.DeclRefExpr{Type: "int (*[3])(int)", Name: "handlers", For: "Var"},
+				},
+			},
+			&ast.IntegerLiteral{Type: "int", Value: index},
+		},
+	}
+}
+
+func addressOf(cType string, n ast.Node) *ast.UnaryOperator {
+	return &ast.UnaryOperator{
+		Type:       cType,
+		Operator:   "&",
+		IsPrefix:   true,
+		ChildNodes: []ast.Node{n},
+	}
+}
+
+func TestAddressOfFunctionPointer(t *testing.T) {
+	onValue := &ast.MemberExpr{
+		Type: "int (*)(int)",
+		Name: "on_value",
+		ChildNodes: []ast.Node{
+			&ast.DeclRefExpr{Type: "struct callbacks", Name: "cb", For: "Var"},
+		},
+	}
+
+	tests := []struct {
+		name string
+		node ast.Node
+	}{
+		{"function", addressOf("int (*)(int (**)(int), int)", &ast.DeclRefExpr{
+			Type: "int (int (**)(int), int)", Name: "apply", For: "Function",
+		})},
+		{"first array element", addressOf("int (**)(int)", handlersElement("0"))},
+		{"array element", addressOf("int (**)(int)", handlersElement("1"))},
+		{"struct field", addressOf("int (**)(int)", onValue)},
+		{"variable", addressOf("int (***)(int)", &ast.DeclRefExpr{
+			Type: "int (**)(int)", Name: "ph", For: "Var",
+		})},
+		{"call through a pointer", &ast.CallExpr{
+			Type: "int",
+			ChildNodes: []ast.Node{
+				&ast.ImplicitCastExpr{
+					Type: "int (*)(int)",
+					Kind: "LValueToRValue",
+					ChildNodes: []ast.Node{&ast.ParenExpr{
+						Type: "int (*)(int)",
+						ChildNodes: []ast.Node{&ast.UnaryOperator{
+							Type:     "int (*)(int)",
+							Operator: "*",
+							IsPrefix: true,
+							ChildNodes: []ast.Node{&ast.ImplicitCastExpr{
+								Type: "int (**)(int)",
+								Kind: "LValueToRValue",
+								ChildNodes: []ast.Node{
+									&ast.DeclRefExpr{Type: "int (**)(int)", Name: "ph", For: "Var"},
+								},
+							}},
+						}},
+					}},
+				},
+				&ast.IntegerLiteral{Type: "int", Value: "10"},
+			},
+		}},
+		{"pass the address of a field", &ast.CallExpr{
+			Type: "int",
+			ChildNodes: []ast.Node{
+				&ast.ImplicitCastExpr{
+					Type: "int (*)(int (**)(int), int)",
+					Kind: ast.ImplicitCastExprFunctionToPointerDecay,
+					ChildNodes: []ast.Node{&ast.DeclRefExpr{
+						Type: "int (int (**)(int), int)", Name: "apply", For: "Function",
+					}},
+				},
+				addressOf("int (**)(int)", onValue),
+				&ast.IntegerLiteral{Type: "int", Value: "3"},
+			},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := program.NewProgram()
+			expr, _, _, _, err := transpileToExpr(test.node, p, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The result must be valid Go.
+			source := functionPointerDecls + "\nvar result = " + formatNodes(t, expr) + "\n"
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "", source, 0)
+			if err != nil {
+				t.Fatalf("%v\n%s", err, source)
+			}
+			conf := gotypes.Config{Importer: importer.Default()}
+			if _, err := conf.Check("p", fset, []*goast.File{file}, nil); err != nil {
+				t.Errorf("invalid Go: %v\n%s", err, source)
+			}
+		})
+	}
+}
@@ -3,80 +3,188 @@ package transpiler
 import (
 	"bytes"
 	"fmt"
-	"html/template"
-
 	goast "go/ast"
 	"go/format"
 	"go/parser"
 	"go/token"
+	"strings"
+	"text/template"
 
 	"github.com/elliotchance/c2go/ast"
 	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
+	"github.com/elliotchance/c2go/util"
 )
 
-func transpileUnion(name string, size int, fields []*goast.Field) (
-	_ []goast.Decl, err error) {
+// unionFieldPrefix is the prefix of the names of the fields that contain the
+// members of a union that have both pointers and other values.
+const unionFieldPrefix = "c2goField"
+
+// transpileUnion returns the declaration of a union. The union is a struct
+// with the memory that is shared by all of its fields:
+//
+//     union number {              type number struct {
+//         int i;                      memory [1]uint64
+//         double d;               }
+//     };
+//                                 func (unionVar *number) i() *int32
+//                                 func (unionVar *number) d() *float64
+//
+// The memory has the size of the largest field and the alignment of the C
+// union (see types.LayoutOf). It is an array so that a union is copied by an
+// assignment and can be used as a value like any other type. Each field is a
+// method that returns a pointer to the memory, so the usage of a field is:
+//
+//     n.d = 1.5;  ->  *n.d() = 1.5
+//
+// The garbage collector must know which words are pointers, so the fields that
+// contain pointers cannot be in the memory. The fields that are only pointers
+// share their own memory of unsafe.Pointer words, and each of the other fields
+// with pointers (like a struct with a pointer and an int) has its own field of
+// its Go type:
+//
+//     union value {               type value struct {
+//         double d;                   memory   [1]uint64
+//         char *p;                    pointers [1]unsafe.Pointer
+//     };                          }
+//
+// So, unlike C, the fields with pointers do not share their memory with the
+// other fields, and a warning is added for the union.
+func transpileUnion(p *program.Program, n *ast.RecordDecl, name string,
+	s *program.Struct, fields []*goast.Field) (_ []goast.Decl, err error) {
 
 	type field struct {
 		Name      string
 		TypeField string
+
+		// Memory is the field of the struct that contains the field.
+		Memory string
+
+		// Own is true if the field has its own field of its Go type.
+		Own bool
 	}
 
 	type union struct {
-		Name   string
-		Size   int
-		Fields []field
+		Name     string
+		Length   int
+		Word     string
+		Pointers int
+		Fields   []field
 	}
 
 	src := `package main
 
-import(
-	"unsafe"
-	"reflect"
-)
+import "unsafe"
 
-type {{ .Name }} struct{
-	memory unsafe.Pointer
+type {{ .Name }} struct {
+{{- if .Length }}
+	memory [{{ .Length }}]{{ .Word }}
+{{- end }}
+{{- if .Pointers }}
+	pointers [{{ .Pointers }}]unsafe.Pointer
+{{- end }}
+{{- range .Fields }}{{ if .Own }}
+	{{ .Memory }} {{ .TypeField }}
+{{- end }}{{ end }}
 }
-
-func (unionVar * {{ .Name }}) copy() ( {{ .Name }}){
-	var buffer [{{ .Size }}]byte
-	for i := range buffer{
-		buffer[i] = (*((*[{{ .Size }}]byte)(unionVar.memory)))[i]
-	}
-	var newUnion {{ .Name }}
-	newUnion.memory = unsafe.Pointer(&buffer)
-	return newUnion
-}
-
 {{ range .Fields }}
-func (unionVar * {{ $.Name }}) {{ .Name }}() (*{{ .TypeField }}){
-	if unionVar.memory == nil{
-		var buffer [{{ $.Size }}]byte
-		unionVar.memory = unsafe.Pointer(&buffer)
-	}
-	return (*{{ .TypeField }})(unionVar.memory)
+func (unionVar *{{ $.Name }}) {{ .Name }}() *{{ .TypeField }} {
+{{- if .Own }}
+	return &unionVar.{{ .Memory }}
+{{- else }}
+	return (*{{ .TypeField }})(unsafe.Pointer(&unionVar.{{ .Memory }}))
+{{- end }}
 }
 {{ end }}
 `
+
+	layout, err := types.LayoutOf(p, s)
+	if err != nil {
+		return
+	}
+
+	goFields := map[string]*goast.Field{}
+	for _, f := range fields {
+		if f != nil && len(f.Names) > 0 {
+			goFields[f.Names[0].Name] = f
+		}
+	}
+
 	// Generate structure of union
 	var un union
 	un.Name = name
-	un.Size = size
-	for i := range fields {
-		var f field
-		f.Name = fields[i].Names[0].Name
+	un.Word = fmt.Sprintf("uint%d", layout.Align*8)
+	wordSize := layout.Align
+	if layout.Align > 8 {
+		un.Word = "uint64"
+		wordSize = 8
+	}
+	var size, own int
+	var pointers []string
+	for _, m := range s.Members {
+		goName := m.Name
+		if util.IsGoKeyword(goName) {
+			goName += "_"
+		}
+		goField, ok := goFields[goName]
+		if !ok {
+			continue
+		}
 
+		f := field{Name: goName, Memory: "memory"}
 		var buf bytes.Buffer
-		err = format.Node(&buf, token.NewFileSet(), fields[i].Type)
+		err = format.Node(&buf, token.NewFileSet(), goField.Type)
 		if err != nil {
-			err = fmt.Errorf("cannot parse type '%s' : %v", fields[i].Type, err)
+			err = fmt.Errorf("cannot parse type '%s' : %v", goField.Type, err)
 			return
 		}
 		f.TypeField = buf.String()
 
+		var fieldSize int
+		fieldSize, err = types.SizeOf(p, m.Type)
+		if err != nil {
+			return
+		}
+
+		switch {
+		case unionOnlyPointers(p, m.Type, 0):
+			f.Memory = "pointers"
+			if words := (fieldSize + 7) / 8; words > un.Pointers {
+				un.Pointers = words
+			}
+			pointers = append(pointers, m.Name)
+
+		case unionHasPointers(p, m.Type, 0):
+			f.Memory = fmt.Sprintf("%s%d", unionFieldPrefix, own)
+			f.Own = true
+			own++
+			pointers = append(pointers, m.Name)
+
+		case fieldSize > size:
+			size = fieldSize
+		}
+
 		un.Fields = append(un.Fields, f)
 	}
+	un.Length = (size + wordSize - 1) / wordSize
+
+	if un.Length > 0 || un.Pointers > 0 {
+		p.AddImports("unsafe")
+	}
+
+	// The number of the memories that are not shared.
+	memories := own
+	if un.Length > 0 {
+		memories++
+	}
+	if un.Pointers > 0 {
+		memories++
+	}
+	if memories > 1 && len(pointers) > 0 {
+		p.AddWarning(program.WithCode(program.CodeUnionPointers, fmt.Errorf(
+			"the fields of union %s with pointers (%s) do not share memory "+
+				"with the other fields", name, strings.Join(pointers, ", "))), n)
+	}
 
 	tmpl := template.Must(template.New("").Parse(src))
 	var source bytes.Buffer
@@ -97,23 +205,153 @@ func (unionVar * {{ $.Name }}) {{ .Name }}() (*{{ .TypeField }}){
 	return f.Decls[1:], nil
 }
 
-func isUnionMemberExpr(p *program.Program, n *ast.MemberExpr) (IsUnion bool) {
-	if len(n.Children()) > 0 {
-		if v, ok := n.Children()[0].(*ast.MemberExpr); ok {
-			if p.IsUnion(v.Type) {
-				IsUnion = true
+// unionHasPointers returns true if the value of the C type contains a pointer
+// (or a slice) in Go.
+func unionHasPointers(p *program.Program, cType string, depth int) bool {
+	cType = types.CleanCType(types.GenerateCorrectType(cType))
+	if depth > 10 {
+		return true
+	}
+
+	if t, ok := p.TypedefType[cType]; ok {
+		return unionHasPointers(p, t, depth+1)
+	}
+	if arrayType, arraySize := types.GetArrayTypeAndSize(cType); arraySize != -1 {
+		return unionHasPointers(p, arrayType, depth+1)
+	}
+	if types.IsPointer(p, cType) || types.IsFunction(cType) ||
+		strings.HasSuffix(cType, "[]") {
+		return true
+	}
+
+	s := p.GetRecord(cType)
+	if s != nil {
+		for _, m := range s.Members {
+			if unionHasPointers(p, m.Type, depth+1) {
+				return true
 			}
 		}
-		if v, ok := n.Children()[0].(*ast.DeclRefExpr); ok {
-			if p.IsUnion(v.Type) {
-				IsUnion = true
-			}
+	}
+
+	return false
+}
+
+// unionOnlyPointers returns true if the value of the C type is only pointers
+// in Go, so that it can be stored in unsafe.Pointer words.
+func unionOnlyPointers(p *program.Program, cType string, depth int) bool {
+	cType = types.CleanCType(types.GenerateCorrectType(cType))
+	if depth > 10 {
+		return false
+	}
+
+	if t, ok := p.TypedefType[cType]; ok {
+		return unionOnlyPointers(p, t, depth+1)
+	}
+	if arrayType, arraySize := types.GetArrayTypeAndSize(cType); arraySize != -1 {
+		return unionOnlyPointers(p, arrayType, depth+1)
+	}
+	if types.IsPointer(p, cType) || types.IsFunction(cType) {
+		return !strings.HasSuffix(cType, "[]")
+	}
+
+	s := p.GetRecord(cType)
+	if s == nil || s.IsUnion || len(s.Members) == 0 {
+		return false
+	}
+	for _, m := range s.Members {
+		if m.IsBitField() || !unionOnlyPointers(p, m.Type, depth+1) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// getUnion returns the union of the C type, or nil if the type is not a union.
+func getUnion(p *program.Program, cType string) *program.Struct {
+	s := p.GetRecord(types.CleanCType(types.GenerateCorrectType(cType)))
+	if s == nil || !s.IsUnion {
+		return nil
+	}
+
+	return s
+}
+
+// transpileUnionInitListExpr transpiles the initialization of a union. Only
+// one of the fields is initialized, this is the first field or the field of a
+// designated initializer:
+//
+//     union number n = {.d = 1.5};
+//
+//     var n number = func() number {
+//         var c2goUnion number
+//         *c2goUnion.d() = 1.5
+//         return c2goUnion
+//     }()
+func transpileUnionInitListExpr(e *ast.InitListExpr, p *program.Program,
+	s *program.Struct) (_ goast.Expr, _ string, err error) {
+	const unionName = "c2goUnion"
+
+	goType, err := types.ResolveType(p, e.Type1)
+	if err != nil {
+		return
+	}
+
+	body := []goast.Stmt{&goast.DeclStmt{Decl: &goast.GenDecl{
+		Tok: token.VAR,
+		Specs: []goast.Spec{&goast.ValueSpec{
+			Names: []*goast.Ident{util.NewIdent(unionName)},
+			Type:  util.NewTypeIdent(goType),
+		}},
+	}}}
+
+	name := e.Field
+	if name == "" && len(s.FieldNames) > 0 {
+		name = s.FieldNames[0]
+	}
+
+	var values []ast.Node
+	for _, node := range e.Children() {
+		if _, ok := node.(*ast.ArrayFiller); !ok {
+			values = append(values, node)
 		}
-		if v, ok := n.Children()[0].(*ast.ImplicitCastExpr); ok {
-			if p.IsUnion(v.Type) {
-				IsUnion = true
+	}
+	if len(values) > 1 {
+		return nil, "", fmt.Errorf("union %s is initialized with %d values",
+			e.Type1, len(values))
+	}
+
+	if len(values) == 1 && name != "" {
+		value, valueType, _, _, err := transpileToExpr(values[0], p, false)
+		if err != nil {
+			return nil, "", err
+		}
+		if fieldType, ok := s.Fields[name].(string); ok {
+			value, err = types.CastExpr(p, value, valueType, fieldType)
+			if err != nil {
+				return nil, "", err
 			}
 		}
+
+		if util.IsGoKeyword(name) {
+			name += "_"
+		}
+		body = append(body, &goast.AssignStmt{
+			Lhs: []goast.Expr{&goast.StarExpr{
+				X: util.NewCallExpr(unionName + "." + name),
+			}},
+			Tok: token.ASSIGN,
+			Rhs: []goast.Expr{value},
+		})
 	}
-	return
+
+	return util.NewAnonymousFunction(body, nil, util.NewIdent(unionName), goType),
+		e.Type1, nil
+}
+
+// isUnionMemberExpr returns true if the member expression is a field of a
+// union, like "n.d" or "numbers[1].d" or "p->d".
+func isUnionMemberExpr(p *program.Program, n *ast.MemberExpr) bool {
+	cType := getMemberBaseType(n)
+	return cType != "" && getUnion(p, cType) != nil
 }
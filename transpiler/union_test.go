@@ -0,0 +1,172 @@
+package transpiler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func newUnion(name string, fields ...[2]string) *ast.RecordDecl {
+	n := &ast.RecordDecl{Name: name, Kind: "union", Definition: true}
+	for _, f := range fields {
+		n.AddChild(&ast.FieldDecl{Name: f[0], Type: f[1]})
+	}
+
+	return n
+}
+
+func newStruct(name string, fields ...[2]string) *ast.RecordDecl {
+	n := newUnion(name, fields...)
+	n.Kind = "struct"
+
+	return n
+}
+
+func TestUnion(t *testing.T) {
+	tests := []struct {
+		name     string
+		records  []*ast.RecordDecl
+		union    *ast.RecordDecl
+		expected string
+
+		// code is the code of the warning for the union, if any.
+		code string
+	}{
+		{
+			name: "numbers",
+			union: newUnion("number",
+				[2]string{"c", "char [6]"},
+				[2]string{"i", "int"},
+				[2]string{"s", "short"}),
+			expected: `type number struct{ memory [2]uint32 }
+func (unionVar *number) c() *[6]byte {
+	return (*[6]byte)(unsafe.Pointer(&unionVar.memory))
+}
+func (unionVar *number) i() *int32 {
+	return (*int32)(unsafe.Pointer(&unionVar.memory))
+}
+func (unionVar *number) s() *int16 {
+	return (*int16)(unsafe.Pointer(&unionVar.memory))
+}`,
+		},
+		{
+			name: "pointers",
+			union: newUnion("value",
+				[2]string{"d", "double"},
+				[2]string{"p", "char *"}),
+			expected: `type value struct {
+	memory   [1]uint64
+	pointers [1]unsafe.Pointer
+}
+func (unionVar *value) d() *float64 {
+	return (*float64)(unsafe.Pointer(&unionVar.memory))
+}
+func (unionVar *value) p() **byte {
+	return (**byte)(unsafe.Pointer(&unionVar.pointers))
+}`,
+			code: program.CodeUnionPointers,
+		},
+		{
+			name: "only pointers",
+			union: newUnion("handle",
+				[2]string{"p", "char *"},
+				[2]string{"f", "int (*)(int)"},
+				[2]string{"a", "int *[2]"}),
+			expected: `type handle struct{ pointers [2]unsafe.Pointer }
+func (unionVar *handle) p() **byte {
+	return (**byte)(unsafe.Pointer(&unionVar.pointers))
+}
+func (unionVar *handle) f() *func(int32) int32 {
+	return (*func(int32) int32)(unsafe.Pointer(&unionVar.pointers))
+}
+func (unionVar *handle) a() *[2]*int32 {
+	return (*[2]*int32)(unsafe.Pointer(&unionVar.pointers))
+}`,
+		},
+		{
+			name: "pointers and values",
+			records: []*ast.RecordDecl{
+				newStruct("item", [2]string{"n", "int"}, [2]string{"s", "char *"}),
+			},
+			union: newUnion("entry",
+				[2]string{"i", "int"},
+				[2]string{"item", "struct item"}),
+			expected: `type entry struct {
+	memory     [1]uint64
+	c2goField0 item
+}
+func (unionVar *entry) i() *int32 {
+	return (*int32)(unsafe.Pointer(&unionVar.memory))
+}
+func (unionVar *entry) item() *item {
+	return &unionVar.c2goField0
+}`,
+			code: program.CodeUnionPointers,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := program.NewProgram()
+			for _, r := range test.records {
+				if _, err := transpileRecordDecl(p, r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			decls, err := transpileRecordDecl(p, test.union)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var nodes []interface{}
+			for _, d := range decls {
+				nodes = append(nodes, d)
+			}
+			if actual := formatNodes(t, nodes...); actual != test.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", test.expected, actual)
+			}
+
+			var codes []string
+			for _, d := range p.Messages() {
+				codes = append(codes, d.Code)
+			}
+			if test.code == "" && len(codes) > 0 ||
+				test.code != "" && !reflect.DeepEqual(codes, []string{test.code}) {
+				t.Errorf("expected the warning %q, got %v", test.code, codes)
+			}
+		})
+	}
+}
+
+func TestUnionInitListExpr(t *testing.T) {
+	p := program.NewProgram()
+	if _, err := transpileRecordDecl(p, newUnion("number",
+		[2]string{"i", "int"},
+		[2]string{"d", "double"})); err != nil {
+		t.Fatal(err)
+	}
+
+	e := &ast.InitListExpr{
+		Type1: "union number",
+		Type2: "union number",
+		Field: "d",
+		ChildNodes: []ast.Node{
+			&ast.FloatingLiteral{Type: "double", Value: 1.5},
+		},
+	}
+	expr, _, err := transpileInitListExpr(e, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `func() number {
+	var c2goUnion number
+	*c2goUnion.d() = 1.5
+	return c2goUnion
+}()`
+	if actual := formatNodes(t, expr); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
@@ -0,0 +1,97 @@
+package transpiler
+
+import (
+	"errors"
+	"fmt"
+	goast "go/ast"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+	"github.com/elliotchance/c2go/types"
+	"github.com/elliotchance/c2go/util"
+)
+
+// Nodes that cannot be transpiled (usually an *ast.Unknown) do not stop the
+// transpiler. Instead a warning is added and a panic() is placed in the Go
+// output at the same location. This produces mostly working Go with a precise
+// marker where something still needs to be done by hand.
+
+// newUnsupportedPanic adds a warning for the node and returns the call to
+// panic() that will replace it.
+func newUnsupportedPanic(p *program.Program, node ast.Node) *goast.CallExpr {
+	name := reflect.TypeOf(node).Elem().Name()
+	if n, ok := node.(*ast.Unknown); ok {
+		name = n.Name
+	}
+
+	location := strings.TrimSpace(node.Position().GetSimpleLocation())
+	message := fmt.Sprintf("unsupported C construct %s at %s", name, location)
+	code := program.CodeUnsupportedConstruct
+
+	// A known node that cannot be parsed is a bug in c2go (or a new format of
+	// clang) rather than a construct that is not supported.
+	if n, ok := node.(*ast.Unknown); ok && n.ParseError != nil {
+		message = fmt.Sprintf("cannot parse %s at %s: %v", name, location,
+			n.ParseError)
+		code = program.CodeParseError
+	}
+
+	p.AddWarning(program.WithCode(code, errors.New(message)), node)
+
+	return util.NewCallExpr("panic", util.NewStringLit(strconv.Quote(message)))
+}
+
+// transpileUnsupportedExpr returns a closure that panics. If the type of the
+// expression is known the closure will return that type so that it can still be
+// used in an expression.
+func transpileUnsupportedExpr(p *program.Program, node ast.Node) (
+	goast.Expr, string) {
+	panicCall := newUnsupportedPanic(p, node)
+
+	var cType string
+	if n, ok := node.(*ast.Unknown); ok {
+		cType = n.Type
+	}
+
+	goType, err := types.ResolveType(p, cType)
+	if err != nil || cType == "" || cType == "void" {
+		return util.NewFuncClosure("", util.NewExprStmt(panicCall)), "void"
+	}
+
+	return util.NewFuncClosure(goType, util.NewExprStmt(panicCall)), cType
+}
+
+// transpileUnsupportedStmt returns the panic() as a statement.
+func transpileUnsupportedStmt(p *program.Program, node ast.Node) goast.Stmt {
+	return util.NewExprStmt(newUnsupportedPanic(p, node))
+}
+
+// transpileUnsupportedDecl is used for nodes that appear where a declaration
+// is expected. Since a panic() cannot be placed at the package level it is
+// wrapped in a function that is never called:
+//
+//	var _ = func() {
+//	    panic("unsupported C construct ...")
+//	}
+func transpileUnsupportedDecl(p *program.Program, node ast.Node) goast.Decl {
+	return &goast.GenDecl{
+		Tok: token.VAR,
+		Specs: []goast.Spec{
+			&goast.ValueSpec{
+				Names: []*goast.Ident{util.NewIdent("_")},
+				Values: []goast.Expr{
+					&goast.FuncLit{
+						Type: util.NewFuncType(&goast.FieldList{}, "", false),
+						Body: &goast.BlockStmt{
+							List: []goast.Stmt{transpileUnsupportedStmt(p, node)},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,89 @@
+package transpiler
+
+import (
+	"bytes"
+	"errors"
+	"go/printer"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func TestTranspileUnknown(t *testing.T) {
+	node := &ast.Unknown{
+		Name: "AtomicExpr",
+		Type: "int",
+		Pos:  ast.Position{File: "foo.c", Line: 12},
+	}
+	expected := `panic("unsupported C construct AtomicExpr at foo.c:12")`
+
+	t.Run("Stmt", func(t *testing.T) {
+		p := program.NewProgram()
+		stmt, _, _, err := transpileToStmt(node, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		printer.Fprint(&buf, token.NewFileSet(), stmt)
+		if buf.String() != expected {
+			t.Errorf("expected %s, got %s", expected, buf.String())
+		}
+
+		comments := p.GetMessageComments().List
+		if len(comments) != 1 ||
+			!strings.Contains(comments[0].Text, "// Warning (AtomicExpr):") {
+			t.Errorf("expected warning, got %#v", comments)
+		}
+
+		if code := p.Messages()[0].Code; code != program.CodeUnsupportedConstruct {
+			t.Errorf("expected code %s, got %s",
+				program.CodeUnsupportedConstruct, code)
+		}
+	})
+
+	t.Run("Expr", func(t *testing.T) {
+		p := program.NewProgram()
+		expr, exprType, _, _, err := transpileToExpr(node, p, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if exprType != "int" {
+			t.Errorf("expected type int, got %s", exprType)
+		}
+
+		var buf bytes.Buffer
+		printer.Fprint(&buf, token.NewFileSet(), expr)
+		if !strings.HasPrefix(buf.String(), "func() int32 {") ||
+			!strings.Contains(buf.String(), expected) {
+			t.Errorf("unexpected expression: %s", buf.String())
+		}
+	})
+	t.Run("ParseError", func(t *testing.T) {
+		p := program.NewProgram()
+		varDecl := &ast.Unknown{
+			Name:       "VarDecl",
+			Pos:        ast.Position{File: "foo.c", Line: 3},
+			ParseError: errors.New("could not match regexp"),
+		}
+		stmt, _, _, err := transpileToStmt(varDecl, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		printer.Fprint(&buf, token.NewFileSet(), stmt)
+		expected := `panic("cannot parse VarDecl at foo.c:3: could not match regexp")`
+		if buf.String() != expected {
+			t.Errorf("expected %s, got %s", expected, buf.String())
+		}
+
+		if code := p.Messages()[0].Code; code != program.CodeParseError {
+			t.Errorf("expected code %s, got %s", program.CodeParseError, code)
+		}
+	})
+}
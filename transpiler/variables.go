@@ -10,7 +10,6 @@ import (
 	"github.com/elliotchance/c2go/util"
 
 	goast "go/ast"
-	"go/parser"
 	"go/token"
 )
 
@@ -88,9 +87,9 @@ func getDefaultValueForVar(p *program.Program, a *ast.VarDecl) (
 			t = v.Type
 		}
 		if t != "" {
-			right, newPre, newPost, err := generateAlloc(p, allocSize, t)
+			right, newPre, newPost, err := generateAlloc(p, a, allocSize, t)
 			if err != nil {
-				p.AddMessage(p.GenerateWarningMessage(err, a))
+				p.AddWarning(err, a)
 				return nil, "", nil, nil, err
 			}
 
@@ -98,42 +97,6 @@ func getDefaultValueForVar(p *program.Program, a *ast.VarDecl) (
 		}
 	}
 
-	if va, ok := a.Children()[0].(*ast.VAArgExpr); ok {
-		outType, err := types.ResolveType(p, va.Type)
-		if err != nil {
-			return nil, "", nil, nil, err
-		}
-		if a, ok := va.Children()[0].(*ast.ImplicitCastExpr); ok {
-		} else {
-			return nil, "", nil, nil, fmt.Errorf("Expect ImplicitCastExpr for vaar, but we have %T", a)
-		}
-		src := fmt.Sprintf(`package main
-var temp = func() %s {
-	var ret %s
-	if v, ok := c2goVaList.Args[c2goVaList.Pos].(int32); ok{
-		// for 'rune' type
-		ret = %s(v)
-	} else {
-		ret = c2goVaList.Args[c2goVaList.Pos].(%s)
-	}
-	c2goVaList.Pos++
-	return ret
-}()`, outType,
-			outType,
-			outType,
-			outType)
-
-		// Create the AST by parsing src.
-		fset := token.NewFileSet() // positions are relative to fset
-		f, err := parser.ParseFile(fset, "", src, 0)
-		if err != nil {
-			return nil, "", nil, nil, err
-		}
-
-		expr := f.Decls[0].(*goast.GenDecl).Specs[0].(*goast.ValueSpec).Values
-		return expr, va.Type, nil, nil, nil
-	}
-
 	defaultValue, defaultValueType, newPre, newPost, err := atomicOperation(a.Children()[0], p)
 	if err != nil {
 		return nil, defaultValueType, newPre, newPost, err
@@ -142,7 +105,7 @@ var temp = func() %s {
 	var values []goast.Expr
 	if !types.IsNullExpr(defaultValue) {
 		t, err := types.CastExpr(p, defaultValue, defaultValueType, a.Type)
-		if !p.AddMessage(p.GenerateWarningMessage(err, a)) {
+		if !p.AddWarning(err, a) {
 			values = append(values, t)
 			defaultValueType = a.Type
 		}
@@ -208,128 +171,241 @@ func GenerateFuncType(fields, returns []string) *goast.FuncType {
 	return &ft
 }
 
+// transpileInitListExpr transpiles an initializer list of an array, struct or
+// union. The list from clang is in the order of the elements (or fields) with
+// the designated initializers already resolved, and the elements that are not
+// initialized are ImplicitValueInitExpr:
+//
+//     struct point p = {.y = 2};    ->  point{y: 2}
+//     int a[5] = {[3] = 7, 8};      ->  (&[5]int32{3: 7, 8})[:]
 func transpileInitListExpr(e *ast.InitListExpr, p *program.Program) (goast.Expr, string, error) {
-	resp := []goast.Expr{}
-	var hasArrayFiller = false
 	e.Type1 = types.GenerateCorrectType(e.Type1)
 	e.Type2 = types.GenerateCorrectType(e.Type2)
 
-	var goType string
-	arrayType, arraySize := types.GetArrayTypeAndSize(e.Type1)
-	if arraySize != -1 {
-		goArrayType, err := types.ResolveType(p, arrayType)
-		if err == nil {
-			goType = goArrayType
-		}
-	} else {
-		goType2, err := types.ResolveType(p, e.Type1)
-		if err == nil {
-			goType = goType2
-		}
+	if s := getUnion(p, e.Type1); s != nil {
+		return transpileUnionInitListExpr(e, p, s)
+	}
+
+	if arrayType, arraySize := types.GetArrayTypeAndSize(e.Type1); arraySize != -1 {
+		return transpileArrayInitListExpr(e, p, arrayType, arraySize, false)
+	}
+
+	goType, err := types.ResolveType(p, e.Type1)
+	p.AddWarning(err, e)
+
+	cType := e.Type1
+	if e.Type2 != "" {
+		cType = e.Type2
 	}
-	var goStruct *program.Struct
-	if e.Type1 == e.Type2 {
-		goStruct = p.GetStruct(goType)
-		if goStruct == nil {
-			goStruct = p.GetStruct("struct " + goType)
+	goStruct := getStructOfType(p, cType)
+	if goStruct == nil {
+		// The fields of the struct are not known so the values are used in
+		// the same order.
+		var values []goast.Expr
+		for _, node := range getInitListValues(e) {
+			if node == nil {
+				return nil, "", fmt.Errorf(
+					"cannot skip a field of the unknown struct %s", e.Type1)
+			}
+			value, _, _, _, err := transpileToExpr(node, p, true)
+			if err != nil {
+				return nil, "", err
+			}
+			values = append(values, value)
 		}
+
+		return &goast.CompositeLit{
+			Type: util.NewTypeIdent(goType),
+			Elts: values,
+		}, e.Type1, nil
 	}
-	fieldIndex := 0
 
-	for _, node := range e.Children() {
-		// Skip ArrayFiller
-		if _, ok := node.(*ast.ArrayFiller); ok {
-			hasArrayFiller = true
+	var names []string
+	var values []goast.Expr
+	for i, node := range getInitListValues(e) {
+		if i >= len(goStruct.FieldNames) {
+			return nil, "", fmt.Errorf("too many values to initialize %s", e.Type1)
+		}
+		if node == nil {
 			continue
 		}
 
-		var expr goast.Expr
-		var exprType string
-		var err error
-		expr, exprType, _, _, err = transpileToExpr(node, p, true)
-		if err != nil {
-			return nil, "", err
-		}
-		if goStruct != nil {
-			if fieldIndex >= len(goStruct.FieldNames) {
-				// index out of range
-				goto CONTINUE_INIT
+		name := goStruct.FieldNames[i]
+		fieldType, _ := goStruct.Fields[name].(string)
+
+		var value goast.Expr
+		var valueType string
+		if list, ok := node.(*ast.InitListExpr); ok && fieldType != "" {
+			// The arrays of a struct are Go arrays rather than slices.
+			if arrayType, arraySize := types.GetArrayTypeAndSize(fieldType); arraySize != -1 {
+				value, _, err = transpileArrayInitListExpr(list, p, arrayType, arraySize, true)
+				if err != nil {
+					return nil, "", err
+				}
 			}
-			fn := goStruct.FieldNames[fieldIndex]
-			if _, ok := goStruct.Fields[fn]; !ok {
-				// field name not in map
-				goto CONTINUE_INIT
+		}
+		if value == nil {
+			value, valueType, _, _, err = transpileToExpr(node, p, true)
+			if err != nil {
+				return nil, "", err
 			}
-			if field, ok := goStruct.Fields[goStruct.FieldNames[fieldIndex]].(string); ok {
-				expr2, err := types.CastExpr(p, expr, exprType, field)
-				if err == nil {
-					expr = expr2
+			if fieldType != "" {
+				v, err := types.CastExpr(p, value, valueType, fieldType)
+				if !p.AddWarning(err, node) {
+					value = v
 				}
 			}
-			fieldIndex++
 		}
-	CONTINUE_INIT:
-		resp = append(resp, expr)
-	}
 
-	var t goast.Expr
-	var cTypeString string
+		names = append(names, name)
+		values = append(values, value)
+	}
 
-	arrayType, arraySize = types.GetArrayTypeAndSize(e.Type1)
-	if arraySize != -1 {
-		goArrayType, err := types.ResolveType(p, arrayType)
-		p.AddMessage(p.GenerateWarningMessage(err, e))
+	// The bit-fields cannot be initialized in a composite literal.
+	if goStruct.HasBitFields() {
+		return transpileBitFieldInitListExpr(p, goStruct, goType, names, values),
+			e.Type1, nil
+	}
 
-		cTypeString = fmt.Sprintf("%s[%d]", arrayType, arraySize)
+	elts := make([]goast.Expr, len(values))
+	for i, name := range names {
+		// TODO: The name of a variable or field cannot be a reserved word
+		// https://github.com/elliotchance/c2go/issues/83
+		if util.IsGoKeyword(name) {
+			name += "_"
+		}
+		elts[i] = &goast.KeyValueExpr{
+			Key:   util.NewIdent(name),
+			Value: values[i],
+		}
+	}
 
-		if hasArrayFiller {
-			t = &goast.ArrayType{
-				Elt: &goast.Ident{
-					Name: goArrayType,
-				},
-				Len: util.NewIntLit(arraySize),
-			}
+	return &goast.CompositeLit{
+		Type: util.NewTypeIdent(goType),
+		Elts: elts,
+	}, e.Type1, nil
+}
 
-			// Array fillers do not work with slices.
-			// We initialize the array first, then convert to a slice.
-			// For example: (&[4]int{1,2})[:]
-			return &goast.SliceExpr{
-				X: &goast.ParenExpr{
-					X: &goast.UnaryExpr{
-						Op: token.AND,
-						X: &goast.CompositeLit{
-							Type: t,
-							Elts: resp,
-						},
-					},
-				},
-			}, cTypeString, nil
+// transpileArrayInitListExpr transpiles the initializer list of an array of
+// arraySize elements of arrayType. The elements that follow the elements that
+// are not initialized have an explicit index:
+//
+//     int a[5] = {1, [3] = 7, 8};  ->  (&[5]int32{1, 3: 7, 8})[:]
+//
+// The arrays are slices in Go, except the arrays of a struct which are Go
+// arrays (if isArray is true):
+//
+//     [5]int32{1, 3: 7, 8}
+func transpileArrayInitListExpr(e *ast.InitListExpr, p *program.Program,
+	arrayType string, arraySize int, isArray bool) (goast.Expr, string, error) {
+
+	goArrayType, err := types.ResolveType(p, arrayType)
+	p.AddWarning(err, e)
+
+	var elts []goast.Expr
+	length := 0
+	keyed := false
+	for i, node := range getInitListValues(e) {
+		if node == nil {
+			keyed = true
+			continue
 		}
 
-		t = &goast.ArrayType{
-			Elt: &goast.Ident{
-				Name: goArrayType,
-			},
-		}
-	} else {
-		goType, err := types.ResolveType(p, e.Type1)
+		value, valueType, _, _, err := transpileToExpr(node, p, true)
 		if err != nil {
 			return nil, "", err
 		}
+		if _, ok := node.(*ast.InitListExpr); !ok {
+			v, err := types.CastExpr(p, value, valueType, arrayType)
+			if !p.AddWarning(err, node) {
+				value = v
+			}
+		}
 
-		t = &goast.Ident{
-			Name: goType,
+		if keyed {
+			value = &goast.KeyValueExpr{
+				Key:   util.NewIntLit(i),
+				Value: value,
+			}
+			keyed = false
 		}
+		elts = append(elts, value)
+		length = i + 1
+	}
+
+	cTypeString := fmt.Sprintf("%s[%d]", arrayType, arraySize)
+
+	if isArray {
+		return &goast.CompositeLit{
+			Type: &goast.ArrayType{
+				Elt: util.NewTypeIdent(goArrayType),
+				Len: util.NewIntLit(arraySize),
+			},
+			Elts: elts,
+		}, cTypeString, nil
+	}
 
-		cTypeString = e.Type1
+	if length < arraySize {
+		// Array fillers do not work with slices.
+		// We initialize the array first, then convert to a slice.
+		// For example: (&[4]int{1,2})[:]
+		return &goast.SliceExpr{
+			X: &goast.ParenExpr{
+				X: &goast.UnaryExpr{
+					Op: token.AND,
+					X: &goast.CompositeLit{
+						Type: &goast.ArrayType{
+							Elt: util.NewTypeIdent(goArrayType),
+							Len: util.NewIntLit(arraySize),
+						},
+						Elts: elts,
+					},
+				},
+			},
+		}, cTypeString, nil
 	}
 
 	return &goast.CompositeLit{
-		Type: t,
-		Elts: resp,
+		Type: &goast.ArrayType{
+			Elt: util.NewTypeIdent(goArrayType),
+		},
+		Elts: elts,
 	}, cTypeString, nil
 }
 
+// getInitListValues returns the values of an initializer list in the order of
+// the elements or fields. The values that are not initialized are nil. The
+// array filler (the value of the elements after the list) is not included.
+func getInitListValues(e *ast.InitListExpr) (values []ast.Node) {
+	for _, node := range e.Children() {
+		switch n := node.(type) {
+		case *ast.ArrayFiller:
+			continue
+
+		case *ast.ImplicitValueInitExpr:
+			if !n.IsArrayFiller {
+				values = append(values, nil)
+			}
+			continue
+		}
+
+		values = append(values, node)
+	}
+
+	return
+}
+
+// getStructOfType returns the struct of the C type, or nil if the type is not
+// a struct.
+func getStructOfType(p *program.Program, cType string) *program.Struct {
+	s := p.GetRecord(types.CleanCType(types.GenerateCorrectType(cType)))
+	if s == nil || s.IsUnion {
+		return nil
+	}
+
+	return s
+}
+
 func transpileDeclStmt(n *ast.DeclStmt, p *program.Program) (stmts []goast.Stmt, err error) {
 	if len(n.Children()) == 0 {
 		return
@@ -339,7 +415,7 @@ func transpileDeclStmt(n *ast.DeclStmt, p *program.Program) (stmts []goast.Stmt,
 	var decls []goast.Decl
 	decls, err = transpileToNode(&tud, p)
 	if err != nil {
-		p.AddMessage(p.GenerateErrorMessage(err, n))
+		p.AddError(err, n)
 		err = nil
 	}
 	stmts = convertDeclToStmt(decls)
@@ -352,7 +428,7 @@ func transpileArraySubscriptExpr(n *ast.ArraySubscriptExpr, p *program.Program,
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("Cannot transpile ArraySubscriptExpr. err = %v", err)
-			p.AddMessage(p.GenerateWarningMessage(err, n))
+			p.AddWarning(err, n)
 		}
 	}()
 
@@ -405,6 +481,44 @@ func transpileArraySubscriptExpr(n *ast.ArraySubscriptExpr, p *program.Program,
 	}, n.Type, preStmts, postStmts, nil
 }
 
+// getMemberBaseType returns the C type of the struct or union of a member
+// expression, like "struct foo" for both "f.bar" and "pf->bar". An empty
+// string is returned if the type is not known.
+func getMemberBaseType(n *ast.MemberExpr) string {
+	if len(n.Children()) == 0 {
+		return ""
+	}
+
+	var cType string
+	switch v := n.Children()[0].(type) {
+	case *ast.DeclRefExpr:
+		cType = v.Type
+	case *ast.ImplicitCastExpr:
+		cType = v.Type
+	case *ast.MemberExpr:
+		cType = v.Type
+	case *ast.ParenExpr:
+		cType = v.Type
+	case *ast.ArraySubscriptExpr:
+		cType = v.Type
+	case *ast.UnaryOperator:
+		cType = v.Type
+	case *ast.CallExpr:
+		cType = v.Type
+	case *ast.CStyleCastExpr:
+		cType = v.Type
+	default:
+		return ""
+	}
+
+	cType = types.CleanCType(types.GenerateCorrectType(cType))
+	if n.IsPointer {
+		cType = strings.TrimSpace(strings.TrimSuffix(cType, "*"))
+	}
+
+	return cType
+}
+
 func transpileMemberExpr(n *ast.MemberExpr, p *program.Program) (
 	_ goast.Expr, _ string, preStmts []goast.Stmt, postStmts []goast.Stmt, err error) {
 
@@ -422,7 +536,7 @@ func transpileMemberExpr(n *ast.MemberExpr, p *program.Program) (
 	preStmts, postStmts = combinePreAndPostStmts(preStmts, postStmts, newPre, newPost)
 
 	lhsResolvedType, err := types.ResolveType(p, lhsType)
-	p.AddMessage(p.GenerateWarningMessage(err, n))
+	p.AddWarning(err, n)
 
 	// lhsType will be something like "struct foo"
 	structType := p.GetStruct(lhsType)
@@ -447,14 +561,14 @@ func transpileMemberExpr(n *ast.MemberExpr, p *program.Program) (
 		//      to be resolved before the real field type can be determined.
 		err = fmt.Errorf("cannot determine type for LHS '%v'"+
 			", will use 'void *' for all fields. Is lvalue = %v", lhsType, n.IsLvalue)
-		p.AddMessage(p.GenerateWarningMessage(err, n))
+		p.AddWarning(err, n)
 	} else {
 		if s, ok := structType.Fields[rhs].(string); ok {
 			rhsType = s
 		} else {
 			err = fmt.Errorf("cannot determine type for RHS '%v', will use"+
 				" 'void *' for all fields. Is lvalue = %v", rhs, n.IsLvalue)
-			p.AddMessage(p.GenerateWarningMessage(err, n))
+			p.AddWarning(err, n)
 		}
 	}
 
@@ -505,6 +619,19 @@ func transpileMemberExpr(n *ast.MemberExpr, p *program.Program) (
 
 	_ = rhsType
 
+	// A bit-field is read with its getter.
+	if _, ok := getBitField(p, n); ok {
+		if util.IsGoKeyword(rhs) {
+			rhs += "_"
+		}
+		return &goast.CallExpr{
+			Fun: &goast.SelectorExpr{
+				X:   x,
+				Sel: util.NewIdent(rhs),
+			},
+		}, n.Type, preStmts, postStmts, nil
+	}
+
 	return &goast.SelectorExpr{
 		X:   x,
 		Sel: util.NewIdent(rhs),
@@ -0,0 +1,204 @@
+package transpiler
+
+import (
+	"testing"
+
+	"github.com/elliotchance/c2go/ast"
+	"github.com/elliotchance/c2go/program"
+)
+
+func intLiteral(value string) *ast.IntegerLiteral {
+	return &ast.IntegerLiteral{Type: "int", Value: value}
+}
+
+func implicitValue(cType string) *ast.ImplicitValueInitExpr {
+	return &ast.ImplicitValueInitExpr{Type1: cType}
+}
+
+func TestTranspileInitListExpr(t *testing.T) {
+	p := program.NewProgram()
+
+	// struct point {
+	//     int x;
+	//     int y;
+	//     int z[3];
+	// };
+	point := &ast.RecordDecl{Name: "point", Kind: "struct", Definition: true}
+	point.AddChild(&ast.FieldDecl{Name: "x", Type: "int"})
+	point.AddChild(&ast.FieldDecl{Name: "y", Type: "int"})
+	point.AddChild(&ast.FieldDecl{Name: "z", Type: "int [3]"})
+	if _, err := transpileRecordDecl(p, point); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		list     *ast.InitListExpr
+		expected string
+	}{
+		{
+			name: "positional struct",
+			list: &ast.InitListExpr{
+				Type1: "struct point",
+				Type2: "struct point",
+				ChildNodes: []ast.Node{
+					intLiteral("1"), intLiteral("2"), implicitValue("int [3]"),
+				},
+			},
+			expected: `point{x: int32(1), y: int32(2)}`,
+		},
+		{
+			// struct point p = {.y = 2, .z[1] = 5};
+			name: "designated struct",
+			list: &ast.InitListExpr{
+				Type1: "struct point",
+				ChildNodes: []ast.Node{
+					implicitValue("int"),
+					intLiteral("2"),
+					&ast.InitListExpr{
+						Type1: "int [3]",
+						ChildNodes: []ast.Node{
+							implicitValue("int"), intLiteral("5"),
+							&ast.ImplicitValueInitExpr{Type1: "int", IsArrayFiller: true},
+						},
+					},
+				},
+			},
+			expected: `point{y: int32(2), z: [3]int32{1: int32(5)}}`,
+		},
+		{
+			// int a[5] = {1, [3] = 7};
+			name: "designated array",
+			list: &ast.InitListExpr{
+				Type1: "int [5]",
+				ChildNodes: []ast.Node{
+					&ast.ImplicitValueInitExpr{Type1: "int", IsArrayFiller: true},
+					intLiteral("1"), implicitValue("int"), implicitValue("int"),
+					intLiteral("7"),
+				},
+			},
+			expected: `(&[5]int32{int32(1), 3: int32(7)})[:]`,
+		},
+		{
+			// int a[3] = {[2] = 3, [0] = 1, [1] = 2};
+			name: "full array",
+			list: &ast.InitListExpr{
+				Type1: "int [3]",
+				ChildNodes: []ast.Node{
+					intLiteral("1"), intLiteral("2"), intLiteral("3"),
+				},
+			},
+			expected: `[]int32{int32(1), int32(2), int32(3)}`,
+		},
+		{
+			// struct point ps[2] = {[1].x = 4};
+			name: "array of structs",
+			list: &ast.InitListExpr{
+				Type1: "struct point [2]",
+				ChildNodes: []ast.Node{
+					implicitValue("struct point"),
+					&ast.InitListExpr{
+						Type1: "struct point",
+						ChildNodes: []ast.Node{
+							intLiteral("4"), implicitValue("int"), implicitValue("int [3]"),
+						},
+					},
+				},
+			},
+			expected: `[]point{1: point{x: int32(4)}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expr, _, err := transpileInitListExpr(test.list, p)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual := formatNodes(t, expr); actual != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDefaultValueForAllocation(t *testing.T) {
+	p := program.NewProgram()
+	p.IncludeHeaders = []program.IncludeHeader{{HeaderName: "/usr/include/stdlib.h"}}
+	pos := ast.Position{File: "/src/main.c", Line: 7}
+
+	tests := []struct {
+		name     string
+		call     *ast.CallExpr
+		expected string
+	}{
+		{
+			// int *a = malloc(40);
+			name: "malloc",
+			call: newLibraryCall(pos, "malloc", "void *(unsigned long)",
+				intLiteral("40")),
+			expected: `(*int32)(noarch.Site("main.c:7").Malloc(int32(40)))`,
+		},
+		{
+			// int *a = calloc(10, 4);
+			name: "calloc",
+			call: newLibraryCall(pos, "calloc", "void *(unsigned long, unsigned long)",
+				intLiteral("10"), intLiteral("4")),
+			expected: `(*int32)(noarch.Site("main.c:7").Calloc(int32(10), int32(4)))`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decl := &ast.VarDecl{
+				Pos:  pos,
+				Name: "a",
+				Type: "int *",
+				ChildNodes: []ast.Node{&ast.ImplicitCastExpr{
+					Type:       "int *",
+					Kind:       "BitCast",
+					ChildNodes: []ast.Node{test.call},
+				}},
+			}
+			values, _, _, _, err := getDefaultValueForVar(p, decl)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual := formatNodes(t, values[0]); actual != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestInitListExprCastWarning(t *testing.T) {
+	p := program.NewProgram()
+
+	// struct names {
+	//     char *list[];
+	// };
+	names := &ast.RecordDecl{Name: "names", Kind: "struct", Definition: true}
+	names.AddChild(&ast.FieldDecl{Name: "list", Type: "char *[]"})
+	transpileRecordDecl(p, names)
+
+	// The value is used without the cast, and the error is a warning.
+	value := &ast.IntegerLiteral{Type: "int", Value: "0", Pos: ast.Position{Line: 7}}
+	before := len(p.Messages())
+	expr, _, err := transpileInitListExpr(&ast.InitListExpr{
+		Type1:      "struct names",
+		ChildNodes: []ast.Node{value},
+	}, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := formatNodes(t, expr); actual != `names{list: int32(0)}` {
+		t.Errorf("expected %s, got %s", `names{list: int32(0)}`, actual)
+	}
+	messages := p.Messages()[before:]
+	if len(messages) != 1 || messages[0].Position.Line != 7 {
+		t.Errorf("expected a warning for the value, got %v", messages)
+	}
+}
@@ -17,6 +17,17 @@ import (
 // is not an array with a fixed size then the the size will be -1 and the
 // returned type should be ignored.
 func GetArrayTypeAndSize(s string) (string, int) {
+	// The brackets of an array of function pointers are in the declarator,
+	// like "int (*[4])(int)".
+	if strings.Contains(s, "(") {
+		if t, err := parseCType(s); err == nil && t.hasFunction() {
+			if t.kind == cTypeArray && t.size >= 0 {
+				return t.elem.String(), t.size
+			}
+			return s, -1
+		}
+	}
+
 	match := util.GetRegex(`([\w\* ]*)\[(\d+)\]((\[\d+\])*)`).FindStringSubmatch(s)
 	if len(match) > 0 {
 		var t = fmt.Sprintf("%s%s", match[1], match[3])
@@ -65,7 +76,8 @@ func CastExpr(p *program.Program, expr goast.Expr, cFromType, cToType string) (
 
 	defer func() {
 		if err2 != nil {
-			err2 = fmt.Errorf("Cannot casting {%s -> %s}. err = %v", cFromType, cToType, err2)
+			err2 = program.WithCode(program.CodeCast,
+				fmt.Errorf("Cannot casting {%s -> %s}. err = %v", cFromType, cToType, err2))
 		}
 	}()
 	cFromType = CleanCType(cFromType)
@@ -91,6 +103,14 @@ func CastExpr(p *program.Program, expr goast.Expr, cFromType, cToType string) (
 			p.AddImport("github.com/elliotchance/c2go/noarch")
 			return util.NewCallExpr("noarch.CastInterfaceToPointer", expr), nil
 		}
+		// A function pointer is true if it is not NULL.
+		if cToType == "bool" {
+			return &goast.BinaryExpr{
+				X:  expr,
+				Op: token.NEQ,
+				Y:  util.NewNil(),
+			}, nil
+		}
 		return expr, nil
 	}
 
@@ -103,11 +123,18 @@ func CastExpr(p *program.Program, expr goast.Expr, cFromType, cToType string) (
 	}
 
 	// Exception for va_list:
-	// A pointer to struct __va_list_tag is always a variable called
-	// "c2goVaList" in go.
+	// A va_list and a pointer to struct __va_list_tag are both a
+	// noarch.VaList in Go.
 	if fromType == "va_list" && toType == "struct __va_list_tag *" {
-		ret := &goast.BasicLit{Kind: token.STRING, Value: "c2goVaList"}
-		return ret, nil
+		return expr, nil
+	}
+
+	// Exception for jmp_buf:
+	// jmp_buf is an array of one struct __jmp_buf_tag in C, but it is a
+	// noarch.JmpBuf in Go.
+	if (fromType == "jmp_buf" || fromType == "sigjmp_buf") &&
+		toType == "struct __jmp_buf_tag *" {
+		return &goast.UnaryExpr{Op: token.AND, X: expr}, nil
 	}
 
 	// casting
@@ -309,7 +336,8 @@ func CastExpr(p *program.Program, expr goast.Expr, cFromType, cToType string) (
 	if strings.HasPrefix(fromType, "[]") && strings.HasPrefix(toType, "*") &&
 		fromType[2:] == toType[1:] {
 		match := util.GetRegex(`\[(\d*)\]$`).FindStringSubmatch(cFromType)
-		if strings.HasSuffix(cToType, "*") && len(match) > 0 {
+		if strings.HasSuffix(cToType, "*") && len(match) > 0 ||
+			isFunctionPointerArrayDecay(cFromType, cToType) {
 			// we need to convert from array to pointer
 			return &goast.UnaryExpr{
 				Op: token.AND,
@@ -519,7 +547,12 @@ func CastExpr(p *program.Program, expr goast.Expr, cFromType, cToType string) (
 		exportedLeftName, exportedRightName)
 
 	if strings.HasSuffix(exportedLeftName, "Slice") && strings.HasSuffix(exportedRightName, "Slice") {
-		p.AddMessage(fmt.Sprintf("// Warning: using unsafe slice cast to convert from %s to %s", fromType, toType))
+		p.AddDiagnostic(&program.Diagnostic{
+			Code:     program.CodeUnsafeCast,
+			Severity: program.SeverityWarning,
+			Message: fmt.Sprintf("using unsafe slice cast to convert from %s to %s",
+				fromType, toType),
+		})
 		fromSize, err := SizeOf(p, GetBaseType(cFromType))
 		if err != nil {
 			return nil, err
@@ -0,0 +1,374 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// cTypeKind is the kind of a parsed C type.
+type cTypeKind int
+
+const (
+	cTypeBase cTypeKind = iota
+	cTypePointer
+	cTypeArray
+	cTypeFunction
+)
+
+// cType is a C type that has been split into its declarators so that types
+// built from function types can be resolved. For example the type of an array
+// of function pointers:
+//
+//     int (*[4])(double)
+//
+// Is an array (of size 4) of a pointer to a function with the parameter
+// "double" that returns the base type "int".
+type cType struct {
+	kind cTypeKind
+
+	// base is the C type of a cTypeBase, like "const char *". It does not
+	// contain any parentheses or brackets.
+	base string
+
+	// elem is the type that is pointed to, the type of the elements of an
+	// array or the return type of a function.
+	elem *cType
+
+	// size is the size of an array, or -1 if the array does not have a size.
+	size int
+
+	// params are the C types of the parameters of a function as they are
+	// written, like "void" or "...".
+	params []string
+}
+
+// parseCType parses a C type like "int (*(*)(int))(double)". An error is
+// returned if the type cannot be parsed, or if the parentheses are not a
+// function (like "struct (anonymous at file.c:1:2)").
+func parseCType(s string) (t *cType, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("type is empty")
+	}
+
+	if !strings.ContainsAny(s, "()[]") {
+		return &cType{kind: cTypeBase, base: s}, nil
+	}
+
+	switch s[len(s)-1] {
+	case ')':
+		open := matchingParenthesis(s, len(s)-1)
+		if open < 0 {
+			return nil, fmt.Errorf("unbalanced parentheses in '%s'", s)
+		}
+
+		left := strings.TrimSpace(s[:open])
+		if left == "" {
+			return nil, fmt.Errorf("function without return type '%s'", s)
+		}
+		if i := strings.LastIndexAny(left, " *"); isRecordKeyword(left[i+1:]) {
+			return nil, fmt.Errorf("not a function '%s'", s)
+		}
+
+		function := &cType{
+			kind:   cTypeFunction,
+			params: splitParameters(s[open+1 : len(s)-1]),
+		}
+
+		// The declarator of the function is in parentheses before the
+		// parameters, like the "(*)" in "int (*)(double)".
+		declarator := ""
+		if left[len(left)-1] == ')' {
+			o := matchingParenthesis(left, len(left)-1)
+			if o < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses in '%s'", s)
+			}
+			declarator = left[o+1 : len(left)-1]
+			left = strings.TrimSpace(left[:o])
+			if left == "" {
+				return nil, fmt.Errorf("function without return type '%s'", s)
+			}
+		}
+
+		function.elem, err = parseCType(left)
+		if err != nil {
+			return nil, err
+		}
+
+		return applyDeclarator(declarator, function)
+
+	case ']':
+		open := strings.LastIndex(s, "[")
+		if open < 0 {
+			return nil, fmt.Errorf("unbalanced brackets in '%s'", s)
+		}
+
+		size := -1
+		if n := strings.TrimSpace(s[open+1 : len(s)-1]); n != "" {
+			size, err = strconv.Atoi(n)
+			if err != nil {
+				return nil, fmt.Errorf("size of array in '%s' : %v", s, err)
+			}
+		}
+
+		elem, err := parseCType(s[:open])
+		if err != nil {
+			return nil, err
+		}
+
+		return &cType{kind: cTypeArray, elem: elem, size: size}, nil
+
+	case '*':
+		elem, err := parseCType(s[:len(s)-1])
+		if err != nil {
+			return nil, err
+		}
+
+		return &cType{kind: cTypePointer, elem: elem}, nil
+	}
+
+	if q := trimQualifier(s); q != s {
+		return parseCType(q)
+	}
+
+	return nil, fmt.Errorf("cannot parse type '%s'", s)
+}
+
+// applyDeclarator returns the type of an abstract declarator (like "*[4]" or
+// "*(*)(int)") of the type t.
+func applyDeclarator(declarator string, t *cType) (*cType, error) {
+	d := strings.TrimSpace(declarator)
+
+	// The pointers are applied first: "*[4]" is an array of pointers.
+	for {
+		if strings.HasPrefix(d, "*") {
+			t = &cType{kind: cTypePointer, elem: t}
+			d = strings.TrimSpace(d[1:])
+			continue
+		}
+		if q := trimQualifier(d); q != d {
+			d = q
+			continue
+		}
+		break
+	}
+
+	// The parentheses are a nested declarator (rather than the parameters of
+	// a function) if they start with a declarator, like "(*)[3]".
+	nested := ""
+	if strings.HasPrefix(d, "(") {
+		closing := matchingParenthesis(d, 0)
+		if closing < 0 {
+			return nil, fmt.Errorf("unbalanced parentheses in '%s'", declarator)
+		}
+		if inner := strings.TrimSpace(d[1:closing]); inner != "" &&
+			strings.ContainsAny(inner[:1], "*([") {
+			nested = inner
+			d = strings.TrimSpace(d[closing+1:])
+		}
+	}
+
+	// The suffixes nearest to the name are the outermost types, so they are
+	// applied from right to left: "[2](int)" is an array of functions.
+	for d != "" {
+		switch d[len(d)-1] {
+		case ']':
+			open := strings.LastIndex(d, "[")
+			if open < 0 {
+				return nil, fmt.Errorf("unbalanced brackets in '%s'", declarator)
+			}
+
+			size := -1
+			if n := strings.TrimSpace(d[open+1 : len(d)-1]); n != "" {
+				var err error
+				size, err = strconv.Atoi(n)
+				if err != nil {
+					return nil, fmt.Errorf("size of array in '%s' : %v", declarator, err)
+				}
+			}
+
+			t = &cType{kind: cTypeArray, elem: t, size: size}
+			d = strings.TrimSpace(d[:open])
+
+		case ')':
+			open := matchingParenthesis(d, len(d)-1)
+			if open < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses in '%s'", declarator)
+			}
+
+			t = &cType{
+				kind:   cTypeFunction,
+				elem:   t,
+				params: splitParameters(d[open+1 : len(d)-1]),
+			}
+			d = strings.TrimSpace(d[:open])
+
+		default:
+			return nil, fmt.Errorf("cannot parse declarator '%s'", declarator)
+		}
+	}
+
+	if nested != "" {
+		return applyDeclarator(nested, t)
+	}
+
+	return t, nil
+}
+
+// String returns the C type, like "int (*)(double)".
+func (t *cType) String() string {
+	return t.declare("")
+}
+
+// declare returns the C type with the abstract declarator.
+func (t *cType) declare(declarator string) string {
+	switch t.kind {
+	case cTypePointer:
+		declarator = "*" + declarator
+		if t.elem.kind == cTypeArray || t.elem.kind == cTypeFunction {
+			declarator = "(" + declarator + ")"
+		}
+		return t.elem.declare(declarator)
+
+	case cTypeArray:
+		size := ""
+		if t.size >= 0 {
+			size = strconv.Itoa(t.size)
+		}
+		return t.elem.declare(declarator + "[" + size + "]")
+
+	case cTypeFunction:
+		return t.elem.declare(declarator + "(" + strings.Join(t.params, ", ") + ")")
+	}
+
+	if declarator == "" {
+		return t.base
+	}
+	if strings.HasSuffix(t.base, "*") {
+		return t.base + declarator
+	}
+	return t.base + " " + declarator
+}
+
+// hasFunction returns true if the type is built from a function type.
+func (t *cType) hasFunction() bool {
+	for ; t != nil; t = t.elem {
+		if t.kind == cTypeFunction {
+			return true
+		}
+	}
+
+	return false
+}
+
+// function returns the function type if the type is a function or a pointer to
+// a function, otherwise nil.
+func (t *cType) function() *cType {
+	if t.kind == cTypePointer {
+		t = t.elem
+	}
+	if t.kind == cTypeFunction {
+		return t
+	}
+
+	return nil
+}
+
+// parameters returns the C types of the parameters of a function type without
+// the "void" of a function that has no parameters.
+func (t *cType) parameters() []string {
+	if len(t.params) == 1 && t.params[0] == "void" {
+		return nil
+	}
+
+	return t.params
+}
+
+// IsFunctionPointer returns true if the type is a pointer to a function, like
+// "int (*)(double)", but not a function like "int (double)".
+func IsFunctionPointer(s string) bool {
+	t, err := parseCType(s)
+	return err == nil && t.kind == cTypePointer && t.elem.kind == cTypeFunction
+}
+
+// matchingParenthesis returns the index of the parenthesis that matches the
+// parenthesis at index i, or -1 if there is not one.
+func matchingParenthesis(s string, i int) int {
+	step, depth := 1, 0
+	if s[i] == ')' {
+		step = -1
+	}
+
+	for ; i >= 0 && i < len(s); i += step {
+		switch s[i] {
+		case '(':
+			depth += step
+		case ')':
+			depth -= step
+		}
+		if depth == 0 {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// splitParameters splits the parameters of a function type, like
+// "int, void (*)(int, int)", at the commas that are not in parentheses.
+func splitParameters(s string) (params []string) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				params = append(params, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+
+	return append(params, strings.TrimSpace(s[start:]))
+}
+
+// trimQualifier removes a type qualifier from the start or end of s.
+func trimQualifier(s string) string {
+	for _, q := range []string{"const", "volatile", "restrict", "__restrict"} {
+		if strings.HasPrefix(s, q) && (len(s) == len(q) || strings.ContainsAny(s[len(q):len(q)+1], " *([")) {
+			return strings.TrimSpace(s[len(q):])
+		}
+		if strings.HasSuffix(s, q) && (len(s) == len(q) || strings.ContainsAny(s[len(s)-len(q)-1:len(s)-len(q)], " *)]")) {
+			return strings.TrimSpace(s[:len(s)-len(q)])
+		}
+	}
+
+	return s
+}
+
+// isRecordKeyword returns true for the keywords that can be followed by the
+// name of an anonymous type in parentheses.
+func isRecordKeyword(s string) bool {
+	return s == "struct" || s == "union" || s == "enum"
+}
+
+// isFunctionPointerArrayDecay returns true if the array of function pointers
+// fromType, like "int (*[3])(int)", decays to the pointer to its first element
+// toType, like "int (**)(int)".
+func isFunctionPointerArrayDecay(fromType, toType string) bool {
+	from, err := parseCType(fromType)
+	if err != nil || from.kind != cTypeArray || !from.hasFunction() {
+		return false
+	}
+	to, err := parseCType(toType)
+	return err == nil && to.kind == cTypePointer && to.elem.String() == from.elem.String()
+}
@@ -132,6 +132,11 @@ var otherStructType = map[string]string{
 	"ldiv_t":  "github.com/elliotchance/c2go/noarch.LdivT",
 	"lldiv_t": "github.com/elliotchance/c2go/noarch.LldivT",
 
+	// setjmp.h
+	"jmp_buf":       "github.com/elliotchance/c2go/noarch.JmpBuf",
+	"sigjmp_buf":    "github.com/elliotchance/c2go/noarch.JmpBuf",
+	"__jmp_buf_tag": "github.com/elliotchance/c2go/noarch.JmpBuf",
+
 	// time.h
 	"tm":        "github.com/elliotchance/c2go/noarch.Tm",
 	"struct tm": "github.com/elliotchance/c2go/noarch.Tm",
@@ -186,7 +191,8 @@ var ToVoid = "ToVoid"
 func ResolveType(p *program.Program, s string) (_ string, err error) {
 	defer func() {
 		if err != nil {
-			err = fmt.Errorf("Cannot resolve type '%s' : %v", s, err)
+			err = program.WithCode(program.CodeTypeResolution,
+				fmt.Errorf("Cannot resolve type '%s' : %v", s, err))
 		}
 	}()
 	s = CleanCType(s)
@@ -266,10 +272,9 @@ func ResolveType(p *program.Program, s string) (_ string, err error) {
 		}
 	}
 
-	// For function
-	if IsFunction(s) {
-		g, e := resolveFunction(p, s)
-		return g, e
+	// For function, function pointers and the types that are built from them
+	if t, err := parseCType(s); err == nil && t.hasFunction() {
+		return resolveFunction(p, t)
 	}
 
 	// Check is it typedef enum
@@ -386,25 +391,58 @@ func ResolveType(p *program.Program, s string) (_ string, err error) {
 	return "unsafe.Pointer", errors.New(errMsg)
 }
 
-// resolveType determines the Go type from a C type.
-func resolveFunction(p *program.Program, s string) (goType string, err error) {
-	var f, r []string
-	f, r, err = SeparateFunction(p, s)
-	goType = "func("
-	for i := range f {
-		goType += f[i]
-		if i < len(f)-1 {
-			goType += " , "
+// resolveFunction determines the Go type of a C type that is built from a
+// function type, like a pointer to a function or an array of function pointers.
+// A pointer to a function is a func in Go.
+func resolveFunction(p *program.Program, t *cType) (string, error) {
+	if !t.hasFunction() {
+		return ResolveType(p, t.String())
+	}
+
+	switch t.kind {
+	case cTypePointer:
+		if t.elem.kind == cTypeFunction {
+			return resolveFunction(p, t.elem)
 		}
+		elem, err := resolveFunction(p, t.elem)
+		return "*" + elem, err
+
+	case cTypeArray:
+		elem, err := resolveFunction(p, t.elem)
+		return "[]" + elem, err
 	}
-	goType += ")("
-	for i := range r {
-		goType += r[i]
-		if i < len(r)-1 {
-			goType += " , "
+
+	fields, err := resolveParameters(p, t.parameters())
+	if err != nil {
+		return "", err
+	}
+
+	r, err := ResolveType(p, t.elem.String())
+	if err != nil {
+		return "", err
+	}
+
+	return "func(" + strings.Join(fields, " , ") + ")(" + r + ")", nil
+}
+
+// resolveParameters determines the Go types of the parameters of a function.
+// The variadic arguments ("...") are an "...interface{}".
+func resolveParameters(p *program.Program, params []string) (
+	fields []string, err error) {
+	for _, param := range params {
+		if param == "..." {
+			fields = append(fields, "...interface{}")
+			continue
 		}
+
+		var t string
+		t, err = ResolveType(p, param)
+		if err != nil {
+			return
+		}
+		fields = append(fields, t)
 	}
-	goType += ")"
+
 	return
 }
 
@@ -420,13 +458,12 @@ func SeparateFunction(p *program.Program, s string) (
 	if err != nil {
 		return
 	}
-	for i := range f {
-		var t string
-		t, err = ResolveType(p, f[i])
-		if err != nil {
-			return
-		}
-		fields = append(fields, t)
+	if len(f) == 1 && f[0] == "void" {
+		f = nil
+	}
+	fields, err = resolveParameters(p, f)
+	if err != nil {
+		return
 	}
 	for i := range r {
 		var t string
@@ -439,8 +476,15 @@ func SeparateFunction(p *program.Program, s string) (
 	return
 }
 
-// IsFunction - return true if string is function like "void (*)(void)"
+// IsFunction - return true if string is function like "void (*)(void)". A
+// type that only contains a function, like an array of function pointers
+// "int (*[4])(int)" or a pointer to a function pointer "int (**)(int)", is not
+// a function.
 func IsFunction(s string) bool {
+	if t, err := parseCType(s); err == nil {
+		return t.function() != nil
+	}
+
 	s = strings.Replace(s, "(*)", "", -1)
 	return strings.Contains(s, "(")
 }
@@ -492,7 +536,12 @@ func IsTypedefFunction(p *program.Program, s string) bool {
 	return false
 }
 
-// ParseFunction - parsing elements of C function
+// ParseFunction - parsing elements of C function or function pointer. The
+// parameters and the return type are returned as C types:
+//
+//     int (*(*)(int, char *))(double)
+//
+// Has the fields "int" and "char *" and returns "int (*)(double)".
 func ParseFunction(s string) (f []string, r []string, err error) {
 	defer func() {
 		if err != nil {
@@ -500,73 +549,19 @@ func ParseFunction(s string) (f []string, r []string, err error) {
 		}
 	}()
 
-	s = strings.TrimSpace(s)
-	if !IsFunction(s) {
-		err = fmt.Errorf("Is not function : %s", s)
+	t, err := parseCType(s)
+	if err != nil {
 		return
 	}
-	var arguments string
-	{
-		// Example of function types :
-		// int (*)(int, float)
-		// int (int, float)
-		// int (*)(int (*)(int))
-		// void (*(*)(int *, void *, const char *))(void)
-		if s[len(s)-1] != ')' {
-			err = fmt.Errorf("function type |%s| haven't last symbol ')'", s)
-			return
-		}
-		counter := 1
-		var pos int
-		for i := len(s) - 2; i >= 0; i-- {
-			if i == 0 {
-				err = fmt.Errorf("Don't found '(' in type : %s", s)
-				return
-			}
-			if s[i] == ')' {
-				counter++
-			}
-			if s[i] == '(' {
-				counter--
-			}
-			if counter == 0 {
-				pos = i
-				break
-			}
-		}
-		r = append(r, strings.Replace(s[:pos], "(*)", "", -1))
-		arguments = s[pos:]
-	}
-	arguments = strings.TrimSpace(arguments)
-	if arguments == "" {
-		err = fmt.Errorf("Cannot parse (right part is nil) : %v", s)
+
+	function := t.function()
+	if function == nil {
+		err = fmt.Errorf("Is not function : %s", s)
 		return
 	}
-	// separate fields of arguments
-	{
-		var pos int = 1
-		counter := 0
-		for i := 1; i < len(arguments)-1; i++ {
-			if arguments[i] == '(' {
-				counter++
-			}
-			if arguments[i] == ')' {
-				counter--
-			}
-			if counter == 0 && arguments[i] == ',' {
-				f = append(f, strings.TrimSpace(arguments[pos:i]))
-				pos = i + 1
-			}
-		}
-		f = append(f, strings.TrimSpace(arguments[pos:len(arguments)-1]))
-	}
 
-	for i := range r {
-		r[i] = strings.TrimSpace(r[i])
-	}
-	for i := range f {
-		f[i] = strings.TrimSpace(f[i])
-	}
+	f = function.params
+	r = []string{function.elem.String()}
 
 	return
 }
@@ -27,10 +27,23 @@ var resolveTestCases = []resolveTestCase{
 	{"ldiv_t", "noarch.LdivT"},
 	{"lldiv_t", "noarch.LldivT"},
 	{"fpos_t", "int32"},
+	{"jmp_buf", "noarch.JmpBuf"},
+	{"struct __jmp_buf_tag *", "*noarch.JmpBuf"},
 	{"int [2]", "[]int32"},
 	{"int [2][3]", "[][]int32"},
 	{"int [2][3][4]", "[][][]int32"},
 	{"int [2][3][4][5]", "[][][][]int32"},
+	{"int (*)(int)", "func(int32)(int32)"},
+	{"void (*)(void)", "func()()"},
+	{"double (*)()", "func()(float64)"},
+	{"int (*const)(int)", "func(int32)(int32)"},
+	{"void (*)(int, ...)", "func(int32 , ...interface{})()"},
+	{"int (**)(int)", "*func(int32)(int32)"},
+	{"int (*[4])(int)", "[]func(int32)(int32)"},
+	{"int (*[2][3])(int)", "[][]func(int32)(int32)"},
+	{"int (*(*)[3])(char)", "*[]func(byte)(int32)"},
+	{"int (*(*)(int))(double)", "func(int32)(func(float64)(int32))"},
+	{"int (*)(int (*)(int))", "func(func(int32)(int32))(int32)"},
 }
 
 func TestResolve(t *testing.T) {
@@ -153,17 +166,32 @@ func TestResolveFunction(t *testing.T) {
 				"void (*)(sqlite3_context *)",
 			},
 			returns: []string{"int"},
-		}, /*
-			{
-				input: "int (*)(sqlite3_vtab *, int, const char *, void (**)(sqlite3_context *, int, sqlite3_value **), void **)",
-				fields: []string{
-					"sqlite3_vtab *",
-					"int",
-					"const char *",
-					"void (**)(sqlite3_context *, int, sqlite3_value **)",
-					"void **"},
-				returns: []string{"int"},
-			},*/
+		},
+		{
+			input: "int (*)(sqlite3_vtab *, int, const char *, void (**)(sqlite3_context *, int, sqlite3_value **), void **)",
+			fields: []string{
+				"sqlite3_vtab *",
+				"int",
+				"const char *",
+				"void (**)(sqlite3_context *, int, sqlite3_value **)",
+				"void **"},
+			returns: []string{"int"},
+		},
+		{
+			input:   "int (*(*)(int, char *))(double)",
+			fields:  []string{"int", "char *"},
+			returns: []string{"int (*)(double)"},
+		},
+		{
+			input:   "void (*(*)(int *, void *, const char *))(void)",
+			fields:  []string{"int *", "void *", "const char *"},
+			returns: []string{"void (*)(void)"},
+		},
+		{
+			input:   "int (*(int))(int)",
+			fields:  []string{"int"},
+			returns: []string{"int (*)(int)"},
+		},
 	}
 	for i, tc := range tcs {
 		t.Run(fmt.Sprintf("Test %d : %s", i, tc.input), func(t *testing.T) {
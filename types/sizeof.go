@@ -40,87 +40,31 @@ func SizeOf(p *program.Program, cType string) (size int, err error) {
 		return SizeOf(p, "int")
 	}
 
-	// A structure will be the sum of its parts.
-	var isStruct, ok bool
-	var s *program.Struct
+	// The size of a struct or union depends on the layout of its fields.
 	cType = GenerateCorrectType(cType)
-	if s, ok = p.Structs[cType]; ok {
-		isStruct = true
-	} else if s, ok = p.Structs["struct "+cType]; ok {
-		isStruct = true
-	}
-	if isStruct {
-		totalBytes := 0
-
-		for _, t := range s.Fields {
-			var bytes int
-			var err error
-
-			switch f := t.(type) {
-			case string:
-				bytes, err = SizeOf(p, f)
-
-			case *program.Struct:
-				bytes, err = SizeOf(p, f.Name)
-			}
-
-			if err != nil {
-				return 0, err
-			}
-			totalBytes += bytes
-		}
-
-		// The size of a struct is rounded up to fit the size of the pointer of
-		// the OS.
-		if totalBytes < 32 && totalBytes%pointerSize != 0 {
-			totalBytes += pointerSize - (totalBytes % pointerSize)
+	if s := getRecord(p, cType); s != nil {
+		layout, err := LayoutOf(p, s)
+		if err != nil {
+			return 0, err
 		}
 
-		return totalBytes, nil
+		return layout.Size, nil
 	}
 
-	// An union will be the max size of its parts.
 	if strings.HasPrefix(cType, "union ") {
-		byteCount := 0
-
-		s := p.Unions[cType]
-		if s == nil {
-			return 0, fmt.Errorf("error in union")
-		}
-
-		for _, t := range s.Fields {
-			var bytes int
-			var err error
-
-			switch f := t.(type) {
-			case string:
-				bytes, err = SizeOf(p, f)
-
-			case *program.Struct:
-				bytes, err = SizeOf(p, f.Name)
-			}
-
-			if err != nil {
-				return 0, err
-			}
-
-			if byteCount < bytes {
-				byteCount = bytes
-			}
-		}
-
-		// The size of an union is rounded up to fit the size of the pointer of
-		// the OS.
-		if byteCount < 32 && byteCount%pointerSize != 0 {
-			byteCount += pointerSize - (byteCount % pointerSize)
-		}
-
-		return byteCount, nil
+		return 0, fmt.Errorf("error in union")
 	}
 
-	// Function pointers are one byte?
+	// A function pointer is a pointer. The size of a function is 1 byte like
+	// in GCC. An array of function pointers is handled like other arrays.
 	if strings.Contains(cType, "(") {
-		return 1, nil
+		t, err := parseCType(cType)
+		if err == nil && t.kind == cTypePointer {
+			return pointerSize, nil
+		}
+		if err != nil || t.kind != cTypeArray {
+			return 1, nil
+		}
 	}
 
 	if strings.HasSuffix(cType, "*") {
@@ -140,7 +84,10 @@ func SizeOf(p *program.Program, cType string) (size int, err error) {
 	case "long", "double":
 		return 8, nil
 
-	case "long double", "long long", "long long int", "long long unsigned int":
+	case "long long", "long long int", "long long unsigned int":
+		return 8, nil
+
+	case "long double":
 		return 16, nil
 	}
 
@@ -163,3 +110,162 @@ func SizeOf(p *program.Program, cType string) (size int, err error) {
 
 	return baseSize * totalArraySize, nil
 }
+
+// AlignOf returns the alignment in bytes of a type. This is the same as using
+// the _Alignof operator in C.
+func AlignOf(p *program.Program, cType string) (align int, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("Cannot determine alignof : |%s|. err = %v", cType, err)
+		}
+	}()
+
+	cType = CleanCType(cType)
+	cType = strings.Replace(cType, "unsigned ", "", -1)
+	cType = strings.Replace(cType, "signed ", "", -1)
+
+	if strings.HasPrefix(cType, "enum") {
+		return AlignOf(p, "int")
+	}
+
+	if v, ok := p.TypedefType[cType]; ok {
+		return AlignOf(p, v)
+	}
+
+	if _, ok := p.EnumTypedefName[cType]; ok {
+		return AlignOf(p, "int")
+	}
+
+	cType = GenerateCorrectType(cType)
+	if s := getRecord(p, cType); s != nil {
+		layout, err := LayoutOf(p, s)
+		if err != nil {
+			return 0, err
+		}
+
+		return layout.Align, nil
+	}
+
+	// The elements of an array are aligned like the array.
+	if arrayType, arraySize := GetArrayTypeAndSize(cType); arraySize != -1 {
+		return AlignOf(p, arrayType)
+	}
+	if strings.HasSuffix(cType, "[]") {
+		return AlignOf(p, cType[:len(cType)-2])
+	}
+
+	// Otherwise the alignment of a scalar is its size.
+	return SizeOf(p, cType)
+}
+
+// RecordLayout is the layout of a struct or union in memory.
+type RecordLayout struct {
+	// Size and Align are the size and alignment in bytes.
+	Size  int
+	Align int
+
+	// BitOffsets are the offsets (in bits) of each of the Members of the
+	// struct.
+	BitOffsets []int
+}
+
+// LayoutOf returns the layout of a struct or union in the same way that the C
+// compiler lays it out (the System V ABI). The fields are placed in order at
+// the next offset that is aligned for the type of the field and the size of a
+// struct is rounded up to the alignment of the struct.
+//
+// A bit-field is placed at the next free bit unless it would cross a boundary
+// of a unit of the size of its type, then it starts in the next unit:
+//
+//     struct flags {
+//         unsigned char a : 5; // bits 0 - 4
+//         unsigned char b : 5; // bits 8 - 12
+//         unsigned int  c : 3; // bits 13 - 15
+//         unsigned int    : 0; // next int
+//         unsigned int  d : 1; // bit 32
+//     };                       // sizeof = 8
+//
+// The fields of a union all start at offset 0.
+func LayoutOf(p *program.Program, s *program.Struct) (layout RecordLayout, err error) {
+	bits, align := 0, 1
+
+	for _, m := range s.Members {
+		var size, fieldAlign int
+		if strings.HasSuffix(strings.TrimSpace(m.Type), "[]") {
+			// A flexible array member does not have a size.
+			size = 0
+		} else if size, err = SizeOf(p, m.Type); err != nil {
+			return
+		}
+		if fieldAlign, err = AlignOf(p, m.Type); err != nil {
+			return
+		}
+
+		offset := bits
+		if s.IsUnion {
+			offset = 0
+		}
+
+		switch {
+		case !m.IsBitField():
+			offset = roundUp(offset, fieldAlign*8)
+			layout.BitOffsets = append(layout.BitOffsets, offset)
+			offset += size * 8
+
+		case m.BitWidth == 0:
+			// A bit-field with a zero width starts the next unit.
+			offset = roundUp(offset, fieldAlign*8)
+			layout.BitOffsets = append(layout.BitOffsets, offset)
+
+		default:
+			if unit := size * 8; unit > 0 &&
+				offset/unit != (offset+m.BitWidth-1)/unit {
+				offset = roundUp(offset, unit)
+			}
+			layout.BitOffsets = append(layout.BitOffsets, offset)
+			offset += m.BitWidth
+
+			// Unnamed bit-fields do not change the alignment of the struct.
+			if m.Name == "" {
+				fieldAlign = 1
+			}
+		}
+
+		if offset > bits {
+			bits = offset
+		}
+		if fieldAlign > align {
+			align = fieldAlign
+		}
+	}
+
+	layout.Align = align
+	layout.Size = roundUp((bits+7)/8, align)
+
+	return
+}
+
+// getRecord returns the struct or union for the type, or nil if the type is
+// not a struct or union.
+func getRecord(p *program.Program, cType string) *program.Struct {
+	if s, ok := p.Structs[cType]; ok {
+		return s
+	}
+	if s, ok := p.Structs["struct "+cType]; ok {
+		return s
+	}
+	if s, ok := p.Unions[cType]; ok {
+		return s
+	}
+
+	return nil
+}
+
+// roundUp rounds n up to a multiple of m.
+func roundUp(n, m int) int {
+	if m <= 1 {
+		return n
+	}
+
+	return (n + m - 1) / m * m
+}
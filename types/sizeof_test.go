@@ -30,6 +30,10 @@ var sizeofTestCases = []sizeofTestCase{
 	{"int ***", 8, nil},
 	{"char *const", 8, nil},
 	{"char *const [3]", 24, nil},
+	{"long long", 8, nil},
+	{"long double", 16, nil},
+	{"int (*)(double)", 8, nil},
+	{"int (*[4])(double)", 8 * 4, nil},
 	{"struct c [2]", 0, fmt.Errorf("Cannot determine sizeof : |struct c [2]|. err = error in sizeof baseSize")},
 }
 
@@ -49,3 +53,78 @@ func TestSizeOf(t *testing.T) {
 		}
 	}
 }
+
+func TestLayoutOf(t *testing.T) {
+	p := program.NewProgram()
+	p.Structs["struct point"] = &program.Struct{
+		Name: "point",
+		Members: []program.Member{
+			{Name: "c", Type: "char", BitWidth: -1},
+			{Name: "x", Type: "double", BitWidth: -1},
+			{Name: "s", Type: "short", BitWidth: -1},
+		},
+	}
+	p.Structs["struct flags"] = &program.Struct{
+		Name: "flags",
+		Members: []program.Member{
+			{Name: "a", Type: "unsigned char", BitWidth: 5},
+			{Name: "b", Type: "unsigned char", BitWidth: 5},
+			{Name: "c", Type: "unsigned int", BitWidth: 3},
+			{Name: "", Type: "unsigned int", BitWidth: 0},
+			{Name: "d", Type: "unsigned int", BitWidth: 1},
+		},
+	}
+	p.Structs["struct bytes"] = &program.Struct{
+		Name: "bytes",
+		Members: []program.Member{
+			{Name: "a", Type: "unsigned char", BitWidth: 4},
+			{Name: "", Type: "int", BitWidth: 2},
+			{Name: "b", Type: "unsigned char", BitWidth: -1},
+		},
+	}
+	p.Unions["union number"] = &program.Struct{
+		Name:    "number",
+		IsUnion: true,
+		Members: []program.Member{
+			{Name: "c", Type: "char [5]", BitWidth: -1},
+			{Name: "i", Type: "int", BitWidth: -1},
+			{Name: "b", Type: "unsigned int", BitWidth: 3},
+		},
+	}
+
+	tests := []struct {
+		cType      string
+		size       int
+		align      int
+		bitOffsets []int
+	}{
+		{"struct point", 24, 8, []int{0, 64, 128}},
+		{"struct flags", 8, 4, []int{0, 8, 13, 32, 32}},
+		{"struct bytes", 2, 1, []int{0, 4, 8}},
+		{"union number", 8, 4, []int{0, 0, 0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.cType, func(t *testing.T) {
+			s := p.GetStruct(test.cType)
+			layout, err := types.LayoutOf(p, s)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if layout.Size != test.size || layout.Align != test.align {
+				t.Errorf("expected size %d and align %d, got %d and %d",
+					test.size, test.align, layout.Size, layout.Align)
+			}
+			if fmt.Sprint(layout.BitOffsets) != fmt.Sprint(test.bitOffsets) {
+				t.Errorf("expected offsets %v, got %v",
+					test.bitOffsets, layout.BitOffsets)
+			}
+
+			size, err := types.SizeOf(p, test.cType)
+			if err != nil || size != test.size {
+				t.Errorf("expected sizeof %d, got %d (%v)", test.size, size, err)
+			}
+		})
+	}
+}